@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/atomicfile"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+const (
+	formatCSV  = "csv"
+	formatJSON = "json"
+
+	apiTimeout = 30 * time.Second
+)
+
+var (
+	errRequiredFlag  = errors.New("flag is required")
+	errUnknownFormat = errors.New("unknown format")
+)
+
+// euros formats the CSV amounts, without symbol nor grouping for spreadsheets.
+//
+//nolint:gochecknoglobals // read-only
+var euros = lclynab.CurrencyFormat{DecimalDigits: 2, DecimalSeparator: "."}
+
+type options struct {
+	token     string
+	budgetID  string
+	accountID string
+	since     string
+	output    string
+	format    string
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:], os.Stdout, http.DefaultClient)
+
+	stop()
+
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, httpClient *http.Client) error {
+	opts, err := parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	var since lclynab.Date
+	if opts.since != "" {
+		if since, err = lclynab.ParseDate(opts.since); err != nil {
+			return fmt.Errorf("parsing -since: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	client := lclynab.NewClient(opts.token, httpClient)
+
+	page, err := client.FetchTransactions(ctx, opts.budgetID, opts.accountID, since, 0)
+	if err != nil {
+		return fmt.Errorf("fetching transactions: %w", err)
+	}
+
+	transactions := page.Transactions
+
+	var buf bytes.Buffer
+
+	if opts.format == formatJSON {
+		err = writeJSON(&buf, transactions)
+	} else {
+		err = writeCSV(&buf, transactions)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "-" {
+		if _, err := stdout.Write(buf.Bytes()); err != nil {
+			return fmt.Errorf("writing export: %w", err)
+		}
+
+		return nil
+	}
+
+	const perm = 0o644
+	if err := atomicfile.WriteFile(opts.output, buf.Bytes(), perm); err != nil {
+		return fmt.Errorf("writing export: %w", err)
+	}
+
+	return nil
+}
+
+// writeCSV writes one row per transaction, amounts in euros.
+func writeCSV(w io.Writer, transactions []lclynab.SavedTransaction) error {
+	writer := csv.NewWriter(w)
+
+	_ = writer.Write([]string{
+		"date", "amount", "payee", "category", "memo", "cleared", "approved", "flag", "import_id",
+	})
+
+	for _, t := range transactions {
+		_ = writer.Write([]string{
+			t.Date.String(),
+			euros.Format(t.Amount),
+			t.PayeeName,
+			t.CategoryName,
+			t.Memo,
+			t.Cleared,
+			strconv.FormatBool(t.Approved),
+			t.FlagColor,
+			t.ImportID,
+		})
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+
+	return nil
+}
+
+// writeJSON writes the transactions as YNAB returned them.
+func writeJSON(w io.Writer, transactions []lclynab.SavedTransaction) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if transactions == nil {
+		transactions = []lclynab.SavedTransaction{}
+	}
+
+	if err := encoder.Encode(transactions); err != nil {
+		return fmt.Errorf("writing JSON: %w", err)
+	}
+
+	return nil
+}
+
+func parseFlags(args []string) (*options, error) {
+	var opts options
+
+	flagset := flag.NewFlagSet("export", flag.ContinueOnError)
+	flagset.StringVar(&opts.token, "t", "", "Token")
+	flagset.StringVar(&opts.budgetID, "b", "", "Budget ID")
+	flagset.StringVar(&opts.accountID, "a", "", "Account ID")
+	flagset.StringVar(&opts.since, "since", "", "Only export transactions dated since this day, as YYYY-MM-DD")
+	flagset.StringVar(&opts.output, "o", "-", "Output file, - for stdout")
+	flagset.StringVar(&opts.format, "format", formatCSV, "Output format: csv or json")
+
+	if err := flagset.Parse(args); err != nil {
+		return nil, err //nolint:wrapcheck // already explicit
+	}
+
+	switch {
+	case opts.token == "":
+		return nil, fmt.Errorf("%w: -t", errRequiredFlag)
+	case opts.budgetID == "":
+		return nil, fmt.Errorf("%w: -b", errRequiredFlag)
+	case opts.accountID == "":
+		return nil, fmt.Errorf("%w: -a", errRequiredFlag)
+	case opts.format != formatCSV && opts.format != formatJSON:
+		return nil, fmt.Errorf("%w: %q, want %v or %v", errUnknownFormat, opts.format, formatCSV, formatJSON)
+	}
+
+	return &opts, nil
+}
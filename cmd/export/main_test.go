@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// exportTransport answers with the account's transactions, an approved payment and a
+// deleted transfer among them.
+func exportTransport() *httpmock.MockTransport {
+	pages := map[string]string{
+		"since_date=2024-10-01": `{"data": {"server_knowledge": 11, "transactions": [
+			{"id": "t-1", "account_id": "acc", "date": "2024-10-28", "amount": -21320, "payee_name": "Merch",
+				"category_name": "Groceries", "memo": "CB MERCH, \"Lyon\"", "cleared": "cleared",
+				"approved": true, "flag_color": "red", "import_id": "YNAB:-21320:2024-10-28:1"},
+			{"id": "t-2", "deleted": true},
+			{"id": "t-3", "account_id": "acc", "date": "2024-10-30", "amount": -1000, "payee_name": "Bakery",
+				"cleared": "cleared"}]}}`,
+	}
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/bud-id/accounts/acc/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			page, ok := pages[req.URL.RawQuery]
+			if !ok {
+				return httpmock.NewStringResponse(http.StatusBadRequest, ""), nil
+			}
+
+			return httpmock.NewStringResponse(http.StatusOK, page), nil
+		})
+
+	return transport
+}
+
+func Test_run(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		format string
+		golden string
+	}{
+		{name: "csv", format: formatCSV, golden: "./testdata/export.golden.csv"},
+		{name: "json", format: formatJSON, golden: "./testdata/export.golden.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := filepath.Join(t.TempDir(), "out")
+
+			err := run(context.Background(), []string{
+				"-t", "tok", "-b", "bud-id", "-a", "acc", "-since", "2024-10-01", "-o", out, "-format", tt.format,
+			}, &bytes.Buffer{}, &http.Client{Transport: exportTransport()})
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != string(want) {
+				t.Errorf("export = \n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func Test_run_stdout(t *testing.T) {
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{"-t", "tok", "-b", "bud-id", "-a", "acc", "-since", "2024-10-01"},
+		stdout, &http.Client{Transport: exportTransport()})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	want, err := os.ReadFile("./testdata/export.golden.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout.String() != string(want) {
+		t.Errorf("stdout = \n%s\nwant\n%s", stdout, want)
+	}
+}
+
+func Test_parseFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{name: "missing token", args: []string{"-b", "bud-id", "-a", "acc"}, wantErr: errRequiredFlag},
+		{name: "missing account", args: []string{"-t", "tok", "-b", "bud-id"}, wantErr: errRequiredFlag},
+		{
+			name:    "unknown format",
+			args:    []string{"-t", "tok", "-b", "bud-id", "-a", "acc", "-format", "xml"},
+			wantErr: errUnknownFormat,
+		},
+		{name: "ok", args: []string{"-t", "tok", "-b", "bud-id", "-a", "acc"}, wantErr: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := parseFlags(tt.args); !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseFlags() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+// Command sync downloads LCL statements, then pushes them to YNAB.
+package main
+
+import (
+	"github.com/Crocmagnon/lcl-ynab-go/internal/lclweb"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/sync"
+)
+
+func main() {
+	sync.Main(lclweb.Launch)
+}
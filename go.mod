@@ -1,17 +1,22 @@
 module github.com/Crocmagnon/lcl-ynab-go
 
-go 1.23.3
+go 1.24
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/carlmjohnson/requests v0.24.3
+	github.com/eclipse/paho.mqtt.golang v1.4.3
 	github.com/jarcoal/httpmock v1.3.1
 	github.com/playwright-community/playwright-go v0.4802.0
 	golang.org/x/text v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/deckarep/golang-set/v2 v2.6.0 // indirect
 	github.com/go-jose/go-jose/v3 v3.0.3 // indirect
 	github.com/go-stack/stack v1.8.1 // indirect
+	github.com/gorilla/websocket v1.5.0 // indirect
 	golang.org/x/net v0.27.0 // indirect
+	golang.org/x/sync v0.9.0 // indirect
 )
@@ -0,0 +1,314 @@
+// Package config reads the TOML or YAML file giving the commands default flag values.
+//
+// The file maps flag names, with underscores or dashes, or their documented aliases
+// to values:
+//
+//	token = "my-ynab-token"
+//	budget_id = "0a1b2c"
+//	drift-alert = 5
+//
+// The retention section groups the keys of the retention policies, each standing
+// for the flag of its name prefixed with "retention-":
+//
+//	[retention]
+//	screenshots_max_age = "720h"
+//	reports_max_count = 50
+//
+// The sections of the accounts table hold values for one account of sync -accounts,
+// winning over the rest of the file for its push:
+//
+//	[accounts."Compte courant"]
+//	webhook = "https://example.com/courant"
+//
+// One file configures every command, each one leaving out the keys of the others.
+// Files ending in .toml are read as TOML, the others as YAML.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultPath is the file read when -config is left out, ~ standing for the home directory.
+	DefaultPath = "~/.config/lcl-ynab/config.toml"
+	// yamlDefaultPath is read instead when there is no file at DefaultPath.
+	yamlDefaultPath = "~/.lcl-ynab.yaml"
+)
+
+// Tables a file may have.
+const (
+	sectionRetention = "retention"
+	tableAccounts    = "accounts"
+)
+
+var (
+	ErrInvalid    = errors.New("invalid config")
+	ErrUnknownKey = errors.New("unknown config key")
+	errNoHome     = errors.New("cannot resolve home directory")
+)
+
+// Expand returns path with a leading ~ replaced by the HOME of getenv.
+func Expand(path string, getenv func(string) string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || rest != "" && rest[0] != '/' && rest[0] != filepath.Separator {
+		return path, nil
+	}
+
+	home := getenv("HOME")
+	if home == "" {
+		return "", fmt.Errorf("%w for %v", errNoHome, path)
+	}
+
+	return filepath.Join(home, rest), nil
+}
+
+// Load reads the values of the file at path, expanded with getenv, from fsys, nil meaning
+// the OS files. When optional, a missing file or home directory is an empty config.
+func Load(fsys fs.FS, path string, optional bool, getenv func(string) string) (map[string]string, error) {
+	expanded, err := Expand(path, getenv)
+	if err != nil {
+		if optional {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	data, err := readFile(fsys, expanded)
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	if filepath.Ext(expanded) == ".toml" {
+		return decodeTOML(data, expanded)
+	}
+
+	return decodeYAML(data, expanded)
+}
+
+// readFile reads the file at path from fsys, or from the OS when fsys is nil.
+func readFile(fsys fs.FS, path string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(path) //nolint:wrapcheck // wrapped by the caller
+	}
+
+	return fs.ReadFile(fsys, path) //nolint:wrapcheck // wrapped by the caller
+}
+
+// decodeYAML reads the top-level values of a YAML file, and those of its retention section.
+func decodeYAML(data []byte, path string) (map[string]string, error) {
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w %v: %w", ErrInvalid, path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+
+	for key, node := range raw {
+		if key == tableAccounts && node.Kind == yaml.MappingNode {
+			var accounts map[string]map[string]string
+			if err := node.Decode(&accounts); err != nil {
+				return nil, fmt.Errorf("%w %v: %v: %w", ErrInvalid, path, key, err)
+			}
+
+			for account, section := range accounts {
+				for name, value := range section {
+					values[accountKey(account, name)] = value
+				}
+			}
+
+			continue
+		}
+
+		if key == sectionRetention && node.Kind == yaml.MappingNode {
+			var section map[string]string
+			if err := node.Decode(&section); err != nil {
+				return nil, fmt.Errorf("%w %v: %v: %w", ErrInvalid, path, key, err)
+			}
+
+			for name, value := range section {
+				values[key+"_"+name] = value
+			}
+
+			continue
+		}
+
+		var value string
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("%w %v: %w", ErrInvalid, path, err)
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// decodeTOML reads the top-level values of a TOML file, and those of its retention
+// and accounts tables, which must be strings, numbers or booleans.
+func decodeTOML(data []byte, path string) (map[string]string, error) {
+	var raw map[string]any
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w %v: %w", ErrInvalid, path, err)
+	}
+
+	if accounts, ok := raw[tableAccounts].(map[string]any); ok {
+		delete(raw, tableAccounts)
+
+		for account, section := range accounts {
+			section, ok := section.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w %v: %v.%v is a %T, want a table", ErrInvalid, path, tableAccounts, account, section)
+			}
+
+			for name, value := range section {
+				raw[accountKey(account, name)] = value
+			}
+		}
+	}
+
+	if section, ok := raw[sectionRetention].(map[string]any); ok {
+		delete(raw, sectionRetention)
+
+		for name, value := range section {
+			raw[sectionRetention+"_"+name] = value
+		}
+	}
+
+	values := make(map[string]string, len(raw))
+
+	for key, value := range raw {
+		switch value := value.(type) {
+		case string:
+			values[key] = value
+		case int64, float64, bool:
+			values[key] = fmt.Sprint(value)
+		default:
+			return nil, fmt.Errorf("%w %v: %v is a %T, want a string, number or boolean", ErrInvalid, path, key, value)
+		}
+	}
+
+	return values, nil
+}
+
+// Apply sets the flags of flagset to the values of config, except the flags given on
+// the command line or already changed from their default, by an environment variable
+// for instance. Keys are flag names, with underscores for dashes, or keys of aliases
+// mapping to flag names. Keys of another command are left out, the only unknown keys
+// being those of no command.
+func Apply(flagset *flag.FlagSet, config map[string]string, aliases map[string]string) error {
+	given := make(map[string]bool)
+	flagset.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		// Account sections are applied through Account.
+		if strings.HasPrefix(key, tableAccounts+".") {
+			continue
+		}
+
+		name, ok := aliases[key]
+		if !ok {
+			name = strings.ReplaceAll(key, "_", "-")
+		}
+
+		f := flagset.Lookup(name)
+		if f == nil && known(key) {
+			// The file is shared, the key is for another command.
+			continue
+		}
+
+		if f == nil {
+			return fmt.Errorf("%w: %q", ErrUnknownKey, key)
+		}
+
+		if given[name] || f.Value.String() != f.DefValue {
+			continue
+		}
+
+		if err := f.Value.Set(config[key]); err != nil {
+			return fmt.Errorf("%w: %v: %w", ErrInvalid, key, err)
+		}
+	}
+
+	return nil
+}
+
+// ApplyFile loads the file at path from fsys and applies it to flagset. The file may only be
+// missing when path is DefaultPath, ~/.lcl-ynab.yaml being read in its place if it exists.
+func ApplyFile(
+	flagset *flag.FlagSet,
+	fsys fs.FS,
+	path string,
+	getenv func(string) string,
+	aliases map[string]string,
+) error {
+	values, path, err := LoadFile(fsys, path, getenv)
+	if err != nil {
+		return err
+	}
+
+	if err := Apply(flagset, values, aliases); err != nil {
+		return fmt.Errorf("config %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// LoadFile loads the file at path as ApplyFile does, returning its values with the
+// path of the file read.
+func LoadFile(fsys fs.FS, path string, getenv func(string) string) (map[string]string, string, error) {
+	values, err := Load(fsys, path, path == DefaultPath, getenv)
+	if err != nil {
+		return nil, path, err
+	}
+
+	if values == nil && path == DefaultPath {
+		path = yamlDefaultPath
+
+		if values, err = Load(fsys, path, true, getenv); err != nil {
+			return nil, path, err
+		}
+	}
+
+	return values, path, nil
+}
+
+// Account returns the values of the section of account in the accounts table.
+func Account(values map[string]string, account string) map[string]string {
+	prefix := accountKey(account, "")
+	section := make(map[string]string)
+
+	for key, value := range values {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			section[name] = value
+		}
+	}
+
+	return section
+}
+
+// accountKey is the key of name in the section of account.
+func accountKey(account, name string) string {
+	return tableAccounts + "." + account + "." + name
+}
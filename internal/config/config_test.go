@@ -0,0 +1,372 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	getenv := func(string) string { return "/home/jean" }
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: DefaultPath, want: "/home/jean/.config/lcl-ynab/config.toml"},
+		{path: yamlDefaultPath, want: "/home/jean/.lcl-ynab.yaml"},
+		{path: "~", want: "/home/jean"},
+		{path: "/etc/lcl-ynab.yaml", want: "/etc/lcl-ynab.yaml"},
+		{path: "~jean/.lcl-ynab.yaml", want: "~jean/.lcl-ynab.yaml"},
+	}
+
+	for _, tt := range tests {
+		got, err := Expand(tt.path, getenv)
+		if err != nil || got != tt.want {
+			t.Errorf("Expand(%q) = %q, %v, want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	getenv := func(string) string { return dir }
+
+	content := "token: tok\nbudget_id: bud-id\ndrift-alert: 5\nverbose: true\n"
+	if err := os.WriteFile(filepath.Join(dir, ".lcl-ynab.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := Load(nil, yamlDefaultPath, true, getenv)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]string{"token": "tok", "budget_id": "bud-id", "drift-alert": "5", "verbose": "true"}
+	for key, value := range want {
+		if values[key] != value {
+			t.Errorf("Load()[%q] = %q, want %q", key, values[key], value)
+		}
+	}
+
+	missing := filepath.Join(dir, "missing.yaml")
+
+	if values, err := Load(nil, missing, true, getenv); err != nil || values != nil {
+		t.Errorf("Load(optional missing) = %v, %v, want nothing", values, err)
+	}
+
+	if _, err := Load(nil, missing, false, getenv); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load(nil, missing) error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	if values, err := Load(nil, DefaultPath, true, func(string) string { return "" }); err != nil || values != nil {
+		t.Errorf("Load() without home = %v, %v, want nothing", values, err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("token: [tok]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(nil, invalid, false, getenv); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Load(nil, invalid) error = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestLoad_toml(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	getenv := func(string) string { return dir }
+
+	path := filepath.Join(dir, "config.toml")
+	content := "token = \"tok\"\ndrift-alert = 5\nmax_duplicates = 2.5\nverbose = true\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := Load(nil, path, false, getenv)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]string{"token": "tok", "drift-alert": "5", "max_duplicates": "2.5", "verbose": "true"}
+	for key, value := range want {
+		if values[key] != value {
+			t.Errorf("Load()[%q] = %q, want %q", key, values[key], value)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"table.toml":  "[push]\ntoken = \"tok\"\n",
+		"array.toml":  "token = [\"tok\"]\n",
+		"syntax.toml": "token: tok\n",
+	} {
+		invalid := filepath.Join(dir, name)
+		if err := os.WriteFile(invalid, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Load(nil, invalid, false, getenv); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Load(%v) error = %v, want %v", name, err, ErrInvalid)
+		}
+	}
+}
+
+func TestLoad_retention(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	getenv := func(string) string { return dir }
+
+	want := map[string]string{
+		"token":                           "tok",
+		"retention_screenshots_max_age":   "720h",
+		"retention_screenshots_max_count": "20",
+	}
+
+	for name, content := range map[string]string{
+		"config.toml": "token = \"tok\"\n[retention]\nscreenshots_max_age = \"720h\"\nscreenshots_max_count = 20\n",
+		"config.yaml": "token: tok\nretention:\n  screenshots_max_age: 720h\n  screenshots_max_count: 20\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		values, err := Load(nil, path, false, getenv)
+		if err != nil {
+			t.Fatalf("Load(%v) error = %v", name, err)
+		}
+
+		if !maps.Equal(values, want) {
+			t.Errorf("Load(%v) = %v, want %v", name, values, want)
+		}
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("retention:\n  screenshots_max_age: [720h]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(nil, invalid, false, getenv); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Load(nil, invalid) error = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestLoad_accounts(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	getenv := func(string) string { return dir }
+
+	for name, content := range map[string]string{
+		"config.toml": "webhook = \"all\"\n[accounts.\"Compte courant\"]\nwebhook = \"courant\"\nmax_duplicates = 2\n",
+		"config.yaml": "webhook: all\naccounts:\n  Compte courant:\n    webhook: courant\n    max_duplicates: 2\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		values, err := Load(nil, path, false, getenv)
+		if err != nil {
+			t.Fatalf("Load(%v) error = %v", name, err)
+		}
+
+		want := map[string]string{"webhook": "courant", "max_duplicates": "2"}
+		if got := Account(values, "Compte courant"); !maps.Equal(got, want) {
+			t.Errorf("Account(Load(%v)) = %v, want %v", name, got, want)
+		}
+
+		if got := Account(values, "Livret"); len(got) > 0 {
+			t.Errorf("Account(Load(%v)) of another account = %v, want nothing", name, got)
+		}
+
+		// The sections are left to Account.
+		var webhook string
+
+		flagset := flag.NewFlagSet("", flag.ContinueOnError)
+		flagset.StringVar(&webhook, "w", "", "")
+
+		if err := Apply(flagset, values, map[string]string{"webhook": "w"}); err != nil || webhook != "all" {
+			t.Errorf("Apply(Load(%v)) = %q, %v, want all", name, webhook, err)
+		}
+	}
+
+	invalid := filepath.Join(dir, "invalid.toml")
+	if err := os.WriteFile(invalid, []byte("[accounts]\ncourant = \"webhook\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(nil, invalid, false, getenv); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Load(nil, invalid) error = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestApplyFile_defaults(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		files     map[string]string
+		wantToken string
+		wantDrift float64
+	}{
+		{
+			name:      "toml",
+			files:     map[string]string{".config/lcl-ynab/config.toml": "token = \"toml\"\n"},
+			wantToken: "toml",
+		},
+		{
+			name:      "yaml",
+			files:     map[string]string{".lcl-ynab.yaml": "token: yaml\ndrift_alert: 5\n"},
+			wantToken: "yaml",
+			wantDrift: 5,
+		},
+		{
+			name: "toml over yaml",
+			files: map[string]string{
+				".config/lcl-ynab/config.toml": "token = \"toml\"\n",
+				".lcl-ynab.yaml":               "token: yaml\ndrift_alert: 5\n",
+			},
+			wantToken: "toml",
+		},
+		{name: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			home := t.TempDir()
+
+			for name, content := range tt.files {
+				path := filepath.Join(home, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var (
+				token string
+				drift float64
+			)
+
+			flagset := flag.NewFlagSet("", flag.ContinueOnError)
+			flagset.StringVar(&token, "t", "", "")
+			flagset.Float64Var(&drift, "drift-alert", 0, "")
+
+			err := ApplyFile(flagset, nil, DefaultPath, func(string) string { return home }, map[string]string{"token": "t"})
+			if err != nil {
+				t.Fatalf("ApplyFile() error = %v", err)
+			}
+
+			if token != tt.wantToken || drift != tt.wantDrift {
+				t.Errorf("ApplyFile() token, drift = %q, %v, want %q, %v", token, drift, tt.wantToken, tt.wantDrift)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		args      []string
+		config    map[string]string
+		wantToken string
+		wantDrift float64
+		wantErr   error
+	}{
+		{
+			name:      "alias and underscores",
+			config:    map[string]string{"token": "from-config", "drift_alert": "5"},
+			wantToken: "from-config",
+			wantDrift: 5,
+		},
+		{
+			name:      "flag wins",
+			args:      []string{"-t", "from-flag", "-drift-alert", "0"},
+			config:    map[string]string{"token": "from-config", "drift-alert": "5"},
+			wantToken: "from-flag",
+		},
+		{
+			name:    "unknown key",
+			config:  map[string]string{"tokn": "from-config"},
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:      "key of another command",
+			config:    map[string]string{"token": "from-config", "identifier": "0123456789", "max_catchup": "3"},
+			wantToken: "from-config",
+		},
+		{
+			name:    "invalid value",
+			config:  map[string]string{"drift-alert": "five"},
+			wantErr: ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				token string
+				drift float64
+			)
+
+			flagset := flag.NewFlagSet("", flag.ContinueOnError)
+			flagset.StringVar(&token, "t", "", "")
+			flagset.Float64Var(&drift, "drift-alert", 0, "")
+
+			if err := flagset.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Apply(flagset, tt.config, map[string]string{"token": "t"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && (token != tt.wantToken || drift != tt.wantDrift) {
+				t.Errorf("Apply() token, drift = %q, %v, want %q, %v", token, drift, tt.wantToken, tt.wantDrift)
+			}
+		})
+	}
+}
+
+func TestApply_changedByEnvironment(t *testing.T) {
+	t.Parallel()
+
+	token := ""
+
+	flagset := flag.NewFlagSet("", flag.ContinueOnError)
+	flagset.StringVar(&token, "t", "", "")
+
+	// As an environment variable fallback does after parsing.
+	token = "from-env"
+
+	if err := Apply(flagset, map[string]string{"token": "from-config"}, map[string]string{"token": "t"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "from-env" {
+		t.Errorf("token = %q, want the environment to win over the config", token)
+	}
+}
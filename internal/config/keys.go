@@ -0,0 +1,62 @@
+package config
+
+import "strings"
+
+// Commands reading the file.
+const (
+	CommandDownload = "download"
+	CommandPush     = "push"
+)
+
+// Keys returns the keys command accepts: its flag names and aliases. The tests of the
+// commands check that they match their flags.
+func Keys(command string) []string {
+	switch command {
+	case CommandDownload:
+		return []string{
+			"accounts", "cleanup", "config", "days", "dry-run", "format", "from", "headless", "i", "identifier",
+			"log-format", "log-level", "max-catchup", "o", "output_file", "p", "password", "preflight", "print-paths",
+			"profile", "retention-screenshots-max-age", "retention-screenshots-max-count", "run-id", "screenshots",
+			"state", "timings", "to",
+		}
+	case CommandPush:
+		return []string{
+			"a", "account-map", "account_id", "b", "budget_id", "ca-cert", "cache-ttl", "caps", "categorize-batch",
+			"categorize-cmd", "categorize-timeout", "check-only", "cleanup", "compare-report", "config",
+			"currency-filter", "demo", "demo-dir", "detect-refunds", "diff", "discord-webhook", "drift-alert",
+			"dry-run", "email-from", "email-on-success", "email-to", "encoding", "f", "fail-on-cap", "file",
+			"fold-exchange-rates", "force", "force-new-import-ids", "format", "gotify-token", "gotify-url",
+			"ha-entity-prefix", "ha-token", "ha-url", "import-id-salt", "import-id-strategy", "include-pending",
+			"insecure-skip-verify", "json", "lang", "list-accounts", "list-budgets", "log-format", "log-level",
+			"max-duplicates", "mqtt-ca-file", "mqtt-password", "mqtt-topic", "mqtt-url", "mqtt-username", "n",
+			"no-cache", "no-truncate", "notify-timeout", "ntfy-on-success", "ntfy-token", "ntfy-url", "output",
+			"preflight", "print-paths", "profile", "progress", "push-backoff", "q", "refund-category", "report",
+			"resolve-names", "retention-demo-max-age", "retention-reports-max-age", "retention-reports-max-count",
+			"retention-watched-max-age", "rules", "run-id", "slack-webhook", "smtp-host", "smtp-password", "smtp-port",
+			"smtp-tls", "smtp-username", "sort", "state", "stats", "strict-webhook", "strip-holder",
+			"suggest-categories", "suggest-min-occurrences", "sync-account", "t", "telegram-chat-id",
+			"telegram-details", "telegram-failures-only", "telegram-token", "timings", "token", "token-file",
+			"undo-last", "undo-run", "update", "use-budget-format", "v", "verify", "verify-webhook", "w", "watch",
+			"watch-glob", "watch-interval", "watch-settle", "webhook", "webhook-always", "webhook-attempts",
+			"webhook-backoff", "webhook-ca-cert", "webhook-content-type", "webhook-failure", "webhook-header",
+			"webhook-success", "webhook-template", "webhook-timeout", "yes",
+		}
+	default:
+		return nil
+	}
+}
+
+// known reports whether a command accepts key, with underscores or dashes.
+func known(key string) bool {
+	key = strings.ReplaceAll(key, "_", "-")
+
+	for _, command := range []string{CommandDownload, CommandPush} {
+		for _, name := range Keys(command) {
+			if strings.ReplaceAll(name, "_", "-") == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
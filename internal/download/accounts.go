@@ -0,0 +1,84 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	errInvalidAccounts = errors.New("invalid -accounts")
+	errAccountsFailed  = errors.New("some accounts failed to download")
+)
+
+// accountTarget is an account to download, selected by a text of its entry in the
+// account list, and the file receiving its statement. An empty name selects the
+// first account.
+type accountTarget struct {
+	name       string
+	outputFile string
+}
+
+// parseAccounts reads the comma-separated name:file pairs of -accounts.
+func parseAccounts(raw string) ([]accountTarget, error) {
+	var targets []accountTarget
+
+	for pair := range strings.SplitSeq(raw, ",") {
+		name, outputFile, ok := strings.Cut(pair, ":")
+		name, outputFile = strings.TrimSpace(name), strings.TrimSpace(outputFile)
+
+		if !ok || name == "" || outputFile == "" {
+			return nil, fmt.Errorf("%w: %q, want name:file", errInvalidAccounts, pair)
+		}
+
+		targets = append(targets, accountTarget{name: name, outputFile: outputFile})
+	}
+
+	return targets, nil
+}
+
+// accountResult is the outcome of the download of an account.
+type accountResult struct {
+	Account    string
+	OutputFile string
+	Err        error
+}
+
+// multiAccountResult reports the download of each account, in the order of -accounts.
+type multiAccountResult struct {
+	Accounts []accountResult
+}
+
+// print writes a line per account to w.
+func (r *multiAccountResult) print(w io.Writer) {
+	for _, account := range r.Accounts {
+		if account.Err != nil {
+			_, _ = fmt.Fprintf(w, "%v: failed: %v\n", account.Account, account.Err)
+		} else {
+			_, _ = fmt.Fprintf(w, "%v: saved to %v\n", account.Account, account.OutputFile)
+		}
+	}
+}
+
+// err returns errAccountsFailed with the failures joined, nil when every account was
+// downloaded. The error of a single account without -accounts is returned as is.
+func (r *multiAccountResult) err() error {
+	if len(r.Accounts) == 1 && r.Accounts[0].Account == "" {
+		return r.Accounts[0].Err
+	}
+
+	var failed []string
+
+	for _, account := range r.Accounts {
+		if account.Err != nil {
+			failed = append(failed, fmt.Sprintf("%v: %v", account.Account, account.Err))
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %v", errAccountsFailed, strings.Join(failed, "; "))
+}
@@ -0,0 +1,75 @@
+package download
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errFake = errors.New("clicking account: timeout")
+
+func Test_parseAccounts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		raw     string
+		want    []accountTarget
+		wantErr error
+	}{
+		{
+			raw: "Compte courant:courant.csv, LIVRET A:/tmp/livret.csv",
+			want: []accountTarget{
+				{name: "Compte courant", outputFile: "courant.csv"},
+				{name: "LIVRET A", outputFile: "/tmp/livret.csv"},
+			},
+		},
+		{raw: "courant.csv", wantErr: errInvalidAccounts},
+		{raw: "Compte courant:", wantErr: errInvalidAccounts},
+		{raw: "Compte courant:courant.csv,", wantErr: errInvalidAccounts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseAccounts(tt.raw)
+			if !reflect.DeepEqual(got, tt.want) || !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseAccounts() = %+v, %v, want %+v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_multiAccountResult(t *testing.T) {
+	t.Parallel()
+
+	result := &multiAccountResult{Accounts: []accountResult{
+		{Account: "Compte courant", OutputFile: "courant.csv"},
+		{Account: "LIVRET A", OutputFile: "livret.csv", Err: errFake},
+	}}
+
+	var out bytes.Buffer
+
+	result.print(&out)
+
+	want := "Compte courant: saved to courant.csv\nLIVRET A: failed: clicking account: timeout\n"
+	if out.String() != want {
+		t.Errorf("print() = %q, want %q", out.String(), want)
+	}
+
+	err := result.err()
+	if !errors.Is(err, errAccountsFailed) || err.Error() != errAccountsFailed.Error()+": LIVRET A: "+errFake.Error() {
+		t.Errorf("err() = %v, want only LIVRET A failed", err)
+	}
+
+	single := &multiAccountResult{Accounts: []accountResult{{OutputFile: "out.csv", Err: errFake}}}
+	if err := single.err(); !errors.Is(err, errFake) || errors.Is(err, errAccountsFailed) {
+		t.Errorf("err() of a single account = %v, want %v as is", err, errFake)
+	}
+
+	result.Accounts[1].Err = nil
+	if err := result.err(); err != nil {
+		t.Errorf("err() = %v, want nil", err)
+	}
+}
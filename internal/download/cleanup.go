@@ -0,0 +1,50 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/paths"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/retention"
+)
+
+// screenshotPattern matches the files saveScreenshot writes.
+const screenshotPattern = "screenshot-*.png"
+
+var errCleanupOnly = errors.New("only valid with -cleanup")
+
+// ownArtifacts are the artifacts the retention flags expire. Only the directories of
+// the tool are cleaned, never one given with -screenshots.
+func ownArtifacts(opts *options, dirs paths.Dirs) []retention.Artifact {
+	if opts.screenshotDir != dirs.Screenshots() {
+		return nil
+	}
+
+	return []retention.Artifact{
+		{Name: "screenshots", Dir: opts.screenshotDir, Pattern: screenshotPattern, Policy: opts.retention},
+	}
+}
+
+// cleanup deletes the artifacts expired at now, logging each one. A dry run lists them
+// to w instead.
+func cleanup(w io.Writer, logger *slog.Logger, artifacts []retention.Artifact, now time.Time, dryRun bool) error {
+	removals, err := retention.Clean(artifacts, now, dryRun)
+
+	for _, removal := range removals {
+		if dryRun {
+			_, _ = fmt.Fprintf(w, "would remove %v %v (%v)\n",
+				removal.Artifact, removal.Path, removal.ModTime.Format(time.DateTime))
+		} else {
+			logger.Info("removed expired artifact", "artifact", removal.Artifact, "path", removal.Path)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("cleaning up: %w", err)
+	}
+
+	return nil
+}
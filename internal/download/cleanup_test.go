@@ -0,0 +1,83 @@
+package download
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/paths"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/retention"
+)
+
+func Test_ownArtifacts(t *testing.T) {
+	t.Parallel()
+
+	dirs := paths.Dirs{State: "/state/lcl-ynab/default"}
+	policy := retention.Policy{MaxCount: 5}
+
+	got := ownArtifacts(&options{screenshotDir: dirs.Screenshots(), retention: policy}, dirs)
+	if len(got) != 1 || got[0].Dir != dirs.Screenshots() || got[0].Policy != policy {
+		t.Errorf("ownArtifacts() = %+v, want the screenshots of the state dir", got)
+	}
+
+	if got := ownArtifacts(&options{screenshotDir: "/tmp/shots", retention: policy}, dirs); got != nil {
+		t.Errorf("ownArtifacts(-screenshots) = %+v, want nothing", got)
+	}
+}
+
+func Test_cleanup(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 11, 30, 3, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+
+	for name, days := range map[string]int{"screenshot-new.png": 1, "screenshot-old.png": 60, "notes.png": 60} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		modTime := now.Add(-time.Duration(days) * 24 * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifacts := []retention.Artifact{{
+		Name: "screenshots", Dir: dir, Pattern: screenshotPattern, Policy: retention.Policy{MaxAge: 30 * 24 * time.Hour},
+	}}
+
+	var stdout bytes.Buffer
+
+	if err := cleanup(&stdout, logging.Discard(), artifacts, now, true); err != nil {
+		t.Fatalf("cleanup(dry run) error = %v", err)
+	}
+
+	want := "would remove screenshots " + filepath.Join(dir, "screenshot-old.png")
+	if !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("stdout = %q, want it to start with %q", stdout.String(), want)
+	}
+
+	if err := cleanup(&stdout, logging.Discard(), artifacts, now, false); err != nil {
+		t.Fatalf("cleanup() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var left []string
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+
+	if want := []string{"notes.png", "screenshot-new.png"}; !slices.Equal(left, want) {
+		t.Errorf("left %v, want %v", left, want)
+	}
+}
@@ -0,0 +1,92 @@
+package download
+
+import (
+	"fmt"
+	"time"
+)
+
+// lclDateFormat is the date format of the LCL export form.
+const lclDateFormat = "02/01/2006"
+
+// maxExportMonths is the longest range LCL exports in one request.
+const maxExportMonths = 12
+
+// defaultDays is the number of days downloaded without -days, -from or -to.
+const defaultDays = 30
+
+type dateRange struct {
+	start time.Time
+	end   time.Time
+}
+
+// downloadState is persisted between runs with -state.
+type downloadState struct {
+	// LastSuccess is the end of the range of the last successful download.
+	LastSuccess time.Time `json:"last_success"`
+}
+
+// lastDays is the range of days days up to yesterday.
+func lastDays(now time.Time, days int) dateRange {
+	end := now.UTC().AddDate(0, 0, -1)
+
+	return dateRange{start: end.AddDate(0, 0, -days), end: end}
+}
+
+// parseRange reads the range of -from and -to, in the format of the LCL form.
+// It's zero when neither is given.
+func parseRange(from, to string) (dateRange, error) {
+	if from == "" && to == "" {
+		return dateRange{}, nil
+	}
+
+	if from == "" || to == "" {
+		return dateRange{}, fmt.Errorf("%w: give both -from and -to", errInvalidRange)
+	}
+
+	start, err := time.Parse(lclDateFormat, from)
+	if err != nil {
+		return dateRange{}, fmt.Errorf("%w: -from %q, want DD/MM/YYYY", errInvalidRange, from)
+	}
+
+	end, err := time.Parse(lclDateFormat, to)
+	if err != nil {
+		return dateRange{}, fmt.Errorf("%w: -to %q, want DD/MM/YYYY", errInvalidRange, to)
+	}
+
+	return dateRange{start: start, end: end}, nil
+}
+
+// check returns an error when r is empty or longer than LCL exports.
+func (r dateRange) check() error {
+	if !r.start.Before(r.end) {
+		return fmt.Errorf("%w: %v to %v, want the start before the end", errInvalidRange,
+			r.start.Format(lclDateFormat), r.end.Format(lclDateFormat))
+	}
+
+	if r.start.AddDate(0, maxExportMonths, 0).Before(r.end) {
+		return fmt.Errorf("%w: %v to %v, want at most %d months", errInvalidRange,
+			r.start.Format(lclDateFormat), r.end.Format(lclDateFormat), maxExportMonths)
+	}
+
+	return nil
+}
+
+// catchUp moves the start of rng back to the end of the last successful download when it is older,
+// but never more than maxMonths before the end of rng. It returns the extended range
+// and the number of days added.
+func catchUp(rng dateRange, lastSuccess time.Time, maxMonths int) (dateRange, int) {
+	if lastSuccess.IsZero() || !lastSuccess.Before(rng.start) {
+		return rng, 0
+	}
+
+	start := lastSuccess
+	if earliest := rng.end.AddDate(0, -maxMonths, 0); start.Before(earliest) {
+		start = earliest
+	}
+
+	const day = 24 * time.Hour
+
+	added := int(rng.start.Sub(start) / day)
+
+	return dateRange{start: start, end: rng.end}, added
+}
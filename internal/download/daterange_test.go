@@ -0,0 +1,110 @@
+package download
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func Test_catchUp(t *testing.T) {
+	t.Parallel()
+
+	rng := lastDays(date(2024, 11, 30), defaultDays)
+
+	tests := []struct {
+		name        string
+		lastSuccess time.Time
+		maxMonths   int
+		wantStart   time.Time
+		wantAdded   int
+	}{
+		{name: "no state", lastSuccess: time.Time{}, maxMonths: 12, wantStart: date(2024, 10, 30), wantAdded: 0},
+		{name: "recent run", lastSuccess: date(2024, 11, 28), maxMonths: 12, wantStart: date(2024, 10, 30), wantAdded: 0},
+		{name: "two weeks off", lastSuccess: date(2024, 11, 14), maxMonths: 12, wantStart: date(2024, 10, 30), wantAdded: 0},
+		{name: "two months off", lastSuccess: date(2024, 9, 28), maxMonths: 12, wantStart: date(2024, 9, 28), wantAdded: 32},
+		{name: "bounded", lastSuccess: date(2022, 1, 1), maxMonths: 3, wantStart: date(2024, 8, 29), wantAdded: 62},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotAdded := catchUp(rng, tt.lastSuccess, tt.maxMonths)
+			if !got.start.Equal(tt.wantStart) || !got.end.Equal(rng.end) {
+				t.Errorf("catchUp() = %v - %v, want %v - %v", got.start, got.end, tt.wantStart, rng.end)
+			}
+
+			if gotAdded != tt.wantAdded {
+				t.Errorf("catchUp() added = %v, want %v", gotAdded, tt.wantAdded)
+			}
+		})
+	}
+}
+
+func Test_lastDays(t *testing.T) {
+	t.Parallel()
+
+	got := lastDays(date(2024, 11, 30), 7)
+	if want := (dateRange{start: date(2024, 11, 22), end: date(2024, 11, 29)}); got != want {
+		t.Errorf("lastDays() = %v - %v, want %v - %v", got.start, got.end, want.start, want.end)
+	}
+}
+
+func Test_parseRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    dateRange
+		wantErr error
+	}{
+		{name: "none"},
+		{name: "both", from: "01/01/2024", to: "31/03/2024", want: dateRange{date(2024, 1, 1), date(2024, 3, 31)}},
+		{name: "only from", from: "01/01/2024", wantErr: errInvalidRange},
+		{name: "only to", to: "31/03/2024", wantErr: errInvalidRange},
+		{name: "ISO date", from: "2024-01-01", to: "31/03/2024", wantErr: errInvalidRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseRange(tt.from, tt.to)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseRange() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_dateRange_check(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		rng     dateRange
+		wantErr error
+	}{
+		{name: "month", rng: dateRange{date(2024, 10, 29), date(2024, 11, 29)}},
+		{name: "twelve months", rng: dateRange{date(2023, 11, 29), date(2024, 11, 29)}},
+		{name: "over twelve months", rng: dateRange{date(2023, 11, 28), date(2024, 11, 29)}, wantErr: errInvalidRange},
+		{name: "same day", rng: dateRange{date(2024, 11, 29), date(2024, 11, 29)}, wantErr: errInvalidRange},
+		{name: "reversed", rng: dateRange{date(2024, 11, 29), date(2024, 10, 29)}, wantErr: errInvalidRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.rng.check(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("check() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
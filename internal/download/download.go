@@ -0,0 +1,573 @@
+// Package download downloads LCL statements through a browser, as the download command.
+package download
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/config"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/paths"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/preflight"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/retention"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/timing"
+)
+
+const (
+	wantIdentifierLen = 10
+	wantPasswordLen   = 6
+)
+
+// LoginURL is the LCL login page, also checked by -preflight.
+const LoginURL = "https://monespace.lcl.fr/connexion"
+
+// Environment variables standing for flags left out.
+const (
+	envIdentifier = "LCL_IDENTIFIER"
+	envPassword   = "LCL_PASSWORD"
+)
+
+var (
+	errInvalidLen    = errors.New("invalid length")
+	errInvalidRange  = errors.New("invalid range")
+	errUnknownFormat = errors.New("unknown export format")
+)
+
+// exportFormats are the positions of the export formats in the file type selector of LCL.
+//
+//nolint:gochecknoglobals // constant lookup table
+var exportFormats = map[string]int{"csv": 0, "ofx": 2}
+
+type options struct {
+	configPath    string
+	identifier    string
+	password      string
+	outputFile    string
+	screenshotDir string
+	headless      bool
+	timings       bool
+	logLevel      string
+	logFormat     string
+	statePath     string
+	maxCatchup    int
+	profile       string
+	printPaths    bool
+	runID         string
+	format        string
+	days          int
+	from          string
+	to            string
+	accountsFlag  string
+	preflight     bool
+	cleanup       bool
+	dryRun        bool
+	// retention bounds the screenshots kept, see ownArtifacts.
+	retention retention.Policy
+	// rng is the range of -from and -to, zero when they aren't given.
+	rng dateRange
+	// accounts are the accounts to download, the first one to -o without -accounts.
+	accounts []accountTarget
+}
+
+// Browser is a session on the LCL website, driven by the download.
+type Browser interface {
+	// Login logs in with the credentials, then waits for the account list.
+	Login(identifier, password string) error
+	// OpenExport opens, from the account list, the export form of the account whose
+	// entry has the text account, the first one when account is empty.
+	OpenExport(account string) error
+	// FillExport fills the export form for the days from start to end, the file type
+	// being the one at position format in its selector.
+	FillExport(start, end time.Time, format int) error
+	// Save downloads the filled export to outputFile.
+	Save(outputFile string) error
+	// Back goes back to the account list.
+	Back() error
+	// Screenshot captures the page, to investigate failures.
+	Screenshot() ([]byte, error)
+}
+
+// LaunchOptions are what a Launcher starts the browser with.
+type LaunchOptions struct {
+	Headless bool
+	Stdout   io.Writer
+	Stderr   io.Writer
+	Logger   *slog.Logger
+	Timings  *timing.Recorder
+}
+
+// Launcher installs and starts the browser, returning it with the function closing it.
+type Launcher func(opts LaunchOptions) (Browser, func(), error)
+
+// Env is what the download depends on, replaced by commands running it and in tests.
+type Env struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Getenv func(string) string
+	Launch Launcher
+}
+
+// Main runs the download command with the arguments and environment of the process,
+// the browser being started by launch, then exits with 1 when it fails.
+func Main(launch Launcher) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := Run(ctx, os.Args[1:], Env{Stdout: os.Stdout, Stderr: os.Stderr, Getenv: os.Getenv, Launch: launch})
+
+	stop()
+
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Flags returns the flags of the command, unbound, for commands running the download
+// to accept them too.
+func Flags() *flag.FlagSet {
+	return newFlagSet(&options{})
+}
+
+// Run runs the download command with args, as Main does without exiting.
+func Run(ctx context.Context, args []string, env Env) error {
+	stdout, stderr := env.Stdout, env.Stderr
+
+	opts, err := parseFlags(args, env.Getenv)
+	if err != nil {
+		return err
+	}
+
+	dirs, err := paths.ResolveEnv(opts.profile, env.Getenv, runtime.GOOS)
+	if err != nil {
+		return fmt.Errorf("resolving paths: %w", err)
+	}
+
+	if opts.screenshotDir == "" {
+		opts.screenshotDir = dirs.Screenshots()
+	}
+
+	if opts.statePath == "" {
+		opts.statePath = dirs.DownloadState()
+	}
+
+	if opts.printPaths {
+		dirs.Print(stdout,
+			paths.Entry{Label: "state file", Path: opts.statePath},
+			paths.Entry{Label: "screenshots", Path: opts.screenshotDir},
+		)
+
+		return nil
+	}
+
+	logger, err := logging.New(stderr, opts.logLevel, opts.logFormat, opts.identifier, opts.password)
+	if err != nil {
+		return fmt.Errorf("configuring logs: %w", err)
+	}
+
+	if opts.runID == "" {
+		opts.runID = logging.NewRunID()
+	}
+
+	logger = logger.With(logging.RunIDKey, opts.runID)
+
+	artifacts := ownArtifacts(opts, dirs)
+	if opts.cleanup {
+		return cleanup(stdout, logger, artifacts, time.Now(), opts.dryRun)
+	}
+
+	// Last, so that the screenshot of a failure counts.
+	defer func() {
+		if err := cleanup(stdout, logger, artifacts, time.Now(), false); err != nil {
+			logger.Warn("expired artifacts left", "error", err)
+		}
+	}()
+
+	timings := timing.New(time.Now)
+
+	if opts.timings {
+		defer func() {
+			_, _ = fmt.Fprintln(stdout, "timings:")
+			_ = timings.WriteTable(stdout)
+		}()
+	}
+
+	if opts.preflight {
+		if err := runPreflight(ctx, stdout, logger, timings); err != nil {
+			return err
+		}
+	}
+
+	rng, previous, err := resolveRange(opts, logger)
+	if err != nil {
+		return err
+	}
+
+	browser, closeBrowser, err := env.Launch(LaunchOptions{
+		Headless: opts.headless,
+		Stdout:   stdout,
+		Stderr:   stderr,
+		Logger:   logger,
+		Timings:  timings,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer closeBrowser()
+
+	result, err := downloadFile(browser, logger, timings, opts, rng)
+	if err != nil {
+		saveScreenshot(browser, logger, opts.screenshotDir, opts.runID)
+		return err
+	}
+
+	if opts.accountsFlag != "" {
+		result.print(stdout)
+	}
+
+	// The state only moves on once every account is downloaded, the others are caught up next time.
+	if err := result.err(); err != nil {
+		return err
+	}
+
+	// A backfill with -from and -to doesn't move the last success back.
+	if rng.end.After(previous.LastSuccess) {
+		previous.LastSuccess = rng.end
+	}
+
+	if err := state.Save(opts.statePath, previous); err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	return nil
+}
+
+// resolveRange returns the range to download: the one of -from and -to as is, or the
+// last -days extended to cover the time since the last successful run recorded in the
+// state file.
+func resolveRange(opts *options, logger *slog.Logger) (dateRange, *downloadState, error) {
+	previous := &downloadState{}
+
+	if err := state.Load(opts.statePath, previous); err != nil {
+		return dateRange{}, nil, err //nolint:wrapcheck // already explicit
+	}
+
+	if !opts.rng.end.IsZero() {
+		return opts.rng, previous, nil
+	}
+
+	rng, added := catchUp(lastDays(time.Now(), opts.days), previous.LastSuccess, opts.maxCatchup)
+	if added > 0 {
+		logger.Warn("previous download is old, catching up",
+			"days", added,
+			"last_success", previous.LastSuccess.Format(time.DateOnly),
+			"from", rng.start.Format(time.DateOnly),
+		)
+	}
+
+	return rng, previous, nil
+}
+
+// runPreflight checks that LCL can be reached and that the clock, which the range to
+// download derives from, agrees with it.
+func runPreflight(ctx context.Context, stdout io.Writer, logger *slog.Logger, timings *timing.Recorder) error {
+	stop := timings.Start("preflight")
+	checks, err := preflight.Run(ctx, LoginURL, preflight.Options{})
+
+	stop()
+
+	preflight.Write(stdout, checks)
+
+	for _, check := range checks {
+		if check.Warning {
+			logger.Warn("preflight "+check.Name, "error", check.Err)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("preflight: %w", err)
+	}
+
+	return nil
+}
+
+// saveScreenshot names the screenshot after the run so that it can be matched with its logs.
+func saveScreenshot(browser Browser, logger *slog.Logger, dir, runID string) {
+	img, err := browser.Screenshot()
+	if err != nil {
+		logger.Error("saving screenshot", "error", err)
+		return
+	}
+
+	const perm = 0o755
+	_ = os.MkdirAll(dir, perm)
+
+	path := filepath.Join(dir, "screenshot-"+runID+".png")
+
+	file, err := os.Create(path)
+	if err != nil {
+		logger.Error("creating screenshot file", "error", err)
+		return
+	}
+
+	defer file.Close()
+	_, _ = file.Write(img)
+
+	logger.Info("saved screenshot", "path", path)
+}
+
+// newFlagSet returns the flags of the command, bound to opts.
+func newFlagSet(opts *options) *flag.FlagSet {
+	flagset := flag.NewFlagSet("", flag.ExitOnError)
+	flagset.StringVar(&opts.configPath, "config", config.DefaultPath,
+		"TOML or YAML file of default flag values, the flags and environment variables winning over it")
+	flagset.StringVar(&opts.identifier, "i", "", "Bank identifier (default $"+envIdentifier+")")
+	flagset.StringVar(&opts.password, "p", "", "Bank password (default $"+envPassword+")")
+	flagset.StringVar(&opts.outputFile, "o", "", "Output file")
+	flagset.StringVar(&opts.screenshotDir, "screenshots", "",
+		"Directory receiving screenshots of failures (default in the state dir)")
+	flagset.BoolVar(&opts.headless, "headless", false, "Headless mode")
+	flagset.BoolVar(&opts.timings, "timings", false, "Print the duration of each phase")
+	flagset.StringVar(&opts.statePath, "state", "",
+		"State file remembering the last successful download (default in the state dir)")
+	flagset.StringVar(&opts.profile, "profile", paths.DefaultProfile, "Profile namespacing the state and config locations")
+	flagset.BoolVar(&opts.printPaths, "print-paths", false, "Print the resolved file locations and exit")
+	flagset.IntVar(&opts.maxCatchup, "max-catchup", maxExportMonths,
+		"Maximum number of months downloaded to catch up since the last successful download")
+	flagset.StringVar(&opts.logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	flagset.StringVar(&opts.logFormat, "log-format", logging.FormatText, "Log format: text or json")
+	flagset.StringVar(&opts.runID, "run-id", "", "ID correlating the logs and screenshots of this run (default: random)")
+	flagset.StringVar(&opts.format, "format", "csv", "Export format: csv or ofx")
+	flagset.StringVar(&opts.accountsFlag, "accounts", "",
+		"Comma-separated name:file pairs downloading each account whose entry has the text name to file, "+
+			"instead of the first account to -o")
+	flagset.BoolVar(&opts.preflight, "preflight", false,
+		"Check that LCL can be reached, and warn when the clock is off from it, before logging in")
+	flagset.DurationVar(&opts.retention.MaxAge, "retention-screenshots-max-age", 0,
+		"Age above which the screenshots of the state dir are deleted at the end of each run, 0 to keep them")
+	flagset.IntVar(&opts.retention.MaxCount, "retention-screenshots-max-count", 0,
+		"Number of most recent screenshots of the state dir kept at the end of each run, 0 to keep them all")
+	flagset.BoolVar(&opts.cleanup, "cleanup", false,
+		"Delete the screenshots the retention flags expire, then exit")
+	flagset.BoolVar(&opts.dryRun, "dry-run", false, "With -cleanup, list the screenshots it would delete")
+	flagset.IntVar(&opts.days, "days", defaultDays, "Number of days downloaded up to yesterday")
+	flagset.StringVar(&opts.from, "from", "", "First day downloaded, as DD/MM/YYYY, with -to and instead of -days")
+	flagset.StringVar(&opts.to, "to", "", "Last day downloaded, as DD/MM/YYYY, with -from and instead of -days")
+
+	return flagset
+}
+
+// parseFlags reads the command line, the identifier and password left out falling
+// back to the environment variables read by getenv, then the flags left out to the
+// config file.
+func parseFlags(args []string, getenv func(string) string) (*options, error) {
+	opts := &options{}
+
+	flagset := newFlagSet(opts)
+
+	err := flagset.Parse(args)
+	if err != nil {
+		return nil, fmt.Errorf("parsing flags: %w", err)
+	}
+
+	if opts.printPaths {
+		return opts, nil
+	}
+
+	opts.identifier = cmp.Or(opts.identifier, getenv(envIdentifier))
+	opts.password = cmp.Or(opts.password, getenv(envPassword))
+
+	if err := config.ApplyFile(flagset, nil, opts.configPath, getenv, configAliases()); err != nil {
+		return nil, err //nolint:wrapcheck // already explicit
+	}
+
+	if err := opts.retention.Check(); err != nil {
+		return nil, err //nolint:wrapcheck // already explicit
+	}
+
+	if opts.dryRun && !opts.cleanup {
+		return nil, fmt.Errorf("%w: -dry-run", errCleanupOnly)
+	}
+
+	// Cleaning up needs no credentials.
+	if opts.cleanup {
+		return opts, nil
+	}
+
+	if len(opts.identifier) != wantIdentifierLen {
+		return nil, fmt.Errorf("%w for identifier: %d, want %d", errInvalidLen, len(opts.identifier), wantIdentifierLen)
+	}
+
+	if len(opts.password) != wantPasswordLen {
+		return nil, fmt.Errorf("%w for password: %d, want %d", errInvalidLen, len(opts.password), wantPasswordLen)
+	}
+
+	if opts.runID != "" {
+		if err := logging.CheckRunID(opts.runID); err != nil {
+			return nil, err //nolint:wrapcheck // already explicit
+		}
+	}
+
+	if _, ok := exportFormats[opts.format]; !ok {
+		return nil, fmt.Errorf("%w: %q, want csv or ofx", errUnknownFormat, opts.format)
+	}
+
+	if opts.maxCatchup < 1 || opts.maxCatchup > maxExportMonths {
+		return nil, fmt.Errorf("%w: -max-catchup %d, want 1 to %d", errInvalidRange, opts.maxCatchup, maxExportMonths)
+	}
+
+	if opts.days < 1 {
+		return nil, fmt.Errorf("%w: -days %d, want 1 or more", errInvalidRange, opts.days)
+	}
+
+	opts.rng, err = parseRange(opts.from, opts.to)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.accounts = []accountTarget{{outputFile: opts.outputFile}}
+
+	if opts.accountsFlag != "" {
+		if opts.outputFile != "" {
+			return nil, fmt.Errorf("%w: give the files in -accounts, not -o", errInvalidAccounts)
+		}
+
+		opts.accounts, err = parseAccounts(opts.accountsFlag)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rng := opts.rng
+	if rng.end.IsZero() {
+		rng = lastDays(time.Now(), opts.days)
+	}
+
+	if err := rng.check(); err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// configAliases are the config keys standing for the one-letter flags.
+func configAliases() map[string]string {
+	return map[string]string{"identifier": "i", "password": "p", "output_file": "o"}
+}
+
+// downloadFile logs in and downloads the statement of each account of opts, in turn.
+// The result reports the accounts that failed, the error is the one of the login.
+func downloadFile(
+	browser Browser,
+	logger *slog.Logger,
+	timings *timing.Recorder,
+	opts *options,
+	rng dateRange,
+) (*multiAccountResult, error) {
+	logger.Debug("logging in")
+
+	stop := timings.Start("login")
+	err := browser.Login(opts.identifier, opts.password)
+
+	stop()
+
+	if err != nil {
+		return nil, fmt.Errorf("logging in: %w", err)
+	}
+
+	result := &multiAccountResult{}
+
+	for i, target := range opts.accounts {
+		accountLogger := logger
+		if target.name != "" {
+			accountLogger = logger.With("account", target.name)
+		}
+
+		if i > 0 {
+			if err := browser.Back(); err != nil {
+				return result, fmt.Errorf("going back to the accounts: %w", err)
+			}
+		}
+
+		err := downloadAccount(browser, accountLogger, timings, opts, rng, target)
+		if err != nil {
+			screenshotID := opts.runID
+			if target.name != "" {
+				screenshotID += "-" + strconv.Itoa(i+1)
+			}
+
+			saveScreenshot(browser, accountLogger, opts.screenshotDir, screenshotID)
+		}
+
+		result.Accounts = append(result.Accounts, accountResult{
+			Account: target.name, OutputFile: target.outputFile, Err: err,
+		})
+	}
+
+	return result, nil
+}
+
+// downloadAccount downloads the statement of the account of target, from the account list.
+func downloadAccount(
+	browser Browser,
+	logger *slog.Logger,
+	timings *timing.Recorder,
+	opts *options,
+	rng dateRange,
+	target accountTarget,
+) error {
+	phase := func(name string) string {
+		if target.name == "" {
+			return name
+		}
+
+		return name + " " + target.name
+	}
+
+	logger.Debug("navigating to export form")
+
+	stop := timings.Start(phase("navigation"))
+	err := browser.OpenExport(target.name)
+
+	stop()
+
+	if err != nil {
+		return fmt.Errorf("navigating to form: %w", err)
+	}
+
+	logger.Debug("filling export form")
+
+	stop = timings.Start(phase("form"))
+	err = browser.FillExport(rng.start, rng.end, exportFormats[opts.format])
+
+	stop()
+
+	if err != nil {
+		return fmt.Errorf("filling form: %w", err)
+	}
+
+	logger.Debug("downloading statement", "path", target.outputFile)
+
+	stop = timings.Start(phase("download"))
+	err = browser.Save(target.outputFile)
+
+	stop()
+
+	if err != nil {
+		return fmt.Errorf("downloading and saving: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package download
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/config"
+)
+
+// sharedConfig configures both download and push.
+const sharedConfig = `token = "conf-tok"
+budget_id = "conf-bud"
+account_id = "conf-acc"
+identifier = "0123456789"
+password = "123456"
+headless = true
+drift-alert = 5
+
+[retention]
+screenshots_max_age = "720h"
+`
+
+func Test_parseFlags_sharedConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(sharedConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	getenv := func(string) string { return "" }
+
+	got, err := parseFlags([]string{"-config", path, "-o", "statement.csv"}, getenv)
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+
+	if got.identifier != "0123456789" || got.password != "123456" || !got.headless ||
+		got.retention.MaxAge != 720*time.Hour {
+		t.Errorf("parseFlags() = %+v, want the values of the config", got)
+	}
+
+	if err := os.WriteFile(path, []byte(sharedConfig+"tokn = \"typo\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys of no command still fail.
+	_, err = parseFlags([]string{"-config", path, "-o", "statement.csv"}, getenv)
+	if !errors.Is(err, config.ErrUnknownKey) {
+		t.Errorf("parseFlags() error = %v, want %v", err, config.ErrUnknownKey)
+	}
+}
+
+func TestConfigKeys(t *testing.T) {
+	t.Parallel()
+
+	var keys []string
+
+	newFlagSet(&options{}).VisitAll(func(f *flag.Flag) { keys = append(keys, f.Name) })
+
+	for key := range configAliases() {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	if want := config.Keys(config.CommandDownload); !slices.Equal(keys, want) {
+		t.Errorf("flags and aliases = %q, want the keys of the config %q", keys, want)
+	}
+}
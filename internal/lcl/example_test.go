@@ -0,0 +1,52 @@
+package lcl_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/lcl"
+)
+
+func ExampleParse() {
+	export := strings.NewReader("29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers\n" +
+		"29/11/2024;100,06;;01234 123456A")
+
+	statement, err := lcl.Parse(export, lcl.Options{AccountID: "acc-id"})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, t := range statement.Transactions {
+		fmt.Println(t.Date.Format("2006-01-02"), t.Amount, t.Payee)
+	}
+
+	fmt.Println(statement.AccountRef, statement.Balance, statement.BalanceDate.Format("2006-01-02"))
+	// Output:
+	// 2024-10-28 -21320 CB  MERCH
+	// 01234 123456A 100060 2024-11-29
+}
+
+func ExampleParse_rules() {
+	export := strings.NewReader("29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers\n" +
+		"29/11/2024;100,06;;01234 123456A")
+
+	statement, err := lcl.Parse(export, lcl.Options{
+		BookingDate:    true,
+		NormalizePayee: func(payee string) string { return strings.TrimPrefix(payee, "CB  ") },
+		Rules: []lcl.Rule{
+			func(t *lcl.Transaction) {
+				if t.Payee == "MERCH" {
+					t.Payee = "Merchant"
+				}
+			},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	t := statement.Transactions[0]
+	fmt.Println(t.Date.Format("2006-01-02"), t.Payee)
+	// Output:
+	// 2024-10-29 Merchant
+}
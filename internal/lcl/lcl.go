@@ -0,0 +1,399 @@
+// Package lcl parses the CSV exports of LCL bank accounts.
+//
+// An export lists one transaction per line, most recent last, and ends with a
+// footer giving the balance of the account, the day it applies to and the
+// account reference:
+//
+//	29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;
+//	29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers
+//	29/11/2024;100,06;;01234 123456A
+package lcl
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const (
+	dateFormat      = "02/01/2006"
+	labelDateFormat = "02/01/06"
+	labelDateLen    = len(labelDateFormat)
+	minFields       = 6
+	milliUnit       = 1000
+	milliDigits     = 3
+	// maxDecimals are the cents of a currency amount.
+	maxDecimals = 2
+)
+
+var (
+	// ErrInvalidAmount is returned by ParseAmount for a malformed amount.
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrInvalidFooter is returned by Parse for a footer whose date or balance doesn't parse.
+	ErrInvalidFooter = errors.New("invalid footer")
+)
+
+// rateLabelRegexp matches the labels of exchange rate lines, like "1 USD = 0,931 EUR",
+// optionally after the amount in the other currency, like "50,00 USD 1 USD = 0,931 EUR".
+var rateLabelRegexp = regexp.MustCompile(
+	`^\s*(?:\d[\d ]*(?:[.,]\d+)?\s+[A-Z]{3}\s+)?\d+(?:[.,]\d+)?\s*[A-Z]{3}\s*=\s*\d+(?:[.,]\d+)?\s*[A-Z]{3}\s*$`)
+
+// Milliunits is an amount in thousandths of the currency unit, as YNAB counts them.
+type Milliunits int
+
+// Statement is the content of an export.
+type Statement struct {
+	Transactions []Transaction
+	// RateLines are the exchange rate lines left out of Transactions.
+	RateLines []RateLine
+	// Balance is the balance of the account given in the footer.
+	Balance Milliunits
+	// BalanceDate is the day Balance applies to, zero when the footer doesn't say.
+	BalanceDate time.Time
+	// AccountRef is the agency and account number given in the footer, e.g. "01234 123456A".
+	AccountRef string
+}
+
+// Transaction is one line of an export.
+type Transaction struct {
+	AccountID string
+	// Date is the day of the card payment when the label gives it, the booking date otherwise.
+	Date   time.Time
+	Amount Milliunits
+	// Payee is the label without the card payment date.
+	Payee string
+	// Label is the full label of the line.
+	Label string
+	// Type is the kind of transaction as the bank writes it, e.g. "Carte" or "Virement",
+	// empty when the line doesn't say.
+	Type string
+	// Line is the fields of the line joined by semicolons, as the export has them unless
+	// it quotes some. It's only kept with Options.KeepLines.
+	Line string
+}
+
+// RateLine is an informational line LCL adds after some card payments in another
+// currency, giving the exchange rate. It isn't a transaction, its tiny amount is bogus.
+type RateLine struct {
+	Transaction
+	// Folded is set when its label was added to the label of the payment before it,
+	// see Options.FoldRates.
+	Folded bool
+}
+
+// Progress tells how far Parse went.
+type Progress struct {
+	// Lines is the number of lines read, the footer included.
+	Lines int
+	// Transactions is the number of transactions parsed.
+	Transactions int
+}
+
+// Rule adjusts a parsed transaction, e.g. to rename a payee.
+type Rule func(t *Transaction)
+
+// Options tune how transactions are read.
+type Options struct {
+	// AccountID is copied to every transaction.
+	AccountID string
+	// BookingDate dates card payments on the day the bank booked them,
+	// rather than on the day of the payment found in their label.
+	BookingDate bool
+	// StripHolder, when set, removes the account holders ending payees, like the
+	// "M OU MME MARTIN" of joint accounts, before NormalizePayee. A payee it would
+	// empty is kept whole.
+	StripHolder func(payee string) string
+	// NormalizePayee, when set, replaces each payee with its result.
+	NormalizePayee func(payee string) string
+	// Rules are applied in order to each transaction, after NormalizePayee.
+	Rules []Rule
+	// Progress, when set, is called after each line.
+	Progress func(Progress)
+	// KeepLines fills the Line of each transaction.
+	KeepLines bool
+	// FoldRates adds the label of each exchange rate line to the label of the payment
+	// right before it, when that payment was booked the same day and has no rate yet.
+	// Other exchange rate lines are only left out.
+	FoldRates bool
+}
+
+// Parse reads an export. A byte order mark is skipped, and a nil reader or an
+// empty export give an empty statement.
+func Parse(r io.Reader, opts Options) (Statement, error) {
+	return ParseContext(context.Background(), r, opts)
+}
+
+// ParseContext is Parse, stopping with the error of ctx between two lines once it's done.
+func ParseContext(ctx context.Context, r io.Reader, opts Options) (Statement, error) {
+	if r == nil {
+		return Statement{}, nil
+	}
+
+	transformer := unicode.BOMOverride(encoding.Nop.NewDecoder())
+
+	csvReader := csv.NewReader(transform.NewReader(r, transformer))
+	csvReader.Comma = ';'
+	// Fields are copied out of the record, its slice can be reused.
+	csvReader.ReuseRecord = true
+
+	var (
+		statement Statement
+		progress  Progress
+		// foldable is the index of the transaction an exchange rate line can be folded
+		// into, -1 when the line before wasn't a transaction or already got a rate.
+		foldable = -1
+		booked   string
+	)
+
+	done := ctx.Done()
+
+	for {
+		select {
+		case <-done:
+			return Statement{}, fmt.Errorf("after line %d: %w", progress.Lines, ctx.Err())
+		default:
+		}
+
+		record, err := csvReader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		progress.Lines++
+
+		// The footer has fewer fields than transactions, and is alone in an
+		// export without transactions.
+		if errors.Is(err, csv.ErrFieldCount) || (err == nil && len(record) < minFields) {
+			if err := readFooter(record, &statement); err != nil {
+				return Statement{}, err
+			}
+
+			report(opts.Progress, progress)
+
+			return statement, nil
+		}
+
+		if err != nil {
+			return Statement{}, fmt.Errorf("reading csv line: %w", err)
+		}
+
+		transaction, err := parseLine(record, opts)
+		if err != nil {
+			return Statement{}, fmt.Errorf("converting line: %w", err)
+		}
+
+		if IsRateLabel(transaction.Label) {
+			rate := RateLine{Transaction: transaction}
+
+			if opts.FoldRates && foldable >= 0 && record[0] == booked {
+				payment := &statement.Transactions[foldable]
+				payment.Label += " " + strings.TrimSpace(transaction.Label)
+				rate.Folded = true
+			}
+
+			statement.RateLines = append(statement.RateLines, rate)
+			foldable = -1
+
+			report(opts.Progress, progress)
+
+			continue
+		}
+
+		statement.Transactions = append(statement.Transactions, transaction)
+		foldable, booked = len(statement.Transactions)-1, record[0]
+		progress.Transactions++
+		report(opts.Progress, progress)
+	}
+
+	return statement, nil
+}
+
+func report(callback func(Progress), progress Progress) {
+	if callback != nil {
+		callback(progress)
+	}
+}
+
+func parseLine(record []string, opts Options) (Transaction, error) {
+	date, err := time.Parse(dateFormat, record[0])
+	if err != nil {
+		return Transaction{}, fmt.Errorf("parsing date: %w", err)
+	}
+
+	amount, err := ParseAmount(record[1])
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	label := record[4]
+	if amount > 0 {
+		label = record[5]
+	}
+
+	payee, labelDate, ok := splitLabel(label)
+	if ok && !opts.BookingDate {
+		date = labelDate
+	}
+
+	transaction := Transaction{
+		AccountID: opts.AccountID,
+		Date:      date,
+		Amount:    amount,
+		Payee:     payee,
+		Label:     label,
+		Type:      record[2],
+	}
+
+	if opts.KeepLines {
+		transaction.Line = strings.Join(record, ";")
+	}
+
+	if opts.StripHolder != nil {
+		if stripped := opts.StripHolder(transaction.Payee); stripped != "" {
+			transaction.Payee = stripped
+		}
+	}
+
+	if opts.NormalizePayee != nil {
+		transaction.Payee = opts.NormalizePayee(transaction.Payee)
+	}
+
+	for _, rule := range opts.Rules {
+		rule(&transaction)
+	}
+
+	return transaction, nil
+}
+
+// ParseAmount parses an amount written with a decimal comma, like "-21,32", or a decimal
+// point, like "-21.32", with at most two decimals. Spaces grouping the thousands are
+// ignored. The digits are read as integers so that no amount is off by a milliunit.
+func ParseAmount(raw string) (Milliunits, error) {
+	s := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\u00a0' || r == '\u202f' {
+			return -1
+		}
+
+		return r
+	}, raw)
+
+	sign := Milliunits(1)
+
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		sign, s = -1, rest
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+
+	units, decimals, _ := strings.Cut(strings.Replace(s, ",", ".", 1), ".")
+	if units == "" || !isDigits(units) || !isDigits(decimals) || len(decimals) > maxDecimals {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, raw)
+	}
+
+	amount, err := strconv.Atoi(units)
+	if err != nil {
+		return 0, fmt.Errorf("parsing amount: %w", err)
+	}
+
+	fraction := 0
+
+	for i := range milliDigits {
+		fraction *= 10
+
+		if i < len(decimals) {
+			fraction += int(decimals[i] - '0')
+		}
+	}
+
+	return sign * Milliunits(amount*milliUnit+fraction), nil
+}
+
+// isDigits reports whether s only has ASCII digits.
+func isDigits(s string) bool {
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readFooter reads the date the balance applies to, the balance and the account reference.
+// The date may be missing, not the balance, so that a file that isn't an export doesn't
+// pass for an empty one.
+func readFooter(record []string, statement *Statement) error {
+	if record[0] != "" {
+		date, err := time.Parse(dateFormat, record[0])
+		if err != nil {
+			return fmt.Errorf("%w: date %q", ErrInvalidFooter, record[0])
+		}
+
+		statement.BalanceDate = date
+	}
+
+	if len(record) < 2 { //nolint:mnd // date, balance
+		return fmt.Errorf("%w: no balance in %q", ErrInvalidFooter, strings.Join(record, ";"))
+	}
+
+	amount, err := ParseAmount(record[1])
+	if err != nil {
+		return fmt.Errorf("%w: balance: %w", ErrInvalidFooter, err)
+	}
+
+	statement.Balance = amount
+
+	if len(record) > 3 { //nolint:mnd // date, balance, empty field, reference
+		statement.AccountRef = strings.TrimSpace(record[3])
+	}
+
+	return nil
+}
+
+// IsRateLabel reports whether label is the one of an exchange rate line, like
+// "1 USD = 0,931 EUR".
+func IsRateLabel(label string) bool {
+	return rateLabelRegexp.MatchString(label)
+}
+
+// splitLabel returns the payee and the date card payment labels end with,
+// or the whole label when it doesn't end with a date.
+func splitLabel(label string) (payee string, date time.Time, ok bool) {
+	if len(label) < labelDateLen || !looksLikeLabelDate(label[len(label)-labelDateLen:]) {
+		return label, time.Time{}, false
+	}
+
+	date, err := time.Parse(labelDateFormat, label[len(label)-labelDateLen:])
+	if err != nil {
+		return label, time.Time{}, false
+	}
+
+	return strings.TrimSpace(label[:len(label)-labelDateLen]), date, true
+}
+
+// looksLikeLabelDate reports whether s has the dd/mm/yy shape, sparing time.Parse
+// and its error allocation on the labels that don't end with a date.
+func looksLikeLabelDate(s string) bool {
+	for i := range len(s) {
+		if i == 2 || i == 5 { //nolint:mnd // positions of the slashes in dd/mm/yy
+			if s[i] != '/' {
+				return false
+			}
+		} else if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
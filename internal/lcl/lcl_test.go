@@ -0,0 +1,409 @@
+package lcl
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const export = "\ufeff29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;\n" +
+	"29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers\n" +
+	"29/11/2024;100,06;;01234 123456A"
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	transfer := Transaction{
+		AccountID: "acc-id",
+		Date:      date(2024, 10, 29),
+		Amount:    80000,
+		Payee:     "VIREMENT M JEAN MARTIN OU",
+		Label:     "VIREMENT M JEAN MARTIN OU",
+		Type:      "Virement",
+	}
+	payment := Transaction{
+		AccountID: "acc-id",
+		Date:      date(2024, 10, 28),
+		Amount:    -21320,
+		Payee:     "CB  MERCH",
+		Label:     "CB  MERCH          28/10/24",
+		Type:      "Carte",
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    Options
+		want    Statement
+		wantErr bool
+	}{
+		{
+			name:  "export",
+			input: export,
+			opts:  Options{AccountID: "acc-id"},
+			want: Statement{
+				Transactions: []Transaction{transfer, payment},
+				Balance:      100060,
+				BalanceDate:  date(2024, 11, 29),
+				AccountRef:   "01234 123456A",
+			},
+		},
+		{
+			name:  "booking date",
+			input: export,
+			opts:  Options{AccountID: "acc-id", BookingDate: true},
+			want: Statement{
+				Transactions: []Transaction{transfer, withDate(payment, date(2024, 10, 29))},
+				Balance:      100060,
+				BalanceDate:  date(2024, 11, 29),
+				AccountRef:   "01234 123456A",
+			},
+		},
+		{
+			name:  "normalization then rules",
+			input: export,
+			opts: Options{
+				AccountID:      "acc-id",
+				NormalizePayee: strings.ToLower,
+				Rules: []Rule{func(t *Transaction) {
+					if t.Payee == "cb  merch" {
+						t.Payee = "Merchant"
+					}
+				}},
+			},
+			want: Statement{
+				Transactions: []Transaction{
+					withPayee(transfer, "virement m jean martin ou"),
+					withPayee(payment, "Merchant"),
+				},
+				Balance:     100060,
+				BalanceDate: date(2024, 11, 29),
+				AccountRef:  "01234 123456A",
+			},
+		},
+		{
+			name:  "strip holder",
+			input: export,
+			opts: Options{
+				AccountID: "acc-id",
+				StripHolder: func(payee string) string {
+					if payee == "CB  MERCH" {
+						return ""
+					}
+
+					return strings.TrimSuffix(payee, " M JEAN MARTIN OU")
+				},
+				NormalizePayee: strings.ToLower,
+			},
+			want: Statement{
+				Transactions: []Transaction{withPayee(transfer, "virement"), withPayee(payment, "cb  merch")},
+				Balance:      100060,
+				BalanceDate:  date(2024, 11, 29),
+				AccountRef:   "01234 123456A",
+			},
+		},
+		{
+			name:  "footer without date",
+			input: ";100,06;;01234 123456A",
+			opts:  Options{},
+			want:  Statement{Balance: 100060, AccountRef: "01234 123456A"},
+		},
+		{
+			name:  "empty",
+			input: "",
+			opts:  Options{},
+			want:  Statement{},
+		},
+		{
+			name:    "invalid amount",
+			input:   "29/10/2024;eighty;Virement;;;VIREMENT M JEAN MARTIN OU;;",
+			opts:    Options{},
+			want:    Statement{},
+			wantErr: true,
+		},
+		{
+			name:    "not an export",
+			input:   "garbage;x",
+			opts:    Options{},
+			want:    Statement{},
+			wantErr: true,
+		},
+		{
+			name:    "footer with unparsable balance",
+			input:   "29/11/2024;x;;01234 123456A",
+			opts:    Options{},
+			want:    Statement{},
+			wantErr: true,
+		},
+		{
+			name: "transaction then unparsable footer",
+			input: `29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;
+2024-11-29;100,06;;01234 123456A`,
+			opts:    Options{},
+			want:    Statement{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(strings.NewReader(tt.input), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_nilReader(t *testing.T) {
+	t.Parallel()
+
+	got, err := Parse(nil, Options{})
+	if err != nil || !reflect.DeepEqual(got, Statement{}) {
+		t.Errorf("Parse(nil) = %+v, %v, want an empty statement", got, err)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s       string
+		want    Milliunits
+		wantErr error
+	}{
+		{s: "80", want: 80000, wantErr: nil},
+		{s: "-21,32", want: -21320, wantErr: nil},
+		{s: "2,01", want: 2010, wantErr: nil},
+		{s: "-0,29", want: -290, wantErr: nil},
+		{s: "0,1", want: 100, wantErr: nil},
+		{s: "-0,01", want: -10, wantErr: nil},
+		{s: "1234,56", want: 1234560, wantErr: nil},
+		{s: "1 234,56", want: 1234560, wantErr: nil},
+		{s: "1\u00a0234,56", want: 1234560, wantErr: nil},
+		{s: "-12.5", want: -12500, wantErr: nil},
+		{s: "+0,05", want: 50, wantErr: nil},
+		{s: "+0,001", want: 0, wantErr: ErrInvalidAmount},
+		{s: "-21,325", want: 0, wantErr: ErrInvalidAmount},
+		{s: "", want: 0, wantErr: ErrInvalidAmount},
+		{s: ",5", want: 0, wantErr: ErrInvalidAmount},
+		{s: "1,2,3", want: 0, wantErr: ErrInvalidAmount},
+		{s: "1,0001", want: 0, wantErr: ErrInvalidAmount},
+		{s: "12e3", want: 0, wantErr: ErrInvalidAmount},
+		{s: "-", want: 0, wantErr: ErrInvalidAmount},
+		{s: "12,-5", want: 0, wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseAmount(tt.s)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("ParseAmount(%q) = %v, %v, want %v, %v", tt.s, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_keepLines(t *testing.T) {
+	t.Parallel()
+
+	statement, err := Parse(strings.NewReader(export), Options{KeepLines: true})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := "29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers"
+	if got := statement.Transactions[1].Line; got != want {
+		t.Errorf("Line = %q, want %q", got, want)
+	}
+}
+
+func TestParse_rateLines(t *testing.T) {
+	t.Parallel()
+
+	// The second rate line was booked a day after the payment before it, too far to be
+	// sure they go together.
+	const foreign = "02/11/2024;-46,55;Carte;;CB  SHOP NEW YORK     31/10/24;;0;Divers\n" +
+		"02/11/2024;-0,93;Carte;;50,00 USD 1 USD = 0,931 EUR;;0;Divers\n" +
+		"04/11/2024;-12,40;Carte;;CB  CAFE LONDON       03/11/24;;0;Divers\n" +
+		"05/11/2024;-1,18;Carte;;10,50 GBP 1 GBP = 1,181 EUR;;0;Divers\n" +
+		"29/11/2024;910,12;;01234 123456A"
+
+	tests := []struct {
+		name       string
+		fold       bool
+		wantLabels []string
+		wantFolded []bool
+	}{
+		{
+			name:       "skipped",
+			wantLabels: []string{"CB  SHOP NEW YORK     31/10/24", "CB  CAFE LONDON       03/11/24"},
+			wantFolded: []bool{false, false},
+		},
+		{
+			name:       "folded",
+			fold:       true,
+			wantLabels: []string{"CB  SHOP NEW YORK     31/10/24 50,00 USD 1 USD = 0,931 EUR", "CB  CAFE LONDON       03/11/24"},
+			wantFolded: []bool{true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			statement, err := Parse(strings.NewReader(foreign), Options{FoldRates: tt.fold})
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			labels := make([]string, 0, len(statement.Transactions))
+			for _, transaction := range statement.Transactions {
+				labels = append(labels, transaction.Label)
+			}
+
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("labels = %q, want %q", labels, tt.wantLabels)
+			}
+
+			folded := make([]bool, 0, len(statement.RateLines))
+			for _, rate := range statement.RateLines {
+				folded = append(folded, rate.Folded)
+			}
+
+			if !reflect.DeepEqual(folded, tt.wantFolded) {
+				t.Errorf("rate lines folded = %v, want %v", folded, tt.wantFolded)
+			}
+
+			if payee := statement.Transactions[0].Payee; payee != "CB  SHOP NEW YORK" {
+				t.Errorf("payee = %q, want it untouched", payee)
+			}
+		})
+	}
+}
+
+func TestIsRateLabel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		label string
+		want  bool
+	}{
+		{label: "1 USD = 0,931 EUR", want: true},
+		{label: "50,00 USD 1 USD = 0,931 EUR", want: true},
+		{label: "1 234,50 CHF 1 CHF = 1,0642 EUR", want: true},
+		{label: "1 EUR=1.0741 USD", want: true},
+		{label: "CB  SHOP NEW YORK     31/10/24", want: false},
+		{label: "VIR 1 USD = 0,93 EUR REMBOURSEMENT", want: false},
+		{label: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRateLabel(tt.label); got != tt.want {
+			t.Errorf("IsRateLabel(%q) = %v, want %v", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestParse_progress(t *testing.T) {
+	t.Parallel()
+
+	var got []Progress
+
+	_, err := Parse(strings.NewReader(export), Options{Progress: func(p Progress) { got = append(got, p) }})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []Progress{{Lines: 1, Transactions: 1}, {Lines: 2, Transactions: 2}, {Lines: 3, Transactions: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("progress = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseContext_cancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lines := 0
+
+	_, err := ParseContext(ctx, strings.NewReader(largeExport(1000)), Options{Progress: func(p Progress) {
+		lines = p.Lines
+		if p.Lines == 2 {
+			cancel()
+		}
+	}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ParseContext() error = %v, want context.Canceled", err)
+	}
+
+	if lines != 2 {
+		t.Errorf("read %d lines, want to stop right after the cancellation at line 2", lines)
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, size := range []struct {
+		name  string
+		lines int
+	}{{"small", 10}, {"large", 10000}} {
+		input := largeExport(size.lines)
+
+		b.Run(size.name, func(b *testing.B) {
+			for range b.N {
+				if _, err := Parse(strings.NewReader(input), Options{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(size.name+" with progress", func(b *testing.B) {
+			var last Progress
+
+			for range b.N {
+				_, err := ParseContext(context.Background(), strings.NewReader(input),
+					Options{Progress: func(p Progress) { last = p }})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			_ = last
+		})
+	}
+}
+
+// largeExport returns an export of n card payments.
+func largeExport(n int) string {
+	return strings.Repeat("29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers\n", n) +
+		"29/11/2024;100,06;;01234 123456A"
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func withDate(t Transaction, date time.Time) Transaction {
+	t.Date = date
+
+	return t
+}
+
+func withPayee(t Transaction, payee string) Transaction {
+	t.Payee = payee
+
+	return t
+}
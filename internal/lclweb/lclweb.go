@@ -0,0 +1,189 @@
+// Package lclweb drives the LCL website in Firefox with Playwright, for the download.
+package lclweb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/download"
+	"github.com/playwright-community/playwright-go"
+)
+
+// dateFormat is the date format of the LCL export form.
+const dateFormat = "02/01/2006"
+
+// browser is a download.Browser on a Playwright page.
+type browser struct {
+	page playwright.Page
+	// home is the account list, reached after logging in.
+	home string
+}
+
+// Launch installs Firefox, then starts it with a page to download from.
+func Launch(opts download.LaunchOptions) (download.Browser, func(), error) {
+	opts.Logger.Debug("installing browser")
+
+	stopInstall := opts.Timings.Start("browser install")
+	err := playwright.Install(&playwright.RunOptions{
+		Browsers: []string{"firefox"},
+		Stdout:   opts.Stdout,
+		Stderr:   opts.Stderr,
+	})
+
+	stopInstall()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("installing playwright: %w", err)
+	}
+
+	opts.Logger.Debug("launching browser", "headless", opts.Headless)
+
+	stopLaunch := opts.Timings.Start("browser launch")
+	defer stopLaunch()
+
+	playw, err := playwright.Run()
+	if err != nil {
+		return nil, nil, fmt.Errorf("launching playwright: %w", err)
+	}
+
+	// closers run in reverse order, the page first.
+	closers := []func(){func() { _ = playw.Stop() }}
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+
+	firefox, err := playw.Firefox.Launch(playwright.BrowserTypeLaunchOptions{
+		Headless: playwright.Bool(opts.Headless),
+	})
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("launching Firefox: %w", err)
+	}
+
+	closers = append(closers, func() { _ = firefox.Close() })
+
+	context, err := firefox.NewContext()
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("creating context: %w", err)
+	}
+
+	closers = append(closers, func() { _ = context.Close() })
+
+	page, err := context.NewPage()
+	if err != nil {
+		closeAll()
+		return nil, nil, fmt.Errorf("creating page: %w", err)
+	}
+
+	closers = append(closers, func() { _ = page.Close() })
+
+	return &browser{page: page}, closeAll, nil
+}
+
+func (b *browser) Login(identifier, password string) error {
+	_, err := b.page.Goto(download.LoginURL)
+	if err != nil {
+		return fmt.Errorf("going to: %w", err)
+	}
+
+	_ = b.page.Locator("#popin_tc_privacy_button_2").Click() // we don't care about this error
+
+	if err := b.page.Locator("#identifier").Fill(identifier); err != nil {
+		return fmt.Errorf("typing identifier: %w", err)
+	}
+
+	if err := b.page.Locator(".app-cta-button").First().Click(); err != nil {
+		return fmt.Errorf("clicking login button: %w", err)
+	}
+
+	for _, char := range password {
+		if err := b.page.Locator(fmt.Sprintf(".pad-button[value='%s']", string(char))).Click(); err != nil {
+			return fmt.Errorf("clicking pad button: %w", err)
+		}
+	}
+
+	if err := b.page.Locator(".app-cta-button").First().Click(); err != nil {
+		return fmt.Errorf("clicking login button: %w", err)
+	}
+
+	// The account list is where each account is picked from.
+	if err := b.page.Locator(".extended-zone").First().WaitFor(); err != nil {
+		return fmt.Errorf("waiting for the accounts: %w", err)
+	}
+
+	b.home = b.page.URL()
+
+	return nil
+}
+
+func (b *browser) OpenExport(account string) error {
+	accounts := b.page.Locator(".extended-zone")
+	if account != "" {
+		accounts = accounts.Filter(playwright.LocatorFilterOptions{HasText: account})
+	}
+
+	if err := accounts.First().Click(); err != nil {
+		return fmt.Errorf("clicking account: %w", err)
+	}
+
+	if err := b.page.Locator("#export-button").First().Click(); err != nil {
+		return fmt.Errorf("clicking export button: %w", err)
+	}
+
+	return nil
+}
+
+func (b *browser) FillExport(start, end time.Time, format int) error {
+	if err := b.page.Locator("#mat-input-0").Fill(start.Format(dateFormat)); err != nil {
+		return fmt.Errorf("filling start date: %w", err)
+	}
+
+	if err := b.page.Locator("#mat-input-1").Fill(end.Format(dateFormat)); err != nil {
+		return fmt.Errorf("filling start date: %w", err)
+	}
+
+	if err := b.page.Locator("ui-desktop-select button").Click(); err != nil {
+		return fmt.Errorf("clicking file type selector button: %w", err)
+	}
+
+	if err := b.page.Locator("ui-select-list ul li").Nth(format).Click(); err != nil {
+		return fmt.Errorf("clicking file format button: %w", err)
+	}
+
+	return nil
+}
+
+func (b *browser) Save(outputFile string) error {
+	file, err := b.page.ExpectDownload(func() error {
+		return b.page.Locator("button.primary").Click()
+	})
+	if err != nil {
+		return fmt.Errorf("downloading file: %w", err)
+	}
+
+	if err := file.SaveAs(outputFile); err != nil {
+		return fmt.Errorf("saving download file: %w", err)
+	}
+
+	return nil
+}
+
+func (b *browser) Back() error {
+	if _, err := b.page.Goto(b.home); err != nil {
+		return fmt.Errorf("going to: %w", err)
+	}
+
+	return nil
+}
+
+func (b *browser) Screenshot() ([]byte, error) {
+	img, err := b.page.Screenshot()
+	if err != nil {
+		return nil, fmt.Errorf("taking screenshot: %w", err)
+	}
+
+	return img, nil
+}
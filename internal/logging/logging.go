@@ -0,0 +1,210 @@
+// Package logging builds the slog loggers shared by the commands.
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redacted replaces secret values in log records and reports.
+const Redacted = "[REDACTED]"
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// RunIDKey is the attribute key of the run ID in log records.
+const RunIDKey = "run_id"
+
+const runIDBytes = 4
+
+// RunIDLen is the length of the run IDs of NewRunID, hex encoded.
+const RunIDLen = 2 * runIDBytes
+
+// minSecretLen is the length from which secrets are redacted wherever they appear.
+// Shorter ones, like a test token "t", are only redacted as whole words, so that
+// "directory" doesn't lose its letters.
+const minSecretLen = 8
+
+var (
+	errUnknownLevel  = errors.New("unknown log level")
+	errUnknownFormat = errors.New("unknown log format")
+	errInvalidRunID  = errors.New("invalid run ID")
+)
+
+// runIDRegexp keeps run IDs safe to use in file names.
+var runIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`) //nolint:gochecknoglobals // constant regexp
+
+// sensitiveKeys are attribute keys whose values are always redacted.
+//
+//nolint:gochecknoglobals // constant list
+var sensitiveKeys = []string{"token", "password", "identifier", "authorization"}
+
+// New returns a logger writing to w at the given level ("debug", "info", "warn" or "error")
+// and format ("text" or "json"). Any occurrence of the secrets in messages or string
+// attributes is replaced by Redacted.
+func New(w io.Writer, level, format string, secrets ...string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("%w: %q, want debug, info, warn or error", errUnknownLevel, level)
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       lvl,
+		ReplaceAttr: replaceAttr(NewRedactor(secrets...)),
+	}
+
+	switch format {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("%w: %q, want %v or %v", errUnknownFormat, format, FormatText, FormatJSON)
+	}
+}
+
+// NewRunID returns a short random ID correlating the logs, report and notifications of a run.
+func NewRunID() string {
+	b := make([]byte, runIDBytes)
+	_, _ = rand.Read(b) // never fails
+
+	return hex.EncodeToString(b)
+}
+
+// CheckRunID validates a run ID given on the command line.
+func CheckRunID(id string) error {
+	if !runIDRegexp.MatchString(id) {
+		return fmt.Errorf("%w: %q, want up to 64 letters, digits, - or _", errInvalidRunID, id)
+	}
+
+	return nil
+}
+
+// Discard returns a logger dropping every record.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// Redactor replaces secrets in text leaving the program other than through the logs,
+// like reports and notifications.
+type Redactor struct {
+	secrets []string
+}
+
+// NewRedactor returns a redactor of the non-empty secrets. A secret URL also has its
+// query string and password redacted on their own, as errors may quote the URL with
+// the password masked.
+func NewRedactor(secrets ...string) *Redactor {
+	var all []string
+
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+
+		all = append(all, secret)
+
+		if u, err := url.Parse(secret); err == nil && u.Host != "" {
+			if password, ok := u.User.Password(); ok && password != "" {
+				all = append(all, password)
+			}
+
+			if u.RawQuery != "" {
+				all = append(all, u.RawQuery)
+			}
+		}
+	}
+
+	// Longest first, so that a secret containing another is replaced whole.
+	slices.SortStableFunc(all, func(a, b string) int { return len(b) - len(a) })
+
+	return &Redactor{secrets: all}
+}
+
+// Redact returns s with every secret replaced by Redacted, unchanged for a nil Redactor.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+
+	for _, secret := range r.secrets {
+		if len(secret) >= minSecretLen {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		} else {
+			s = replaceWord(s, secret)
+		}
+	}
+
+	return s
+}
+
+// replaceWord returns s with the occurrences of secret that aren't part of a longer
+// word replaced by Redacted.
+func replaceWord(s, secret string) string {
+	var b strings.Builder
+
+	for {
+		i := strings.Index(s, secret)
+		if i < 0 {
+			break
+		}
+
+		end := i + len(secret)
+		if (i == 0 || !isWordByte(s[i-1])) && (end == len(s) || !isWordByte(s[end])) {
+			b.WriteString(s[:i] + Redacted)
+		} else {
+			b.WriteString(s[:end])
+		}
+
+		s = s[end:]
+	}
+
+	return b.String() + s
+}
+
+// isWordByte reports whether c may be part of a word or token.
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' ||
+		c >= utf8.RuneSelf
+}
+
+func replaceAttr(redactor *Redactor) func(groups []string, attr slog.Attr) slog.Attr {
+	return func(_ []string, attr slog.Attr) slog.Attr {
+		if slices.Contains(sensitiveKeys, strings.ToLower(attr.Key)) {
+			return slog.String(attr.Key, Redacted)
+		}
+
+		if len(redactor.secrets) == 0 {
+			return attr
+		}
+
+		var value string
+
+		switch attr.Value.Kind() { //nolint:exhaustive // only textual values can hold secrets
+		case slog.KindString:
+			value = attr.Value.String()
+		case slog.KindAny:
+			err, ok := attr.Value.Any().(error)
+			if !ok {
+				return attr
+			}
+
+			value = err.Error()
+		default:
+			return attr
+		}
+
+		return slog.String(attr.Key, redactor.Redact(value))
+	}
+}
@@ -0,0 +1,165 @@
+// Package paths resolves where the commands keep their configuration, state and cache files.
+//
+// On Linux and other Unix systems the XDG base directory variables are honored,
+// with their usual defaults under the home directory. macOS and Windows use
+// their native locations unless the XDG variables are set explicitly.
+// Every location is namespaced by application name and profile.
+package paths
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const appName = "lcl-ynab"
+
+// DefaultProfile is used when no profile is given.
+const DefaultProfile = "default"
+
+var errNoHome = errors.New("cannot resolve home directory")
+
+// Dirs are the directories of one profile.
+type Dirs struct {
+	Config string
+	State  string
+	Cache  string
+}
+
+// Resolve returns the directories of profile for the current user and OS.
+func Resolve(profile string) (Dirs, error) {
+	return ResolveEnv(profile, os.Getenv, runtime.GOOS)
+}
+
+// ResolveEnv is Resolve with the environment and OS provided by the caller.
+func ResolveEnv(profile string, getenv func(string) string, goos string) (Dirs, error) {
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	home := getenv("HOME")
+	if goos == "windows" && home == "" {
+		home = getenv("USERPROFILE")
+	}
+
+	config, err := baseDir(getenv("XDG_CONFIG_HOME"), home, nativeConfig(goos, getenv), ".config")
+	if err != nil {
+		return Dirs{}, err
+	}
+
+	state, err := baseDir(getenv("XDG_STATE_HOME"), home, nativeState(goos, getenv), filepath.Join(".local", "state"))
+	if err != nil {
+		return Dirs{}, err
+	}
+
+	cache, err := baseDir(getenv("XDG_CACHE_HOME"), home, nativeCache(goos, getenv), ".cache")
+	if err != nil {
+		return Dirs{}, err
+	}
+
+	return Dirs{
+		Config: filepath.Join(config, appName, profile),
+		State:  filepath.Join(state, appName, profile),
+		Cache:  filepath.Join(cache, appName, profile),
+	}, nil
+}
+
+// DownloadState is the state file of the download command.
+func (d Dirs) DownloadState() string {
+	return filepath.Join(d.State, "download-state.json")
+}
+
+// PushState is the state file of the push command.
+func (d Dirs) PushState() string {
+	return filepath.Join(d.State, "push-state.json")
+}
+
+// Screenshots is the directory receiving screenshots of failed downloads.
+func (d Dirs) Screenshots() string {
+	return filepath.Join(d.State, "screenshots")
+}
+
+// Entry is a labelled location printed by Print.
+type Entry struct {
+	Label string
+	Path  string
+}
+
+// Print writes the profile directories followed by extra entries, for -print-paths.
+func (d Dirs) Print(w io.Writer, extra ...Entry) {
+	entries := []Entry{
+		{Label: "config dir", Path: d.Config},
+		{Label: "state dir", Path: d.State},
+		{Label: "cache dir", Path: d.Cache},
+	}
+
+	for _, entry := range append(entries, extra...) {
+		_, _ = fmt.Fprintf(w, "%-16v%v\n", entry.Label+":", entry.Path)
+	}
+}
+
+// baseDir picks the XDG value if set, then the native location, then the home-relative default.
+func baseDir(xdg, home, native, homeRelative string) (string, error) {
+	switch {
+	case filepath.IsAbs(xdg):
+		return xdg, nil
+	case native != "":
+		return native, nil
+	case home != "":
+		return filepath.Join(home, homeRelative), nil
+	default:
+		return "", errNoHome
+	}
+}
+
+func nativeConfig(goos string, getenv func(string) string) string {
+	switch goos {
+	case "darwin":
+		return darwinSupport(getenv)
+	case "windows":
+		return getenv("AppData")
+	default:
+		return ""
+	}
+}
+
+func nativeState(goos string, getenv func(string) string) string {
+	switch goos {
+	case "darwin":
+		return darwinSupport(getenv)
+	case "windows":
+		return getenv("LocalAppData")
+	default:
+		return ""
+	}
+}
+
+func nativeCache(goos string, getenv func(string) string) string {
+	switch goos {
+	case "darwin":
+		if home := getenv("HOME"); home != "" {
+			return filepath.Join(home, "Library", "Caches")
+		}
+
+		return ""
+	case "windows":
+		if local := getenv("LocalAppData"); local != "" {
+			return filepath.Join(local, "cache")
+		}
+
+		return ""
+	default:
+		return ""
+	}
+}
+
+func darwinSupport(getenv func(string) string) string {
+	if home := getenv("HOME"); home != "" {
+		return filepath.Join(home, "Library", "Application Support")
+	}
+
+	return ""
+}
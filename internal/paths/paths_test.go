@@ -0,0 +1,109 @@
+package paths
+
+import (
+	"bytes"
+	"testing"
+)
+
+//nolint:funlen // mostly test cases in list
+func TestResolveEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		goos    string
+		profile string
+		want    Dirs
+		wantErr bool
+	}{
+		{
+			name:    "linux defaults",
+			env:     map[string]string{"HOME": "/home/jean"},
+			goos:    "linux",
+			profile: "",
+			want: Dirs{
+				Config: "/home/jean/.config/lcl-ynab/default",
+				State:  "/home/jean/.local/state/lcl-ynab/default",
+				Cache:  "/home/jean/.cache/lcl-ynab/default",
+			},
+		},
+		{
+			name: "xdg overrides",
+			env: map[string]string{
+				"HOME":            "/home/jean",
+				"XDG_CONFIG_HOME": "/etc/xdg",
+				"XDG_STATE_HOME":  "/var/lib",
+				"XDG_CACHE_HOME":  "/var/cache",
+			},
+			goos:    "linux",
+			profile: "joint",
+			want: Dirs{
+				Config: "/etc/xdg/lcl-ynab/joint",
+				State:  "/var/lib/lcl-ynab/joint",
+				Cache:  "/var/cache/lcl-ynab/joint",
+			},
+		},
+		{
+			name:    "relative xdg is ignored",
+			env:     map[string]string{"HOME": "/home/jean", "XDG_STATE_HOME": "state"},
+			goos:    "linux",
+			profile: "",
+			want: Dirs{
+				Config: "/home/jean/.config/lcl-ynab/default",
+				State:  "/home/jean/.local/state/lcl-ynab/default",
+				Cache:  "/home/jean/.cache/lcl-ynab/default",
+			},
+		},
+		{
+			name:    "macOS",
+			env:     map[string]string{"HOME": "/Users/jean"},
+			goos:    "darwin",
+			profile: "",
+			want: Dirs{
+				Config: "/Users/jean/Library/Application Support/lcl-ynab/default",
+				State:  "/Users/jean/Library/Application Support/lcl-ynab/default",
+				Cache:  "/Users/jean/Library/Caches/lcl-ynab/default",
+			},
+		},
+		{
+			name:    "no home",
+			env:     map[string]string{},
+			goos:    "linux",
+			profile: "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ResolveEnv(tt.profile, func(key string) string { return tt.env[key] }, tt.goos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ResolveEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirs_Print(t *testing.T) {
+	t.Parallel()
+
+	dirs := Dirs{Config: "/c", State: "/s", Cache: "/k"}
+	out := &bytes.Buffer{}
+	dirs.Print(out, Entry{Label: "screenshots", Path: dirs.Screenshots()})
+
+	want := `config dir:     /c
+state dir:      /s
+cache dir:      /k
+screenshots:    /s/screenshots
+`
+	if out.String() != want {
+		t.Errorf("Print() = %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,191 @@
+// Package preflight checks, before a run does any work, that a host it calls can be
+// reached and that the local clock agrees with the one of the host.
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds each check.
+	DefaultTimeout = 5 * time.Second
+	// DefaultMaxSkew is the clock difference with the host above which Run warns.
+	DefaultMaxSkew = 2 * time.Minute
+)
+
+// Errors of the checks, wrapping the underlying ones.
+var (
+	ErrResolve   = errors.New("resolving host failed")
+	ErrConnect   = errors.New("connecting to host failed")
+	ErrRequest   = errors.New("request to host failed")
+	ErrClockSkew = errors.New("clock skew")
+)
+
+// Check is the outcome of a check.
+type Check struct {
+	Name    string
+	Latency time.Duration
+	// Err is why the check failed, nil when it passed.
+	Err error
+	// Warning is set when Err shouldn't stop the run, like a clock skew.
+	Warning bool
+}
+
+// Options tune Run.
+type Options struct {
+	// Client sends the HEAD request, nil meaning http.DefaultClient.
+	Client *http.Client
+	// Dial connects to an address, nil meaning a net.Dialer.
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+	// Now is the local clock compared with the host, nil meaning time.Now.
+	Now func() time.Time
+	// Timeout bounds each check, 0 meaning DefaultTimeout.
+	Timeout time.Duration
+	// MaxSkew is the clock difference tolerated, 0 meaning DefaultMaxSkew.
+	MaxSkew time.Duration
+}
+
+// Run connects to the host of rawURL, which also resolves it, then compares the local
+// clock with the Date header of a HEAD request to rawURL. It returns the checks run,
+// and the error of the first one that should stop the run.
+func Run(ctx context.Context, rawURL string, opts Options) ([]Check, error) {
+	opts = withDefaults(opts)
+
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing preflight URL: %w", err)
+	}
+
+	connect := timed("connect "+hostPort(target), func() error { return dial(ctx, target, opts) })
+	if connect.Err != nil {
+		return []Check{connect}, connect.Err
+	}
+
+	clock := timed("clock "+target.Host, func() error { return compareClock(ctx, target, opts) })
+	if clock.Err != nil && !errors.Is(clock.Err, ErrClockSkew) {
+		return []Check{connect, clock}, clock.Err
+	}
+
+	clock.Warning = clock.Err != nil
+
+	return []Check{connect, clock}, nil
+}
+
+// Write prints a line per check to w.
+func Write(w io.Writer, checks []Check) {
+	for _, check := range checks {
+		status := "ok"
+
+		switch {
+		case check.Warning:
+			status = fmt.Sprintf("warning: %v", check.Err)
+		case check.Err != nil:
+			status = fmt.Sprintf("failed: %v", check.Err)
+		}
+
+		_, _ = fmt.Fprintf(w, "preflight: %v: %v (%v)\n", check.Name, status, check.Latency.Round(time.Millisecond))
+	}
+}
+
+func withDefaults(opts Options) Options {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	if opts.Dial == nil {
+		opts.Dial = (&net.Dialer{}).DialContext
+	}
+
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	if opts.MaxSkew == 0 {
+		opts.MaxSkew = DefaultMaxSkew
+	}
+
+	return opts
+}
+
+func timed(name string, check func() error) Check {
+	start := time.Now()
+	err := check()
+
+	return Check{Name: name, Latency: time.Since(start), Err: err}
+}
+
+// hostPort is the address of the host of target, with the default port of its scheme.
+func hostPort(target *url.URL) string {
+	port := target.Port()
+	if port == "" {
+		port = "443"
+		if target.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	return net.JoinHostPort(target.Hostname(), port)
+}
+
+func dial(ctx context.Context, target *url.URL, opts Options) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	conn, err := opts.Dial(ctx, "tcp", hostPort(target))
+	if err != nil {
+		if dnsErr := (*net.DNSError)(nil); errors.As(err, &dnsErr) {
+			return fmt.Errorf("%w: %w", ErrResolve, err)
+		}
+
+		return fmt.Errorf("%w: %w", ErrConnect, err)
+	}
+
+	_ = conn.Close()
+
+	return nil
+}
+
+func compareClock(ctx context.Context, target *url.URL, opts Options) error {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target.String(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRequest, err)
+	}
+
+	_ = resp.Body.Close()
+
+	// Any status will do: the host answered, with its time.
+	hostTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return fmt.Errorf("%w: no valid Date header to compare with", ErrClockSkew)
+	}
+
+	skew := opts.Now().Sub(hostTime)
+
+	switch {
+	case skew > opts.MaxSkew:
+		return fmt.Errorf("%w: local clock %v ahead of %v", ErrClockSkew, skew.Round(time.Second), target.Host)
+	case -skew > opts.MaxSkew:
+		return fmt.Errorf("%w: local clock %v behind %v", ErrClockSkew, (-skew).Round(time.Second), target.Host)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,168 @@
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	hostTime := time.Date(2024, 11, 30, 3, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %v, want HEAD", r.Method)
+		}
+
+		w.Header().Set("Date", hostTime.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		wantWarning string
+	}{
+		{name: "in sync", now: hostTime.Add(30 * time.Second)},
+		{name: "ahead", now: hostTime.Add(time.Hour), wantWarning: "local clock 1h0m0s ahead of"},
+		{name: "behind", now: hostTime.Add(-5 * time.Minute), wantWarning: "local clock 5m0s behind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			checks, err := Run(context.Background(), server.URL, Options{
+				Client: server.Client(),
+				Now:    func() time.Time { return tt.now },
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if len(checks) != 2 || checks[0].Err != nil {
+				t.Fatalf("Run() = %+v, want the connect and clock checks", checks)
+			}
+
+			clock := checks[1]
+			if tt.wantWarning == "" {
+				if clock.Err != nil || clock.Warning {
+					t.Errorf("clock check = %+v, want it to pass", clock)
+				}
+
+				return
+			}
+
+			if !clock.Warning || !errors.Is(clock.Err, ErrClockSkew) || !strings.Contains(clock.Err.Error(), tt.wantWarning) {
+				t.Errorf("clock check = %+v, want a warning %q", clock, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestRun_failures(t *testing.T) {
+	t.Parallel()
+
+	unreachable := &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection reset")
+	})}
+
+	tests := []struct {
+		name       string
+		dial       func(ctx context.Context, network, address string) (net.Conn, error)
+		client     *http.Client
+		wantErr    error
+		wantChecks int
+	}{
+		{
+			name: "DNS",
+			dial: func(context.Context, string, string) (net.Conn, error) {
+				return nil, &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}
+			},
+			wantErr:    ErrResolve,
+			wantChecks: 1,
+		},
+		{
+			name: "connection refused",
+			dial: func(context.Context, string, string) (net.Conn, error) {
+				return nil, &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+			},
+			wantErr:    ErrConnect,
+			wantChecks: 1,
+		},
+		{
+			name: "hanging",
+			dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				<-ctx.Done()
+
+				return nil, ctx.Err()
+			},
+			wantErr:    context.DeadlineExceeded,
+			wantChecks: 1,
+		},
+		{name: "request", client: unreachable, wantErr: ErrRequest, wantChecks: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dial := tt.dial
+			if dial == nil {
+				dial = func(context.Context, string, string) (net.Conn, error) {
+					client, server := net.Pipe()
+					_ = server.Close()
+
+					return client, nil
+				}
+			}
+
+			checks, err := Run(context.Background(), "https://api.example.com/v1", Options{
+				Client:  tt.client,
+				Dial:    dial,
+				Timeout: 10 * time.Millisecond,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(checks) != tt.wantChecks || checks[len(checks)-1].Err == nil {
+				t.Errorf("Run() = %+v, want %d checks, the last failed", checks, tt.wantChecks)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	Write(&out, []Check{
+		{Name: "connect api.example.com:443", Latency: 23400 * time.Microsecond},
+		{Name: "clock api.example.com", Latency: time.Second, Err: ErrClockSkew, Warning: true},
+		{Name: "connect bank.example.com:443", Latency: 5 * time.Second, Err: ErrConnect},
+	})
+
+	want := "preflight: connect api.example.com:443: ok (23ms)\n" +
+		"preflight: clock api.example.com: warning: clock skew (1s)\n" +
+		"preflight: connect bank.example.com:443: failed: connecting to host failed (5s)\n"
+	if out.String() != want {
+		t.Errorf("Write() = %q, want %q", out.String(), want)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,105 @@
+package push
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// unmappedAccountID is the account of the transactions converted without -a, until
+// the account map gives theirs.
+const unmappedAccountID = "unmapped"
+
+var (
+	errInvalidAccountMap = errors.New("invalid account map")
+	errUnmappedAccount   = errors.New("account reference not in -account-map")
+	errNoAccountRef      = errors.New("file has no account reference, give the account with -a")
+)
+
+// accountMap maps the account references of bank files, or their end like the
+// account number, to YNAB account IDs. It's read from the JSON object of -account-map:
+//
+//	{"01234 123456A": "0a1b2c", "654321B": "3d4e5f"}
+type accountMap map[string]string
+
+// loadAccountMap reads the account map at path in fsys, none when path is empty.
+func loadAccountMap(fsys fs.FS, path string) (accountMap, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading account map: %w", err)
+	}
+
+	var accounts accountMap
+	if err := json.Unmarshal(content, &accounts); err != nil {
+		return nil, fmt.Errorf("%w %v: %w", errInvalidAccountMap, path, err)
+	}
+
+	for ref, accountID := range accounts {
+		if compactRef(ref) == "" || accountID == "" {
+			return nil, fmt.Errorf("%w %v: %q: want a reference and an account ID", errInvalidAccountMap, path, ref)
+		}
+	}
+
+	return accounts, nil
+}
+
+// lookup returns the YNAB account of the reference ref, mapped by the longest
+// key ending it, spaces and case ignored.
+func (m accountMap) lookup(ref string) (string, error) {
+	if ref == "" {
+		return "", errNoAccountRef
+	}
+
+	var (
+		accountID string
+		longest   int
+	)
+
+	for key, id := range m {
+		key = compactRef(key)
+		if strings.HasSuffix(compactRef(ref), key) && len(key) > longest {
+			accountID, longest = id, len(key)
+		}
+	}
+
+	if accountID == "" {
+		known := slices.Sorted(maps.Keys(m))
+
+		return "", fmt.Errorf("%w: %q, known: %v", errUnmappedAccount, ref, strings.Join(known, ", "))
+	}
+
+	return accountID, nil
+}
+
+// compactRef returns ref without spaces, in upper case.
+func compactRef(ref string) string {
+	return strings.ToUpper(strings.ReplaceAll(ref, " ", ""))
+}
+
+// mapAccount sets the account of the run and its transactions to the one the
+// account map gives for the reference of the file.
+func mapAccount(opts *options, state *runState, transactions []Transaction, ref string) error {
+	accountID, err := state.accounts.lookup(ref)
+	if err != nil {
+		return err
+	}
+
+	state.logger.Debug("mapped account", "reference", ref, "account_id", accountID)
+
+	opts.accountID = accountID
+	state.result.AccountName = accountID
+
+	for i := range transactions {
+		transactions[i].AccountID = accountID
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_accountMap_lookup(t *testing.T) {
+	t.Parallel()
+
+	accounts := accountMap{"01234 123456A": "acc-courant", "123456a": "acc-other", "654321B": "acc-livret"}
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr error
+	}{
+		{ref: "01234 123456A", want: "acc-courant"},
+		{ref: "99999 123456A", want: "acc-other"},
+		{ref: "01234 654321B", want: "acc-livret"},
+		{ref: "01234 111111C", wantErr: errUnmappedAccount},
+		{ref: "", wantErr: errNoAccountRef},
+	}
+
+	for _, tt := range tests {
+		got, err := accounts.lookup(tt.ref)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("lookup(%q) = %q, %v, want %q, %v", tt.ref, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	_, err := accounts.lookup("01234 111111C")
+	if want := `"01234 111111C", known: 01234 123456A, 123456a, 654321B`; err == nil || !bytes.HasSuffix(
+		[]byte(err.Error()), []byte(want)) {
+		t.Errorf("lookup() error = %v, want it to list the known references", err)
+	}
+}
+
+func Test_loadAccountMap(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"accounts.json": {Data: []byte(`{"01234 123456A": "acc-courant"}`)},
+		"empty-id.json": {Data: []byte(`{"01234 123456A": ""}`)},
+		"list.json":     {Data: []byte(`["01234 123456A"]`)},
+	}
+
+	tests := []struct {
+		path    string
+		want    int
+		wantErr error
+	}{
+		{path: "", want: 0},
+		{path: "accounts.json", want: 1},
+		{path: "empty-id.json", wantErr: errInvalidAccountMap},
+		{path: "list.json", wantErr: errInvalidAccountMap},
+	}
+
+	for _, tt := range tests {
+		got, err := loadAccountMap(fsys, tt.path)
+		if len(got) != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("loadAccountMap(%q) = %v, %v, want %d accounts, %v", tt.path, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func Test_run_accountMap(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	accountsPath := filepath.Join(dir, "accounts.json")
+
+	if err := os.WriteFile(accountsPath, []byte(`{"123456A": "acc-courant"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	statement, err := os.ReadFile("./testdata/one-positive.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withoutFooter := "29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;\n"
+
+	tests := []struct {
+		name        string
+		input       string
+		args        []string
+		wantAccount string
+		wantErr     error
+	}{
+		{name: "mapped", input: string(statement), wantAccount: "acc-courant"},
+		{name: "-a wins", input: string(statement), args: []string{"-a", testAccountID}, wantAccount: testAccountID},
+		{
+			name:    "unmapped",
+			input:   string(bytes.Replace(statement, []byte("123456A"), []byte("111111C"), 1)),
+			wantErr: errUnmappedAccount,
+		},
+		{name: "no footer", input: withoutFooter, wantErr: errNoAccountRef},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var body []byte
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(
+				http.MethodPost,
+				"/v1/budgets/"+testBudgetID+"/transactions",
+				func(req *http.Request) (*http.Response, error) {
+					body, _ = io.ReadAll(req.Body)
+
+					return createdResponder(http.StatusOK)(req)
+				},
+			)
+
+			input := filepath.Join(dir, tt.name+".csv")
+			if err := os.WriteFile(input, []byte(tt.input), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			err := run(context.Background(), append([]string{
+				"-t", "tok", "-b", testBudgetID, "-account-map", accountsPath,
+				"-state", filepath.Join(dir, tt.name+".json"), "-f", input,
+			}, tt.args...), env{
+				stdout:     io.Discard,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if want := `"account_id":"` + tt.wantAccount + `"`; !bytes.Contains(body, []byte(want)) {
+				t.Errorf("pushed %s, want %s", body, want)
+			}
+		})
+	}
+}
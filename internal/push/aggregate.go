@@ -0,0 +1,222 @@
+package push
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// defaultAccountsTemplate renders the webhook of several accounts as JSON.
+const defaultAccountsTemplate = `{{json .}}`
+
+// statusRanks orders the statuses of the accounts, the worst being the status of them all.
+//
+//nolint:gochecknoglobals // constant lookup table
+var statusRanks = []string{statusEmpty, statusOK, statusNotificationFailed, statusCancelled, statusError}
+
+// webhookFlags are the flags configuring the webhook sent at the end of a run, as
+// opposed to how notifications are delivered.
+//
+//nolint:gochecknoglobals // constant lookup table
+var webhookFlags = map[string]bool{
+	"w": true, "webhook": true, "webhook-success": true, "webhook-failure": true, "webhook-header": true,
+	"webhook-template": true, "webhook-content-type": true, "webhook-always": true, "strict-webhook": true,
+}
+
+// IsWebhookFlag reports whether the flag name configures the webhook sent at the end of
+// a run, for sync to send it once for all the accounts.
+func IsWebhookFlag(name string) bool {
+	return webhookFlags[name]
+}
+
+// AccountResult is the outcome of the push of one account of sync -accounts.
+type AccountResult struct {
+	Name string
+	// Result is nil when the push failed before reading the file.
+	Result *Result
+	Err    error
+}
+
+// accountsData is what the webhook of several accounts is rendered with: their totals,
+// then each one. Reconciled is empty unless every file was converted.
+type accountsData struct {
+	Status               string        `json:"status"`
+	Reconciled           string        `json:"reconciled,omitempty"`
+	ReconciledMilliunits int           `json:"reconciled_milliunits"`
+	Pushed               int           `json:"pushed"`
+	Duplicates           int           `json:"duplicates"`
+	Accounts             []accountData `json:"accounts"`
+	SentAt               time.Time     `json:"timestamp"`
+}
+
+// accountData is one account in accountsData, Reconciled being empty when its file
+// couldn't be converted.
+type accountData struct {
+	Name                 string `json:"name"`
+	Status               string `json:"status"`
+	Reconciled           string `json:"reconciled,omitempty"`
+	ReconciledMilliunits int    `json:"reconciled_milliunits"`
+	Pushed               int    `json:"pushed"`
+	Duplicates           int    `json:"duplicates"`
+	Error                string `json:"error,omitempty"`
+}
+
+func newAccountsData(accounts []AccountResult, redactor *logging.Redactor, sentAt time.Time) accountsData {
+	data := accountsData{Status: statusEmpty, Accounts: []accountData{}, SentAt: sentAt}
+	converted := len(accounts) > 0
+
+	for _, account := range accounts {
+		item := accountData{Name: account.Name, Status: statusOf(account.Err)}
+
+		if res := account.Result; res != nil {
+			// The date is only known once the file is converted.
+			if res.ReconciledDate != "" {
+				item.Reconciled = reconciledString(res.Reconciled)
+			}
+
+			item.ReconciledMilliunits = res.Reconciled
+			item.Pushed = res.Counts.Pushed
+			item.Duplicates = res.Counts.Duplicates
+		}
+
+		if item.Status == statusError {
+			item.Error = redactor.Redact(account.Err.Error())
+		}
+
+		converted = converted && item.Reconciled != ""
+
+		data.ReconciledMilliunits += item.ReconciledMilliunits
+		data.Pushed += item.Pushed
+		data.Duplicates += item.Duplicates
+		data.Accounts = append(data.Accounts, item)
+
+		if slices.Index(statusRanks, item.Status) > slices.Index(statusRanks, data.Status) {
+			data.Status = item.Status
+		}
+	}
+
+	if converted {
+		data.Reconciled = reconciledString(data.ReconciledMilliunits)
+	}
+
+	return data
+}
+
+// NotifyAccounts sends one webhook summing up the pushes of accounts, configured by
+// the webhook flags of args and the config file.
+func NotifyAccounts(ctx context.Context, args []string, accounts []AccountResult, e Env) error {
+	return notifyAccounts(ctx, args, accounts, e.env())
+}
+
+// CheckAccounts reads args as NotifyAccounts does, without sending anything, for sync to
+// report mistakes before the download.
+func CheckAccounts(args []string, e Env) error {
+	_, _, err := parseAccountsWebhook(args, e.env())
+
+	return err
+}
+
+func notifyAccounts(ctx context.Context, args []string, accounts []AccountResult, env env) error {
+	if env.fsys == nil {
+		env.fsys = osFS{}
+	}
+
+	opts, hook, err := parseAccountsWebhook(args, env)
+	if err != nil {
+		return err
+	}
+
+	secrets := append(secretFlags(opts), headerValues(hook.headers)...)
+
+	logger, err := logging.New(env.stderr, logLevel(opts), opts.logFormat, secrets...)
+	if err != nil {
+		return fmt.Errorf("configuring logs: %w", err)
+	}
+
+	data := newAccountsData(accounts, logging.NewRedactor(secrets...), env.now())
+
+	// Like a single push, failures are only sent when a failure webhook is configured.
+	failed := data.Status == statusError || data.Status == statusCancelled
+
+	url := hook.urlFor(data.Status)
+	if url == "" || failed && opts.webhookFailure == "" && !opts.webhookAlways {
+		return nil
+	}
+
+	client, err := withTLS(env.httpClient, env.fsys, cmp.Or(opts.webhookCACert, opts.caCert), opts.insecureSkipVerify)
+	if err != nil {
+		return fmt.Errorf("configuring notifications TLS: %w", err)
+	}
+
+	logger.Debug("sending accounts webhook")
+
+	err = hook.send(ctx, lclynab.WrapClient(client, env.middlewares...), logger, url, data)
+	if err != nil {
+		if opts.strictWebhook {
+			return fmt.Errorf("sending webhook: %w", err)
+		}
+
+		logger.Warn("sending webhook failed", "error", err)
+
+		return errNotificationFailed
+	}
+
+	return nil
+}
+
+// parseAccountsWebhook reads the webhook flags of args and the config file, leaving the
+// other flags unchecked: they are those of the pushes.
+func parseAccountsWebhook(args []string, env env) (*options, *webhook, error) {
+	opts := &options{}
+
+	flagset := newFlagSet(opts)
+
+	if _, err := parseInterspersed(flagset, args); err != nil {
+		return nil, nil, fmt.Errorf("parsing flags: %w", err)
+	}
+
+	opts.token = cmp.Or(opts.token, env.variable(envToken))
+
+	if err := applyConfig(flagset, opts, env); err != nil {
+		return nil, nil, err
+	}
+
+	if env.fsys == nil {
+		env.fsys = osFS{}
+	}
+
+	if opts.webhookAttempts < 1 {
+		return nil, nil, fmt.Errorf("%w: -webhook-attempts %d, want at least 1", errInvalidAttempts, opts.webhookAttempts)
+	}
+
+	hook, err := newAccountsWebhook(opts, env.fsys, os.LookupEnv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return opts, hook, nil
+}
+
+// newAccountsWebhook is newWebhook, with the template rendering accountsData.
+func newAccountsWebhook(opts *options, fsys fs.FS, lookupEnv func(string) (string, bool)) (*webhook, error) {
+	withoutTemplate := *opts
+	withoutTemplate.webhookTemplate = ""
+
+	hook, err := newWebhook(&withoutTemplate, fsys, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	if hook.template, err = parseTemplate(fsys, opts.webhookTemplate, defaultAccountsTemplate, accountsData{}); err != nil {
+		return nil, err
+	}
+
+	return hook, nil
+}
@@ -0,0 +1,216 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// testAccounts are the outcomes of the pushes of two accounts, the second one failing
+// when fail is set.
+func testAccounts(fail bool) []AccountResult {
+	livret := AccountResult{
+		Name:   "Livret",
+		Result: &Result{Reconciled: 5000, ReconciledDate: "2024-11-29"},
+		Err:    errNothingToPush,
+	}
+	if fail {
+		livret = AccountResult{Name: "Livret", Err: lclynabAuthError()}
+	}
+
+	return []AccountResult{
+		{
+			Name:   "Compte courant",
+			Result: &Result{Reconciled: 100060, ReconciledDate: "2024-11-29", Counts: counts{Pushed: 2, Duplicates: 1}},
+		},
+		livret,
+	}
+}
+
+// lclynabAuthError is the error of a push whose token was refused, carrying it.
+func lclynabAuthError() error {
+	return errors.New("pushing transactions: 401 Unauthorized with token ynab-s3cr3t") //nolint:err113 // test error
+}
+
+func Test_notifyAccounts(t *testing.T) {
+	t.Parallel()
+
+	const (
+		success = "https://ha.example/api/webhook/ynab"
+		failure = "https://ha.example/api/webhook/failed"
+	)
+
+	tests := []struct {
+		name     string
+		args     []string
+		fail     bool
+		status   int
+		wantErr  error
+		wantURL  string
+		wantBody string
+		golden   string
+		// wantIn is part of the body.
+		wantIn string
+	}{
+		{
+			name:    "default template",
+			args:    []string{"-w", success},
+			status:  http.StatusOK,
+			wantURL: success,
+			golden:  "./testdata/accounts-webhook.golden",
+		},
+		{
+			name:     "template",
+			args:     []string{"-w", success, "-webhook-template", "accounts.tmpl"},
+			status:   http.StatusOK,
+			wantURL:  success,
+			wantBody: "ok: Compte courant 2, Livret 0, total 105.06",
+		},
+		{
+			name:    "failure",
+			args:    []string{"-t", "ynab-s3cr3t", "-w", success, "-webhook-failure", failure},
+			fail:    true,
+			status:  http.StatusOK,
+			wantURL: failure,
+			wantIn: `"accounts":[{"name":"Compte courant","status":"ok","reconciled":"100.06",` +
+				`"reconciled_milliunits":100060,"pushed":2,"duplicates":1},{"name":"Livret","status":"error",` +
+				`"reconciled_milliunits":0,"pushed":0,"duplicates":0,` +
+				`"error":"pushing transactions: 401 Unauthorized with token [REDACTED]"}]`,
+		},
+		{
+			name: "failure without failure webhook",
+			args: []string{"-w", success},
+			fail: true,
+		},
+		{
+			name:    "delivery failed",
+			args:    []string{"-w", success, "-webhook-attempts", "1"},
+			status:  http.StatusBadRequest,
+			wantErr: errNotificationFailed,
+			wantURL: success,
+		},
+		{
+			name:    "strict",
+			args:    []string{"-w", success, "-webhook-attempts", "1", "-strict-webhook"},
+			status:  http.StatusBadRequest,
+			wantURL: success,
+		},
+		{
+			name: "no webhook",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				gotURL string
+				body   []byte
+			)
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterNoResponder(func(req *http.Request) (*http.Response, error) {
+				gotURL = req.URL.String()
+				body, _ = io.ReadAll(req.Body)
+
+				return httpmock.NewStringResponse(tt.status, ""), nil
+			})
+
+			fsys := fstest.MapFS{"accounts.tmpl": {Data: []byte(`{{.Status}}: {{range $i, $a := .Accounts}}` +
+				`{{if $i}}, {{end}}{{$a.Name}} {{$a.Pushed}}{{end}}, total {{.Reconciled}}`)}}
+
+			err := notifyAccounts(context.Background(), tt.args, testAccounts(tt.fail), env{
+				stdout:     io.Discard,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				fsys:       fsys,
+				getenv:     testGetenv(t),
+			})
+
+			switch {
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("notifyAccounts() error = %v, want %v", err, tt.wantErr)
+			case tt.wantErr == nil && tt.status >= http.StatusBadRequest && err == nil:
+				t.Errorf("notifyAccounts() succeeded, want the delivery error")
+			case tt.wantErr == nil && tt.status < http.StatusBadRequest && err != nil:
+				t.Errorf("notifyAccounts() error = %v", err)
+			}
+
+			if gotURL != tt.wantURL {
+				t.Errorf("webhook URL = %q, want %q", gotURL, tt.wantURL)
+			}
+
+			if tt.golden != "" {
+				want, err := os.ReadFile(tt.golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				tt.wantBody = string(want)
+			}
+
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("body = \n%s\nwant\n%s", body, tt.wantBody)
+			}
+
+			if !strings.Contains(string(body), tt.wantIn) {
+				t.Errorf("body = %s, want it to contain %s", body, tt.wantIn)
+			}
+		})
+	}
+}
+
+func Test_applyConfig_syncAccount(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := "webhook = \"https://example.com/all\"\nwebhook_template = \"all.tmpl\"\n\n" +
+		"[accounts.Livret]\nwebhook = \"https://example.com/livret\"\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		syncAccount  string
+		wantWebhook  string
+		wantTemplate string
+	}{
+		{name: "push", wantWebhook: "https://example.com/all", wantTemplate: "all.tmpl"},
+		{name: "account with a section", syncAccount: "Livret", wantWebhook: "https://example.com/livret"},
+		{name: "account without a section", syncAccount: "Compte courant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := &options{}
+			flagset := newFlagSet(opts)
+
+			if err := flagset.Parse([]string{"-config", path, "-sync-account", tt.syncAccount}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := applyConfig(flagset, opts, env{}); err != nil {
+				t.Fatalf("applyConfig() error = %v", err)
+			}
+
+			if opts.webhook != tt.wantWebhook || opts.webhookTemplate != tt.wantTemplate {
+				t.Errorf("webhook = %q with template %q, want %q with %q",
+					opts.webhook, opts.webhookTemplate, tt.wantWebhook, tt.wantTemplate)
+			}
+		})
+	}
+}
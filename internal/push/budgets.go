@@ -0,0 +1,95 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// listBudgets prints the name, ID and last change of each budget the token can read,
+// for -list-budgets, so that the ID of -b doesn't have to be found in a YNAB URL.
+func listBudgets(ctx context.Context, opts *options, env env) error {
+	client, err := withTLS(env.httpClient, env.fsys, opts.caCert, opts.insecureSkipVerify)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	budgets, err := lclynab.NewClient(opts.token, lclynab.WrapClient(client, env.middlewares...)).ListBudgets(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	table := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "NAME\tID\tLAST MODIFIED")
+
+	for _, budget := range budgets {
+		modified := "-"
+		if !budget.LastModifiedOn.IsZero() {
+			modified = budget.LastModifiedOn.UTC().Format("2006-01-02 15:04")
+		}
+
+		_, _ = fmt.Fprintf(table, "%v\t%v\t%v\n", budget.Name, budget.ID, modified)
+	}
+
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("printing budgets: %w", err)
+	}
+
+	return nil
+}
+
+// listAccounts prints the accounts of the budget of -b with their cleared balance, for
+// -list-accounts, so that the ID of -a doesn't have to be found in a YNAB URL. Closed
+// accounts are listed too, marked as such, deleted ones aren't.
+func listAccounts(ctx context.Context, opts *options, env env) error {
+	client, err := withTLS(env.httpClient, env.fsys, opts.caCert, opts.insecureSkipVerify)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	ynab := lclynab.NewClient(opts.token, lclynab.WrapClient(client, env.middlewares...))
+
+	if err := resolveIDs(ctx, ynab, opts, env.stdout); err != nil {
+		return fmt.Errorf("resolving names: %w", err)
+	}
+
+	accounts, err := ynab.ListAccounts(ctx, opts.budgetID)
+	if err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	table := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "NAME\tTYPE\tID\tON BUDGET\tCLEARED BALANCE\tSTATUS")
+
+	for _, account := range accounts {
+		if account.Deleted {
+			continue
+		}
+
+		onBudget, status := "no", "open"
+		if account.OnBudget {
+			onBudget = "yes"
+		}
+
+		if account.Closed {
+			status = "closed"
+		}
+
+		_, _ = fmt.Fprintf(table, "%v\t%v\t%v\t%v\t%v\t%v\n", account.Name, account.Type, account.ID, onBudget,
+			amountFormat{}.amount(account.ClearedBalance), status)
+	}
+
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("printing accounts: %w", err)
+	}
+
+	return nil
+}
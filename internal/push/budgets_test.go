@@ -0,0 +1,132 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_listBudgets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "budgets",
+			status: http.StatusOK,
+			body: `{"data": {"budgets": [
+				{"id": "bud-id", "name": "Personal", "last_modified_on": "2024-11-29T18:42:07.000Z", "accounts": []},
+				{"id": "other-id", "name": "Old", "accounts": []}
+			]}}`,
+			want: "NAME      ID        LAST MODIFIED\n" +
+				"Personal  bud-id    2024-11-29 18:42\n" +
+				"Old       other-id  -\n",
+		},
+		{
+			name:    "unauthorized",
+			status:  http.StatusUnauthorized,
+			body:    `{"error": {"id": "401", "name": "unauthorized", "detail": "Unauthorized"}}`,
+			wantErr: lclynab.ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodGet, "/v1/budgets", httpmock.NewStringResponder(tt.status, tt.body))
+
+			var stdout bytes.Buffer
+
+			// No file, budget or account needed.
+			err := run(context.Background(), []string{"-t", "tok", "-list-budgets"}, env{
+				stdout:     &stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				if text := ErrorText(err); strings.Contains(text, "{") || ExitCode(err) != exitAuth {
+					t.Errorf("error = %q, exit code %d, want a readable authentication error", text, ExitCode(err))
+				}
+
+				return
+			}
+
+			if stdout.String() != tt.want {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseFlags_listBudgets(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseFlags([]string{"-list-budgets"}, env{}); !errors.Is(err, errRequiredFlag) {
+		t.Errorf("parseFlags() error = %v, want %v", err, errRequiredFlag)
+	}
+}
+
+func Test_run_listAccounts(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/accounts",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"accounts": [
+			{"id": "acc-id", "name": "LCL", "type": "checking", "on_budget": true, "cleared_balance": 100060},
+			{"id": "old-id", "name": "Livret A", "type": "savings", "cleared_balance": 0, "closed": true},
+			{"id": "gone-id", "name": "Gone", "type": "cash", "deleted": true},
+			{"id": "card-id", "name": "Card", "type": "creditCard", "on_budget": true, "cleared_balance": -21320}
+		]}}`))
+
+	var stdout bytes.Buffer
+
+	// No file or account needed.
+	err := run(context.Background(), []string{"-t", "tok", "-b", testBudgetID, "-list-accounts"}, env{
+		stdout:     &stdout,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	want := "NAME      TYPE        ID       ON BUDGET  CLEARED BALANCE  STATUS\n" +
+		"LCL       checking    acc-id   yes        100.06€          open\n" +
+		"Livret A  savings     old-id   no         0.00€            closed\n" +
+		"Card      creditCard  card-id  yes        -21.32€          open\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func Test_parseFlags_listAccounts(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{{"-list-accounts", "-b", testBudgetID}, {"-list-accounts", "-t", "tok"}} {
+		if _, err := parseFlags(args, env{}); !errors.Is(err, errRequiredFlag) {
+			t.Errorf("parseFlags(%q) error = %v, want %v", args, err, errRequiredFlag)
+		}
+	}
+}
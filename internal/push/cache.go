@@ -0,0 +1,25 @@
+package push
+
+import "time"
+
+// defaultCacheTTL is how long the names and settings cached from YNAB are used.
+const defaultCacheTTL = 24 * time.Hour
+
+// cachePolicy decides whether what the state file caches from YNAB is used.
+// A lookup missing from the cache always calls YNAB.
+type cachePolicy struct {
+	now time.Time
+	// ttl is how long a cached entry is used, 0 meaning for ever.
+	ttl time.Duration
+	// disabled neither reads nor fills the cache, as with -no-cache.
+	disabled bool
+}
+
+func newCachePolicy(opts *options, env env) cachePolicy {
+	return cachePolicy{now: env.now(), ttl: opts.cacheTTL, disabled: opts.noCache}
+}
+
+// fresh reports whether an entry fetched at fetchedAt may be used.
+func (c cachePolicy) fresh(fetchedAt time.Time) bool {
+	return !c.disabled && (c.ttl <= 0 || c.now.Sub(fetchedAt) < c.ttl)
+}
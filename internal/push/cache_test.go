@@ -0,0 +1,132 @@
+package push
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_resolveNames_cache(t *testing.T) {
+	t.Parallel()
+
+	fetchedAt := fixedNow().Add(-time.Hour)
+	cached := `{"names": {"budgets": {"` + testBudgetID + `": "Old"}, "accounts": {"` + testAccountID + `": "Old"}, ` +
+		`"fetched_at": "` + fetchedAt.Format(time.RFC3339) + `"}}`
+
+	tests := []struct {
+		name      string
+		cache     cachePolicy
+		accountID string
+		wantCalls int
+		wantName  string
+		// wantFetchedAt is what the state file records afterwards.
+		wantFetchedAt time.Time
+	}{
+		{
+			name:          "hit",
+			cache:         cachePolicy{now: fixedNow(), ttl: 2 * time.Hour},
+			accountID:     testAccountID,
+			wantName:      "Old",
+			wantFetchedAt: fetchedAt,
+		},
+		{
+			name:          "expired",
+			cache:         cachePolicy{now: fixedNow(), ttl: 30 * time.Minute},
+			accountID:     testAccountID,
+			wantCalls:     1,
+			wantName:      "Checking",
+			wantFetchedAt: fixedNow(),
+		},
+		{
+			name:          "miss",
+			cache:         cachePolicy{now: fixedNow(), ttl: 2 * time.Hour},
+			accountID:     "joint",
+			wantCalls:     1,
+			wantName:      "Joint",
+			wantFetchedAt: fixedNow(),
+		},
+		{
+			name:          "disabled",
+			cache:         cachePolicy{now: fixedNow(), ttl: 2 * time.Hour, disabled: true},
+			accountID:     testAccountID,
+			wantCalls:     1,
+			wantName:      "Checking",
+			wantFetchedAt: fetchedAt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(
+				http.MethodGet,
+				"https://api.youneedabudget.com/v1/budgets?include_accounts=true",
+				httpmock.NewStringResponder(http.StatusOK, `{"data": {"budgets": [
+					{"id": "`+testBudgetID+`", "name": "Personal", "accounts": [
+						{"id": "`+testAccountID+`", "name": "Checking"}, {"id": "joint", "name": "Joint"}
+					]}
+				]}}`),
+			)
+
+			statePath := filepath.Join(t.TempDir(), "push-state.json")
+			if err := os.WriteFile(statePath, []byte(cached), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, accountName, err := resolveNames(context.Background(), &http.Client{Transport: transport}, osFS{},
+				tt.cache, statePath, "tok", testBudgetID, tt.accountID)
+			if err != nil {
+				t.Fatalf("resolveNames() error = %v", err)
+			}
+
+			if accountName != tt.wantName {
+				t.Errorf("resolveNames() account = %q, want %q", accountName, tt.wantName)
+			}
+
+			if got := transport.GetTotalCallCount(); got != tt.wantCalls {
+				t.Errorf("API calls = %d, want %d", got, tt.wantCalls)
+			}
+
+			saved := &pushState{}
+			if err := state.Load(statePath, saved); err != nil {
+				t.Fatal(err)
+			}
+
+			if !saved.Names.FetchedAt.Equal(tt.wantFetchedAt) {
+				t.Errorf("fetched at = %v, want %v", saved.Names.FetchedAt, tt.wantFetchedAt)
+			}
+		})
+	}
+}
+
+func Test_resolveCurrencyFormat_expired(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/settings",
+		httpmock.NewStringResponder(http.StatusOK, gbpSettings))
+
+	client := &http.Client{Transport: transport}
+	statePath := filepath.Join(t.TempDir(), "push-state.json")
+
+	for _, now := range []time.Time{fixedNow(), fixedNow().Add(time.Hour), fixedNow().Add(2 * time.Hour)} {
+		cache := cachePolicy{now: now, ttl: 90 * time.Minute}
+
+		format, err := resolveCurrencyFormat(context.Background(), client, osFS{}, cache, statePath, "tok", testBudgetID)
+		if err != nil || format.ISOCode != "GBP" {
+			t.Fatalf("resolveCurrencyFormat() = %+v, %v, want GBP", format, err)
+		}
+	}
+
+	if got := transport.GetTotalCallCount(); got != 2 {
+		t.Errorf("API calls = %d, want 2, the cache expiring after 90 minutes", got)
+	}
+}
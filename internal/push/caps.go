@@ -0,0 +1,142 @@
+package push
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"slices"
+	"strings"
+)
+
+// capMonthFormat is how caps name the calendar month they apply to.
+const capMonthFormat = "2006-01"
+
+var (
+	errInvalidCaps = errors.New("invalid caps")
+	errCapExceeded = errors.New("monthly cap exceeded")
+)
+
+// capsFile is the file of -caps.
+type capsFile struct {
+	Budgets []capRule `json:"budgets"`
+}
+
+// capRule caps the monthly spending on the transactions matching its payee, its
+// category or both.
+type capRule struct {
+	// Name labels the rule in warnings, the payee or category when empty.
+	Name string `json:"name"`
+	// Payee matches the payees containing it, ignoring case.
+	Payee      string `json:"payee"`
+	CategoryID string `json:"category_id"`
+	// MonthlyCap is in euros.
+	MonthlyCap float64 `json:"monthly_cap"`
+}
+
+// capExcess is a rule whose transactions of a month spend more than its cap, in milliunits.
+type capExcess struct {
+	name  string
+	month string
+	spent int
+	cap   int
+}
+
+// loadCaps reads the rules of the caps file at path in fsys, none when path is empty.
+func loadCaps(fsys fs.FS, path string) ([]capRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading caps: %w", err)
+	}
+
+	var file capsFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidCaps, err)
+	}
+
+	for i, rule := range file.Budgets {
+		switch {
+		case rule.Payee == "" && rule.CategoryID == "":
+			return nil, fmt.Errorf("%w: budget %d: payee or category_id is required", errInvalidCaps, i+1)
+		case rule.MonthlyCap <= 0:
+			return nil, fmt.Errorf("%w: budget %d: monthly_cap %v, want more than 0", errInvalidCaps, i+1, rule.MonthlyCap)
+		}
+
+		if rule.Name == "" {
+			file.Budgets[i].Name = cmp.Or(rule.Payee, rule.CategoryID)
+		}
+	}
+
+	return file.Budgets, nil
+}
+
+func (r capRule) matches(transaction Transaction) bool {
+	if r.Payee != "" && !strings.Contains(strings.ToLower(transaction.PayeeName), strings.ToLower(r.Payee)) {
+		return false
+	}
+
+	return r.CategoryID == "" || transaction.CategoryID != nil && *transaction.CategoryID == r.CategoryID
+}
+
+// checkCaps sums the spending of the transactions matching each rule by calendar month
+// of their date, inflows counting as refunds, and returns the months above the cap,
+// by rule then month.
+func checkCaps(transactions []Transaction, rules []capRule) []capExcess {
+	var excesses []capExcess
+
+	for _, rule := range rules {
+		var months []string
+
+		spent := make(map[string]int)
+
+		for _, transaction := range transactions {
+			if !rule.matches(transaction) {
+				continue
+			}
+
+			month := transaction.Date.Format(capMonthFormat)
+			if _, ok := spent[month]; !ok {
+				months = append(months, month)
+			}
+
+			spent[month] -= transaction.Amount
+		}
+
+		slices.Sort(months)
+
+		limit := int(math.Round(rule.MonthlyCap * milliUnit))
+
+		for _, month := range months {
+			if spent[month] > limit {
+				excesses = append(excesses, capExcess{name: rule.Name, month: month, spent: spent[month], cap: limit})
+			}
+		}
+	}
+
+	return excesses
+}
+
+// printCaps warns about each excess on w and returns them as errCapExceeded, nil when there's none.
+func printCaps(w io.Writer, state *runState, excesses []capExcess) error {
+	errs := make([]error, 0, len(excesses))
+
+	for _, excess := range excesses {
+		spent, limit := state.amounts.amount(excess.spent), state.amounts.amount(excess.cap)
+
+		state.logger.Warn("monthly cap exceeded", "budget", excess.name, "month", excess.month, "spent", excess.spent,
+			"cap", excess.cap)
+		_, _ = fmt.Fprintln(w, state.messages.capExceeded(excess.name, excess.month, spent, limit))
+
+		errs = append(errs, fmt.Errorf("%w: %v in %v: %v, want at most %v",
+			errCapExceeded, excess.name, excess.month, spent, limit))
+	}
+
+	return errors.Join(errs...)
+}
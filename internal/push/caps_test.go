@@ -0,0 +1,200 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_loadCaps(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []capRule
+		wantErr error
+	}{
+		{
+			name:    "named by payee",
+			content: `{"budgets": [{"payee": "carrefour", "monthly_cap": 300}]}`,
+			want:    []capRule{{Name: "carrefour", Payee: "carrefour", MonthlyCap: 300}},
+		},
+		{
+			name:    "named",
+			content: `{"budgets": [{"name": "Sport", "category_id": "cat-sport", "monthly_cap": 50.5}]}`,
+			want:    []capRule{{Name: "Sport", CategoryID: "cat-sport", MonthlyCap: 50.5}},
+		},
+		{
+			name:    "no matcher",
+			content: `{"budgets": [{"name": "Sport", "monthly_cap": 50}]}`,
+			wantErr: errInvalidCaps,
+		},
+		{
+			name:    "no cap",
+			content: `{"budgets": [{"payee": "carrefour"}]}`,
+			wantErr: errInvalidCaps,
+		},
+		{
+			name:    "invalid JSON",
+			content: `{"budgets": `,
+			wantErr: errInvalidCaps,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fsys := fstest.MapFS{"caps.json": {Data: []byte(tt.content)}}
+
+			got, err := loadCaps(fsys, "caps.json")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("loadCaps() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadCaps() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkCaps(t *testing.T) {
+	t.Parallel()
+
+	transactions := []Transaction{
+		{Date: mustDate("2024-10-27"), Amount: -60000, PayeeName: "CB  CARREFOUR"},
+		{Date: mustDate("2024-10-31"), Amount: -50500, PayeeName: "CB  CARREFOUR"},
+		{Date: mustDate("2024-11-01"), Amount: -30000, PayeeName: "CB  CARREFOUR"},
+		withCategoryID(Transaction{Date: mustDate("2024-11-03"), Amount: -70000, PayeeName: "CB  DECATHLON"}, "cat-sport"),
+		withCategoryID(Transaction{Date: mustDate("2024-11-20"), Amount: 40000, PayeeName: "CB  DECATHLON"}, "cat-sport"),
+	}
+
+	tests := []struct {
+		name  string
+		rules []capRule
+		want  []capExcess
+	}{
+		{
+			name:  "one month of two",
+			rules: []capRule{{Name: "Courses", Payee: "carrefour", MonthlyCap: 100}},
+			want:  []capExcess{{name: "Courses", month: "2024-10", spent: 110500, cap: 100000}},
+		},
+		{
+			name:  "both months",
+			rules: []capRule{{Name: "Courses", Payee: "carrefour", MonthlyCap: 25}},
+			want: []capExcess{
+				{name: "Courses", month: "2024-10", spent: 110500, cap: 25000},
+				{name: "Courses", month: "2024-11", spent: 30000, cap: 25000},
+			},
+		},
+		{
+			name:  "at the cap",
+			rules: []capRule{{Name: "Courses", Payee: "carrefour", MonthlyCap: 110.5}},
+		},
+		{
+			name:  "refund counted",
+			rules: []capRule{{Name: "Sport", CategoryID: "cat-sport", MonthlyCap: 40}},
+		},
+		{
+			name:  "payee and category",
+			rules: []capRule{{Name: "Sport", Payee: "carrefour", CategoryID: "cat-sport", MonthlyCap: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := checkCaps(transactions, tt.rules); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkCaps() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_run_caps(t *testing.T) {
+	t.Parallel()
+
+	// caps.csv spends 110.50 at Carrefour in October, the second charge booked on
+	// November 2nd for a card payment of October 31st, then 30 in November.
+	tests := []struct {
+		name       string
+		args       []string
+		wantErr    error
+		wantStdout string
+	}{
+		{
+			name:       "warning",
+			wantStdout: "WARNING: carrefour spends 110.50€ in 2024-10, more than its cap of 100.00€\n",
+		},
+		{
+			name:       "fail on cap",
+			args:       []string{"-fail-on-cap"},
+			wantErr:    errCapExceeded,
+			wantStdout: "successfully pushed 4 transaction(s)\n",
+		},
+		{
+			name:       "french",
+			args:       []string{"-lang", "fr"},
+			wantStdout: "ATTENTION : carrefour dépense 110.50€ en 2024-10, plus que le plafond de 100.00€\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+				createdResponder(http.StatusOK))
+
+			dir := t.TempDir()
+			caps := filepath.Join(dir, "caps.json")
+
+			content := `{"budgets": [{"payee": "carrefour", "monthly_cap": 100}]}`
+			if err := os.WriteFile(caps, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := &bytes.Buffer{}
+
+			err := run(context.Background(), append([]string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/caps.csv",
+				"-state", filepath.Join(dir, "state.json"), "-caps", caps,
+			}, tt.args...), env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil && ExitCode(err) != exitCapExceeded {
+				t.Errorf("ExitCode() = %v, want %v", ExitCode(err), exitCapExceeded)
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = \n%s\nwant it to contain %q", stdout, tt.wantStdout)
+			}
+
+			if got := transport.GetTotalCallCount(); got != 1 {
+				t.Errorf("API calls = %d, want 1, the transactions being pushed", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package push
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+var errCategorizerOutput = errors.New("unexpected categorizer output")
+
+// categorizeOverrides is what the external categorizer may answer for a transaction.
+// Omitted fields leave the transaction untouched.
+type categorizeOverrides struct {
+	CategoryID *string `json:"category_id"`
+	PayeeName  *string `json:"payee_name"`
+	FlagColor  *string `json:"flag_color"`
+	Memo       *string `json:"memo"`
+}
+
+// apply sets the overrides on transaction, leaving it untouched when one of them is invalid.
+func (o categorizeOverrides) apply(transaction *Transaction) error {
+	var opts []lclynab.TransactionOption
+
+	if o.CategoryID != nil {
+		opts = append(opts, lclynab.WithCategory(*o.CategoryID))
+	}
+
+	if o.PayeeName != nil {
+		opts = append(opts, lclynab.WithPayee(*o.PayeeName))
+	}
+
+	if o.FlagColor != nil {
+		opts = append(opts, lclynab.WithFlag(*o.FlagColor))
+	}
+
+	if o.Memo != nil {
+		opts = append(opts, lclynab.WithMemo(*o.Memo))
+	}
+
+	updated, err := transaction.With(opts...)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errCategorizerOutput, err)
+	}
+
+	*transaction = updated
+
+	return nil
+}
+
+type warner interface {
+	warn(msg string, err error)
+}
+
+// categorizer runs an external command to enrich transactions.
+// The command gets a transaction as JSON on stdin and may print a JSON object of overrides.
+// In batch mode, a single process receives one transaction per line and answers one line per transaction.
+type categorizer struct {
+	command  []string
+	timeout  time.Duration
+	batch    bool
+	warnings warner
+}
+
+func (c *categorizer) categorize(ctx context.Context, transactions []Transaction) {
+	if len(transactions) == 0 {
+		return
+	}
+
+	if c.batch {
+		c.categorizeBatch(ctx, transactions)
+		return
+	}
+
+	for i := range transactions {
+		input, err := json.Marshal(transactions[i])
+		if err != nil {
+			c.warnings.warn("encoding transaction for categorizer", err)
+			continue
+		}
+
+		output, err := c.exec(ctx, append(input, '\n'))
+		if err != nil {
+			c.warnings.warn("categorizing "+transactions[i].ImportID, err)
+			continue
+		}
+
+		if err := applyOverrides(&transactions[i], output); err != nil {
+			c.warnings.warn("categorizing "+transactions[i].ImportID, err)
+		}
+	}
+}
+
+func (c *categorizer) categorizeBatch(ctx context.Context, transactions []Transaction) {
+	var input bytes.Buffer
+
+	encoder := json.NewEncoder(&input)
+	for _, transaction := range transactions {
+		if err := encoder.Encode(transaction); err != nil {
+			c.warnings.warn("encoding transaction for categorizer", err)
+			return
+		}
+	}
+
+	output, err := c.exec(ctx, input.Bytes())
+	if err != nil {
+		c.warnings.warn("categorizing transactions", err)
+		return
+	}
+
+	var lines [][]byte
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		lines = append(lines, bytes.Clone(scanner.Bytes()))
+	}
+
+	if len(lines) != len(transactions) {
+		c.warnings.warn("categorizing transactions",
+			fmt.Errorf("%w: %d line(s) for %d transaction(s)", errCategorizerOutput, len(lines), len(transactions)))
+
+		return
+	}
+
+	for i, line := range lines {
+		if err := applyOverrides(&transactions[i], line); err != nil {
+			c.warnings.warn("categorizing "+transactions[i].ImportID, err)
+		}
+	}
+}
+
+func (c *categorizer) exec(ctx context.Context, input []byte) ([]byte, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, c.command[0], c.command[1:]...) //nolint:gosec // command chosen by the user
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("running categorizer: %w", ctx.Err())
+		}
+
+		return nil, fmt.Errorf("running categorizer: %w: %v", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
+
+func applyOverrides(transaction *Transaction, output []byte) error {
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil
+	}
+
+	var overrides categorizeOverrides
+	if err := json.Unmarshal(output, &overrides); err != nil {
+		return fmt.Errorf("%w: %w", errCategorizerOutput, err)
+	}
+
+	return overrides.apply(transaction)
+}
@@ -0,0 +1,117 @@
+package push
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeWarner struct {
+	warnings []string
+}
+
+func (w *fakeWarner) warn(msg string, err error) {
+	w.warnings = append(w.warnings, msg+": "+err.Error())
+}
+
+//nolint:funlen // mostly test cases in list
+func Test_categorizer(t *testing.T) {
+	t.Parallel()
+
+	input := func() []Transaction {
+		return []Transaction{
+			{Date: mustDate("2024-10-28"), Amount: -21320, PayeeName: "CB  MERCH", Memo: "CB  MERCH 28/10/24", ImportID: "id-1"},
+			{Date: mustDate("2024-10-29"), Amount: 80000, PayeeName: "VIREMENT", Memo: "VIREMENT", ImportID: "id-2"},
+		}
+	}
+
+	categorized := func() []Transaction {
+		transactions := input()
+		transactions[0].CategoryID = ptr("cat-groceries")
+		transactions[0].PayeeName = "Merch"
+		transactions[0].FlagColor = ptr("green")
+
+		return transactions
+	}
+
+	tests := []struct {
+		name         string
+		command      string
+		batch        bool
+		want         []Transaction
+		wantWarnings int
+	}{
+		{
+			name:         "per transaction",
+			command:      "./testdata/categorize.sh",
+			want:         categorized(),
+			wantWarnings: 0,
+		},
+		{
+			name:         "batch",
+			command:      "./testdata/categorize.sh batch",
+			batch:        true,
+			want:         categorized(),
+			wantWarnings: 0,
+		},
+		{
+			name:         "batch with wrong line count",
+			command:      "./testdata/categorize.sh",
+			batch:        true,
+			want:         input(),
+			wantWarnings: 1,
+		},
+		{
+			name:         "non-zero exit",
+			command:      "./testdata/categorize-fail.sh",
+			want:         input(),
+			wantWarnings: 2,
+		},
+		{
+			name:         "invalid JSON",
+			command:      "./testdata/categorize-invalid.sh",
+			want:         input(),
+			wantWarnings: 2,
+		},
+		{
+			name:         "invalid flag color",
+			command:      "./testdata/categorize-invalid-flag.sh",
+			want:         input(),
+			wantWarnings: 2,
+		},
+		{
+			name:         "timeout",
+			command:      "./testdata/categorize-slow.sh",
+			batch:        true,
+			want:         input(),
+			wantWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			warnings := &fakeWarner{}
+			categorizer := &categorizer{
+				command:  strings.Fields(tt.command),
+				timeout:  time.Second,
+				batch:    tt.batch,
+				warnings: warnings,
+			}
+
+			got := input()
+			categorizer.categorize(context.Background(), got)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("categorize() got = %+v, want %+v", got, tt.want)
+			}
+
+			if len(warnings.warnings) != tt.wantWarnings {
+				t.Errorf("categorize() warnings = %v, want %d", warnings.warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
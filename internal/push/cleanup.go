@@ -0,0 +1,137 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/retention"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+)
+
+// demoPattern matches the files -demo keeps: the fake budget and the state file.
+const demoPattern = "*.json"
+
+// ownArtifacts are the artifacts the retention flags expire. Only the files the tool
+// names itself are cleaned: the reports -watch names after their run beside -report,
+// never -report itself, and the default demo directory, never a -demo-dir.
+func ownArtifacts(opts *options) []retention.Artifact {
+	var artifacts []retention.Artifact
+
+	if opts.report != "" && opts.report != "-" {
+		artifacts = append(artifacts, retention.Artifact{
+			Name: "reports", Dir: filepath.Dir(opts.report), Pattern: watchReportPattern(opts.report),
+			Policy: opts.retentionReports,
+		})
+	}
+
+	if opts.demoDir == "" {
+		artifacts = append(artifacts, retention.Artifact{
+			Name: "demo", Dir: demoDir(opts), Pattern: demoPattern, Policy: retention.Policy{MaxAge: opts.retentionDemoMaxAge},
+		})
+	}
+
+	return artifacts
+}
+
+// watchReportPattern matches the paths watchReportPath derives from report, the run
+// IDs being those of logging.NewRunID.
+func watchReportPattern(report string) string {
+	ext := filepath.Ext(report)
+	base := strings.TrimSuffix(filepath.Base(report), ext)
+
+	return escapeMatch(base) + "-" + strings.Repeat("[0-9a-f]", logging.RunIDLen) + escapeMatch(ext)
+}
+
+// escapeMatch quotes the characters of s filepath.Match gives a meaning to.
+func escapeMatch(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// cleanup deletes the artifacts expired at now and forgets the expired watched files
+// of the state, logging each one. A dry run lists them to w instead.
+func cleanup(w io.Writer, logger *slog.Logger, opts *options, fsys fs.FS, now time.Time, dryRun bool) error {
+	removals, err := retention.Clean(ownArtifacts(opts), now, dryRun)
+
+	for _, removal := range removals {
+		if dryRun {
+			_, _ = fmt.Fprintf(w, "would remove %v %v (%v)\n",
+				removal.Artifact, removal.Path, removal.ModTime.Format(time.DateTime))
+		} else {
+			logger.Info("removed expired artifact", "artifact", removal.Artifact, "path", removal.Path)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("cleaning up: %w", err)
+	}
+
+	forgotten, err := forgetWatched(fsys, opts.statePath, opts.retentionWatchedMaxAge, now, dryRun)
+
+	for _, path := range forgotten {
+		if dryRun {
+			_, _ = fmt.Fprintf(w, "would forget watched file %v\n", path)
+		} else {
+			logger.Info("forgot expired watched file", "path", path)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("cleaning up: %w", err)
+	}
+
+	return nil
+}
+
+// forgetWatched drops from the state file the watched files gone from their directory
+// and last modified more than maxAge before now, and returns their paths. The files
+// still there are kept, so that they aren't pushed again. A dry run only returns them.
+func forgetWatched(fsys fs.FS, statePath string, maxAge time.Duration, now time.Time, dryRun bool) ([]string, error) {
+	if maxAge == 0 {
+		return nil, nil
+	}
+
+	previous := &pushState{}
+	if err := state.LoadFS(fsys, statePath, previous); err != nil {
+		return nil, err //nolint:wrapcheck // already explicit
+	}
+
+	var forgotten []string
+
+	for _, path := range slices.Sorted(maps.Keys(previous.Watched)) {
+		if now.Sub(previous.Watched[path].ModTime) <= maxAge {
+			continue
+		}
+
+		if _, err := fs.Stat(fsys, path); !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		forgotten = append(forgotten, path)
+		delete(previous.Watched, path)
+	}
+
+	if dryRun || len(forgotten) == 0 {
+		return forgotten, nil
+	}
+
+	return forgotten, state.Save(statePath, previous) //nolint:wrapcheck // already explicit
+}
@@ -0,0 +1,136 @@
+package push
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/retention"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+)
+
+func Test_ownArtifacts(t *testing.T) {
+	t.Parallel()
+
+	policy := retention.Policy{MaxCount: 5}
+
+	got := ownArtifacts(&options{report: "/reports/run.json", retentionReports: policy, retentionDemoMaxAge: time.Hour})
+	want := []retention.Artifact{
+		{Name: "reports", Dir: "/reports", Pattern: "run-" + strings.Repeat("[0-9a-f]", 8) + ".json", Policy: policy},
+		{Name: "demo", Dir: demoDir(&options{}), Pattern: demoPattern, Policy: retention.Policy{MaxAge: time.Hour}},
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("ownArtifacts() = %+v, want %+v", got, want)
+	}
+
+	if got := ownArtifacts(&options{report: "-", demoDir: "/tmp/demo"}); got != nil {
+		t.Errorf("ownArtifacts(-report -, -demo-dir) = %+v, want nothing", got)
+	}
+}
+
+func Test_cleanup(t *testing.T) {
+	t.Parallel()
+
+	now := fixedNow()
+	dir := t.TempDir()
+	day := 24 * time.Hour
+
+	for name, days := range map[string]int{
+		"run.json": 60, "run-0123abcd.json": 1, "run-4567cdef.json": 10, "run-89abcdef.json": 60, "run-final.json": 60,
+		"watched-new.csv": 60,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		modTime := now.Add(-time.Duration(days) * day)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statePath := filepath.Join(dir, "state.json")
+	watched := map[string]watchedFile{
+		filepath.Join(dir, "watched-gone.csv"):   {ModTime: now.Add(-60 * day)},
+		filepath.Join(dir, "watched-recent.csv"): {ModTime: now.Add(-day)},
+		filepath.Join(dir, "watched-new.csv"):    {ModTime: now.Add(-60 * day)},
+	}
+
+	if err := state.Save(statePath, &pushState{Watched: watched}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &options{
+		report:                 filepath.Join(dir, "run.json"),
+		demoDir:                dir,
+		statePath:              statePath,
+		retentionReports:       retention.Policy{MaxAge: 30 * day, MaxCount: 1},
+		retentionWatchedMaxAge: 30 * day,
+	}
+
+	var stdout bytes.Buffer
+
+	if err := cleanup(&stdout, logging.Discard(), opts, osFS{}, now, true); err != nil {
+		t.Fatalf("cleanup(dry run) error = %v", err)
+	}
+
+	wantLines := []string{
+		"would remove reports " + filepath.Join(dir, "run-89abcdef.json"),
+		"would remove reports " + filepath.Join(dir, "run-4567cdef.json"),
+		"would forget watched file " + filepath.Join(dir, "watched-gone.csv"),
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != len(wantLines) {
+		t.Fatalf("stdout = %q, want %d lines", stdout.String(), len(wantLines))
+	}
+
+	for i, want := range wantLines {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("line %d = %q, want it to start with %q", i, lines[i], want)
+		}
+	}
+
+	if err := cleanup(&stdout, logging.Discard(), opts, osFS{}, now, false); err != nil {
+		t.Fatalf("cleanup() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var left []string
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+
+	want := []string{"run-0123abcd.json", "run-final.json", "run.json", "state.json", "watched-new.csv"}
+	if !slices.Equal(left, want) {
+		t.Errorf("left %v, want %v", left, want)
+	}
+
+	got := &pushState{}
+	if err := state.Load(statePath, got); err != nil {
+		t.Fatal(err)
+	}
+
+	delete(watched, filepath.Join(dir, "watched-gone.csv"))
+
+	for path := range watched {
+		if _, ok := got.Watched[path]; !ok {
+			t.Errorf("watched file %v forgotten, want it kept", path)
+		}
+	}
+
+	if len(got.Watched) != len(watched) {
+		t.Errorf("watched files = %v, want %v", got.Watched, watched)
+	}
+}
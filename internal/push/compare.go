@@ -0,0 +1,164 @@
+package push
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+var (
+	errReportChanged       = errors.New("the conversion differs from the report")
+	errReportNoTransaction = errors.New("report lists no transactions")
+)
+
+// reportDiff is how a conversion compares to the transactions of a previous report.
+type reportDiff struct {
+	added   []Transaction
+	removed []Transaction
+	// changed are transactions of the same import ID whose payee, memo, category or flag differ.
+	changed []reportChange
+}
+
+type reportChange struct {
+	before Transaction
+	after  Transaction
+}
+
+func (d reportDiff) count() int {
+	return len(d.added) + len(d.removed) + len(d.changed)
+}
+
+// compareReport compares the converted transactions with those of the report at path,
+// as written by -report or -output json, and prints the differences. Differences end
+// the run with errReportChanged.
+func compareReport(w io.Writer, opts *options, env env, state *runState, transactions []Transaction) error {
+	before, err := loadReportTransactions(env.fsys, opts.compareReport)
+	if err != nil {
+		return err
+	}
+
+	diff := diffReport(before, transactions)
+
+	_, _ = fmt.Fprintln(w)
+	_ = printReportDiff(w, diff, state.amounts, opts.noTruncate)
+
+	if n := diff.count(); n > 0 {
+		return fmt.Errorf("%w: %d differences with %v", errReportChanged, n, opts.compareReport)
+	}
+
+	return nil
+}
+
+// loadReportTransactions reads the transactions of a JSON report, or of a JSON array of transactions.
+func loadReportTransactions(fsys fs.FS, path string) ([]Transaction, error) {
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading report: %w", err)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		var transactions []Transaction
+		if err := json.Unmarshal(content, &transactions); err != nil {
+			return nil, fmt.Errorf("decoding report: %w", err)
+		}
+
+		return transactions, nil
+	}
+
+	var report Result
+	if err := json.Unmarshal(content, &report); err != nil {
+		return nil, fmt.Errorf("decoding report: %w", err)
+	}
+
+	if report.Transactions == nil {
+		return nil, fmt.Errorf("%w: %v", errReportNoTransaction, path)
+	}
+
+	return report.Transactions, nil
+}
+
+// diffReport aligns the transactions of a report with a conversion by import ID.
+func diffReport(before, after []Transaction) reportDiff {
+	var diff reportDiff
+
+	for _, transaction := range after {
+		i := slices.IndexFunc(before, func(t Transaction) bool { return t.ImportID == transaction.ImportID })
+		if i < 0 {
+			diff.added = append(diff.added, transaction)
+
+			continue
+		}
+
+		if len(fieldChanges(before[i], transaction)) > 0 {
+			diff.changed = append(diff.changed, reportChange{before: before[i], after: transaction})
+		}
+	}
+
+	for _, transaction := range before {
+		if !slices.ContainsFunc(after, func(t Transaction) bool { return t.ImportID == transaction.ImportID }) {
+			diff.removed = append(diff.removed, transaction)
+		}
+	}
+
+	return diff
+}
+
+// fieldChanges describes the payee, memo, category and flag changed from before to after.
+func fieldChanges(before, after Transaction) []string {
+	var changes []string
+
+	change := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, fmt.Sprintf("%v %v -> %v", field, from, to))
+		}
+	}
+
+	change("payee", fmt.Sprintf("%q", before.PayeeName), fmt.Sprintf("%q", after.PayeeName))
+	change("memo", fmt.Sprintf("%q", before.Memo), fmt.Sprintf("%q", after.Memo))
+	change("category", optional(before.CategoryID), optional(after.CategoryID))
+	change("flag", optional(before.FlagColor), optional(after.FlagColor))
+
+	return changes
+}
+
+// optional quotes the value of a field that may be left out.
+func optional(value *string) string {
+	if value == nil {
+		return "none"
+	}
+
+	return fmt.Sprintf("%q", *value)
+}
+
+func printReportDiff(w io.Writer, diff reportDiff, amounts amountFormat, noTruncate bool) error {
+	_, _ = fmt.Fprintf(w, "added: %d\n", len(diff.added))
+	if len(diff.added) > 0 {
+		if err := renderTable(w, diff.added, amounts, noTruncate); err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "removed: %d\n", len(diff.removed))
+	if len(diff.removed) > 0 {
+		if err := renderTable(w, diff.removed, amounts, noTruncate); err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "changed: %d\n", len(diff.changed))
+
+	for _, change := range diff.changed {
+		_, err := fmt.Fprintf(w, "  %v  %v: %v\n",
+			change.after.Date, change.after.ImportID, strings.Join(fieldChanges(change.before, change.after), ", "))
+		if err != nil {
+			return fmt.Errorf("writing diff: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_printReportDiff(t *testing.T) {
+	t.Parallel()
+
+	transaction := func(day string, amount int, payee, importID string) Transaction {
+		return Transaction{Date: mustDate(day), Amount: amount, PayeeName: payee, Memo: payee, ImportID: importID}
+	}
+
+	red := "red"
+	kept := transaction("2024-10-27", -21320, "CB MERCH", "id-kept")
+	payee := transaction("2024-10-28", -12500, "CB BOULANGERIE", "id-payee")
+	memo := transaction("2024-10-28", -4200, "CB PRESSE", "id-memo")
+	category := withCategoryID(transaction("2024-10-29", -60000, "CB CARREFOUR", "id-category"), "cat-food")
+	flag := transaction("2024-10-29", -9900, "PRLV SEPA FREE", "id-flag")
+	removed := transaction("2024-10-30", -5000, "PRLV SEPA FOO", "id-removed")
+	added := transaction("2024-10-31", 80000, "VIREMENT", "id-added")
+
+	before := []Transaction{kept, payee, memo, category, flag, removed}
+
+	after := slices.Clone(before[:5])
+	after[1].PayeeName = "Boulangerie"
+	after[2].Memo = "Le Monde"
+	after[3] = withCategoryID(after[3], "cat-groceries")
+	after[4].FlagColor = &red
+	after = append(after, added)
+
+	var got bytes.Buffer
+	if err := printReportDiff(&got, diffReport(before, after), amountFormat{}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("./testdata/compare.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.String() != string(want) {
+		t.Errorf("printReportDiff() = \n%s\nwant\n%s", &got, want)
+	}
+}
+
+func Test_run_compareReport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	reportPath := filepath.Join(dir, "report.json")
+
+	dryRun := func(args ...string) (string, error) {
+		stdout := &bytes.Buffer{}
+
+		err := run(context.Background(), append([]string{
+			"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/refunds.csv", "-dry-run",
+			"-state", filepath.Join(dir, "state.json"),
+		}, args...), env{
+			stdout:     stdout,
+			stderr:     io.Discard,
+			httpClient: &http.Client{Transport: httpmock.NewMockTransport()},
+			now:        fixedNow,
+			getenv:     testGetenv(t),
+		})
+
+		return stdout.String(), err
+	}
+
+	if _, err := dryRun("-report", reportPath); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	stdout, err := dryRun("-compare-report", reportPath)
+	if err != nil {
+		t.Fatalf("run() with the same rules error = %v\n%s", err, stdout)
+	}
+
+	// Linking refunds changes the memos of both transactions.
+	stdout, err = dryRun("-compare-report", reportPath, "-detect-refunds", "30")
+	if !errors.Is(err, errReportChanged) || ExitCode(err) != exitReportChanged {
+		t.Fatalf("run() with other rules error = %v, want errReportChanged", err)
+	}
+
+	want := "changed: 2\n" +
+		`  2024-10-11  YNAB:-45000:2024-10-11:1: memo "CB  DECATHLON      11/10/24" -> ` +
+		`"CB  DECATHLON      11/10/24 - remboursé le 14/10"` + "\n" +
+		`  2024-10-14  YNAB:45000:2024-10-14:1: memo "CB  DECATHLON      14/10/24" -> ` +
+		`"CB  DECATHLON      14/10/24 - remboursement de l'achat du 11/10"` + "\n"
+	if !bytes.HasSuffix([]byte(stdout), []byte(want)) {
+		t.Errorf("stdout = \n%s\nwant it to end with\n%s", stdout, want)
+	}
+}
+
+func Test_loadReportTransactions(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"report.json": {Data: []byte(`{"schema": 1, "transactions": [{"import_id": "id-1"}]}`)},
+		"dump.json":   {Data: []byte(`[{"import_id": "id-1"}, {"import_id": "id-2"}]`)},
+		"empty.json":  {Data: []byte(`{"schema": 1}`)},
+	}
+
+	tests := []struct {
+		path    string
+		want    int
+		wantErr error
+	}{
+		{path: "report.json", want: 1},
+		{path: "dump.json", want: 2},
+		{path: "empty.json", wantErr: errReportNoTransaction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := loadReportTransactions(fsys, tt.path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("loadReportTransactions() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(got) != tt.want {
+				t.Errorf("loadReportTransactions() = %d transactions, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseFlags_compareReportNeedsDryRun(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseFlags([]string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "statement.csv", "-compare-report", "report.json",
+	}, env{})
+	if !errors.Is(err, errRequiredFlag) {
+		t.Errorf("parseFlags() error = %v, want %v", err, errRequiredFlag)
+	}
+}
@@ -0,0 +1,82 @@
+package push
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// amountFormat formats amounts for people: in euros with a dot by default,
+// as the budget does with -use-budget-format.
+type amountFormat struct {
+	currency *lclynab.CurrencyFormat
+}
+
+// amount returns milliunits as an amount of money, e.g. "100.06€".
+func (f amountFormat) amount(milliunits int) string {
+	if f.currency != nil {
+		return f.currency.Format(milliunits)
+	}
+
+	return reconciledString(milliunits) + "€"
+}
+
+// signed is amount with a + in front of inflows.
+func (f amountFormat) signed(milliunits int) string {
+	if milliunits >= 0 {
+		return "+" + f.amount(milliunits)
+	}
+
+	return f.amount(milliunits)
+}
+
+// resolveCurrencyFormat returns the currency format of the budget, from the state file
+// when known there and fresh, otherwise from YNAB.
+func resolveCurrencyFormat(
+	ctx context.Context,
+	client *http.Client,
+	fsys fs.FS,
+	cache cachePolicy,
+	statePath, token, budgetID string,
+) (lclynab.CurrencyFormat, error) {
+	previous := &pushState{}
+	if err := state.LoadFS(fsys, statePath, previous); err != nil {
+		return lclynab.CurrencyFormat{}, err //nolint:wrapcheck // already explicit
+	}
+
+	if format, ok := previous.CurrencyFormats[budgetID]; ok && cache.fresh(previous.CurrencyFormatsFetchedAt[budgetID]) {
+		return format, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	settings, err := lclynab.NewClient(token, client).GetBudgetSettings(ctx, budgetID)
+	if err != nil {
+		return lclynab.CurrencyFormat{}, err //nolint:wrapcheck // already explicit
+	}
+
+	if cache.disabled {
+		return settings.CurrencyFormat, nil
+	}
+
+	if previous.CurrencyFormats == nil {
+		previous.CurrencyFormats = map[string]lclynab.CurrencyFormat{}
+	}
+
+	if previous.CurrencyFormatsFetchedAt == nil {
+		previous.CurrencyFormatsFetchedAt = map[string]time.Time{}
+	}
+
+	previous.CurrencyFormats[budgetID] = settings.CurrencyFormat
+	previous.CurrencyFormatsFetchedAt[budgetID] = cache.now
+	if err := state.Save(statePath, previous); err != nil {
+		return lclynab.CurrencyFormat{}, err //nolint:wrapcheck // already explicit
+	}
+
+	return settings.CurrencyFormat, nil
+}
@@ -0,0 +1,126 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+const (
+	gbpSettings = `{"data": {"settings": {"currency_format": {"iso_code": "GBP", "decimal_digits": 2,
+		"decimal_separator": ".", "symbol_first": true, "group_separator": ",", "currency_symbol": "£",
+		"display_symbol": true}}}}`
+	jpySettings = `{"data": {"settings": {"currency_format": {"iso_code": "JPY", "decimal_digits": 0,
+		"decimal_separator": ".", "symbol_first": true, "group_separator": ",", "currency_symbol": "¥",
+		"display_symbol": true}}}}`
+)
+
+func Test_run_useBudgetFormat(t *testing.T) {
+	t.Parallel()
+
+	// one-positive.csv reconciles at 100.06 and holds a transaction of +80.
+	tests := []struct {
+		name     string
+		flag     bool
+		settings string
+		status   int
+		want     []string
+	}{
+		{
+			name:     "GBP",
+			flag:     true,
+			settings: gbpSettings,
+			status:   http.StatusOK,
+			want:     []string{"reconciled: £100.06 as of", "+£80.00"},
+		},
+		{
+			name:     "JPY",
+			flag:     true,
+			settings: jpySettings,
+			status:   http.StatusOK,
+			want:     []string{"reconciled: ¥100 as of", "+¥80"},
+		},
+		{
+			name:     "settings failing",
+			flag:     true,
+			settings: `{}`,
+			status:   http.StatusInternalServerError,
+			want:     []string{"reconciled: 100.06€ as of", "+80.00€"},
+		},
+		{
+			name:     "flag off",
+			flag:     false,
+			settings: gbpSettings,
+			status:   http.StatusOK,
+			want:     []string{"reconciled: 100.06€ as of", "+80.00€"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/settings",
+				httpmock.NewStringResponder(tt.status, tt.settings))
+			transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+				createdResponder(http.StatusOK))
+
+			args := []string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv", "-v",
+				"-state", filepath.Join(t.TempDir(), "state.json"),
+			}
+			if tt.flag {
+				args = append(args, "-use-budget-format")
+			}
+
+			stdout := &bytes.Buffer{}
+
+			err := run(context.Background(), args, env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout = %v, want %q", stdout, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_resolveCurrencyFormat_cached(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/settings",
+		httpmock.NewStringResponder(http.StatusOK, gbpSettings))
+
+	client := &http.Client{Transport: transport}
+	statePath := filepath.Join(t.TempDir(), "push-state.json")
+
+	for range 2 {
+		format, err := resolveCurrencyFormat(context.Background(), client, osFS{}, cachePolicy{}, statePath, "tok",
+			testBudgetID)
+		if err != nil || format.ISOCode != "GBP" {
+			t.Fatalf("resolveCurrencyFormat() = %+v, %v, want GBP", format, err)
+		}
+	}
+
+	if got := transport.GetTotalCallCount(); got != 1 {
+		t.Errorf("API calls = %d, want 1, the second lookup being served from the state file", got)
+	}
+}
@@ -0,0 +1,120 @@
+package push
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/ynabmock"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+const (
+	demoToken = "demo-token"
+	// demoLabel starts each line printed on stdout in demo mode.
+	demoLabel = "DEMO "
+)
+
+// setDemoDefaults fills the token, budget and account of -demo left empty.
+func setDemoDefaults(opts *options) {
+	opts.token = cmp.Or(opts.token, demoToken)
+	opts.budgetID = cmp.Or(opts.budgetID, ynabmock.BudgetID)
+	opts.accountID = cmp.Or(opts.accountID, ynabmock.AccountID)
+}
+
+// demoDir is where -demo keeps its fake budget and state file by default.
+func demoDir(opts *options) string {
+	return cmp.Or(opts.demoDir, filepath.Join(os.TempDir(), "lcl-ynab-go-demo"))
+}
+
+// demoStatePath is the state file of -demo, apart from the real one.
+func demoStatePath(opts *options) string {
+	return filepath.Join(demoDir(opts), "state.json")
+}
+
+// startDemo serves a fake YNAB API in process and sends the YNAB calls of env to it.
+// Its data persists in the -demo-dir, so that pushing twice shows duplicates.
+// The returned function stops the server.
+func startDemo(opts *options, env *env) (func(), error) {
+	dir := demoDir(opts)
+
+	fake, err := ynabmock.New(filepath.Join(dir, "ynab.json"))
+	if err != nil {
+		return nil, err //nolint:wrapcheck // already explicit
+	}
+
+	server := httptest.NewServer(fake)
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		server.Close()
+
+		return nil, fmt.Errorf("starting the demo server: %w", err)
+	}
+
+	env.middlewares = append(env.middlewares, demoMiddleware(target, server.Client().Transport))
+
+	_, _ = fmt.Fprintf(env.stderr, "DEMO MODE: nothing is sent to YNAB, the fake budget is kept in %v\n", dir)
+
+	if opts.output == outputText && !opts.jsonOutput {
+		env.stdout = &labelWriter{w: env.stdout, label: demoLabel}
+	}
+
+	return server.Close, nil
+}
+
+// demoMiddleware sends the calls to the YNAB API to target through transport, and
+// lets the others, like webhooks, through.
+func demoMiddleware(target *url.URL, transport http.RoundTripper) lclynab.Middleware {
+	ynab, _ := url.Parse(lclynab.DefaultBaseURL)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return lclynab.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Host != ynab.Host {
+				return next.RoundTrip(req) //nolint:wrapcheck // transparent middleware
+			}
+
+			req = req.Clone(req.Context())
+			req.URL.Scheme, req.URL.Host, req.Host = target.Scheme, target.Host, ""
+
+			return transport.RoundTrip(req) //nolint:wrapcheck // transparent middleware
+		})
+	}
+}
+
+// labelWriter starts each line written to w with label.
+type labelWriter struct {
+	w     io.Writer
+	label string
+	// midLine is whether the last write didn't end a line.
+	midLine bool
+}
+
+func (l *labelWriter) Write(p []byte) (int, error) {
+	var out bytes.Buffer
+
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		if !l.midLine {
+			out.WriteString(l.label)
+		}
+
+		out.Write(line)
+		l.midLine = line[len(line)-1] != '\n'
+	}
+
+	if _, err := l.w.Write(out.Bytes()); err != nil {
+		return 0, err //nolint:wrapcheck // the writer's own error
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,56 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_demo(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// No responder: nothing may reach the real YNAB.
+	client := &http.Client{Transport: httpmock.NewMockTransport()}
+
+	for _, wantStdout := range []string{
+		"DEMO reconciled: 100.06€ as of 2024-11-29\nDEMO successfully pushed 1 transaction(s)\nDEMO found 0 duplicate(s)\n",
+		"DEMO reconciled: 100.06€ as of 2024-11-29\nDEMO successfully pushed 0 transaction(s)\nDEMO found 1 duplicate(s)\n",
+	} {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+		err := run(context.Background(), []string{
+			"-demo", "-demo-dir", dir, "-q", "./testdata/one-positive.csv",
+		}, env{stdout: stdout, stderr: stderr, httpClient: client, now: fixedNow})
+		if err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+
+		if stdout.String() != wantStdout {
+			t.Errorf("stdout = %q, want %q", stdout.String(), wantStdout)
+		}
+
+		if !strings.Contains(stderr.String(), "DEMO MODE") {
+			t.Errorf("stderr = %q, want the demo banner", stderr.String())
+		}
+	}
+}
+
+func Test_labelWriter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	w := &labelWriter{w: &out, label: "DEMO "}
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\n\nthree\n"))
+
+	if want := "DEMO one\nDEMO two\nDEMO \nDEMO three\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
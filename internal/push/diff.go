@@ -0,0 +1,206 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+var errDiscrepancies = errors.New("the file and YNAB differ")
+
+// diffResult is how the converted file compares to YNAB over the period it covers.
+type diffResult struct {
+	onlyInFile []Transaction
+	onlyInYNAB []lclynab.SavedTransaction
+	// changed are matched transactions whose amount or date differ.
+	changed []diffPair
+}
+
+type diffPair struct {
+	file Transaction
+	ynab lclynab.SavedTransaction
+}
+
+func (d diffResult) count() int {
+	return len(d.onlyInFile) + len(d.onlyInYNAB) + len(d.changed)
+}
+
+// diffFile compares the converted transactions with those of the account in YNAB, from
+// the first to the last day of the file, and prints the discrepancies. Nothing is pushed.
+// Discrepancies end the run with errDiscrepancies.
+func diffFile(ctx context.Context, opts *options, env env, state *runState, transactions []Transaction) error {
+	if len(transactions) == 0 {
+		return errNothingToPush
+	}
+
+	first, last := dateRange(transactions)
+
+	stop := state.start("diff")
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	// A day before the file, for transactions YNAB dated a day earlier.
+	since := lclynab.NewDate(first.AddDate(0, 0, -1))
+
+	client := lclynab.NewClient(opts.token, env.httpClient)
+
+	page, err := client.FetchTransactions(ctx, opts.budgetID, opts.accountID, since, 0)
+	if err != nil {
+		return fmt.Errorf("fetching YNAB transactions: %w", err)
+	}
+
+	diff := diffTransactions(transactions, page.Transactions, first, last)
+
+	_ = printDiff(env.stdout, diff, state.amounts, opts.noTruncate)
+
+	if n := diff.count(); n > 0 {
+		return fmt.Errorf("%w: %d discrepancies between %v and %v", errDiscrepancies, n, first, last)
+	}
+
+	return nil
+}
+
+// diffTransactions matches the file with YNAB by import ID first, then by amount on the
+// same day or a day apart. YNAB transactions outside [first, last] are only used for matching.
+func diffTransactions(file []Transaction, ynab []lclynab.SavedTransaction, first, last lclynab.Date) diffResult {
+	ynab = slices.DeleteFunc(slices.Clone(ynab), func(t lclynab.SavedTransaction) bool { return t.Deleted })
+	used := make([]bool, len(ynab))
+
+	var (
+		diff      diffResult
+		unmatched []Transaction
+	)
+
+	for _, transaction := range file {
+		i := slices.IndexFunc(ynab, func(t lclynab.SavedTransaction) bool {
+			return transaction.ImportID != "" && t.ImportID == transaction.ImportID
+		})
+		if i < 0 {
+			unmatched = append(unmatched, transaction)
+
+			continue
+		}
+
+		used[i] = true
+
+		if ynab[i].Amount != transaction.Amount || !ynab[i].Date.Equal(transaction.Date) {
+			diff.changed = append(diff.changed, diffPair{file: transaction, ynab: ynab[i]})
+		}
+	}
+
+	for _, transaction := range unmatched {
+		i := closestMatch(transaction, ynab, used)
+		if i < 0 {
+			diff.onlyInFile = append(diff.onlyInFile, transaction)
+
+			continue
+		}
+
+		used[i] = true
+
+		if !ynab[i].Date.Equal(transaction.Date) {
+			diff.changed = append(diff.changed, diffPair{file: transaction, ynab: ynab[i]})
+		}
+	}
+
+	for i, t := range ynab {
+		if !used[i] && !t.Date.Before(first) && !t.Date.After(last) {
+			diff.onlyInYNAB = append(diff.onlyInYNAB, t)
+		}
+	}
+
+	return diff
+}
+
+// closestMatch returns the index of the unused YNAB transaction of the same amount
+// dated closest to transaction, at most a day apart, or -1.
+func closestMatch(transaction Transaction, ynab []lclynab.SavedTransaction, used []bool) int {
+	best, bestDays := -1, 2
+
+	for i, t := range ynab {
+		if used[i] || t.Amount != transaction.Amount {
+			continue
+		}
+
+		if days := abs(daysBetween(t.Date, transaction.Date)); days < bestDays {
+			best, bestDays = i, days
+		}
+	}
+
+	return best
+}
+
+func printDiff(w io.Writer, diff diffResult, amounts amountFormat, noTruncate bool) error {
+	_, _ = fmt.Fprintf(w, "only in the file: %d\n", len(diff.onlyInFile))
+	if len(diff.onlyInFile) > 0 {
+		if err := renderTable(w, diff.onlyInFile, amounts, noTruncate); err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "only in YNAB: %d\n", len(diff.onlyInYNAB))
+	if len(diff.onlyInYNAB) > 0 {
+		ynab := make([]Transaction, 0, len(diff.onlyInYNAB))
+		for _, t := range diff.onlyInYNAB {
+			ynab = append(ynab, Transaction{
+				Date: t.Date, Amount: t.Amount, PayeeName: t.PayeeName, Memo: t.Memo, ImportID: t.ImportID,
+			})
+		}
+
+		if err := renderTable(w, ynab, amounts, noTruncate); err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "matched with differences: %d\n", len(diff.changed))
+
+	for _, pair := range diff.changed {
+		var differences []string
+
+		if pair.file.Amount != pair.ynab.Amount {
+			differences = append(differences, fmt.Sprintf("amount %v in the file, %v in YNAB",
+				amounts.signed(pair.file.Amount), amounts.signed(pair.ynab.Amount)))
+		}
+
+		if !pair.file.Date.Equal(pair.ynab.Date) {
+			differences = append(differences, fmt.Sprintf("date %v in the file, %v in YNAB", pair.file.Date, pair.ynab.Date))
+		}
+
+		_, err := fmt.Fprintf(w, "  %v  %v: %v\n", pair.file.Date, pair.file.PayeeName, strings.Join(differences, ", "))
+		if err != nil {
+			return fmt.Errorf("writing diff: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// dateRange returns the first and last days of the transactions, which must not be empty.
+func dateRange(transactions []Transaction) (first, last lclynab.Date) {
+	first, last = transactions[0].Date, transactions[0].Date
+
+	for _, t := range transactions[1:] {
+		if t.Date.Before(first) {
+			first = t.Date
+		}
+
+		if t.Date.After(last) {
+			last = t.Date
+		}
+	}
+
+	return first, last
+}
+
+// daysBetween returns the number of days from b to a.
+func daysBetween(a, b lclynab.Date) int {
+	return int(a.Sub(b.Time) / (24 * time.Hour)) //nolint:mnd // hours in a day
+}
@@ -0,0 +1,87 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_diff(t *testing.T) {
+	t.Parallel()
+
+	// diff.csv holds a transfer YNAB has as is, a card payment YNAB has with another amount,
+	// a card payment entered by hand in YNAB a day later and a debit YNAB misses.
+	// YNAB also has a payment missing from the file, a deleted one and one before the file.
+	const ynab = `{"data": {"server_knowledge": 1, "transactions": [
+		{"id": "t-1", "date": "2024-10-29", "amount": 80000, "payee_name": "Transfer",
+			"import_id": "YNAB:80000:2024-10-29:1"},
+		{"id": "t-2", "date": "2024-10-28", "amount": -21000, "payee_name": "Merch",
+			"import_id": "YNAB:-21320:2024-10-28:1"},
+		{"id": "t-3", "date": "2024-10-31", "amount": -10000, "payee_name": "Bakery"},
+		{"id": "t-4", "date": "2024-10-29", "amount": -42000, "payee_name": "Cash", "memo": "ATM"},
+		{"id": "t-5", "date": "2024-10-30", "amount": -5000, "payee_name": "Gone", "deleted": true},
+		{"id": "t-6", "date": "2024-10-27", "amount": -1000, "payee_name": "Before"}
+	]}}`
+
+	var query string
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/accounts/"+testAccountID+"/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			query = req.URL.RawQuery
+
+			return httpmock.NewStringResponse(http.StatusOK, ynab), nil
+		})
+
+	stdout := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/diff.csv", "-diff",
+		"-state", filepath.Join(t.TempDir(), "state.json"),
+	}, env{
+		stdout:     stdout,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if !errors.Is(err, errDiscrepancies) || ExitCode(err) != exitDiscrepancies {
+		t.Fatalf("run() error = %v, want errDiscrepancies", err)
+	}
+
+	if want := "since_date=2024-10-27"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+
+	if calls := transport.GetTotalCallCount(); calls != 1 {
+		t.Errorf("calls = %v, want only the listing", calls)
+	}
+
+	want, err := os.ReadFile("./testdata/diff.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout.String() != string(want) {
+		t.Errorf("stdout = \n%s\nwant\n%s", stdout, want)
+	}
+}
+
+func Test_diffTransactions_none(t *testing.T) {
+	t.Parallel()
+
+	file := []Transaction{{Date: mustDate("2024-10-28"), Amount: -21320, ImportID: "id-1"}}
+	ynab := []lclynab.SavedTransaction{{Date: mustDate("2024-10-28"), Amount: -21320, ImportID: "id-1"}}
+
+	if diff := diffTransactions(file, ynab, mustDate("2024-10-28"), mustDate("2024-10-28")); diff.count() != 0 {
+		t.Errorf("diffTransactions() = %+v, want no discrepancy", diff)
+	}
+}
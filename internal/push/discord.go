@@ -0,0 +1,134 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Discord embed limits, in characters.
+const (
+	discordTitleLen       = 256
+	discordDescriptionLen = 4096
+	discordFieldValueLen  = 1024
+
+	discordGreen = 0x2ecc71
+	discordRed   = 0xe74c3c
+)
+
+// discord posts the run result to a Discord webhook as an embed. Delivery goes
+// through the retry policy of the generic webhook.
+type discord struct {
+	client   *http.Client
+	logger   *slog.Logger
+	url      string
+	delivery *webhook
+}
+
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+	Timestamp   string         `json:"timestamp"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+func newDiscord(opts *options, client *http.Client, logger *slog.Logger) *discord {
+	return &discord{
+		client:   client,
+		logger:   logger,
+		url:      opts.discordWebhook,
+		delivery: newDelivery(opts),
+	}
+}
+
+func (d *discord) name() string {
+	return "discord"
+}
+
+func (d *discord) notify(ctx context.Context, data webhookData) error {
+	messages, err := d.render(data)
+	if err != nil {
+		return err
+	}
+
+	return d.delivery.deliver(ctx, d.client, d.logger, d.url, messages[0].body)
+}
+
+func (d *discord) render(data webhookData) ([]message, error) {
+	body, err := json.Marshal(discordPayload(data))
+	if err != nil {
+		return nil, fmt.Errorf("encoding Discord message: %w", err)
+	}
+
+	return []message{{target: "webhook", body: body}}, nil
+}
+
+// discordPayload renders the run result as a single embed, green on success and red on failure.
+func discordPayload(data webhookData) discordMessage {
+	failed := data.Status == statusError || data.Status == statusCancelled
+
+	embed := discordEmbed{
+		Title:     truncateRunes(fmt.Sprintf("YNAB import %v: %v", data.Status, data.AccountName), discordTitleLen),
+		Color:     discordGreen,
+		Timestamp: data.SentAt.Format(time.RFC3339),
+	}
+
+	if failed {
+		embed.Color = discordRed
+	}
+
+	if data.RunID != "" {
+		embed.Footer = &discordFooter{Text: "run " + data.RunID}
+	}
+
+	if data.Error != "" {
+		embed.Description = truncateRunes(fmt.Sprintf("Failed during %v: %v", data.Phase, data.Error), discordDescriptionLen)
+	}
+
+	if data.Reconciled != "" {
+		embed.Fields = append(embed.Fields, discordField{Name: "Reconciled", Value: data.Reconciled + "€", Inline: true})
+	}
+
+	embed.Fields = append(embed.Fields,
+		discordField{Name: "Pushed", Value: strconv.Itoa(data.Pushed), Inline: true},
+		discordField{Name: "Duplicates", Value: strconv.Itoa(data.Duplicates), Inline: true},
+	)
+
+	if data.BudgetName != "" {
+		embed.Fields = append(embed.Fields,
+			discordField{Name: "Budget", Value: truncateRunes(data.BudgetName, discordFieldValueLen), Inline: false})
+	}
+
+	return discordMessage{Embeds: []discordEmbed{embed}}
+}
+
+// truncateRunes cuts s so that it is at most maxLen characters long, as Discord counts them.
+func truncateRunes(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+
+	return string(runes[:maxLen-utf8.RuneCountInString(ellipsis)]) + ellipsis
+}
@@ -0,0 +1,150 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_discordPayload(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		data   webhookData
+		golden string
+	}{
+		{
+			name: "success",
+			data: webhookData{
+				Status:      statusOK,
+				Reconciled:  "100.06",
+				Pushed:      3,
+				Duplicates:  1,
+				BudgetName:  "Personal",
+				AccountName: "Checking",
+				SentAt:      fixedNow(),
+			},
+			golden: "./testdata/discord.golden",
+		},
+		{
+			name: "failure",
+			data: webhookData{
+				Status:      statusError,
+				Error:       "pushing to YNAB: rate limit (429)",
+				Phase:       "api call",
+				BudgetName:  "Personal",
+				AccountName: "Checking",
+				SentAt:      fixedNow(),
+			},
+			golden: "./testdata/discord-failure.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := json.MarshalIndent(discordPayload(tt.data), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got)+"\n" != string(want) {
+				t.Errorf("discordPayload() = \n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func Test_discordPayload_limits(t *testing.T) {
+	t.Parallel()
+
+	embed := discordPayload(webhookData{
+		Status:      statusError,
+		Error:       strings.Repeat("\u00e9", 5000),
+		Phase:       "api call",
+		BudgetName:  strings.Repeat("b", 2000),
+		AccountName: strings.Repeat("a", 300),
+	}).Embeds[0]
+
+	if got := utf8.RuneCountInString(embed.Title); got != discordTitleLen {
+		t.Errorf("title length = %d, want %d", got, discordTitleLen)
+	}
+
+	if got := utf8.RuneCountInString(embed.Description); got != discordDescriptionLen {
+		t.Errorf("description length = %d, want %d", got, discordDescriptionLen)
+	}
+
+	for _, field := range embed.Fields {
+		if got := utf8.RuneCountInString(field.Value); got > discordFieldValueLen {
+			t.Errorf("field %v length = %d, want at most %d", field.Name, got, discordFieldValueLen)
+		}
+	}
+}
+
+func Test_run_discord(t *testing.T) {
+	t.Parallel()
+
+	const discordURL = "https://discord.example/api/webhooks/123/s3cr3t"
+
+	var bodies []string
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK),
+	)
+	// Discord is down for the first attempt, which is retried like the generic webhook.
+	transport.RegisterResponder(http.MethodPost, discordURL, func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+
+		if len(bodies) == 1 {
+			return httpmock.NewStringResponse(http.StatusBadGateway, ""), nil
+		}
+
+		return httpmock.NewStringResponse(http.StatusNoContent, ""), nil
+	})
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-discord-webhook", discordURL, "-webhook-backoff", time.Millisecond.String(),
+	}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if len(bodies) != 2 {
+		t.Fatalf("Discord calls = %d, want 2", len(bodies))
+	}
+
+	var message discordMessage
+	if err := json.Unmarshal([]byte(bodies[1]), &message); err != nil {
+		t.Fatalf("decoding Discord message: %v", err)
+	}
+
+	if got := message.Embeds[0]; got.Title != "YNAB import ok: "+testAccountID || got.Color != discordGreen {
+		t.Errorf("embed = %+v, want a green embed titled with the account", got)
+	}
+}
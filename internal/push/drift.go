@@ -0,0 +1,68 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+var errDriftExceeded = errors.New("reconciliation drift exceeded")
+
+// checkDrift compares the cleared balance of the account in YNAB with the bank balance.
+// A drift above -drift-alert is returned as errDriftExceeded, while failing to get the
+// YNAB balance is only a warning: the transactions are pushed at this point.
+func checkDrift(ctx context.Context, opts *options, env env, state *runState) error {
+	res := state.result
+
+	cleared, err := fetchClearedBalance(ctx, env.httpClient, opts.token, opts.budgetID, opts.accountID)
+	if err != nil {
+		state.warnings.warn("checking drift failed", err)
+
+		return nil
+	}
+
+	drift := cleared - res.Reconciled
+	res.Drift = &drift
+
+	threshold := driftThreshold(opts.driftAlert)
+	if abs(drift) <= threshold {
+		return nil
+	}
+
+	res.DriftExceeded = true
+
+	state.logger.Warn("reconciliation drift exceeded", "drift", drift, "threshold", threshold)
+	amounts := state.amounts
+	_, _ = fmt.Fprintln(env.stdout, state.messages.driftExceeded(amounts.signed(drift), amounts.amount(threshold)))
+
+	return fmt.Errorf("%w: %v, want at most %v", errDriftExceeded, amounts.signed(drift), amounts.amount(threshold))
+}
+
+// driftThreshold converts the -drift-alert amount, in euros, to milliunits.
+func driftThreshold(euros float64) int {
+	return int(math.Round(euros * milliUnit))
+}
+
+func fetchClearedBalance(ctx context.Context, client *http.Client, token, budgetID, accountID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	account, err := lclynab.NewClient(token, client).GetAccount(ctx, budgetID, accountID)
+	if err != nil {
+		return 0, err //nolint:wrapcheck // already explicit
+	}
+
+	return account.ClearedBalance, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
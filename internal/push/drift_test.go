@@ -0,0 +1,125 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_driftAlert(t *testing.T) {
+	t.Parallel()
+
+	const (
+		webhook        = "https://hooks.example/ynab"
+		webhookFailure = "https://hooks.example/ynab-failure"
+	)
+
+	// one-positive.csv reconciles at 100.06, the threshold is 5.
+	tests := []struct {
+		name         string
+		cleared      int
+		wantErr      error
+		wantURL      string
+		wantStatus   string
+		wantExceeded bool
+	}{
+		{
+			name:         "just below",
+			cleared:      105059,
+			wantErr:      nil,
+			wantURL:      webhook,
+			wantStatus:   statusOK,
+			wantExceeded: false,
+		},
+		{
+			name:         "exactly at",
+			cleared:      95060,
+			wantErr:      nil,
+			wantURL:      webhook,
+			wantStatus:   statusOK,
+			wantExceeded: false,
+		},
+		{
+			name:         "above",
+			cleared:      105061,
+			wantErr:      errDriftExceeded,
+			wantURL:      webhookFailure,
+			wantStatus:   statusError,
+			wantExceeded: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				url     string
+				payload struct {
+					Status        string `json:"status"`
+					Drift         *int   `json:"drift_milliunits"`
+					DriftExceeded bool   `json:"drift_exceeded"`
+				}
+			)
+
+			capture := func(req *http.Request) (*http.Response, error) {
+				url = req.URL.String()
+				_ = json.NewDecoder(req.Body).Decode(&payload)
+
+				return httpmock.NewStringResponse(http.StatusOK, ""), nil
+			}
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(
+				http.MethodPost,
+				"/v1/budgets/"+testBudgetID+"/transactions",
+				createdResponder(http.StatusOK),
+			)
+			transport.RegisterResponder(
+				http.MethodGet,
+				"/v1/budgets/"+testBudgetID+"/accounts/"+testAccountID,
+				httpmock.NewStringResponder(http.StatusOK,
+					`{"data": {"account": {"cleared_balance": `+strconv.Itoa(tt.cleared)+`}}}`),
+			)
+			transport.RegisterResponder(http.MethodPost, webhook, capture)
+			transport.RegisterResponder(http.MethodPost, webhookFailure, capture)
+
+			err := run(context.Background(), []string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+				"-drift-alert", "5", "-w", webhook, "-webhook-failure", webhookFailure,
+			}, env{
+				stdout:     io.Discard,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil && ExitCode(err) != exitDriftExceeded {
+				t.Errorf("ExitCode() = %v, want %v", ExitCode(err), exitDriftExceeded)
+			}
+
+			if url != tt.wantURL {
+				t.Errorf("webhook sent to %v, want %v", url, tt.wantURL)
+			}
+
+			if payload.Status != tt.wantStatus || payload.DriftExceeded != tt.wantExceeded {
+				t.Errorf("status, drift_exceeded = %v, %v, want %v, %v",
+					payload.Status, payload.DriftExceeded, tt.wantStatus, tt.wantExceeded)
+			}
+
+			if want := tt.cleared - 100060; payload.Drift == nil || *payload.Drift != want {
+				t.Errorf("drift_milliunits = %v, want %v", payload.Drift, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package push
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// dryRun prints the transactions a run would push and the notifications it would send,
+// rendered exactly as they would be, without calling YNAB nor any channel. With
+// -compare-report, the differences with a previous report follow.
+func dryRun(opts *options, env env, state *runState, transactions []Transaction) error {
+	state.result.DryRun = true
+
+	_, _ = fmt.Fprintln(env.stdout, state.messages.wouldPush(len(transactions)))
+
+	if !opts.verbose {
+		_ = renderTable(env.stdout, transactions, state.amounts, opts.noTruncate)
+	}
+
+	// Notifications are rendered as if every transaction was pushed, without duplicates.
+	data := newWebhookData(state.result, checkOutcome(opts, len(transactions), 0), state, opts.accountID, env.now())
+	data.Pushed = len(transactions)
+
+	if url := state.webhook.urlFor(data.Status); url != "" {
+		body, err := state.webhook.render(data)
+		if err != nil {
+			return err
+		}
+
+		target := "-w"
+		if url != state.webhook.successURL {
+			target = "-webhook-failure"
+		}
+
+		printMessage(env.stdout, "webhook", message{target: target, body: body})
+	}
+
+	for _, n := range state.notifiers {
+		messages, err := n.render(data)
+		if err != nil {
+			return fmt.Errorf("rendering %v notification: %w", n.name(), err)
+		}
+
+		if len(messages) == 0 {
+			_, _ = fmt.Fprintf(env.stdout, "\n=== %v: nothing to send ===\n", n.name())
+		}
+
+		for _, msg := range messages {
+			printMessage(env.stdout, n.name(), msg)
+		}
+	}
+
+	if opts.compareReport != "" {
+		return compareReport(env.stdout, opts, env, state, transactions)
+	}
+
+	return nil
+}
+
+func printMessage(w io.Writer, channel string, msg message) {
+	_, _ = fmt.Fprintf(w, "\n=== %v: %v ===\n%s\n", channel, msg.target, bytes.TrimSuffix(msg.body, []byte("\n")))
+}
@@ -0,0 +1,82 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_dryRun(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	stdout := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv", "-dry-run",
+		"-run-id", "test-run", "-w", "https://hooks.example/ynab", "-webhook-template", "./testdata/webhook-ha.tmpl",
+		"-ha-url", "https://ha.example/", "-ha-token", "ha-token",
+		"-ntfy-url", "https://ntfy.example/bank",
+		"-slack-webhook", "https://hooks.slack.example/services/T000/B000/s3cr3t",
+	}, env{
+		stdout:     stdout,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if calls := transport.GetTotalCallCount(); calls != 0 {
+		t.Errorf("HTTP calls = %v, want none", calls)
+	}
+
+	gotPath := filepath.Join(t.TempDir(), "stdout")
+	if err := os.WriteFile(gotPath, stdout.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	assertGolden(t, gotPath, "./testdata/dry-run.golden")
+}
+
+func Test_run_dryRun_spellings(t *testing.T) {
+	t.Parallel()
+
+	for _, flag := range []string{"-n", "--dry-run"} {
+		t.Run(flag, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			stdout := &bytes.Buffer{}
+
+			err := run(context.Background(), []string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, flag, "./testdata/one-positive.csv",
+			}, env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			if calls := transport.GetTotalCallCount(); calls != 0 {
+				t.Errorf("HTTP calls = %v, want none", calls)
+			}
+
+			if want := "dry run: would push 1 transaction(s)\n"; !bytes.Contains(stdout.Bytes(), []byte(want)) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+			}
+		})
+	}
+}
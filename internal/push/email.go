@@ -0,0 +1,256 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	smtpTLSNone     = "none"
+	smtpTLSStart    = "starttls"
+	smtpTLSImplicit = "implicit"
+
+	defaultSMTPPort = 587
+	logTailLines    = 20
+)
+
+var errUnknownSMTPTLS = errors.New("unknown SMTP TLS mode")
+
+// email sends a plain-text report of the run over SMTP.
+type email struct {
+	host      string
+	port      int
+	tlsMode   string
+	username  string
+	password  string
+	from      string
+	to        []string
+	profile   string
+	onSuccess bool
+	logs      *logTail
+}
+
+func newEmail(opts *options, logs *logTail) *email {
+	return &email{
+		host:      opts.smtpHost,
+		port:      opts.smtpPort,
+		tlsMode:   opts.smtpTLS,
+		username:  opts.smtpUsername,
+		password:  opts.smtpPassword,
+		from:      opts.emailFrom,
+		to:        strings.Split(opts.emailTo, ","),
+		profile:   opts.profile,
+		onSuccess: opts.emailOnSuccess,
+		logs:      logs,
+	}
+}
+
+func (e *email) name() string {
+	return "email"
+}
+
+func (e *email) notify(ctx context.Context, data webhookData) error {
+	msg, ok := e.compose(data)
+	if !ok {
+		return nil
+	}
+
+	return e.send(ctx, msg)
+}
+
+func (e *email) render(data webhookData) ([]message, error) {
+	msg, ok := e.compose(data)
+	if !ok {
+		return nil, nil
+	}
+
+	return []message{{target: strings.Join(e.to, ", "), body: msg}}, nil
+}
+
+// compose returns the message to send, if any.
+func (e *email) compose(data webhookData) ([]byte, bool) {
+	failed := data.Status == statusError || data.Status == statusCancelled
+	if !failed && !e.onSuccess {
+		return nil, false
+	}
+
+	subject := fmt.Sprintf("[lcl-ynab %v] import %v", e.profile, data.Status)
+	if failed && data.Phase != "" {
+		subject = fmt.Sprintf("[lcl-ynab %v] import failed during %v", e.profile, data.Phase)
+	}
+
+	return e.message(subject, e.body(data), data.SentAt), true
+}
+
+func (e *email) body(data webhookData) string {
+	var body strings.Builder
+
+	fmt.Fprintf(&body, "Account: %v (%v)\nStatus: %v\n", data.AccountName, data.BudgetName, data.Status)
+
+	if data.Error != "" {
+		fmt.Fprintf(&body, "Phase: %v\nError: %v\n", data.Phase, data.Error)
+	}
+
+	fmt.Fprintf(&body, "Pushed: %d\nDuplicates: %d\n", data.Pushed, data.Duplicates)
+	fmt.Fprintf(&body, "Run ID: %v\n", data.RunID)
+
+	if data.Reconciled != "" {
+		fmt.Fprintf(&body, "Reconciled: %v€\n", data.Reconciled)
+	}
+
+	if lines := e.logs.Lines(); len(lines) > 0 {
+		body.WriteString("\nLast log lines:\n")
+
+		for _, line := range lines {
+			body.WriteString(line + "\n")
+		}
+	}
+
+	return body.String()
+}
+
+func (e *email) message(subject, body string, date time.Time) []byte {
+	var msg bytes.Buffer
+
+	for _, header := range [][2]string{
+		{"From", e.from},
+		{"To", strings.Join(e.to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "8bit"},
+	} {
+		fmt.Fprintf(&msg, "%v: %v\r\n", header[0], header[1])
+	}
+
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return msg.Bytes()
+}
+
+func (e *email) send(ctx context.Context, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(e.host, strconv.Itoa(e.port))
+	tlsConfig := &tls.Config{ServerName: e.host, MinVersion: tls.VersionTLS12}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if e.tlsMode == smtpTLSImplicit {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("connecting to SMTP server: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+
+		return fmt.Errorf("greeting SMTP server: %w", err)
+	}
+
+	defer client.Close()
+
+	if err := e.deliver(client, tlsConfig, msg); err != nil {
+		return fmt.Errorf("sending email: %w", err)
+	}
+
+	return nil
+}
+
+func (e *email) deliver(client *smtp.Client, tlsConfig *tls.Config, msg []byte) error {
+	if e.tlsMode == smtpTLSStart {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+
+	if e.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.username, e.password, e.host)); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	if err := client.Mail(e.from); err != nil {
+		return fmt.Errorf("setting sender: %w", err)
+	}
+
+	for _, to := range e.to {
+		if err := client.Rcpt(strings.TrimSpace(to)); err != nil {
+			return fmt.Errorf("adding recipient %v: %w", to, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("starting data: %w", err)
+	}
+
+	if _, err := writer.Write(msg); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("ending data: %w", err)
+	}
+
+	return client.Quit() //nolint:wrapcheck // wrapped by the caller
+}
+
+// logTail keeps the last lines written to it, to include them in failure emails.
+type logTail struct {
+	mu    sync.Mutex
+	max   int
+	lines []string
+}
+
+func newLogTail(maxLines int) *logTail {
+	return &logTail{max: maxLines}
+}
+
+func (l *logTail) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		l.lines = append(l.lines, line)
+	}
+
+	if len(l.lines) > l.max {
+		l.lines = l.lines[len(l.lines)-l.max:]
+	}
+
+	return len(p), nil
+}
+
+// Lines returns a copy of the kept lines.
+func (l *logTail) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.lines...)
+}
@@ -0,0 +1,161 @@
+package push
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// smtpSession is what the test SMTP server received.
+type smtpSession struct {
+	from string
+	to   []string
+	data string
+}
+
+// serveSMTP accepts one SMTP session on a local port and sends what it received on the returned channel.
+func serveSMTP(t *testing.T) (int, <-chan smtpSession) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		session := smtpSession{}
+
+		_ = text.PrintfLine("220 localhost ESMTP test")
+
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+
+			command, arg, _ := strings.Cut(line, " ")
+
+			switch strings.ToUpper(command) {
+			case "EHLO", "HELO":
+				_ = text.PrintfLine("250 localhost")
+			case "MAIL":
+				session.from = arg
+				_ = text.PrintfLine("250 OK")
+			case "RCPT":
+				session.to = append(session.to, arg)
+				_ = text.PrintfLine("250 OK")
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, _ := text.ReadDotBytes()
+				session.data = string(data)
+				_ = text.PrintfLine("250 OK")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				sessions <- session
+
+				return
+			default:
+				_ = text.PrintfLine("502 unknown command")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, sessions //nolint:forcetypeassert // always TCP
+}
+
+func Test_email_notify(t *testing.T) {
+	t.Parallel()
+
+	port, sessions := serveSMTP(t)
+
+	logs := newLogTail(2)
+	_, _ = logs.Write([]byte("level=DEBUG msg=\"converting file\"\n"))
+	_, _ = logs.Write([]byte("level=DEBUG msg=\"converted transactions\"\nlevel=DEBUG msg=\"pushing transactions\"\n"))
+
+	mail := &email{
+		host:    "127.0.0.1",
+		port:    port,
+		tlsMode: smtpTLSNone,
+		from:    "lcl-ynab@example.com",
+		to:      []string{"me@example.com", "other@example.com"},
+		profile: "joint",
+		logs:    logs,
+	}
+
+	err := mail.notify(context.Background(), webhookData{
+		Status:     statusError,
+		Phase:      "api call",
+		Error:      "pushing to YNAB: YNAB rate limit reached",
+		Reconciled: "100.06",
+		SentAt:     fixedNow(),
+	})
+	if err != nil {
+		t.Fatalf("notify() error = %v", err)
+	}
+
+	session := <-sessions
+
+	if session.from != "FROM:<lcl-ynab@example.com>" {
+		t.Errorf("from = %v", session.from)
+	}
+
+	if strings.Join(session.to, " ") != "TO:<me@example.com> TO:<other@example.com>" {
+		t.Errorf("to = %v", session.to)
+	}
+
+	for _, want := range []string{
+		"Subject: [lcl-ynab joint] import failed during api call\n",
+		"Date: Sat, 30 Nov 2024 03:00:00 +0000\n",
+		"Error: pushing to YNAB: YNAB rate limit reached\n",
+		"Reconciled: 100.06€\n",
+		"Last log lines:\nlevel=DEBUG msg=\"converted transactions\"\nlevel=DEBUG msg=\"pushing transactions\"\n",
+	} {
+		if !strings.Contains(session.data, want) {
+			t.Errorf("message = \n%v\nwant it to contain %q", session.data, want)
+		}
+	}
+}
+
+func Test_email_notify_successSkipped(t *testing.T) {
+	t.Parallel()
+
+	mail := &email{host: "127.0.0.1", port: 1, tlsMode: smtpTLSNone, logs: newLogTail(1)}
+
+	// Nothing listens on port 1: any connection attempt fails.
+	if err := mail.notify(context.Background(), webhookData{Status: statusOK}); err != nil {
+		t.Errorf("notify() error = %v, want no email sent", err)
+	}
+
+	mail.onSuccess = true
+	if err := mail.notify(context.Background(), webhookData{Status: statusOK}); err == nil {
+		t.Errorf("notify() succeeded, want a connection error")
+	}
+}
+
+func Test_logTail(t *testing.T) {
+	t.Parallel()
+
+	logs := newLogTail(3)
+	for i := range 5 {
+		_, _ = logs.Write([]byte("line " + strconv.Itoa(i) + "\n"))
+	}
+
+	if got := strings.Join(logs.Lines(), ","); got != "line 2,line 3,line 4" {
+		t.Errorf("Lines() = %v", got)
+	}
+}
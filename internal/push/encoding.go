@@ -0,0 +1,64 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// encodingAuto leaves the charset to the importers, which only look for a byte order mark.
+const encodingAuto = "auto"
+
+var errUnknownEncoding = errors.New("unknown encoding")
+
+// encodings are the charsets -encoding may force, by name.
+//
+//nolint:gochecknoglobals // constant table
+var encodings = map[string]encoding.Encoding{
+	"utf-8":        unicode.UTF8,
+	"latin-1":      charmap.ISO8859_1,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+}
+
+// checkEncoding validates the -encoding name, case insensitive.
+func checkEncoding(name string) error {
+	name = strings.ToLower(name)
+	if _, ok := encodings[name]; ok || name == encodingAuto {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q, want one of %v", errUnknownEncoding, name, strings.Join(encodingNames(), ", "))
+}
+
+// encodingNames returns auto and the names of every supported encoding, sorted.
+func encodingNames() []string {
+	names := []string{encodingAuto}
+	for name := range encodings {
+		names = append(names, name)
+	}
+
+	slices.Sort(names[1:])
+
+	return names
+}
+
+// decode returns reader converted to UTF-8 from the named encoding,
+// or reader as is for auto. The name must have been checked.
+func decode(reader io.Reader, name string) io.Reader {
+	enc, ok := encodings[strings.ToLower(name)]
+	if !ok {
+		return reader
+	}
+
+	return transform.NewReader(reader, enc.NewDecoder())
+}
@@ -0,0 +1,103 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarcoal/httpmock"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// latin1Export has a payee with an é (0xe9), which isn't valid UTF-8.
+const latin1Export = "29/10/2024;-4,50;Carte;;CB  CAF\xe9 DU COIN   28/10/24;;0;Divers\n" +
+	"29/11/2024;100,06;;01234 123456A"
+
+func Test_decode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		encoding string
+		want     string
+	}{
+		{encoding: "latin-1", want: "CB  CAF\u00e9 DU COIN"},
+		{encoding: "ISO-8859-1", want: "CB  CAF\u00e9 DU COIN"},
+		{encoding: "windows-1252", want: "CB  CAF\u00e9 DU COIN"},
+		// Forced UTF-8 replaces the invalid byte rather than guessing.
+		{encoding: "utf-8", want: "CB  CAF\ufffd DU COIN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			t.Parallel()
+
+			reader := decode(strings.NewReader(latin1Export), tt.encoding)
+
+			transactions, _, err := convert(context.Background(), reader, "acc-id", importerOptions{})
+			if err != nil {
+				t.Fatalf("convert() error = %v", err)
+			}
+
+			if got := transactions[0].PayeeName; got != tt.want {
+				t.Errorf("payee = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkEncoding(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"auto", "utf-8", "Latin-1", "iso-8859-1", "windows-1252", "utf-16le", "UTF-16BE"} {
+		if err := checkEncoding(name); err != nil {
+			t.Errorf("checkEncoding(%q) error = %v", name, err)
+		}
+	}
+
+	err := checkEncoding("ebcdic")
+	if !errors.Is(err, errUnknownEncoding) {
+		t.Fatalf("checkEncoding() error = %v, want %v", err, errUnknownEncoding)
+	}
+
+	want := "auto, iso-8859-1, latin-1, utf-16be, utf-16le, utf-8, windows-1252"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("checkEncoding() error = %v, want it to list %v", err, want)
+	}
+}
+
+func Test_run_encodingUTF16(t *testing.T) {
+	t.Parallel()
+
+	utf16, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String(
+		"29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;\n29/11/2024;100,06;;01234 123456A\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := &bytes.Buffer{}
+
+	// The format is detected from the decoded content, no -format needed.
+	err = run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "statement.csv", "-dry-run", "-encoding", "utf-16le",
+	}, env{
+		stdout:     stdout,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: httpmock.NewMockTransport()},
+		now:        fixedNow,
+		fsys:       fstest.MapFS{"statement.csv": {Data: []byte(utf16)}},
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if want := "reconciled: 100.06€ as of 2024-11-29\ndry run: would push 1 transaction(s)"; !strings.Contains(
+		stdout.String(), want) {
+		t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+	}
+}
@@ -0,0 +1,54 @@
+package push
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// stdinName is the file name reading the input from stdin.
+const stdinName = "-"
+
+var (
+	errNoStdin    = errors.New("stdin isn't available")
+	errEmptyStdin = errors.New("stdin is empty, pipe an export or give its path with -f")
+)
+
+// osFS opens files by their OS path, relative ones from the working directory.
+// Unlike os.DirFS, it takes the paths users give as they are, absolute or with "..".
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name) //nolint:wrapcheck // *PathError already holds the path
+}
+
+// openInput opens the file to convert, stdin for "-". Closing stdin is left to the OS.
+func openInput(env env, name string) (io.ReadCloser, error) {
+	if name != stdinName {
+		return env.fsys.Open(name) //nolint:wrapcheck // wrapped by the caller
+	}
+
+	if env.stdin == nil {
+		return nil, errNoStdin
+	}
+
+	reader := bufio.NewReader(env.stdin)
+	if _, err := reader.Peek(1); errors.Is(err, io.EOF) {
+		return nil, errEmptyStdin
+	} else if err != nil {
+		return nil, fmt.Errorf("reading stdin: %w", err)
+	}
+
+	return io.NopCloser(reader), nil
+}
+
+// stdinPiped reports whether stdin is a pipe or a file rather than a terminal,
+// push then reading it when no file is given.
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
@@ -0,0 +1,217 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarcoal/httpmock"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const onePositive = "\ufeff29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;\n29/11/2024;100,06;;01234 123456A\n"
+
+// deniedFS fails to open the files it lists as if their permissions forbade it.
+type deniedFS struct {
+	fs.FS
+
+	denied map[string]bool
+}
+
+func (d deniedFS) Open(name string) (fs.File, error) {
+	if d.denied[name] {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+
+	return d.FS.Open(name) //nolint:wrapcheck // already explicit
+}
+
+//nolint:funlen // mostly test cases in list
+func Test_run_files(t *testing.T) {
+	t.Parallel()
+
+	files := fstest.MapFS{
+		"statement.csv":  {Data: []byte(onePositive)},
+		"webhook.tmpl":   {Data: []byte(`{"pushed":{{.Pushed}}}`)},
+		"broken.tmpl":    {Data: []byte(`{{.Pushed`)},
+		"secrets/ca.pem": {Data: []byte("not a certificate")},
+	}
+
+	tests := []struct {
+		name       string
+		fsys       fs.FS
+		args       []string
+		wantStdout string
+		wantErr    error
+		wantMsg    string
+	}{
+		{
+			name:       "input",
+			fsys:       files,
+			args:       []string{"-f", "statement.csv"},
+			wantStdout: "dry run: would push 1 transaction(s)",
+		},
+		{
+			name:    "missing input",
+			fsys:    files,
+			args:    []string{"-f", "missing.csv"},
+			wantErr: fs.ErrNotExist,
+			wantMsg: "opening file: open missing.csv: file does not exist",
+		},
+		{
+			name:    "input permission denied",
+			fsys:    deniedFS{FS: files, denied: map[string]bool{"statement.csv": true}},
+			args:    []string{"-f", "statement.csv"},
+			wantErr: fs.ErrPermission,
+			wantMsg: "opening file: open statement.csv: permission denied",
+		},
+		{
+			name:       "webhook template",
+			fsys:       files,
+			args:       []string{"-f", "statement.csv", "-w", "https://hooks.example/ynab", "-webhook-template", "webhook.tmpl"},
+			wantStdout: `{"pushed":1}`,
+		},
+		{
+			name:    "broken webhook template",
+			fsys:    files,
+			args:    []string{"-f", "statement.csv", "-w", "https://hooks.example/ynab", "-webhook-template", "broken.tmpl"},
+			wantMsg: "parsing webhook template",
+		},
+		{
+			name:    "invalid CA",
+			fsys:    files,
+			args:    []string{"-f", "statement.csv", "-ca-cert", "secrets/ca.pem"},
+			wantErr: errInvalidCA,
+			wantMsg: "secrets/ca.pem",
+		},
+		{
+			name:    "CA permission denied",
+			fsys:    deniedFS{FS: files, denied: map[string]bool{"secrets/ca.pem": true}},
+			args:    []string{"-f", "statement.csv", "-ca-cert", "secrets/ca.pem"},
+			wantErr: fs.ErrPermission,
+			wantMsg: "open secrets/ca.pem: permission denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := &bytes.Buffer{}
+
+			err := run(context.Background(), append([]string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-dry-run",
+			}, tt.args...), env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: httpmock.NewMockTransport()},
+				now:        fixedNow,
+				fsys:       tt.fsys,
+				getenv:     testGetenv(t),
+			})
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantMsg == "" && err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			if tt.wantMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.wantMsg)) {
+				t.Errorf("run() error = %v, want it to mention %q", err, tt.wantMsg)
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func Test_run_stdin(t *testing.T) {
+	t.Parallel()
+
+	// The encoder writes its own BOM.
+	utf16, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String(
+		strings.TrimPrefix(onePositive, "\ufeff"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		stdin      io.Reader
+		piped      bool
+		args       []string
+		wantStdout string
+		wantErr    error
+	}{
+		{
+			name:       "dash with BOM",
+			stdin:      strings.NewReader(onePositive),
+			args:       []string{"-f", "-"},
+			wantStdout: "reconciled: 100.06€ as of 2024-11-29\ndry run: would push 1 transaction(s)",
+		},
+		{
+			name:       "dash with encoding",
+			stdin:      strings.NewReader(utf16),
+			args:       []string{"-", "-encoding", "utf-16le"},
+			wantStdout: "reconciled: 100.06€ as of 2024-11-29\ndry run: would push 1 transaction(s)",
+		},
+		{
+			name:       "piped without file",
+			stdin:      strings.NewReader(onePositive),
+			piped:      true,
+			wantStdout: "dry run: would push 1 transaction(s)",
+		},
+		{
+			name:    "empty",
+			stdin:   strings.NewReader(""),
+			piped:   true,
+			wantErr: errEmptyStdin,
+		},
+		{
+			name:    "none",
+			args:    []string{"-f", "-"},
+			wantErr: errNoStdin,
+		},
+		{
+			name:    "not piped without file",
+			stdin:   strings.NewReader(onePositive),
+			wantErr: errRequiredFlag,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := &bytes.Buffer{}
+
+			err := run(context.Background(), append([]string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-dry-run",
+			}, tt.args...), env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: httpmock.NewMockTransport()},
+				now:        fixedNow,
+				stdin:      tt.stdin,
+				stdinPiped: tt.piped,
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
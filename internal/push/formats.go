@@ -0,0 +1,191 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// Names of the supported formats, as given to -format.
+const (
+	formatLCL     = "lcl"
+	formatRevolut = "revolut"
+	formatOFX     = "ofx"
+	formatQIF     = "qif"
+)
+
+// sniffLen is the number of bytes read from the start of the input to detect its format.
+const sniffLen = 512
+
+var (
+	errDuplicateFormat  = errors.New("format already registered")
+	errUndetectedFormat = errors.New("could not detect format")
+)
+
+// importer converts a bank export into YNAB transactions and the reconciled balance.
+// Importers stop between two lines once ctx is done.
+type importer interface {
+	convert(ctx context.Context, reader io.Reader, accountID string) ([]Transaction, balance, error)
+}
+
+type importerFunc func(ctx context.Context, reader io.Reader, accountID string) ([]Transaction, balance, error)
+
+func (f importerFunc) convert(ctx context.Context, reader io.Reader, accountID string) ([]Transaction, balance, error) {
+	return f(ctx, reader, accountID)
+}
+
+// importerOptions holds the flags an importer may care about.
+type importerOptions struct {
+	includePending bool
+	currencyFilter string
+	warnings       io.Writer
+	// truncated, when set, gets a warning for each payee and memo cut to the length
+	// YNAB accepts: stdout with -v.
+	truncated io.Writer
+	// skipped, when set, is called for each row left out by a filter.
+	skipped func(skippedRow)
+	// progress, when set, is called after each line read.
+	progress func(lclynab.Progress)
+	// stripHolder, when set, removes the account holders ending payees.
+	stripHolder func(payee string) string
+	// hashIDs derives the import IDs from a hash of the lines, see -import-id-strategy.
+	hashIDs bool
+	// foldRates adds the exchange rate lines of LCL exports to the memo of their payment.
+	foldRates bool
+}
+
+// progressPrinter returns a progress callback printing a line to w every
+// `every` lines, or nil when every is 0.
+func progressPrinter(w io.Writer, every int) func(lclynab.Progress) {
+	if every <= 0 {
+		return nil
+	}
+
+	return func(p lclynab.Progress) {
+		if p.Lines%every == 0 {
+			_, _ = fmt.Fprintf(w, "converting: %d line(s) read, %d transaction(s)\n", p.Lines, p.Transactions)
+		}
+	}
+}
+
+// balance is the reconciled balance of a statement.
+type balance struct {
+	milliunits int
+	// date is the day the balance applies to, zero when unknown.
+	date lclynab.Date
+	// accountRef is the account reference the file gives, empty when it has none.
+	accountRef string
+}
+
+type format struct {
+	name        string
+	extensions  []string
+	sniff       func(head []byte) bool
+	newImporter func(opts importerOptions) importer
+	// currency is the ISO 4217 code of the amounts, empty when it follows -currency-filter.
+	currency string
+}
+
+type registry struct {
+	formats  []format
+	fallback string
+}
+
+// defaultFormats returns the registry of every supported format.
+// Register new adapters here.
+func defaultFormats() *registry {
+	formats := &registry{fallback: formatLCL}
+	formats.mustRegister(lclFormat())
+	formats.mustRegister(revolutFormat())
+	formats.mustRegister(ofxFormat())
+	formats.mustRegister(qifFormat())
+
+	return formats
+}
+
+func (r *registry) register(f format) error {
+	if _, ok := r.find(f.name); ok {
+		return fmt.Errorf("%w: %q", errDuplicateFormat, f.name)
+	}
+
+	r.formats = append(r.formats, f)
+
+	return nil
+}
+
+func (r *registry) mustRegister(f format) {
+	if err := r.register(f); err != nil {
+		panic(err)
+	}
+}
+
+func (r *registry) lookup(name string) (format, error) {
+	f, ok := r.find(name)
+	if !ok {
+		return format{}, fmt.Errorf("%w: %q, supported formats: %v", errUnknownFormat, name, strings.Join(r.names(), ", "))
+	}
+
+	return f, nil
+}
+
+// detect guesses the format of a file from its extension and first bytes.
+// Content sniffing wins over extensions, and the fallback format is used when neither is conclusive.
+func (r *registry) detect(filename string, head []byte) (format, error) {
+	var byExtension, bySniff []format
+
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	for _, f := range r.formats {
+		if slices.Contains(f.extensions, ext) {
+			byExtension = append(byExtension, f)
+		}
+
+		if f.sniff != nil && f.sniff(head) {
+			bySniff = append(bySniff, f)
+		}
+	}
+
+	switch {
+	case len(bySniff) == 1:
+		return bySniff[0], nil
+	case len(bySniff) == 0 && len(byExtension) == 1:
+		return byExtension[0], nil
+	case r.fallback != "":
+		return r.lookup(r.fallback)
+	default:
+		return format{}, fmt.Errorf("%w for %v, supported formats: %v",
+			errUndetectedFormat, filename, strings.Join(r.names(), ", "))
+	}
+}
+
+func (r *registry) names() []string {
+	names := make([]string, 0, len(r.formats))
+	for _, f := range r.formats {
+		names = append(names, f.name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+func (r *registry) find(name string) (format, bool) {
+	for _, f := range r.formats {
+		if f.name == name {
+			return f, true
+		}
+	}
+
+	return format{}, false
+}
+
+func trimBOM(head []byte) []byte {
+	return bytes.TrimPrefix(head, []byte("\ufeff"))
+}
@@ -0,0 +1,241 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_registry_register(t *testing.T) {
+	t.Parallel()
+
+	formats := &registry{}
+
+	if err := formats.register(format{name: "one"}); err != nil {
+		t.Fatalf("register() error = %v", err)
+	}
+
+	if err := formats.register(format{name: "two"}); err != nil {
+		t.Fatalf("register() error = %v", err)
+	}
+
+	if err := formats.register(format{name: "one"}); !errors.Is(err, errDuplicateFormat) {
+		t.Errorf("register() error = %v, want %v", err, errDuplicateFormat)
+	}
+
+	if got, want := strings.Join(formats.names(), ","), "one,two"; got != want {
+		t.Errorf("names() = %v, want %v", got, want)
+	}
+}
+
+func Test_registry_lookup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		format  string
+		wantErr error
+	}{
+		{name: "lcl", format: "lcl", wantErr: nil},
+		{name: "revolut", format: "revolut", wantErr: nil},
+		{name: "ofx", format: "ofx", wantErr: nil},
+		{name: "qif", format: "qif", wantErr: nil},
+		{name: "unknown", format: "qfx", wantErr: errUnknownFormat},
+		{name: "case sensitive", format: "LCL", wantErr: errUnknownFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := defaultFormats().lookup(tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("lookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				if !strings.Contains(err.Error(), "lcl, ofx, qif, revolut") {
+					t.Errorf("lookup() error = %v, want supported formats listed", err)
+				}
+
+				return
+			}
+
+			if got.name != tt.format {
+				t.Errorf("lookup() got = %v, want %v", got.name, tt.format)
+			}
+		})
+	}
+}
+
+func Test_registry_detect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filename string
+		head     string
+		want     string
+	}{
+		{
+			name:     "lcl",
+			filename: "out.csv",
+			head:     "29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;\n",
+			want:     "lcl",
+		},
+		{
+			name:     "lcl with BOM",
+			filename: "out.csv",
+			head:     "\ufeff29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;\n",
+			want:     "lcl",
+		},
+		{
+			name:     "revolut",
+			filename: "account-statement.csv",
+			head:     "Type,Product,Started Date,Completed Date,Description,Amount,Fee,Currency,State,Balance\n",
+			want:     "revolut",
+		},
+		{
+			name:     "ofx",
+			filename: "export.ofx",
+			head:     "OFXHEADER:100\nDATA:OFXSGML\nVERSION:102\n",
+			want:     "ofx",
+		},
+		{
+			name:     "qif",
+			filename: "export.qif",
+			head:     "!Type:Bank\nD29/10/2024\n",
+			want:     "qif",
+		},
+		{
+			name:     "empty file falls back",
+			filename: "out.csv",
+			head:     "",
+			want:     "lcl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := defaultFormats().detect(tt.filename, []byte(tt.head))
+			if err != nil {
+				t.Fatalf("detect() error = %v", err)
+			}
+
+			if got.name != tt.want {
+				t.Errorf("detect() got = %v, want %v", got.name, tt.want)
+			}
+		})
+	}
+}
+
+func Test_registry_detect_byExtension(t *testing.T) {
+	t.Parallel()
+
+	formats := &registry{}
+	formats.mustRegister(format{name: "qif", extensions: []string{".qif"}})
+	formats.mustRegister(format{name: "ofx", extensions: []string{".ofx"}})
+
+	got, err := formats.detect("archive.QIF", nil)
+	if err != nil {
+		t.Fatalf("detect() error = %v", err)
+	}
+
+	if got.name != "qif" {
+		t.Errorf("detect() got = %v, want qif", got.name)
+	}
+
+	if _, err := formats.detect("archive.txt", nil); !errors.Is(err, errUndetectedFormat) {
+		t.Errorf("detect() error = %v, want %v", err, errUndetectedFormat)
+	}
+}
+
+func Test_progressPrinter(t *testing.T) {
+	t.Parallel()
+
+	if progressPrinter(io.Discard, 0) != nil {
+		t.Error("progressPrinter(0) != nil, want no callback")
+	}
+
+	var out bytes.Buffer
+
+	progress := progressPrinter(&out, 2)
+	for lines := 1; lines <= 5; lines++ {
+		progress(lclynab.Progress{Lines: lines, Transactions: lines - 1})
+	}
+
+	want := "converting: 2 line(s) read, 1 transaction(s)\nconverting: 4 line(s) read, 3 transaction(s)\n"
+	if out.String() != want {
+		t.Errorf("progress output = %q, want %q", out.String(), want)
+	}
+}
+
+func Test_run_progress(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	stderr := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-dry-run", "-progress", "1",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     stderr,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	want := "converting: 1 line(s) read, 1 transaction(s)\nconverting: 2 line(s) read, 1 transaction(s)\n"
+	if !strings.Contains(stderr.String(), want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr.String(), want)
+	}
+}
+
+func Test_importers_cancelled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format string
+		file   string
+	}{
+		{format: formatLCL, file: "./testdata/one-positive.csv"},
+		{format: formatRevolut, file: "./testdata/revolut.csv"},
+		{format: formatOFX, file: "./testdata/lcl.ofx"},
+		{format: formatQIF, file: "./testdata/lcl.qif"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := os.Open(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Cleanup(func() { _ = file.Close() })
+
+			f, _ := defaultFormats().find(tt.format)
+			imp := f.newImporter(importerOptions{currencyFilter: "EUR", warnings: io.Discard})
+
+			if _, _, err := imp.convert(cancelledContext(), file, "acc-id"); !errors.Is(err, context.Canceled) {
+				t.Errorf("convert() error = %v, want context.Canceled", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// Gotify priorities: low enough not to ring on success, high enough to on failure.
+const (
+	gotifyPriorityOK     = 2
+	gotifyPriorityFailed = 8
+)
+
+var errGotifyAuth = errors.New("Gotify rejected the application token")
+
+// gotify posts the run result to a Gotify server, once: a failed delivery is
+// reported as a notification failure rather than retried.
+type gotify struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+type gotifyMessage struct {
+	Title    string         `json:"title"`
+	Message  string         `json:"message"`
+	Priority int            `json:"priority"`
+	Extras   map[string]any `json:"extras"`
+}
+
+func newGotify(opts *options, client *http.Client) *gotify {
+	return &gotify{
+		client: client,
+		url:    strings.TrimSuffix(opts.gotifyURL, "/"),
+		token:  opts.gotifyToken,
+	}
+}
+
+func (g *gotify) name() string {
+	return "gotify"
+}
+
+func (g *gotify) notify(ctx context.Context, data webhookData) error {
+	messages, err := g.render(data)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	err = requests.URL(g.url).
+		Client(g.client).
+		Path("message").
+		Header("X-Gotify-Key", g.token).
+		BodyBytes(messages[0].body).
+		ContentType("application/json").
+		Fetch(ctx)
+	if err != nil {
+		if requests.HasStatusErr(err, http.StatusUnauthorized, http.StatusForbidden) {
+			return fmt.Errorf("%w: %w", errGotifyAuth, err)
+		}
+
+		return fmt.Errorf("posting to Gotify: %w", err)
+	}
+
+	return nil
+}
+
+func (g *gotify) render(data webhookData) ([]message, error) {
+	body, err := json.Marshal(gotifyPayload(data))
+	if err != nil {
+		return nil, fmt.Errorf("encoding Gotify message: %w", err)
+	}
+
+	return []message{{target: g.url + "/message", body: body}}, nil
+}
+
+// gotifyPayload renders the run result as a Markdown message.
+func gotifyPayload(data webhookData) gotifyMessage {
+	failed := data.Status == statusError || data.Status == statusCancelled
+
+	message := gotifyMessage{
+		Title:    fmt.Sprintf("YNAB import %v: %v", data.Status, data.AccountName),
+		Priority: gotifyPriorityOK,
+		Extras: map[string]any{
+			"client::display": map[string]string{"contentType": "text/markdown"},
+		},
+	}
+
+	var body strings.Builder
+
+	if failed {
+		message.Title = "YNAB import failed: " + data.AccountName
+		message.Priority = gotifyPriorityFailed
+
+		if data.Error != "" {
+			fmt.Fprintf(&body, "**Failed during %v**: %v\n\n", data.Phase, data.Error)
+		}
+	}
+
+	if data.Reconciled != "" {
+		fmt.Fprintf(&body, "- Reconciled: **%v€**\n", data.Reconciled)
+	}
+
+	fmt.Fprintf(&body, "- Pushed: %d\n- Duplicates: %d\n- Skipped: %d", data.Pushed, data.Duplicates, data.Skipped)
+
+	message.Message = body.String()
+
+	return message
+}
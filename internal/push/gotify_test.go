@@ -0,0 +1,126 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_gotify(t *testing.T) {
+	t.Parallel()
+
+	const server = "https://gotify.example/message"
+
+	tests := []struct {
+		name         string
+		pushStatus   int
+		gotifyStatus int
+		wantErr      error
+		wantTitle    string
+		wantPriority int
+		wantMessage  string
+	}{
+		{
+			name:         "success",
+			pushStatus:   http.StatusOK,
+			gotifyStatus: http.StatusOK,
+			wantErr:      nil,
+			wantTitle:    "YNAB import ok: " + testAccountID,
+			wantPriority: gotifyPriorityOK,
+			wantMessage:  "- Reconciled: **100.06\u20ac**\n- Pushed: 1\n- Duplicates: 0\n- Skipped: 0",
+		},
+		{
+			name:         "failure",
+			pushStatus:   http.StatusUnauthorized,
+			gotifyStatus: http.StatusOK,
+			wantErr:      lclynab.ErrUnauthorized,
+			wantTitle:    "YNAB import failed: " + testAccountID,
+			wantPriority: gotifyPriorityFailed,
+			wantMessage:  "**Failed during api call**: pushing to YNAB: YNAB authentication failed",
+		},
+		{
+			name:         "rejected token",
+			pushStatus:   http.StatusOK,
+			gotifyStatus: http.StatusUnauthorized,
+			wantErr:      errNotificationFailed,
+			wantTitle:    "YNAB import ok: " + testAccountID,
+			wantPriority: gotifyPriorityOK,
+			wantMessage:  "- Reconciled: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				calls    int
+				received http.Header
+				message  gotifyMessage
+			)
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(
+				http.MethodPost,
+				"/v1/budgets/"+testBudgetID+"/transactions",
+				createdResponder(tt.pushStatus),
+			)
+			transport.RegisterResponder(http.MethodPost, server, func(req *http.Request) (*http.Response, error) {
+				calls++
+				received = req.Header.Clone()
+				_ = json.NewDecoder(req.Body).Decode(&message)
+
+				resp := httpmock.NewStringResponse(tt.gotifyStatus, "{}")
+				resp.Request = req
+
+				return resp, nil
+			})
+
+			stderr := &bytes.Buffer{}
+
+			err := run(context.Background(), []string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+				"-gotify-url", "https://gotify.example/", "-gotify-token", "app-token",
+			}, env{
+				stdout:     io.Discard,
+				stderr:     stderr,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// Delivery is attempted once, whatever the outcome.
+			if calls != 1 {
+				t.Fatalf("Gotify calls = %v, want 1", calls)
+			}
+
+			if got := received.Get("X-Gotify-Key"); got != "app-token" {
+				t.Errorf("X-Gotify-Key = %q, want the application token", got)
+			}
+
+			if message.Title != tt.wantTitle || message.Priority != tt.wantPriority {
+				t.Errorf("title, priority = %q, %v, want %q, %v",
+					message.Title, message.Priority, tt.wantTitle, tt.wantPriority)
+			}
+
+			if !strings.HasPrefix(message.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want prefix %q", message.Message, tt.wantMessage)
+			}
+
+			if tt.gotifyStatus != http.StatusOK && strings.Count(stderr.String(), "notifying gotify failed") != 1 {
+				t.Errorf("stderr = %v, want the rejected token reported once", stderr)
+			}
+		})
+	}
+}
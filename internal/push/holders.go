@@ -0,0 +1,71 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// commonHolders is the -strip-holder value standing for commonHolderPatterns.
+const commonHolders = "common"
+
+// commonHolderPatterns match the holders LCL writes at the end of labels, like
+// "M OU MME MARTIN" for joint accounts or "MLLE MARTIN".
+//
+//nolint:gochecknoglobals // constant list
+var commonHolderPatterns = []string{
+	`(?:M|MR|MME|MLLE)\.? (?:OU|ET) (?:M|MR|MME|MLLE)\.? [A-Z][A-Z' -]*`,
+	`MLLE [A-Z][A-Z' -]*`,
+}
+
+var errInvalidHolder = errors.New("invalid -strip-holder pattern")
+
+// holderFlags collects repeated -strip-holder flags.
+type holderFlags []string
+
+func (h *holderFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *holderFlags) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+// newHolderStripper returns a function removing the first of the patterns found at
+// the end of a payee, nil without patterns. The patterns are regular expressions,
+// "common" standing for the common holder forms.
+func newHolderStripper(patterns []string) (func(payee string) string, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	var suffixes []*regexp.Regexp
+
+	for _, pattern := range patterns {
+		expanded := []string{pattern}
+		if pattern == commonHolders {
+			expanded = commonHolderPatterns
+		}
+
+		for _, expr := range expanded {
+			suffix, err := regexp.Compile(`\s*(?:` + expr + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("%w %q: %w", errInvalidHolder, pattern, err)
+			}
+
+			suffixes = append(suffixes, suffix)
+		}
+	}
+
+	return func(payee string) string {
+		for _, suffix := range suffixes {
+			if loc := suffix.FindStringIndex(payee); loc != nil {
+				return payee[:loc[0]]
+			}
+		}
+
+		return payee
+	}, nil
+}
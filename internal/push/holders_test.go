@@ -0,0 +1,83 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_newHolderStripper(t *testing.T) {
+	t.Parallel()
+
+	strip, err := newHolderStripper([]string{commonHolders, `MME DUPONT \d{2}/\d{2}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		payee string
+		want  string
+	}{
+		{payee: "PRLV SEPA EDF M OU MME MARTIN", want: "PRLV SEPA EDF"},
+		{payee: "VIREMENT MR. ET MME LE GALL", want: "VIREMENT"},
+		{payee: "CB BOULANGERIE MLLE MARTIN", want: "CB BOULANGERIE"},
+		{payee: "VIR SALAIRE MME DUPONT 01/02", want: "VIR SALAIRE"},
+		// The common forms don't take digits, they aren't names.
+		{payee: "VIR M OU MME MARTIN 01/02", want: "VIR M OU MME MARTIN 01/02"},
+		{payee: "CB  MERCH", want: "CB  MERCH"},
+		// Left empty, the payee is kept whole by the parser.
+		{payee: "M OU MME MARTIN", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := strip(tt.payee); got != tt.want {
+			t.Errorf("strip(%q) = %q, want %q", tt.payee, got, tt.want)
+		}
+	}
+}
+
+func Test_newHolderStripper_errors(t *testing.T) {
+	t.Parallel()
+
+	strip, err := newHolderStripper(nil)
+	if strip != nil || err != nil {
+		t.Errorf("newHolderStripper(nil) = %p, %v, want nil, nil", strip, err)
+	}
+
+	if _, err := newHolderStripper([]string{"MME (DUPONT"}); !errors.Is(err, errInvalidHolder) {
+		t.Errorf("newHolderStripper() error = %v, want %v", err, errInvalidHolder)
+	}
+}
+
+func Test_convert_stripHolder(t *testing.T) {
+	t.Parallel()
+
+	strip, err := newHolderStripper([]string{commonHolders})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "29/10/2024;-21,32;Carte;;CB BOULANGERIE M OU MME MARTIN 28/10/24;;0;Divers\n" +
+		"29/10/2024;80;Virement;;;M OU MME MARTIN;;\n" +
+		"29/11/2024;100,06;;01234 123456A\n"
+
+	transactions, _, err := convert(context.Background(), strings.NewReader(input), "acc-id",
+		importerOptions{stripHolder: strip})
+	if err != nil {
+		t.Fatalf("convert() error = %v", err)
+	}
+
+	payment := transactions[0]
+	if payment.PayeeName != "CB BOULANGERIE" || payment.Date != mustDate("2024-10-28") {
+		t.Errorf("convert() payment = %q on %v, want CB BOULANGERIE on 2024-10-28", payment.PayeeName, payment.Date)
+	}
+
+	if want := "CB BOULANGERIE M OU MME MARTIN 28/10/24"; payment.Memo != want {
+		t.Errorf("convert() memo = %q, want %q", payment.Memo, want)
+	}
+
+	if transfer := transactions[1]; transfer.PayeeName != "M OU MME MARTIN" {
+		t.Errorf("convert() transfer payee = %q, want it kept whole", transfer.PayeeName)
+	}
+}
@@ -0,0 +1,135 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+const defaultHAEntityPrefix = "lcl_ynab"
+
+// homeAssistant updates sensors through the Home Assistant REST API.
+type homeAssistant struct {
+	client *http.Client
+	url    string
+	token  string
+	prefix string
+}
+
+type haState struct {
+	State      string         `json:"state"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+func newHomeAssistant(opts *options, client *http.Client) *homeAssistant {
+	return &homeAssistant{
+		client: client,
+		url:    strings.TrimSuffix(opts.haURL, "/"),
+		token:  opts.haToken,
+		prefix: opts.haEntityPrefix,
+	}
+}
+
+func (ha *homeAssistant) name() string {
+	return "home assistant"
+}
+
+// haUpdate is the state of one sensor.
+type haUpdate struct {
+	entity string
+	state  haState
+}
+
+// notify sets the reconciled sensor, when the balance is known, and the status sensor.
+func (ha *homeAssistant) notify(ctx context.Context, data webhookData) error {
+	for _, update := range ha.updates(data) {
+		if err := ha.setState(ctx, update.entity, update.state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ha *homeAssistant) render(data webhookData) ([]message, error) {
+	updates := ha.updates(data)
+	messages := make([]message, 0, len(updates))
+
+	for _, update := range updates {
+		body, err := json.Marshal(update.state)
+		if err != nil {
+			return nil, fmt.Errorf("encoding %v: %w", update.entity, err)
+		}
+
+		messages = append(messages, message{target: update.entity, body: body})
+	}
+
+	return messages, nil
+}
+
+func (ha *homeAssistant) updates(data webhookData) []haUpdate {
+	lastRun := data.SentAt.Format(time.RFC3339)
+
+	var updates []haUpdate
+
+	if data.Reconciled != "" {
+		updates = append(updates, haUpdate{
+			entity: ha.entity("reconciled"),
+			state: haState{
+				State: data.Reconciled,
+				Attributes: map[string]any{
+					"unit_of_measurement":   "€",
+					"device_class":          "monetary",
+					"reconciled_milliunits": data.ReconciledMilliunits,
+					"currency":              data.Currency,
+					"pushed":                data.Pushed,
+					"duplicates":            data.Duplicates,
+					"drift_milliunits":      data.Drift,
+					"drift_exceeded":        data.DriftExceeded,
+					"last_run":              lastRun,
+				},
+			},
+		})
+	}
+
+	return append(updates, haUpdate{
+		entity: ha.entity("status"),
+		state: haState{
+			State: data.Status,
+			Attributes: map[string]any{
+				"error":        data.Error,
+				"phase":        data.Phase,
+				"budget_name":  data.BudgetName,
+				"account_name": data.AccountName,
+				"run_id":       data.RunID,
+				"last_run":     lastRun,
+			},
+		},
+	})
+}
+
+func (ha *homeAssistant) entity(name string) string {
+	return fmt.Sprintf("sensor.%v_%v", ha.prefix, name)
+}
+
+func (ha *homeAssistant) setState(ctx context.Context, entity string, state haState) error {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	err := requests.URL(ha.url + "/api/states/" + entity).
+		Client(ha.client).
+		Method(http.MethodPost).
+		Bearer(ha.token).
+		BodyJSON(state).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("updating %v: %w", entity, err)
+	}
+
+	return nil
+}
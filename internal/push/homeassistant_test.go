@@ -0,0 +1,123 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_homeAssistant(t *testing.T) {
+	t.Parallel()
+
+	const (
+		reconciledURL = "https://ha.example/api/states/sensor.bank_reconciled"
+		statusURL     = "https://ha.example/api/states/sensor.bank_status"
+	)
+
+	states := map[string]haState{}
+	capture := func(req *http.Request) (*http.Response, error) {
+		if got := req.Header.Get("Authorization"); got != "Bearer ha-token" {
+			t.Errorf("Authorization = %q, want the access token", got)
+		}
+
+		var state haState
+		if err := json.NewDecoder(req.Body).Decode(&state); err != nil {
+			t.Errorf("decoding state: %v", err)
+		}
+
+		states[req.URL.String()] = state
+
+		return httpmock.NewStringResponse(http.StatusOK, "{}"), nil
+	}
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK),
+	)
+	transport.RegisterResponder(http.MethodPost, reconciledURL, capture)
+	transport.RegisterResponder(http.MethodPost, statusURL, capture)
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-ha-url", "https://ha.example/", "-ha-token", "ha-token", "-ha-entity-prefix", "bank",
+		"-run-id", "test-run",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	want := map[string]haState{
+		reconciledURL: {
+			State: "100.06",
+			Attributes: map[string]any{
+				"unit_of_measurement":   "\u20ac",
+				"device_class":          "monetary",
+				"reconciled_milliunits": float64(100060),
+				"currency":              "EUR",
+				"pushed":                float64(1),
+				"duplicates":            float64(0),
+				"drift_milliunits":      nil,
+				"drift_exceeded":        false,
+				"last_run":              "2024-11-30T03:00:00Z",
+			},
+		},
+		statusURL: {
+			State: "ok",
+			Attributes: map[string]any{
+				"error":        "",
+				"phase":        "",
+				"budget_name":  testBudgetID,
+				"account_name": testAccountID,
+				"run_id":       "test-run",
+				"last_run":     "2024-11-30T03:00:00Z",
+			},
+		},
+	}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("states = %v, want %v", states, want)
+	}
+}
+
+func Test_run_homeAssistantFailure(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK),
+	)
+	transport.RegisterResponder(
+		http.MethodPost,
+		"=~^https://ha.example/api/states/",
+		httpmock.NewStringResponder(http.StatusUnauthorized, ""),
+	)
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-ha-url", "https://ha.example", "-ha-token", "ha-token",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if !errors.Is(err, errNotificationFailed) {
+		t.Errorf("run() error = %v, want %v", err, errNotificationFailed)
+	}
+}
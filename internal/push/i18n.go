@@ -0,0 +1,235 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Languages of -lang.
+const (
+	langEN = "en"
+	langFR = "fr"
+)
+
+var errUnknownLang = errors.New("unknown language")
+
+// messages words the summary lines printed for people in the language of -lang.
+// Logs, the JSON report and the notifications stay in English.
+type messages struct {
+	lang string
+}
+
+// resolveLang returns the language of -lang, or else the one of the locale
+// environment variables, falling back to English.
+func resolveLang(flagLang string, getenv func(string) string) string {
+	if flagLang != "" {
+		return flagLang
+	}
+
+	if getenv == nil {
+		return langEN
+	}
+
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := getenv(name); locale != "" {
+			if strings.HasPrefix(locale, langFR) {
+				return langFR
+			}
+
+			return langEN
+		}
+	}
+
+	return langEN
+}
+
+func checkLang(lang string) error {
+	if lang != "" && lang != langEN && lang != langFR {
+		return fmt.Errorf("%w: %q, want %v or %v", errUnknownLang, lang, langEN, langFR)
+	}
+
+	return nil
+}
+
+// frenchPlural picks the singular for 0 and 1, as French does.
+func frenchPlural(n int, one, other string) string {
+	if n <= 1 {
+		return fmt.Sprintf(one, n)
+	}
+
+	return fmt.Sprintf(other, n)
+}
+
+func (m messages) transactionsHeader() string {
+	if m.lang == langFR {
+		return "transactions :"
+	}
+
+	return "transactions:"
+}
+
+func (m messages) duplicatesHeader() string {
+	if m.lang == langFR {
+		return "doublons :"
+	}
+
+	return "duplicates:"
+}
+
+func (m messages) importIDSalt(salt string) string {
+	if m.lang == langFR {
+		return "sel des import id : " + salt
+	}
+
+	return "import id salt: " + salt
+}
+
+// reconciled is the balance of the file, asOf its date or empty.
+func (m messages) reconciled(amount, asOf string) string {
+	if m.lang == langFR {
+		if asOf != "" {
+			asOf = " au " + asOf
+		}
+
+		return "solde rapproché : " + amount + asOf
+	}
+
+	if asOf != "" {
+		asOf = " as of " + asOf
+	}
+
+	return "reconciled: " + amount + asOf
+}
+
+func (m messages) pushed(n int) string {
+	if m.lang == langFR {
+		return frenchPlural(n, "%d transaction poussée", "%d transactions poussées")
+	}
+
+	return fmt.Sprintf("successfully pushed %d transaction(s)", n)
+}
+
+func (m messages) duplicates(n int) string {
+	if m.lang == langFR {
+		return frenchPlural(n, "%d doublon trouvé", "%d doublons trouvés")
+	}
+
+	return fmt.Sprintf("found %d duplicate(s)", n)
+}
+
+// skipped sums up the skipped rows, breakdown listing the counts by reason.
+func (m messages) skipped(breakdown string) string {
+	if m.lang == langFR {
+		return "lignes ignorées : " + breakdown
+	}
+
+	return "skipped: " + breakdown
+}
+
+func (m messages) skipReason(reason skipReason) string {
+	switch {
+	case reason == skipPending && m.lang == langFR:
+		return "en attente"
+	case reason == skipCurrency && m.lang == langFR:
+		return "dans une autre devise"
+	case reason == skipCurrency:
+		return "in another currency"
+	case reason == skipRate && m.lang == langFR:
+		return "taux de change"
+	case reason == skipRate:
+		return "exchange rate"
+	case reason == skipRateFolded && m.lang == langFR:
+		return "taux de change ajouté au mémo"
+	case reason == skipRateFolded:
+		return "exchange rate folded into a memo"
+	case reason == skipImported && m.lang == langFR:
+		return "déjà importé"
+	case reason == skipImported:
+		return "already imported"
+	default:
+		return string(reason)
+	}
+}
+
+func (m messages) wouldPush(n int) string {
+	if m.lang == langFR {
+		return frenchPlural(n,
+			"simulation : %d transaction serait poussée", "simulation : %d transactions seraient poussées")
+	}
+
+	return fmt.Sprintf("dry run: would push %d transaction(s)", n)
+}
+
+func (m messages) driftExceeded(drift, threshold string) string {
+	if m.lang == langFR {
+		return fmt.Sprintf("ATTENTION : YNAB s'écarte de la banque de %v, plus de %v", drift, threshold)
+	}
+
+	return fmt.Sprintf("WARNING: YNAB differs from the bank by %v, more than %v", drift, threshold)
+}
+
+func (m messages) capExceeded(name, month, spent, limit string) string {
+	if m.lang == langFR {
+		return fmt.Sprintf("ATTENTION : %v dépense %v en %v, plus que le plafond de %v", name, spent, month, limit)
+	}
+
+	return fmt.Sprintf("WARNING: %v spends %v in %v, more than its cap of %v", name, spent, month, limit)
+}
+
+// localizedError keeps the English error for the report and exit code, with the
+// line printed to the terminal in another language.
+type localizedError struct {
+	err  error
+	text string
+}
+
+func (e *localizedError) Error() string { return e.err.Error() }
+
+func (e *localizedError) Unwrap() error { return e.err }
+
+// localize explains err in the language of the messages, keeping the English
+// details after it. English errors are returned as is.
+func (m messages) localize(err error) error {
+	if err == nil || m.lang != langFR {
+		return err
+	}
+
+	var summary string
+
+	switch ExitCode(err) {
+	case exitCancelled:
+		summary = "annulé"
+	case exitAuth:
+		summary = "YNAB refuse le jeton"
+	case exitRateLimited:
+		summary = "trop d'appels à YNAB, réessayez plus tard"
+	case exitTooManyDuplicates:
+		summary = "trop de doublons"
+	case exitDriftExceeded:
+		summary = "YNAB s'écarte de la banque"
+	case exitDiscrepancies:
+		summary = "le fichier et YNAB diffèrent"
+	case exitCapExceeded:
+		summary = "plafond mensuel dépassé"
+	case exitReportChanged:
+		summary = "la conversion diffère du rapport"
+	case exitNotificationFailed:
+		summary = "transactions poussées, mais une notification a échoué"
+	case exitNothingToPush:
+		summary = "rien à pousser"
+	default:
+		summary = "échec"
+	}
+
+	return &localizedError{err: err, text: summary + " (" + err.Error() + ")"}
+}
+
+// ErrorText is the line printed for err at the end of the run.
+func ErrorText(err error) string {
+	if localized := new(localizedError); errors.As(err, &localized) {
+		return localized.text
+	}
+
+	return err.Error()
+}
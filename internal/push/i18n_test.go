@@ -0,0 +1,131 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_lang(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		args   []string
+		locale string
+		want   string
+	}{
+		{
+			name: "english",
+			args: []string{"-lang", "en"},
+			want: "reconciled: 100.06€ as of 2024-10-31\nsuccessfully pushed 3 transaction(s)\nfound 1 duplicate(s)\n",
+		},
+		{
+			name: "french",
+			args: []string{"-lang", "fr"},
+			want: "solde rapproché : 100.06€ au 2024-10-31\n3 transactions poussées\n1 doublon trouvé\n",
+		},
+		{
+			name:   "french from LANG",
+			locale: "fr_FR.UTF-8",
+			want:   "solde rapproché : 100.06€ au 2024-10-31\n3 transactions poussées\n1 doublon trouvé\n",
+		},
+		{
+			name:   "flag over LANG",
+			args:   []string{"-lang", "en"},
+			locale: "fr_FR.UTF-8",
+			want:   "reconciled: 100.06€ as of 2024-10-31\nsuccessfully pushed 3 transaction(s)\nfound 1 duplicate(s)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+				createdResponder(http.StatusOK, "YNAB:-21320:2024-10-28:1"))
+
+			stdout := &bytes.Buffer{}
+			args := append([]string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/diff.csv",
+				"-state", filepath.Join(t.TempDir(), "state.json"),
+			}, tt.args...)
+
+			err := run(context.Background(), args, env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv: func(name string) string {
+					if name == "LANG" {
+						return tt.locale
+					}
+
+					return ""
+				},
+			})
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			if stdout.String() != tt.want {
+				t.Errorf("stdout = \n%s\nwant\n%s", stdout, tt.want)
+			}
+		})
+	}
+}
+
+func Test_messages_plural(t *testing.T) {
+	t.Parallel()
+
+	french := messages{lang: langFR}
+
+	tests := map[int]string{0: "0 transaction poussée", 1: "1 transaction poussée", 12: "12 transactions poussées"}
+
+	for n, want := range tests {
+		if got := french.pushed(n); got != want {
+			t.Errorf("pushed(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func Test_messages_localize(t *testing.T) {
+	t.Parallel()
+
+	err := messages{lang: langFR}.localize(errNothingToPush)
+
+	if !errors.Is(err, errNothingToPush) || ExitCode(err) != exitNothingToPush {
+		t.Errorf("localize() = %v, want errNothingToPush kept", err)
+	}
+
+	if err.Error() != errNothingToPush.Error() {
+		t.Errorf("Error() = %q, want the English error for the report", err)
+	}
+
+	if text := ErrorText(err); !strings.HasPrefix(text, "rien à pousser (") {
+		t.Errorf("ErrorText() = %q, want it in French", text)
+	}
+
+	if err := (messages{lang: langEN}).localize(errNothingToPush); ErrorText(err) != errNothingToPush.Error() {
+		t.Errorf("ErrorText() = %q, want the English error", ErrorText(err))
+	}
+}
+
+func Test_parseFlags_lang(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "file.csv", "-lang", "de"}
+
+	_, err := parseFlags(args, env{})
+	if !errors.Is(err, errUnknownLang) {
+		t.Errorf("parseFlags() error = %v, want errUnknownLang", err)
+	}
+}
@@ -0,0 +1,92 @@
+package push
+
+import (
+	"io/fs"
+	"slices"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// maxImportedIDs bounds the import IDs remembered by account in the state file, well
+// above what overlapping exports of a few months hold.
+const maxImportedIDs = 5000
+
+// loadImportedIDs returns the import IDs YNAB got from previous runs for the account.
+func loadImportedIDs(fsys fs.FS, statePath, accountID string) (map[string]bool, error) {
+	previous := &pushState{}
+	if err := state.LoadFS(fsys, statePath, previous); err != nil {
+		return nil, err //nolint:wrapcheck // already explicit
+	}
+
+	imported := make(map[string]bool, len(previous.ImportedIDs[accountID]))
+	for _, id := range previous.ImportedIDs[accountID] {
+		imported[id] = true
+	}
+
+	return imported, nil
+}
+
+// filterImported splits transactions between those to push and those whose import ID
+// YNAB already got from a previous run, keeping their order.
+func filterImported(transactions []Transaction, imported map[string]bool) (kept, removed []Transaction) {
+	for _, t := range transactions {
+		if t.ImportID != "" && imported[t.ImportID] {
+			removed = append(removed, t)
+		} else {
+			kept = append(kept, t)
+		}
+	}
+
+	return kept, removed
+}
+
+// recordImportedIDs remembers the import IDs YNAB now has for the account, those it
+// created and those it already had, forgetting the oldest ones.
+func recordImportedIDs(fsys fs.FS, statePath, accountID string, synced *lclynab.Result) error {
+	ids := make([]string, 0, len(synced.Created)+len(synced.Duplicates))
+
+	for _, created := range synced.Created {
+		ids = append(ids, created.ImportID)
+	}
+
+	for _, duplicate := range synced.Duplicates {
+		ids = append(ids, duplicate.ImportID)
+	}
+
+	ids = slices.DeleteFunc(ids, func(id string) bool { return id == "" })
+	if len(ids) == 0 {
+		return nil
+	}
+
+	previous := &pushState{}
+	if err := state.LoadFS(fsys, statePath, previous); err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	if previous.ImportedIDs == nil {
+		previous.ImportedIDs = make(map[string][]string)
+	}
+
+	known := previous.ImportedIDs[accountID]
+
+	seen := make(map[string]bool, len(known))
+	for _, id := range known {
+		seen[id] = true
+	}
+
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			known = append(known, id)
+		}
+	}
+
+	if len(known) > maxImportedIDs {
+		known = slices.Clone(known[len(known)-maxImportedIDs:])
+	}
+
+	previous.ImportedIDs[accountID] = known
+
+	return state.Save(statePath, previous) //nolint:wrapcheck // already explicit
+}
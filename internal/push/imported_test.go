@@ -0,0 +1,155 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_filterImported(t *testing.T) {
+	t.Parallel()
+
+	transactions := []Transaction{
+		{ImportID: "YNAB:80000:2024-10-29:1"},
+		{ImportID: "YNAB:-21320:2024-10-28:1"},
+		{ImportID: ""},
+		{ImportID: "YNAB:-21320:2024-10-28:2"},
+	}
+
+	kept, removed := filterImported(transactions, map[string]bool{"YNAB:-21320:2024-10-28:1": true, "": true})
+
+	if want := []Transaction{transactions[0], transactions[2], transactions[3]}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("filterImported() kept = %v, want %v", kept, want)
+	}
+
+	if want := []Transaction{transactions[1]}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("filterImported() removed = %v, want %v", removed, want)
+	}
+}
+
+func Test_recordImportedIDs(t *testing.T) {
+	t.Parallel()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	old := make([]string, maxImportedIDs)
+	for i := range old {
+		old[i] = "old-" + strconv.Itoa(i)
+	}
+
+	err := state.Save(statePath, &pushState{ImportedIDs: map[string][]string{testAccountID: old, "other": {"kept"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = recordImportedIDs(osFS{}, statePath, testAccountID, &lclynab.Result{
+		Created:    []lclynab.SavedTransaction{{ImportID: "new-1"}, {ImportID: "old-4999"}, {ImportID: ""}},
+		Duplicates: []lclynab.Transaction{{ImportID: "new-2"}},
+	})
+	if err != nil {
+		t.Fatalf("recordImportedIDs() error = %v", err)
+	}
+
+	imported, err := loadImportedIDs(osFS{}, statePath, testAccountID)
+	if err != nil {
+		t.Fatalf("loadImportedIDs() error = %v", err)
+	}
+
+	if len(imported) != maxImportedIDs || !imported["new-1"] || !imported["new-2"] || imported["old-0"] {
+		t.Errorf("imported = %d IDs, want the %d latest with new-1 and new-2", len(imported), maxImportedIDs)
+	}
+
+	if other, _ := loadImportedIDs(osFS{}, statePath, "other"); !reflect.DeepEqual(other, map[string]bool{"kept": true}) {
+		t.Errorf("other account imported = %v, want it untouched", other)
+	}
+}
+
+func Test_run_importedIDs(t *testing.T) {
+	t.Parallel()
+
+	var (
+		posts int
+		mu    sync.Mutex
+	)
+
+	// YNAB creates whatever it's sent.
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			posts++
+			mu.Unlock()
+
+			var body struct {
+				Transactions []lclynab.SavedTransaction `json:"transactions"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+
+			return httpmock.NewJsonResponse(http.StatusCreated, map[string]any{
+				"data": map[string]any{"transactions": body.Transactions},
+			})
+		})
+
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	reportPath := filepath.Join(dir, "report.json")
+	environment := env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	}
+
+	push := func(args ...string) error {
+		return run(context.Background(), append([]string{
+			"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+			"-state", statePath, "-report", reportPath,
+		}, args...), environment)
+	}
+
+	if err := push(); err != nil {
+		t.Fatalf("first run() error = %v", err)
+	}
+
+	if err := push(); !errors.Is(err, errNothingToPush) {
+		t.Fatalf("second run() error = %v, want %v", err, errNothingToPush)
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report Result
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[skipReason]int{skipImported: 1}; !reflect.DeepEqual(report.SkippedByReason, want) {
+		t.Errorf("skipped_by_reason = %v, want %v", report.SkippedByReason, want)
+	}
+
+	if err := push("-force-new-import-ids", "-yes"); err != nil {
+		t.Fatalf("salted run() error = %v", err)
+	}
+
+	// The second run had nothing left to send.
+	if posts != 2 {
+		t.Errorf("YNAB got %d pushes, want 2", posts)
+	}
+}
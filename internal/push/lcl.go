@@ -0,0 +1,121 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// Values of -import-id-strategy.
+const (
+	importIDCounter = "counter"
+	importIDHash    = "hash"
+)
+
+var errUnknownImportIDStrategy = errors.New("unknown import ID strategy")
+
+var lclLineRegexp = regexp.MustCompile(`^\d{2}/\d{2}/\d{4};`)
+
+func lclFormat() format {
+	return format{
+		name:       formatLCL,
+		extensions: []string{".csv"},
+		currency:   "EUR",
+		sniff: func(head []byte) bool {
+			return lclLineRegexp.Match(trimBOM(head))
+		},
+		newImporter: func(opts importerOptions) importer {
+			return importerFunc(func(ctx context.Context, reader io.Reader, accountID string) ([]Transaction, balance, error) {
+				return convert(ctx, reader, accountID, opts)
+			})
+		},
+	}
+}
+
+// convert reads an LCL export and turns it into YNAB transactions, warning opts.truncated
+// about the payees and labels cut to the lengths YNAB accepts.
+func convert(
+	ctx context.Context,
+	reader io.Reader,
+	accountID string,
+	opts importerOptions,
+) ([]Transaction, balance, error) {
+	statement, err := lclynab.ParseContext(ctx, reader, lclynab.ParseOptions{
+		AccountID:   accountID,
+		StripHolder: opts.stripHolder,
+		Progress:    opts.progress,
+		KeepLines:   opts.hashIDs,
+		FoldRates:   opts.foldRates,
+	})
+	if err != nil {
+		return nil, balance{}, err //nolint:wrapcheck // already explicit
+	}
+
+	if opts.skipped != nil {
+		for _, rate := range statement.RateLines {
+			opts.skipped(rateRow(rate))
+		}
+	}
+
+	if opts.truncated != nil {
+		warnTruncated(opts.truncated, statement.Transactions)
+	}
+
+	reconciled := balance{
+		milliunits: int(statement.Balance),
+		date:       lclynab.NewDate(statement.BalanceDate),
+		accountRef: statement.AccountRef,
+	}
+
+	transactions, err := lclynab.Transactions(statement)
+	if err != nil {
+		return nil, balance{}, err //nolint:wrapcheck // already explicit
+	}
+
+	if opts.hashIDs {
+		lines := make(map[string]int)
+		for i, t := range statement.Transactions {
+			transactions[i].ImportID = lclynab.HashImportID(t.Line, lines)
+		}
+	}
+
+	return transactions, reconciled, nil
+}
+
+// rateRow is the skipped row of an exchange rate line.
+func rateRow(rate lclynab.RateLine) skippedRow {
+	reason := skipRate
+	if rate.Folded {
+		reason = skipRateFolded
+	}
+
+	return skippedRow{
+		reason: reason,
+		date:   rate.Date.Format(time.DateOnly),
+		amount: reconciledString(int(rate.Amount)),
+		payee:  rate.Label,
+	}
+}
+
+// warnTruncated writes a warning for each payee and label longer than YNAB accepts.
+func warnTruncated(w io.Writer, transactions []lclynab.StatementTransaction) {
+	for i, t := range transactions {
+		for _, field := range []struct {
+			name, value string
+			limit       int
+		}{
+			{name: "payee", value: t.Payee, limit: lclynab.MaxPayeeNameLen},
+			{name: "memo", value: t.Label, limit: lclynab.MaxMemoLen},
+		} {
+			if _, cut := lclynab.Truncate(field.value, field.limit); cut {
+				_, _ = fmt.Fprintf(w, "warning: %v of transaction %d (%v) cut to %d characters\n",
+					field.name, i+1, t.Date.Format(time.DateOnly), field.limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+//nolint:funlen // mostly test cases in list
+func Test_convert(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		reader    io.Reader
+		accountID string
+	}
+
+	tests := []struct {
+		name             string
+		args             args
+		wantTransactions []Transaction
+		wantReconciled   balance
+		wantErr          bool
+	}{
+		{
+			name:             "nil reader",
+			args:             args{nil, "acc-id"},
+			wantTransactions: nil,
+			wantReconciled:   balance{},
+			wantErr:          false,
+		},
+		{
+			name:             "no transactions",
+			args:             args{strings.NewReader(""), "acc-id"},
+			wantTransactions: nil,
+			wantReconciled:   balance{},
+			wantErr:          false,
+		},
+		{
+			name:             "footer only",
+			args:             args{strings.NewReader(`29/11/2024;100,06;;01234 123456A`), "acc-id"},
+			wantTransactions: nil,
+			wantReconciled:   balance{milliunits: 100060, date: mustDate("2024-11-29"), accountRef: "01234 123456A"},
+			wantErr:          false,
+		},
+		{
+			name:             "footer without date",
+			args:             args{strings.NewReader(`;100,06;;01234 123456A`), "acc-id"},
+			wantTransactions: nil,
+			wantReconciled:   balance{milliunits: 100060, accountRef: "01234 123456A"},
+			wantErr:          false,
+		},
+		{
+			name:             "footer with unparsable date",
+			args:             args{strings.NewReader(`2024-11-29;100,06;;01234 123456A`), "acc-id"},
+			wantTransactions: nil,
+			wantReconciled:   balance{},
+			wantErr:          true,
+		},
+		{
+			name: "one positive transaction",
+			args: args{strings.NewReader(`29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;
+29/11/2024;100,06;;01234 123456A`), "acc-id"},
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-29"),
+					Amount:    80000,
+					PayeeName: "VIREMENT M JEAN MARTIN OU",
+					Memo:      "VIREMENT M JEAN MARTIN OU",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:80000:2024-10-29:1",
+				},
+			},
+			wantReconciled: balance{milliunits: 100060, date: mustDate("2024-11-29"), accountRef: "01234 123456A"},
+			wantErr:        false,
+		},
+		{
+			name: "one negative and one positive transactions",
+			args: args{strings.NewReader(`29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;
+29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers
+29/11/2024;100,06;;01234 123456A`), "acc-id"},
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-29"),
+					Amount:    80000,
+					PayeeName: "VIREMENT M JEAN MARTIN OU",
+					Memo:      "VIREMENT M JEAN MARTIN OU",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:80000:2024-10-29:1",
+				},
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-28"),
+					Amount:    -21320,
+					PayeeName: "CB  MERCH",
+					Memo:      "CB  MERCH          28/10/24",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:-21320:2024-10-28:1",
+				},
+			},
+			wantReconciled: balance{milliunits: 100060, date: mustDate("2024-11-29"), accountRef: "01234 123456A"},
+			wantErr:        false,
+		},
+		{
+			name: "same amount same date",
+			args: args{strings.NewReader(`29/10/2024;-21,32;Carte;;CB  MERCH1          28/10/24;;0;Divers
+29/10/2024;-21,32;Carte;;CB  MERCH2          28/10/24;;0;Divers
+29/11/2024;100,06;;01234 123456A`), "acc-id"},
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-28"),
+					Amount:    -21320,
+					PayeeName: "CB  MERCH1",
+					Memo:      "CB  MERCH1          28/10/24",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:-21320:2024-10-28:1",
+				},
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-28"),
+					Amount:    -21320,
+					PayeeName: "CB  MERCH2",
+					Memo:      "CB  MERCH2          28/10/24",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:-21320:2024-10-28:2",
+				},
+			},
+			wantReconciled: balance{milliunits: 100060, date: mustDate("2024-11-29"), accountRef: "01234 123456A"},
+			wantErr:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotReconciled, err := convert(context.Background(), tt.args.reader, tt.args.accountID, importerOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("convert() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.wantTransactions) {
+				t.Errorf("convert() got = %v, want %v", got, tt.wantTransactions)
+			}
+
+			if gotReconciled != tt.wantReconciled {
+				t.Errorf("convert() gotReconciled = %v, want %v", gotReconciled, tt.wantReconciled)
+			}
+		})
+	}
+}
+
+func Test_convert_truncated(t *testing.T) {
+	t.Parallel()
+
+	label := "VIREMENT " + strings.Repeat("M JEAN MARTIN ", 50)
+	input := "29/10/2024;80;Virement;;;" + label + ";;\n29/11/2024;100,06;;01234 123456A\n"
+
+	var truncated bytes.Buffer
+
+	transactions, _, err := convert(context.Background(), strings.NewReader(input), "acc-id",
+		importerOptions{truncated: &truncated})
+	if err != nil {
+		t.Fatalf("convert() error = %v", err)
+	}
+
+	if got := utf8.RuneCountInString(transactions[0].Memo); got != lclynab.MaxMemoLen {
+		t.Errorf("convert() memo = %d characters, want %d", got, lclynab.MaxMemoLen)
+	}
+
+	want := "warning: payee of transaction 1 (2024-10-29) cut to 200 characters\n" +
+		"warning: memo of transaction 1 (2024-10-29) cut to 500 characters\n"
+	if truncated.String() != want {
+		t.Errorf("convert() warnings = %q, want %q", truncated.String(), want)
+	}
+}
+
+func Test_run_truncated(t *testing.T) {
+	t.Parallel()
+
+	label := "VIREMENT " + strings.Repeat("M JEAN MARTIN ", 50)
+	path := filepath.Join(t.TempDir(), "statement.csv")
+	content := "29/10/2024;80;Virement;;;" + label + ";;\n29/11/2024;100,06;;01234 123456A\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, verbose := range []bool{false, true} {
+		var stdout, stderr bytes.Buffer
+
+		args := []string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", path, "-dry-run"}
+		if verbose {
+			args = append(args, "-v")
+		}
+
+		err := run(context.Background(), args, env{stdout: &stdout, stderr: &stderr, now: fixedNow, getenv: testGetenv(t)})
+		if err != nil {
+			t.Fatalf("run() error = %v", err)
+		}
+
+		if got := strings.Contains(stdout.String(), "memo of transaction 1 (2024-10-29) cut to"); got != verbose {
+			t.Errorf("-v=%v: stdout = %q, want the warning %v", verbose, stdout.String(), verbose)
+		}
+
+		if strings.Contains(stderr.String(), "cut to") {
+			t.Errorf("-v=%v: stderr = %q, want no warning", verbose, stderr.String())
+		}
+	}
+}
+
+func Test_convert_importIDStrategy(t *testing.T) {
+	t.Parallel()
+
+	// Two exports of the same day, each with a 5 € payment the other doesn't have.
+	exports := []string{
+		"28/10/2024;-5;Carte;;CB BOULANGERIE 28/10/24;;0;Divers\n29/11/2024;100,06;;01234 123456A\n",
+		"28/10/2024;-5;Carte;;CB PHARMACIE 28/10/24;;0;Divers\n29/11/2024;95,06;;01234 123456A\n",
+	}
+
+	importIDs := func(hashIDs bool) []string {
+		t.Helper()
+
+		var ids []string
+
+		for _, export := range exports {
+			transactions, _, err := convert(context.Background(), strings.NewReader(export), "acc-id",
+				importerOptions{hashIDs: hashIDs})
+			if err != nil {
+				t.Fatalf("convert() error = %v", err)
+			}
+
+			ids = append(ids, transactions[0].ImportID)
+		}
+
+		return ids
+	}
+
+	// The counter restarts with each export: YNAB would skip the second payment.
+	if counter := importIDs(false); counter[0] != counter[1] {
+		t.Errorf("counter import IDs = %q, want the collision they're known for", counter)
+	}
+
+	hash := importIDs(true)
+	if hash[0] == hash[1] {
+		t.Errorf("hash import IDs = %q, want them told apart", hash)
+	}
+
+	for _, id := range hash {
+		if !strings.HasPrefix(id, "YNAB:SHA256:") || len(id) > 36-4 {
+			t.Errorf("hash import ID = %q, want YNAB:SHA256: and room for a salt", id)
+		}
+	}
+}
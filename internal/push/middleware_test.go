@@ -0,0 +1,90 @@
+package push
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+// requestLog is a middleware recording the host and path of every request.
+type requestLog struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (l *requestLog) middleware(next http.RoundTripper) http.RoundTripper {
+	return lclynab.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		l.mu.Lock()
+		l.requests = append(l.requests, req.Method+" "+req.URL.Host+req.URL.Path)
+		l.mu.Unlock()
+
+		return next.RoundTrip(req) //nolint:wrapcheck // transparent middleware
+	})
+}
+
+func Test_run_middlewares(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "https://api.youneedabudget.com/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusCreated))
+	transport.RegisterResponder(http.MethodGet, "https://api.youneedabudget.com/v1/budgets",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"budgets": [{"id": "`+testBudgetID+`", "name": "Personal",
+			"accounts": [{"id": "`+testAccountID+`", "name": "Checking"}]}]}}`))
+	transport.RegisterResponder(http.MethodGet,
+		"https://api.youneedabudget.com/v1/budgets/"+testBudgetID+"/accounts/"+testAccountID,
+		httpmock.NewStringResponder(http.StatusOK,
+			`{"data": {"account": {"id": "`+testAccountID+`", "cleared_balance": 100060}}}`))
+	transport.RegisterNoResponder(httpmock.NewStringResponder(http.StatusOK, `{"ok": true}`))
+
+	requests := &requestLog{}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-state", filepath.Join(t.TempDir(), "push-state.json"), "-resolve-names", "-drift-alert", "1",
+		"-w", "https://hooks.example/ynab",
+		"-ha-url", "https://ha.example", "-ha-token", "ha-token",
+		"-ntfy-url", "https://ntfy.example/bank", "-ntfy-on-success",
+		"-telegram-token", "tg-token", "-telegram-chat-id", "42",
+		"-discord-webhook", "https://discord.example/api/webhooks/1/abc",
+		"-slack-webhook", "https://hooks.slack.example/services/T000/B000/s3cr3t",
+		"-gotify-url", "https://gotify.example", "-gotify-token", "gotify-token",
+	}, env{
+		stdout:      io.Discard,
+		stderr:      io.Discard,
+		httpClient:  &http.Client{Transport: transport},
+		now:         fixedNow,
+		middlewares: []lclynab.Middleware{requests.middleware},
+		getenv:      testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if got, want := len(requests.requests), transport.GetTotalCallCount(); got != want {
+		t.Errorf("middleware saw %d requests, want all %d: %v", got, want, requests.requests)
+	}
+
+	for _, want := range []string{
+		"POST api.youneedabudget.com/v1/budgets/" + testBudgetID + "/transactions",
+		"GET api.youneedabudget.com/v1/budgets",
+		"GET api.youneedabudget.com/v1/budgets/" + testBudgetID + "/accounts/" + testAccountID,
+		"POST hooks.example/ynab",
+		"POST api.telegram.org/bottg-token/sendMessage",
+		"POST discord.example/api/webhooks/1/abc",
+		"POST hooks.slack.example/services/T000/B000/s3cr3t",
+		"POST gotify.example/message",
+		"POST ntfy.example/bank",
+	} {
+		if !slices.Contains(requests.requests, want) {
+			t.Errorf("middleware didn't see %q, saw %v", want, requests.requests)
+		}
+	}
+}
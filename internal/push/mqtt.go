@@ -0,0 +1,245 @@
+package push
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	defaultMQTTTopic      = "lcl-ynab"
+	mqttDiscoveryPrefix   = "homeassistant"
+	mqttQoS               = 1
+	mqttDisconnectQuiesce = 250 // milliseconds
+)
+
+var errInvalidCA = errors.New("no certificate found")
+
+// mqttClient is the part of the paho client used to publish, replaced in tests.
+type mqttClient interface {
+	Connect() mqtt.Token
+	Publish(topic string, qos byte, retained bool, payload any) mqtt.Token
+	Disconnect(quiesce uint)
+}
+
+// mqttNotifier publishes the run result to an MQTT broker, along with the
+// Home Assistant discovery configuration of the matching sensors.
+type mqttNotifier struct {
+	topic     string
+	newClient func() (mqttClient, error)
+}
+
+// mqttState is the retained message published to <topic>/state.
+// ReconciledMilliunits is nil, rather than zero, when the balance is unknown.
+type mqttState struct {
+	Reconciled           string    `json:"reconciled,omitempty"`
+	ReconciledMilliunits *int      `json:"reconciled_milliunits,omitempty"`
+	Currency             string    `json:"currency,omitempty"`
+	Status               string    `json:"status"`
+	Error                string    `json:"error,omitempty"`
+	Pushed               int       `json:"pushed"`
+	Duplicates           int       `json:"duplicates"`
+	Skipped              int       `json:"skipped"`
+	DriftMilliunits      *int      `json:"drift_milliunits,omitempty"`
+	DriftExceeded        bool      `json:"drift_exceeded"`
+	BudgetName           string    `json:"budget_name"`
+	AccountName          string    `json:"account_name"`
+	RunID                string    `json:"run_id"`
+	Timestamp            time.Time `json:"timestamp"`
+}
+
+type mqttDiscovery struct {
+	Name                string     `json:"name"`
+	UniqueID            string     `json:"unique_id"`
+	StateTopic          string     `json:"state_topic"`
+	ValueTemplate       string     `json:"value_template"`
+	JSONAttributesTopic string     `json:"json_attributes_topic"`
+	DeviceClass         string     `json:"device_class,omitempty"`
+	UnitOfMeasurement   string     `json:"unit_of_measurement,omitempty"`
+	Device              mqttDevice `json:"device"`
+}
+
+type mqttDevice struct {
+	Identifiers []string `json:"identifiers"`
+	Name        string   `json:"name"`
+}
+
+func newMQTTNotifier(opts *options, fsys fs.FS) *mqttNotifier {
+	topic := strings.TrimSuffix(opts.mqttTopic, "/")
+
+	return &mqttNotifier{
+		topic: topic,
+		newClient: func() (mqttClient, error) {
+			clientOpts := mqtt.NewClientOptions().
+				AddBroker(opts.mqttURL).
+				SetClientID("lcl-ynab-" + objectID(topic)).
+				SetUsername(opts.mqttUsername).
+				SetPassword(opts.mqttPassword).
+				SetCleanSession(true).
+				SetAutoReconnect(false).
+				SetConnectTimeout(apiTimeout)
+
+			if opts.mqttCAFile != "" {
+				tlsConfig, err := loadCA(fsys, opts.mqttCAFile)
+				if err != nil {
+					return nil, err
+				}
+
+				clientOpts.SetTLSConfig(tlsConfig)
+			}
+
+			return mqtt.NewClient(clientOpts), nil
+		},
+	}
+}
+
+func (m *mqttNotifier) name() string {
+	return "mqtt"
+}
+
+// notify publishes the discovery configuration then the state, all retained with QoS 1,
+// so that Home Assistant picks the sensors up whenever it (re)starts.
+func (m *mqttNotifier) notify(ctx context.Context, data webhookData) error {
+	client, err := m.newClient()
+	if err != nil {
+		return err
+	}
+
+	if err := wait(ctx, client.Connect()); err != nil {
+		return fmt.Errorf("connecting to broker: %w", err)
+	}
+
+	defer client.Disconnect(mqttDisconnectQuiesce)
+
+	messages, err := m.render(data)
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range messages {
+		if err := wait(ctx, client.Publish(msg.target, mqttQoS, true, msg.body)); err != nil {
+			return fmt.Errorf("publishing to %v: %w", msg.target, err)
+		}
+	}
+
+	return nil
+}
+
+// render returns the messages to publish, their target being the topic.
+func (m *mqttNotifier) render(data webhookData) ([]message, error) {
+	id := objectID(m.topic)
+	stateTopic := m.topic + "/state"
+	device := mqttDevice{Identifiers: []string{id}, Name: "LCL YNAB"}
+
+	var milliunits *int
+	if data.Reconciled != "" {
+		milliunits = &data.ReconciledMilliunits
+	}
+
+	payloads := []struct {
+		topic string
+		value any
+	}{
+		{
+			topic: fmt.Sprintf("%v/sensor/%v/reconciled/config", mqttDiscoveryPrefix, id),
+			value: mqttDiscovery{
+				Name:                "Reconciled balance",
+				UniqueID:            id + "_reconciled",
+				StateTopic:          stateTopic,
+				ValueTemplate:       "{{ value_json.reconciled }}",
+				JSONAttributesTopic: stateTopic,
+				DeviceClass:         "monetary",
+				UnitOfMeasurement:   "€",
+				Device:              device,
+			},
+		},
+		{
+			topic: fmt.Sprintf("%v/sensor/%v/last_import/config", mqttDiscoveryPrefix, id),
+			value: mqttDiscovery{
+				Name:                "Last import",
+				UniqueID:            id + "_last_import",
+				StateTopic:          stateTopic,
+				ValueTemplate:       "{{ value_json.timestamp }}",
+				JSONAttributesTopic: stateTopic,
+				DeviceClass:         "timestamp",
+				Device:              device,
+			},
+		},
+		{
+			topic: stateTopic,
+			value: mqttState{
+				Reconciled:           data.Reconciled,
+				ReconciledMilliunits: milliunits,
+				Currency:             data.Currency,
+				Status:               data.Status,
+				Error:                data.Error,
+				Pushed:               data.Pushed,
+				Duplicates:           data.Duplicates,
+				Skipped:              data.Skipped,
+				DriftMilliunits:      data.Drift,
+				DriftExceeded:        data.DriftExceeded,
+				BudgetName:           data.BudgetName,
+				AccountName:          data.AccountName,
+				RunID:                data.RunID,
+				Timestamp:            data.SentAt,
+			},
+		},
+	}
+
+	messages := make([]message, 0, len(payloads))
+
+	for _, p := range payloads {
+		payload, err := json.Marshal(p.value)
+		if err != nil {
+			return nil, fmt.Errorf("encoding %v: %w", p.topic, err)
+		}
+
+		messages = append(messages, message{target: p.topic, body: payload})
+	}
+
+	return messages, nil
+}
+
+// wait blocks until the token completes or ctx is done.
+func wait(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error() //nolint:wrapcheck // wrapped by the caller
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // wrapped by the caller
+	}
+}
+
+// objectID turns a topic into an identifier Home Assistant accepts.
+func objectID(topic string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, topic)
+}
+
+func loadCA(fsys fs.FS, path string) (*tls.Config, error) {
+	pem, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%w in %v", errInvalidCA, path)
+	}
+
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
@@ -0,0 +1,118 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+type fakeToken struct {
+	err error
+}
+
+func (t fakeToken) Wait() bool                     { return true }
+func (t fakeToken) WaitTimeout(time.Duration) bool { return true }
+func (t fakeToken) Error() error                   { return t.err }
+
+func (t fakeToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+
+	return done
+}
+
+type fakeMQTTClient struct {
+	connectErr   error
+	published    map[string]string
+	retained     bool
+	disconnected bool
+}
+
+func (c *fakeMQTTClient) Connect() mqtt.Token {
+	return fakeToken{err: c.connectErr}
+}
+
+func (c *fakeMQTTClient) Publish(topic string, _ byte, retained bool, payload any) mqtt.Token {
+	c.published[topic] = string(payload.([]byte)) //nolint:forcetypeassert // always bytes
+	c.retained = retained
+
+	return fakeToken{}
+}
+
+func (c *fakeMQTTClient) Disconnect(uint) {
+	c.disconnected = true
+}
+
+func Test_mqttNotifier_notify(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeMQTTClient{published: map[string]string{}}
+	notifier := &mqttNotifier{
+		topic:     "home/bank",
+		newClient: func() (mqttClient, error) { return client, nil },
+	}
+
+	err := notifier.notify(context.Background(), webhookData{
+		Status:               statusOK,
+		Reconciled:           "100.06",
+		ReconciledMilliunits: 100060,
+		Currency:             "EUR",
+		Pushed:               1,
+		BudgetName:           "Personal",
+		AccountName:          "Checking",
+		RunID:                "test-run",
+		SentAt:               fixedNow(),
+	})
+	if err != nil {
+		t.Fatalf("notify() error = %v", err)
+	}
+
+	want := map[string]string{
+		"homeassistant/sensor/home_bank/reconciled/config": `{"name":"Reconciled balance","unique_id":"home_bank_reconciled",` +
+			`"state_topic":"home/bank/state","value_template":"{{ value_json.reconciled }}",` +
+			`"json_attributes_topic":"home/bank/state","device_class":"monetary","unit_of_measurement":"€",` +
+			`"device":{"identifiers":["home_bank"],"name":"LCL YNAB"}}`,
+		"homeassistant/sensor/home_bank/last_import/config": `{"name":"Last import","unique_id":"home_bank_last_import",` +
+			`"state_topic":"home/bank/state","value_template":"{{ value_json.timestamp }}",` +
+			`"json_attributes_topic":"home/bank/state","device_class":"timestamp",` +
+			`"device":{"identifiers":["home_bank"],"name":"LCL YNAB"}}`,
+		"home/bank/state": `{"reconciled":"100.06","reconciled_milliunits":100060,"currency":"EUR",` +
+			`"status":"ok","pushed":1,"duplicates":0,"skipped":0,"drift_exceeded":false,` +
+			`"budget_name":"Personal","account_name":"Checking","run_id":"test-run",` +
+			`"timestamp":"2024-11-30T03:00:00Z"}`,
+	}
+	if !reflect.DeepEqual(client.published, want) {
+		t.Errorf("published = %v, want %v", client.published, want)
+	}
+
+	if !client.retained {
+		t.Errorf("messages are not retained")
+	}
+
+	if !client.disconnected {
+		t.Errorf("client was not disconnected")
+	}
+}
+
+func Test_mqttNotifier_notify_connectError(t *testing.T) {
+	t.Parallel()
+
+	errRefused := errors.New("connection refused")
+	client := &fakeMQTTClient{connectErr: errRefused, published: map[string]string{}}
+	notifier := &mqttNotifier{
+		topic:     defaultMQTTTopic,
+		newClient: func() (mqttClient, error) { return client, nil },
+	}
+
+	if err := notifier.notify(context.Background(), webhookData{}); !errors.Is(err, errRefused) {
+		t.Errorf("notify() error = %v, want %v", err, errRefused)
+	}
+
+	if len(client.published) != 0 {
+		t.Errorf("published = %v, want nothing", client.published)
+	}
+}
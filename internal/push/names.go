@@ -0,0 +1,95 @@
+package push
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// pushState is what push remembers between runs.
+type pushState struct {
+	Names names `json:"names"`
+	// CurrencyFormats are the currency formats of the budgets, by ID.
+	CurrencyFormats map[string]lclynab.CurrencyFormat `json:"currency_formats,omitempty"`
+	// CurrencyFormatsFetchedAt are when CurrencyFormats were got from YNAB, by budget ID.
+	CurrencyFormatsFetchedAt map[string]time.Time `json:"currency_formats_fetched_at,omitempty"`
+	// Runs are the last runs that created transactions, oldest first.
+	Runs []pushedRun `json:"runs,omitempty"`
+	// CategoryHistories are what -suggest-categories learns from, by account ID.
+	CategoryHistories map[string]categoryHistory `json:"category_histories,omitempty"`
+	// Watched are the files of -watch directories pushed already, by path.
+	Watched map[string]watchedFile `json:"watched,omitempty"`
+	// ImportedIDs are the import IDs YNAB got, by account ID, oldest first.
+	ImportedIDs map[string][]string `json:"imported_ids,omitempty"`
+}
+
+// names caches the display names of budgets and accounts, by ID.
+type names struct {
+	Budgets   map[string]string `json:"budgets"`
+	Accounts  map[string]string `json:"accounts"`
+	FetchedAt time.Time         `json:"fetched_at,omitzero"`
+}
+
+// resolveNames returns the names of the budget and account, from the state file when
+// known there and fresh, otherwise from a single call listing every budget with its accounts.
+func resolveNames(
+	ctx context.Context,
+	client *http.Client,
+	fsys fs.FS,
+	cache cachePolicy,
+	statePath, token, budgetID, accountID string,
+) (budgetName, accountName string, err error) {
+	previous := &pushState{}
+	if err := state.LoadFS(fsys, statePath, previous); err != nil {
+		return "", "", err //nolint:wrapcheck // already explicit
+	}
+
+	budgetName, accountName = previous.Names.Budgets[budgetID], previous.Names.Accounts[accountID]
+	if budgetName != "" && accountName != "" && cache.fresh(previous.Names.FetchedAt) {
+		return budgetName, accountName, nil
+	}
+
+	fetched, err := fetchNames(ctx, client, token)
+	if err != nil {
+		return "", "", err
+	}
+
+	if cache.disabled {
+		return fetched.Budgets[budgetID], fetched.Accounts[accountID], nil
+	}
+
+	fetched.FetchedAt = cache.now
+	previous.Names = fetched
+
+	if err := state.Save(statePath, previous); err != nil {
+		return "", "", err //nolint:wrapcheck // already explicit
+	}
+
+	return fetched.Budgets[budgetID], fetched.Accounts[accountID], nil
+}
+
+func fetchNames(ctx context.Context, client *http.Client, token string) (names, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	budgets, err := lclynab.NewClient(token, client).ListBudgets(ctx)
+	if err != nil {
+		return names{}, err //nolint:wrapcheck // already explicit
+	}
+
+	fetched := names{Budgets: map[string]string{}, Accounts: map[string]string{}}
+
+	for _, budget := range budgets {
+		fetched.Budgets[budget.ID] = budget.Name
+
+		for _, account := range budget.Accounts {
+			fetched.Accounts[account.ID] = account.Name
+		}
+	}
+
+	return fetched, nil
+}
@@ -0,0 +1,99 @@
+package push
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_resolveNames(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodGet,
+		"https://api.youneedabudget.com/v1/budgets?include_accounts=true",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"budgets": [
+			{"id": "`+testBudgetID+`", "name": "Personal", "accounts": [{"id": "`+testAccountID+`", "name": "Checking"}]},
+			{"id": "other", "name": "Shared", "accounts": [{"id": "joint", "name": "Joint"}]}
+		]}}`),
+	)
+
+	client := &http.Client{Transport: transport}
+	statePath := filepath.Join(t.TempDir(), "push-state.json")
+
+	for range 2 {
+		budgetName, accountName, err := resolveNames(context.Background(), client, osFS{}, cachePolicy{},
+			statePath, "tok", testBudgetID, testAccountID)
+		if err != nil {
+			t.Fatalf("resolveNames() error = %v", err)
+		}
+
+		if budgetName != "Personal" || accountName != "Checking" {
+			t.Errorf("resolveNames() = %q, %q, want %q, %q", budgetName, accountName, "Personal", "Checking")
+		}
+	}
+
+	if got := transport.GetTotalCallCount(); got != 1 {
+		t.Errorf("API calls = %d, want 1, the second lookup being served from the state file", got)
+	}
+}
+
+func Test_resolveNames_unknownAccount(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodGet,
+		"https://api.youneedabudget.com/v1/budgets?include_accounts=true",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"budgets": []}}`),
+	)
+
+	budgetName, accountName, err := resolveNames(
+		context.Background(),
+		&http.Client{Transport: transport},
+		osFS{},
+		cachePolicy{},
+		filepath.Join(t.TempDir(), "push-state.json"),
+		"tok", testBudgetID, testAccountID,
+	)
+	if err != nil {
+		t.Fatalf("resolveNames() error = %v", err)
+	}
+
+	if budgetName != "" || accountName != "" {
+		t.Errorf("resolveNames() = %q, %q, want empty names", budgetName, accountName)
+	}
+}
+
+func Test_resolveNames_fromFS(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	fsys := fstest.MapFS{
+		"state/push.json": {Data: []byte(`{"names": {"budgets": {"` + testBudgetID + `": "Personal"}, ` +
+			`"accounts": {"` + testAccountID + `": "Checking"}}}`)},
+		"state/bad.json": {Data: []byte(`{`)},
+	}
+
+	budgetName, accountName, err := resolveNames(context.Background(), &http.Client{Transport: transport}, fsys,
+		cachePolicy{}, "state/push.json", "tok", testBudgetID, testAccountID)
+	if err != nil || budgetName != "Personal" || accountName != "Checking" {
+		t.Errorf("resolveNames() = %q, %q, %v, want the cached names", budgetName, accountName, err)
+	}
+
+	if calls := transport.GetTotalCallCount(); calls != 0 {
+		t.Errorf("calls = %v, want none", calls)
+	}
+
+	_, _, err = resolveNames(context.Background(), &http.Client{Transport: transport}, fsys, cachePolicy{},
+		"state/bad.json", "tok", testBudgetID, testAccountID)
+	if err == nil || !strings.Contains(err.Error(), "state/bad.json") {
+		t.Errorf("resolveNames() error = %v, want it to name the state file", err)
+	}
+}
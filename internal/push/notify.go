@@ -0,0 +1,140 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+var errNotifierPanicked = errors.New("notifier panicked")
+
+// Statuses of a notification channel in the report.
+const (
+	notifySent   = "sent"
+	notifyFailed = "failed"
+)
+
+// notifier delivers the result of a run to a notification channel.
+// Notifier failures don't fail the run: they end it with errNotificationFailed.
+type notifier interface {
+	name() string
+	// render returns the messages notify sends for data, none when it wouldn't notify.
+	render(data webhookData) ([]message, error)
+	notify(ctx context.Context, data webhookData) error
+}
+
+// message is a notification as sent, shown by -dry-run. The target names where it
+// goes without revealing secrets such as webhook URLs.
+type message struct {
+	target string
+	body   []byte
+}
+
+// notification is the outcome of a notification channel, as reported.
+type notification struct {
+	Channel string `json:"channel"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// newNotifiers returns the notifiers configured by the flags, calling their
+// HTTP endpoints with client and reading their files from fsys.
+func newNotifiers(opts *options, client *http.Client, fsys fs.FS, logger *slog.Logger, logs *logTail) []notifier {
+	var notifiers []notifier
+
+	if opts.haURL != "" {
+		notifiers = append(notifiers, newHomeAssistant(opts, client))
+	}
+
+	if opts.mqttURL != "" {
+		notifiers = append(notifiers, newMQTTNotifier(opts, fsys))
+	}
+
+	if opts.ntfyURL != "" {
+		notifiers = append(notifiers, newNtfy(opts, client))
+	}
+
+	if opts.telegramToken != "" {
+		notifiers = append(notifiers, newTelegram(opts, client))
+	}
+
+	if opts.smtpHost != "" {
+		notifiers = append(notifiers, newEmail(opts, logs))
+	}
+
+	if opts.discordWebhook != "" {
+		notifiers = append(notifiers, newDiscord(opts, client, logger))
+	}
+
+	if opts.slackWebhook != "" {
+		notifiers = append(notifiers, newSlack(opts, client, logger))
+	}
+
+	if opts.gotifyURL != "" {
+		notifiers = append(notifiers, newGotify(opts, client))
+	}
+
+	return notifiers
+}
+
+// notifyAll sends data to the notifiers in turn, in the order newNotifiers gives them.
+// Each channel gets its own timeout, 0 for none, and a channel failing, hanging or
+// panicking is only a warning: the next ones are still notified.
+func notifyAll(ctx context.Context, state *runState, timeout time.Duration, data webhookData) []notification {
+	outcomes := make([]notification, 0, len(state.notifiers))
+
+	for _, n := range state.notifiers {
+		state.logger.Debug("notifying", "channel", n.name())
+
+		stopNotify := state.start(n.name())
+		err := notifyOne(ctx, n, timeout, data)
+
+		stopNotify()
+
+		outcome := notification{Channel: n.name(), Status: notifySent}
+		if err != nil {
+			state.warnings.warn("notifying "+n.name()+" failed", err)
+
+			outcome.Status = notifyFailed
+			outcome.Error = state.redactor.Redact(err.Error())
+		}
+
+		outcomes = append(outcomes, outcome)
+	}
+
+	return outcomes
+}
+
+// notifyOne calls n in its own goroutine, so that a notifier ignoring its context
+// is given up on once the timeout expires.
+func notifyOne(ctx context.Context, n notifier, timeout time.Duration, data webhookData) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("%w: %v", errNotifierPanicked, r)
+			}
+		}()
+
+		done <- n.notify(ctx, data)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("giving up: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,98 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/timing"
+)
+
+var errFakeNotifier = errors.New("fake notifier failed")
+
+// fakeNotifier records its calls and then behaves as its kind says.
+type fakeNotifier struct {
+	channel string
+	kind    string // "ok", "fail", "hang" or "panic"
+	calls   *[]string
+	mu      *sync.Mutex
+}
+
+func (f fakeNotifier) name() string { return f.channel }
+
+func (f fakeNotifier) render(webhookData) ([]message, error) { return nil, nil }
+
+func (f fakeNotifier) notify(_ context.Context, _ webhookData) error {
+	f.mu.Lock()
+	*f.calls = append(*f.calls, f.channel)
+	f.mu.Unlock()
+
+	switch f.kind {
+	case "fail":
+		return errFakeNotifier
+	case "hang":
+		// Ignores its context, like a stuck client would.
+		select {}
+	case "panic":
+		panic("secret-token in a bad state")
+	default:
+		return nil
+	}
+}
+
+func Test_notifyAll(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls []string
+		mu    sync.Mutex
+	)
+
+	fake := func(channel, kind string) notifier {
+		return fakeNotifier{channel: channel, kind: kind, calls: &calls, mu: &mu}
+	}
+
+	warnings := &warningCollector{logger: logging.Discard(), redactor: logging.NewRedactor("secret-token")}
+	state := &runState{
+		logger:   logging.Discard(),
+		redactor: warnings.redactor,
+		timings:  timing.New(fixedNow),
+		warnings: warnings,
+		notifiers: []notifier{
+			fake("failing", "fail"), fake("hanging", "hang"), fake("panicking", "panic"), fake("working", "ok"),
+		},
+	}
+
+	got := notifyAll(context.Background(), state, 10*time.Millisecond, webhookData{})
+
+	want := []string{"failing", "hanging", "panicking", "working"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("notified %v, want %v", calls, want)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("notifyAll() = %+v, want %d outcomes", got, len(want))
+	}
+
+	for i, status := range []string{notifyFailed, notifyFailed, notifyFailed, notifySent} {
+		if got[i].Channel != want[i] || got[i].Status != status {
+			t.Errorf("outcome %d = %+v, want %v %v", i, got[i], want[i], status)
+		}
+	}
+
+	if got[1].Error != "giving up: context deadline exceeded" {
+		t.Errorf("hanging outcome error = %q", got[1].Error)
+	}
+
+	if got[2].Error != "notifier panicked: [REDACTED] in a bad state" {
+		t.Errorf("panicking outcome error = %q, want it redacted", got[2].Error)
+	}
+
+	if len(warnings.lines) != 3 {
+		t.Errorf("warnings = %q, want one per failed channel", warnings.lines)
+	}
+}
@@ -0,0 +1,110 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// ntfy publishes the run result to an ntfy topic.
+type ntfy struct {
+	client    *http.Client
+	url       string
+	token     string
+	onSuccess bool
+}
+
+func newNtfy(opts *options, client *http.Client) *ntfy {
+	return &ntfy{
+		client:    client,
+		url:       opts.ntfyURL,
+		token:     opts.ntfyToken,
+		onSuccess: opts.ntfyOnSuccess,
+	}
+}
+
+func (n *ntfy) name() string {
+	return "ntfy"
+}
+
+// ntfyMessage is a message and the headers ntfy displays it with.
+type ntfyMessage struct {
+	title    string
+	priority string
+	tags     string
+	body     string
+}
+
+// notify publishes a high priority message on failure and, when enabled,
+// a low priority summary on success.
+func (n *ntfy) notify(ctx context.Context, data webhookData) error {
+	msg, ok := n.message(data)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	err := requests.URL(n.url).
+		Client(n.client).
+		Method(http.MethodPost).
+		Header("Title", msg.title).
+		Header("Priority", msg.priority).
+		Header("Tags", msg.tags).
+		Config(func(rb *requests.Builder) {
+			if n.token != "" {
+				rb.Bearer(n.token)
+			}
+		}).
+		BodyReader(strings.NewReader(msg.body)).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("publishing to ntfy: %w", err)
+	}
+
+	return nil
+}
+
+// render shows the headers ntfy displays before the message.
+func (n *ntfy) render(data webhookData) ([]message, error) {
+	msg, ok := n.message(data)
+	if !ok {
+		return nil, nil
+	}
+
+	body := fmt.Sprintf("Title: %v\nPriority: %v\nTags: %v\n\n%v", msg.title, msg.priority, msg.tags, msg.body)
+
+	return []message{{target: n.url, body: []byte(body)}}, nil
+}
+
+func (n *ntfy) message(data webhookData) (ntfyMessage, bool) {
+	failed := data.Status == statusError || data.Status == statusCancelled
+	if !failed && !n.onSuccess {
+		return ntfyMessage{}, false
+	}
+
+	msg := ntfyMessage{
+		title:    fmt.Sprintf("YNAB import %v: %v", data.Status, data.AccountName),
+		priority: "low",
+		tags:     "bank",
+		body:     fmt.Sprintf("Pushed %d transaction(s), found %d duplicate(s).", data.Pushed, data.Duplicates),
+	}
+
+	if data.Reconciled != "" {
+		msg.body += fmt.Sprintf(" Reconciled: %v€.", data.Reconciled)
+	}
+
+	if failed {
+		msg.title, msg.priority, msg.tags = "YNAB import failed: "+data.AccountName, "high", "bank,warning"
+
+		if data.Error != "" {
+			msg.body = fmt.Sprintf("Failed during %v: %v", data.Phase, data.Error)
+		}
+	}
+
+	return msg, true
+}
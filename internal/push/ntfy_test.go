@@ -0,0 +1,121 @@
+package push
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_ntfy(t *testing.T) {
+	t.Parallel()
+
+	const topic = "https://ntfy.example/bank"
+
+	tests := []struct {
+		name         string
+		pushStatus   int
+		extraArgs    []string
+		wantCalls    int
+		wantTitle    string
+		wantPriority string
+		wantTags     string
+		wantBody     string
+	}{
+		{
+			name:         "success",
+			pushStatus:   http.StatusOK,
+			extraArgs:    []string{"-ntfy-on-success"},
+			wantCalls:    1,
+			wantTitle:    "YNAB import ok: " + testAccountID,
+			wantPriority: "low",
+			wantTags:     "bank",
+			wantBody:     "Pushed 1 transaction(s), found 0 duplicate(s). Reconciled: 100.06€.",
+		},
+		{
+			name:         "success not notified",
+			pushStatus:   http.StatusOK,
+			extraArgs:    nil,
+			wantCalls:    0,
+			wantTitle:    "",
+			wantPriority: "",
+			wantTags:     "",
+			wantBody:     "",
+		},
+		{
+			name:         "failure",
+			pushStatus:   http.StatusInternalServerError,
+			extraArgs:    []string{"-push-backoff", "1ms"},
+			wantCalls:    1,
+			wantTitle:    "YNAB import failed: " + testAccountID,
+			wantPriority: "high",
+			wantTags:     "bank,warning",
+			wantBody:     "Failed during api call: after 4 attempt(s): pushing to YNAB: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				calls    int
+				received http.Header
+				body     []byte
+			)
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(
+				http.MethodPost,
+				"/v1/budgets/"+testBudgetID+"/transactions",
+				createdResponder(tt.pushStatus),
+			)
+			transport.RegisterResponder(http.MethodPost, topic, func(req *http.Request) (*http.Response, error) {
+				calls++
+				received = req.Header.Clone()
+				body, _ = io.ReadAll(req.Body)
+
+				return httpmock.NewStringResponse(http.StatusOK, "{}"), nil
+			})
+
+			args := append([]string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+				"-ntfy-url", topic, "-ntfy-token", "tk_secret",
+			}, tt.extraArgs...)
+
+			_ = run(context.Background(), args, env{
+				stdout:     io.Discard,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+
+			if calls != tt.wantCalls {
+				t.Fatalf("ntfy calls = %v, want %v", calls, tt.wantCalls)
+			}
+
+			if calls == 0 {
+				return
+			}
+
+			for name, want := range map[string]string{
+				"Title":         tt.wantTitle,
+				"Priority":      tt.wantPriority,
+				"Tags":          tt.wantTags,
+				"Authorization": "Bearer tk_secret",
+			} {
+				if got := received.Get(name); got != want {
+					t.Errorf("%v = %q, want %q", name, got, want)
+				}
+			}
+
+			if !strings.HasPrefix(string(body), tt.wantBody) {
+				t.Errorf("body = %q, want prefix %q", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package push
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// ofxDateFormat is the day part of OFX dates, which may go on with a time and zone.
+const ofxDateFormat = "20060102"
+
+// ofxMaxFITIDLen is the longest FITID kept as is in import IDs, leaving room for
+// the prefix of -import-id-salt. Longer ones are hashed to this length.
+const ofxMaxFITIDLen = 24
+
+var errInvalidOFX = errors.New("invalid OFX")
+
+//nolint:gochecknoglobals // constant replacer
+var ofxUnescaper = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&", "&nbsp;", " ")
+
+// ofxTransaction holds the elements of a STMTTRN aggregate push reads.
+type ofxTransaction struct {
+	posted, amount, fitid, name, memo string
+}
+
+func ofxFormat() format {
+	return format{
+		name:       formatOFX,
+		extensions: []string{".ofx", ".qfx"},
+		sniff: func(head []byte) bool {
+			head = trimBOM(head)
+
+			return bytes.HasPrefix(head, []byte("OFXHEADER:")) || bytes.Contains(head, []byte("<OFX>"))
+		},
+		newImporter: func(opts importerOptions) importer {
+			return importerFunc(func(ctx context.Context, reader io.Reader, accountID string) ([]Transaction, balance, error) {
+				return convertOFX(ctx, reader, accountID, opts)
+			})
+		},
+	}
+}
+
+// convertOFX reads an OFX statement, the SGML of OFX 1 or the XML of OFX 2, and turns
+// its transactions into YNAB transactions, their FITID making the import ID. The
+// ledger balance is the reconciled one.
+// Statements in another currency than -currency-filter are skipped.
+func convertOFX(
+	ctx context.Context,
+	reader io.Reader,
+	accountID string,
+	opts importerOptions,
+) ([]Transaction, balance, error) {
+	if reader == nil {
+		return nil, balance{}, nil
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, balance{}, fmt.Errorf("reading OFX: %w", err)
+	}
+
+	var (
+		transactions []Transaction
+		reconciled   balance
+		current      *ofxTransaction
+		currency     string
+		inLedger     bool
+		balanceAt    string
+		balanceValue string
+		read         int
+	)
+
+	importIDs := make(map[string]int)
+
+	for tag, value := range ofxElements(string(data)) {
+		switch {
+		case tag == "STMTTRN":
+			current = &ofxTransaction{}
+		case tag == "/STMTTRN" && current != nil:
+			read++
+
+			if err := ctx.Err(); err != nil {
+				return nil, balance{}, fmt.Errorf("after transaction %d: %w", read, err)
+			}
+
+			if currency != "" && currency != opts.currencyFilter {
+				_, _ = fmt.Fprintf(opts.warnings, "warning: skipping %v transaction in %v: %v\n",
+					currency, current.posted, current.name)
+
+				if opts.skipped != nil {
+					opts.skipped(skippedRow{
+						reason: skipCurrency, date: current.posted, amount: current.amount, payee: current.name,
+					})
+				}
+			} else {
+				transaction, err := convertOFXTransaction(*current, accountID, importIDs)
+				if err != nil {
+					return nil, balance{}, fmt.Errorf("transaction %d: %w", read, err)
+				}
+
+				transactions = append(transactions, transaction)
+			}
+
+			if opts.progress != nil {
+				opts.progress(lclynab.Progress{Lines: read, Transactions: len(transactions)})
+			}
+
+			current = nil
+		case tag == "CURDEF":
+			currency = value
+		case tag == "LEDGERBAL":
+			inLedger = true
+		case tag == "/LEDGERBAL":
+			inLedger = false
+		case current != nil:
+			current.set(tag, value)
+		case inLedger && tag == "BALAMT":
+			balanceValue = value
+		case inLedger && tag == "DTASOF":
+			balanceAt = value
+		}
+	}
+
+	if balanceValue != "" {
+		reconciled, err = ofxBalance(balanceValue, balanceAt)
+		if err != nil {
+			return nil, balance{}, err
+		}
+	}
+
+	return transactions, reconciled, nil
+}
+
+func (t *ofxTransaction) set(tag, value string) {
+	switch tag {
+	case "DTPOSTED":
+		t.posted = value
+	case "TRNAMT":
+		t.amount = value
+	case "FITID":
+		t.fitid = value
+	case "NAME":
+		t.name = value
+	case "MEMO":
+		t.memo = value
+	}
+}
+
+func convertOFXTransaction(t ofxTransaction, accountID string, importIDs map[string]int) (Transaction, error) {
+	day, err := parseOFXDate(t.posted)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	amount, err := lclynab.ParseAmount(t.amount)
+	if err != nil {
+		return Transaction{}, err //nolint:wrapcheck // already explicit
+	}
+
+	//nolint:wrapcheck // already explicit
+	return lclynab.NewTransaction(accountID, day, int(amount),
+		lclynab.WithPayee(t.name),
+		lclynab.WithMemo(cmp.Or(t.memo, t.name)),
+		lclynab.WithCleared(lclynab.ClearedCleared),
+		lclynab.WithImportID(ofxImportID(t.fitid, int(amount), day, importIDs)),
+	)
+}
+
+// ofxImportID derives the import ID from the FITID the bank gives the transaction,
+// falling back to the one of the CSV imports when it's missing.
+func ofxImportID(fitid string, amount int, day lclynab.Date, importIDs map[string]int) string {
+	if fitid == "" {
+		return lclynab.ImportID(amount, day, importIDs)
+	}
+
+	if len(fitid) > ofxMaxFITIDLen {
+		sum := sha256.Sum256([]byte(fitid))
+		fitid = hex.EncodeToString(sum[:])[:ofxMaxFITIDLen]
+	}
+
+	return "OFX:" + fitid
+}
+
+func ofxBalance(amount, asOf string) (balance, error) {
+	milliunits, err := lclynab.ParseAmount(amount)
+	if err != nil {
+		return balance{}, fmt.Errorf("parsing balance: %w", err)
+	}
+
+	reconciled := balance{milliunits: int(milliunits)}
+
+	if asOf != "" {
+		if reconciled.date, err = parseOFXDate(asOf); err != nil {
+			return balance{}, fmt.Errorf("parsing balance: %w", err)
+		}
+	}
+
+	return reconciled, nil
+}
+
+// parseOFXDate reads the day of an OFX date like 20241029 or 20241029120000[+1:CET].
+func parseOFXDate(s string) (lclynab.Date, error) {
+	if len(s) < len(ofxDateFormat) {
+		return lclynab.Date{}, fmt.Errorf("%w date: %q", errInvalidOFX, s)
+	}
+
+	day, err := time.Parse(ofxDateFormat, s[:len(ofxDateFormat)])
+	if err != nil {
+		return lclynab.Date{}, fmt.Errorf("%w date: %q", errInvalidOFX, s)
+	}
+
+	return lclynab.NewDate(day), nil
+}
+
+// ofxElements yields the tags of an OFX document, closing ones starting with a
+// slash, each with the text following it up to the next tag. The header of OFX 1
+// and the processing instructions of OFX 2 are skipped.
+func ofxElements(data string) func(yield func(tag, value string) bool) {
+	return func(yield func(tag, value string) bool) {
+		for {
+			start := strings.IndexByte(data, '<')
+			if start < 0 {
+				return
+			}
+
+			end := strings.IndexByte(data[start:], '>')
+			if end < 0 {
+				return
+			}
+
+			tag := strings.TrimSpace(data[start+1 : start+end])
+			data = data[start+end+1:]
+
+			if strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+				continue
+			}
+
+			value := data
+			if next := strings.IndexByte(data, '<'); next >= 0 {
+				value = data[:next]
+			}
+
+			if !yield(strings.ToUpper(tag), ofxUnescaper.Replace(strings.TrimSpace(value))) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//nolint:funlen // mostly test cases in list
+func Test_convertOFX(t *testing.T) {
+	t.Parallel()
+
+	const xmlStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>EUR</CURDEF><BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20241031</DTPOSTED><TRNAMT>-12.50</TRNAMT>` +
+		`<NAME>CB BOULANGERIE</NAME><MEMO>CB BOULANGERIE 30/10</MEMO></STMTTRN>
+</BANKTRANLIST><LEDGERBAL><BALAMT>-12.50</BALAMT><DTASOF>20241031</DTASOF></LEDGERBAL></STMTRS></STMTTRNRS>` +
+		`</BANKMSGSRSV1></OFX>`
+
+	fixture, err := os.ReadFile("./testdata/lcl.ofx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		input            string
+		wantTransactions []Transaction
+		wantReconciled   balance
+		wantWarnings     string
+		wantErr          error
+	}{
+		{
+			name:  "sgml",
+			input: string(fixture),
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-29"),
+					Amount:    80000,
+					PayeeName: "VIREMENT M JEAN MARTIN",
+					Memo:      "VIREMENT M JEAN MARTIN OU MME",
+					Cleared:   "cleared",
+					ImportID:  "OFX:0000001",
+				},
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-31"),
+					Amount:    -12500,
+					PayeeName: "CB BOULANGERIE & CIE",
+					Memo:      "CB BOULANGERIE & CIE",
+					Cleared:   "cleared",
+					ImportID:  "OFX:0000002",
+				},
+			},
+			wantReconciled: balance{milliunits: 100060, date: mustDate("2024-11-29")},
+		},
+		{
+			name:  "xml",
+			input: xmlStatement,
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-31"),
+					Amount:    -12500,
+					PayeeName: "CB BOULANGERIE",
+					Memo:      "CB BOULANGERIE 30/10",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:-12500:2024-10-31:1",
+				},
+			},
+			wantReconciled: balance{milliunits: -12500, date: mustDate("2024-10-31")},
+		},
+		{
+			name: "long FITID",
+			input: strings.Replace(xmlStatement, "<NAME>",
+				"<FITID>20241031-CB-BOULANGERIE-0000000000000002</FITID><NAME>", 1),
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-31"),
+					Amount:    -12500,
+					PayeeName: "CB BOULANGERIE",
+					Memo:      "CB BOULANGERIE 30/10",
+					Cleared:   "cleared",
+					ImportID:  "OFX:64ac65ccdfbf16adfe969464",
+				},
+			},
+			wantReconciled: balance{milliunits: -12500, date: mustDate("2024-10-31")},
+		},
+		{
+			name:           "other currency",
+			input:          strings.Replace(xmlStatement, "<CURDEF>EUR", "<CURDEF>USD", 1),
+			wantReconciled: balance{milliunits: -12500, date: mustDate("2024-10-31")},
+			wantWarnings:   "warning: skipping USD transaction in 20241031: CB BOULANGERIE\n",
+		},
+		{
+			name:    "invalid date",
+			input:   strings.Replace(xmlStatement, "<DTPOSTED>20241031", "<DTPOSTED>2024", 1),
+			wantErr: errInvalidOFX,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var warnings bytes.Buffer
+
+			transactions, reconciled, err := convertOFX(context.Background(), strings.NewReader(tt.input), "acc-id",
+				importerOptions{currencyFilter: "EUR", warnings: &warnings})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("convertOFX() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(transactions, tt.wantTransactions) {
+				t.Errorf("convertOFX() transactions = %+v, want %+v", transactions, tt.wantTransactions)
+			}
+
+			if reconciled != tt.wantReconciled {
+				t.Errorf("convertOFX() reconciled = %+v, want %+v", reconciled, tt.wantReconciled)
+			}
+
+			if warnings.String() != tt.wantWarnings {
+				t.Errorf("warnings = %q, want %q", warnings.String(), tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func Test_convertOFX_nilReader(t *testing.T) {
+	t.Parallel()
+
+	transactions, reconciled, err := convertOFX(context.Background(), nil, "acc-id", importerOptions{warnings: io.Discard})
+	if err != nil || transactions != nil || reconciled != (balance{}) {
+		t.Errorf("convertOFX(nil) = %v, %v, %v, want nothing", transactions, reconciled, err)
+	}
+}
@@ -0,0 +1,38 @@
+package push
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/preflight"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// runPreflight checks that the YNAB API can be reached and that the clock agrees with
+// it, before any conversion. A skewed clock is only a warning: YNAB rejects the
+// transactions it would date in the future on its own.
+func runPreflight(ctx context.Context, env env, state *runState) error {
+	stop := state.start("preflight")
+
+	checks, err := preflight.Run(ctx, lclynab.DefaultBaseURL, preflight.Options{
+		Client: env.httpClient,
+		Dial:   env.dial,
+		Now:    env.now,
+	})
+
+	stop()
+
+	preflight.Write(env.stdout, checks)
+
+	for _, check := range checks {
+		if check.Warning {
+			state.warnings.warn("preflight "+check.Name, check.Err)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("preflight: %w", err)
+	}
+
+	return nil
+}
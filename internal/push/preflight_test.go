@@ -0,0 +1,104 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/preflight"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_preflight(t *testing.T) {
+	t.Parallel()
+
+	connected := func(context.Context, string, string) (net.Conn, error) {
+		client, server := net.Pipe()
+		_ = server.Close()
+
+		return client, nil
+	}
+
+	tests := []struct {
+		name       string
+		dial       func(ctx context.Context, network, address string) (net.Conn, error)
+		hostTime   string
+		wantErr    error
+		wantPushed bool
+		wantOut    string
+	}{
+		{
+			name:       "in sync",
+			dial:       connected,
+			hostTime:   "Sat, 30 Nov 2024 03:00:10 GMT",
+			wantPushed: true,
+			wantOut:    "preflight: clock api.youneedabudget.com: ok",
+		},
+		{
+			name:       "skewed clock",
+			dial:       connected,
+			hostTime:   "Sat, 30 Nov 2024 02:00:00 GMT",
+			wantPushed: true,
+			wantOut:    "warning: clock skew: local clock 1h0m0s ahead of api.youneedabudget.com",
+		},
+		{
+			name: "DNS failure",
+			dial: func(context.Context, string, string) (net.Conn, error) {
+				return nil, &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}
+			},
+			wantErr: preflight.ErrResolve,
+			wantOut: "preflight: connect api.youneedabudget.com:443: failed: resolving host failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodHead, "/v1", func(*http.Request) (*http.Response, error) {
+				resp := httpmock.NewStringResponse(http.StatusUnauthorized, "")
+				resp.Header.Set("Date", tt.hostTime)
+
+				return resp, nil
+			})
+			transport.RegisterResponder(
+				http.MethodPost,
+				"/v1/budgets/"+testBudgetID+"/transactions",
+				createdResponder(http.StatusOK),
+			)
+
+			var stdout bytes.Buffer
+
+			err := run(context.Background(), []string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-preflight",
+				"-state", filepath.Join(t.TempDir(), "state.json"), "-f", "./testdata/one-positive.csv",
+			}, env{
+				stdout:     &stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				dial:       tt.dial,
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			pushes := transport.GetCallCountInfo()["POST /v1/budgets/"+testBudgetID+"/transactions"]
+			if pushed := pushes > 0; pushed != tt.wantPushed {
+				t.Errorf("pushed = %v, want %v", pushed, tt.wantPushed)
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantOut) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantOut)
+			}
+		})
+	}
+}
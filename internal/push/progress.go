@@ -0,0 +1,105 @@
+package push
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// progressFrameEvery is how many lines of the export are read between two frames
+// of the progress line, so that large files don't flood the terminal.
+const progressFrameEvery = 100
+
+// progressLine shows how far the run is. When stdout is a terminal, a single line
+// is redrawn in place there; otherwise, plain lines are printed on stderr every
+// -progress lines of the export. A nil *progressLine prints nothing, as with -q.
+type progressLine struct {
+	w io.Writer
+	// lines receives the plain lines when w isn't a terminal.
+	lines io.Writer
+	// width returns the columns of the terminal, asked for each frame so that a
+	// resize is seen. Nil means w isn't a terminal.
+	width func() (int, bool)
+	every int
+	// drawn is whether a frame is on screen, not yet ended by a newline.
+	drawn bool
+}
+
+// newProgressLine returns the progress line of the run, nil with -q.
+func newProgressLine(opts *options, env env) *progressLine {
+	if opts.quiet {
+		return nil
+	}
+
+	progress := &progressLine{w: env.stdout, lines: env.stderr, every: opts.progressEvery}
+
+	if env.terminal != nil {
+		if _, ok := env.terminal(); ok {
+			progress.width = env.terminal
+		}
+	}
+
+	return progress
+}
+
+// stdoutTerminal is the terminal of env in main.
+func stdoutTerminal() (int, bool) {
+	return terminalWidth(os.Stdout)
+}
+
+// converting returns the callback of the importer, nil when nothing would be printed.
+func (p *progressLine) converting() func(lclynab.Progress) {
+	if p == nil {
+		return nil
+	}
+
+	if p.width == nil {
+		return progressPrinter(p.lines, p.every)
+	}
+
+	return func(progress lclynab.Progress) {
+		if progress.Lines%progressFrameEvery == 0 {
+			p.draw(fmt.Sprintf("converting: %d line(s) read, %d transaction(s)", progress.Lines, progress.Transactions))
+		}
+	}
+}
+
+// pushing shows the transactions being sent to YNAB, on a terminal only.
+func (p *progressLine) pushing(n int) {
+	if p == nil || p.width == nil {
+		return
+	}
+
+	p.draw(fmt.Sprintf("pushing %d transaction(s) to YNAB", n))
+}
+
+// draw replaces the line on screen with text, cut to the width of the terminal so
+// that it never wraps. It stops drawing if the output stops being a terminal.
+func (p *progressLine) draw(text string) {
+	width, ok := p.width()
+	if !ok {
+		return
+	}
+
+	if runes := []rune(text); len(runes) > width-1 {
+		text = string(runes[:max(width-1, 0)])
+	}
+
+	// \r goes back to the start of the line and \x1b[K clears what's left of the
+	// previous frame.
+	_, _ = fmt.Fprint(p.w, "\r\x1b[K"+text)
+	p.drawn = true
+}
+
+// done ends the frame on screen with a newline, so that what's printed next starts
+// on its own line. It may be called more than once.
+func (p *progressLine) done() {
+	if p == nil || !p.drawn {
+		return
+	}
+
+	_, _ = fmt.Fprintln(p.w)
+	p.drawn = false
+}
@@ -0,0 +1,124 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_progressLine_frames(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	width := 40
+	progress := &progressLine{w: &out, width: func() (int, bool) { return width, true }}
+	convert := progress.converting()
+
+	for lines := 1; lines <= 250; lines++ {
+		if lines == 150 {
+			width = 20
+		}
+
+		convert(lclynab.Progress{Lines: lines, Transactions: lines - 1})
+	}
+
+	progress.done()
+	progress.done()
+	progress.pushing(3000)
+	progress.done()
+
+	want := "\r\x1b[Kconverting: 100 line(s) read, 99 transa" +
+		"\r\x1b[Kconverting: 200 lin\n" +
+		"\r\x1b[Kpushing 3000 transa\n"
+	if out.String() != want {
+		t.Errorf("frames = %q, want %q", out.String(), want)
+	}
+}
+
+func Test_progressLine_notTerminal(t *testing.T) {
+	t.Parallel()
+
+	var stdout, stderr bytes.Buffer
+
+	progress := newProgressLine(&options{progressEvery: 2}, env{
+		stdout: &stdout, stderr: &stderr, terminal: func() (int, bool) { return 0, false },
+		getenv: testGetenv(t),
+	})
+
+	convert := progress.converting()
+	for lines := 1; lines <= 3; lines++ {
+		convert(lclynab.Progress{Lines: lines, Transactions: lines})
+	}
+
+	progress.pushing(3)
+	progress.done()
+
+	if stdout.Len() > 0 {
+		t.Errorf("stdout = %q, want nothing", stdout.String())
+	}
+
+	if want := "converting: 2 line(s) read, 2 transaction(s)\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+}
+
+func Test_run_progressLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		quiet      bool
+		wantStdout string
+	}{
+		{
+			name: "terminal",
+			wantStdout: "reconciled: 100.06€ as of 2024-11-29\n" +
+				"\r\x1b[Kpushing 1 transacti\n" +
+				"successfully pushed 1 transaction(s)\nfound 0 duplicate(s)\n",
+		},
+		{
+			name:       "quiet",
+			quiet:      true,
+			wantStdout: "reconciled: 100.06€ as of 2024-11-29\nsuccessfully pushed 1 transaction(s)\nfound 0 duplicate(s)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+				createdResponder(http.StatusOK))
+
+			args := []string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv"}
+			if tt.quiet {
+				args = append(args, "-q")
+			}
+
+			stdout := &bytes.Buffer{}
+
+			err := run(context.Background(), args, env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				terminal:   func() (int, bool) { return 20, true },
+				getenv:     testGetenv(t),
+			})
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+		})
+	}
+}
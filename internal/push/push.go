@@ -0,0 +1,1697 @@
+// Package push converts bank exports and pushes them to YNAB, as the push command.
+package push
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/config"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/paths"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/retention"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/timing"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+const (
+	milliUnit  = 1000
+	apiTimeout = 10 * time.Second
+
+	defaultCategorizeTimeout = 5 * time.Second
+	failureWebhookTimeout    = 3 * time.Second
+)
+
+// Exit codes, also listed in -help.
+const (
+	exitGeneric            = 1
+	exitNotificationFailed = 2
+	exitNothingToPush      = 3
+	exitAuth               = 4
+	exitRateLimited        = 5
+	exitTooManyDuplicates  = 6
+	exitDriftExceeded      = 7
+	exitDiscrepancies      = 8
+	exitCapExceeded        = 9
+	exitReportChanged      = 10
+	// exitCancelled is the conventional exit code of a process interrupted by SIGINT.
+	exitCancelled = 130
+)
+
+// ExitCodesHelp lists the exit codes of ExitCode, for -help.
+const ExitCodesHelp = `
+Exit codes:
+  0    success
+  1    generic or conversion error
+  2    transactions pushed, a notification failed
+  3    nothing to push
+  4    YNAB authentication error
+  5    YNAB rate limit reached
+  6    more duplicates than -max-duplicates
+  7    transactions pushed, YNAB drifted from the bank by more than -drift-alert
+  8    -diff found discrepancies between the file and YNAB
+  9    transactions pushed, a monthly cap of -caps exceeded with -fail-on-cap
+  10   -compare-report found differences with the previous report
+  130  cancelled
+`
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// Environment variables standing for flags left out.
+const (
+	envToken     = "LCL_YNAB_TOKEN"
+	envBudgetID  = "LCL_YNAB_BUDGET_ID"
+	envAccountID = "LCL_YNAB_ACCOUNT_ID"
+)
+
+var (
+	errRequiredFlag    = errors.New("flag is required")
+	errUnknownFormat   = errors.New("unknown format")
+	errTooManyFiles    = errors.New("only one input file is supported")
+	errConflictingFile = errors.New("input file given twice")
+
+	errUnknownOutput     = errors.New("unknown output mode")
+	errConflictingOutput = errors.New("both write JSON to stdout")
+	errConflictingSalt   = errors.New("import ID salt given twice")
+	errConflictingToken  = errors.New("token given twice")
+	errConflictingMode   = errors.New("output mode given twice")
+	errInvalidTokenFile  = errors.New("invalid token file")
+	errInvalidTimeout    = errors.New("invalid timeout")
+	errInvalidAttempts   = errors.New("invalid number of attempts")
+	errInvalidDrift      = errors.New("invalid drift threshold")
+	errInvalidProgress   = errors.New("invalid progress interval")
+	errInvalidCacheTTL   = errors.New("invalid cache TTL")
+	errNotConfirmed      = errors.New("confirmation required")
+	errConflictingUndo   = errors.New("both select the run to undo")
+	errInvalidWindow     = errors.New("invalid window")
+
+	errNotificationFailed = errors.New("transactions pushed but notification failed")
+	errNothingToPush      = errors.New("nothing to push")
+	errTooManyDuplicates  = errors.New("too many duplicates")
+)
+
+type options struct {
+	configPath      string
+	syncAccount     string
+	filename        string
+	budgetID        string
+	accountID       string
+	token           string
+	webhook         string
+	verbose         bool
+	format          string
+	includePending  bool
+	currencyFilter  string
+	strictWebhook   bool
+	timings         bool
+	report          string
+	output          string
+	logLevel        string
+	logFormat       string
+	noTruncate      bool
+	maxDuplicates   int
+	driftAlert      float64
+	caps            string
+	accountMap      string
+	tokenFile       string
+	rules           string
+	stripHolder     holderFlags
+	compareReport   string
+	failOnCap       bool
+	progressEvery   int
+	quiet           bool
+	demo            bool
+	cacheTTL        time.Duration
+	noCache         bool
+	demoDir         string
+	encoding        string
+	sortOrder       string
+	profile         string
+	printPaths      bool
+	runID           string
+	statePath       string
+	resolveNames    bool
+	useBudgetFormat bool
+	webhookHeaders  headerFlags
+	importIDSalt    string
+	idStrategy      string
+	foldRates       bool
+	forceNewIDs     bool
+	yes             bool
+	dryRun          bool
+	diff            bool
+	undoRun         string
+	undoLast        bool
+	verify          bool
+	stats           bool
+	update          bool
+	checkOnly       bool
+	verifyWebhook   bool
+	preflight       bool
+	listBudgets     bool
+	listAccounts    bool
+	jsonOutput      bool
+	force           bool
+	cleanup         bool
+
+	// retentionReports bounds the reports of -watch, see ownArtifacts.
+	retentionReports       retention.Policy
+	retentionDemoMaxAge    time.Duration
+	retentionWatchedMaxAge time.Duration
+
+	webhookTemplate    string
+	webhookContentType string
+	webhookAttempts    int
+	webhookBackoff     time.Duration
+	webhookTimeout     time.Duration
+	notifyTimeout      time.Duration
+	pushBackoff        time.Duration
+	watch              string
+	watchGlob          string
+	watchInterval      time.Duration
+	watchSettle        time.Duration
+	webhookAlways      bool
+	webhookFailure     string
+	webhookCACert      string
+
+	caCert             string
+	insecureSkipVerify bool
+
+	haURL          string
+	haToken        string
+	haEntityPrefix string
+
+	mqttURL      string
+	mqttUsername string
+	mqttPassword string
+	mqttTopic    string
+	mqttCAFile   string
+
+	ntfyURL       string
+	ntfyToken     string
+	ntfyOnSuccess bool
+
+	telegramToken        string
+	telegramChatID       string
+	telegramFailuresOnly bool
+	telegramDetails      bool
+
+	smtpHost       string
+	smtpPort       int
+	smtpTLS        string
+	smtpUsername   string
+	smtpPassword   string
+	emailFrom      string
+	emailTo        string
+	emailOnSuccess bool
+
+	discordWebhook string
+	slackWebhook   string
+	gotifyURL      string
+	gotifyToken    string
+
+	categorizeCmd     string
+	categorizeTimeout time.Duration
+	categorizeBatch   bool
+
+	suggestCategories     bool
+	suggestMinOccurrences int
+
+	detectRefunds  int
+	refundCategory bool
+
+	lang string
+}
+
+// Main runs the push command with the arguments and environment of the process, then
+// exits with the code of its outcome.
+func Main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := run(ctx, os.Args[1:], processEnv())
+
+	stop()
+
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, ErrorText(err))
+		os.Exit(ExitCode(err))
+	}
+}
+
+// processEnv is the env of the process.
+func processEnv() env {
+	return env{
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+		httpClient: http.DefaultClient,
+		now:        time.Now,
+		fsys:       osFS{},
+		getenv:     os.Getenv,
+		terminal:   stdoutTerminal,
+		stdin:      os.Stdin,
+		stdinPiped: stdinPiped(),
+	}
+}
+
+// Env is what a command running push, like sync, provides to Run. The other
+// dependencies are those of the process, stdin excepted.
+type Env struct {
+	Stdout     io.Writer
+	Stderr     io.Writer
+	HTTPClient *http.Client
+	Getenv     func(string) string
+	// OnResult receives the result of each file pushed, nil to ignore them.
+	OnResult func(*Result)
+}
+
+// Run runs the push command with args, as Main does without exiting.
+func Run(ctx context.Context, args []string, e Env) error {
+	return run(ctx, args, e.env())
+}
+
+// Check reads args as Run does, without running anything, for commands running push
+// to report mistakes before their own work.
+func Check(args []string, e Env) error {
+	_, err := parseFlags(args, e.env())
+
+	return err
+}
+
+func (e Env) env() env {
+	env := processEnv()
+	env.stdout, env.stderr = e.Stdout, e.Stderr
+	env.httpClient = e.HTTPClient
+	env.getenv = e.Getenv
+	env.onResult = e.OnResult
+	env.stdin, env.stdinPiped = nil, false
+
+	return env
+}
+
+// Flags returns the flags of the command, unbound, for commands running push to
+// accept them too.
+func Flags() *flag.FlagSet {
+	return newFlagSet(&options{})
+}
+
+// configAliases are the config keys standing for the one-letter flags.
+func configAliases() map[string]string {
+	return map[string]string{"file": "f", "budget_id": "b", "account_id": "a", "token": "t", "webhook": "w"}
+}
+
+// ExitCode maps an error returned by Run to the process exit code.
+func ExitCode(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitCancelled
+	case lclynab.IsAuth(err):
+		return exitAuth
+	case lclynab.IsRateLimited(err):
+		return exitRateLimited
+	case errors.Is(err, errTooManyDuplicates):
+		return exitTooManyDuplicates
+	case errors.Is(err, errDriftExceeded):
+		return exitDriftExceeded
+	case errors.Is(err, errDiscrepancies):
+		return exitDiscrepancies
+	case errors.Is(err, errCapExceeded):
+		return exitCapExceeded
+	case errors.Is(err, errReportChanged):
+		return exitReportChanged
+	case errors.Is(err, errNotificationFailed):
+		return exitNotificationFailed
+	case errors.Is(err, errNothingToPush):
+		return exitNothingToPush
+	default:
+		return exitGeneric
+	}
+}
+
+// env holds the process dependencies of a run, replaced in tests.
+type env struct {
+	stdout     io.Writer
+	stderr     io.Writer
+	httpClient *http.Client
+	now        func() time.Time
+	// fsys reads the input file, templates, secrets, CAs and state, nil meaning the OS files.
+	fsys fs.FS
+	// middlewares wrap the transport of every HTTP call: YNAB, the webhook and the notifiers.
+	middlewares []lclynab.Middleware
+	// getenv reads the locale for -lang and the variables standing for flags,
+	// nil meaning an empty environment.
+	getenv func(string) string
+	// executable returns the path -update replaces, nil meaning os.Executable.
+	executable func() (string, error)
+	// terminal returns the width of stdout when it's a terminal, nil meaning it never is.
+	terminal func() (int, bool)
+	// stdin is read with -f -, nil meaning there is none.
+	stdin io.Reader
+	// stdinPiped makes stdin the input when no file is given.
+	stdinPiped bool
+	// dial connects to the YNAB API for -preflight, nil meaning a net.Dialer.
+	dial func(ctx context.Context, network, address string) (net.Conn, error)
+	// onResult receives the result of each file pushed, nil to ignore them.
+	onResult func(*Result)
+}
+
+// variable returns the environment variable name, empty when env has no environment.
+func (e env) variable(name string) string {
+	if e.getenv == nil {
+		return ""
+	}
+
+	return e.getenv(name)
+}
+
+func run(ctx context.Context, args []string, env env) error {
+	opts, err := parseFlags(args, env)
+	if err != nil {
+		return err
+	}
+
+	if env.fsys == nil {
+		env.fsys = osFS{}
+	}
+
+	if opts.statePath == "" && opts.demo {
+		opts.statePath = demoStatePath(opts)
+	}
+
+	// The profile directories are only needed for the default state file.
+	if opts.statePath == "" || opts.printPaths {
+		dirs, err := paths.ResolveEnv(opts.profile, env.variable, runtime.GOOS)
+		if err != nil {
+			return fmt.Errorf("resolving paths: %w", err)
+		}
+
+		if opts.statePath == "" {
+			opts.statePath = dirs.PushState()
+		}
+
+		if opts.printPaths {
+			dirs.Print(env.stdout, paths.Entry{Label: "state file", Path: opts.statePath})
+
+			return nil
+		}
+	}
+
+	if opts.update {
+		return update(ctx, opts, env)
+	}
+
+	if opts.cleanup {
+		logger, err := logging.New(env.stderr, logLevel(opts), opts.logFormat, secretFlags(opts)...)
+		if err != nil {
+			return fmt.Errorf("configuring logs: %w", err)
+		}
+
+		return cleanup(env.stdout, logger, opts, env.fsys, env.now(), opts.dryRun)
+	}
+
+	if opts.demo {
+		stopDemo, err := startDemo(opts, &env)
+		if err != nil {
+			return err
+		}
+		defer stopDemo()
+	}
+
+	if opts.verify {
+		return verify(ctx, opts, env)
+	}
+
+	if opts.listBudgets {
+		return listBudgets(ctx, opts, env)
+	}
+
+	if opts.listAccounts {
+		return listAccounts(ctx, opts, env)
+	}
+
+	if opts.stats {
+		return stats(ctx, opts, env)
+	}
+
+	if opts.watch != "" {
+		return watch(ctx, opts, env)
+	}
+
+	if !opts.dryRun {
+		// Last, so that the report of this run counts.
+		defer cleanupAfterRun(opts, env)
+	}
+
+	if opts.jsonOutput {
+		return runJSON(ctx, opts, env)
+	}
+
+	return runFile(ctx, opts, env)
+}
+
+// cleanupAfterRun deletes the artifacts expired at the end of a run, only logging a failure.
+func cleanupAfterRun(opts *options, env env) {
+	logger, err := logging.New(env.stderr, logLevel(opts), opts.logFormat, secretFlags(opts)...)
+	if err != nil {
+		return
+	}
+
+	if err := cleanup(io.Discard, logger, opts, env.fsys, env.now(), false); err != nil {
+		logger.Warn("expired artifacts left", "error", err)
+	}
+}
+
+// checkRetention returns an error for a negative retention flag.
+func checkRetention(opts *options) error {
+	for _, policy := range []retention.Policy{
+		opts.retentionReports, {MaxAge: opts.retentionDemoMaxAge}, {MaxAge: opts.retentionWatchedMaxAge},
+	} {
+		if err := policy.Check(); err != nil {
+			return err //nolint:wrapcheck // already explicit
+		}
+	}
+
+	return nil
+}
+
+// runJSON is runFile with -json: stdout only gets the outcome of the run as one JSON object.
+func runJSON(ctx context.Context, opts *options, env env) error {
+	reporter := &jsonReporter{w: env.stdout}
+	env.stdout = reporter
+
+	onResult := env.onResult
+	env.onResult = func(res *Result) {
+		reporter.result = res
+
+		if onResult != nil {
+			onResult(res)
+		}
+	}
+
+	err := runFile(ctx, opts, env)
+	if flushErr := reporter.flush(err); flushErr != nil {
+		return errors.Join(err, flushErr)
+	}
+
+	return err
+}
+
+// runFile converts and pushes opts.filename, then reports the outcome and notifies it.
+func runFile(ctx context.Context, opts *options, env env) error {
+	hook, err := newWebhook(opts, env.fsys, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	caps, err := loadCaps(env.fsys, opts.caps)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := loadAccountMap(env.fsys, opts.accountMap)
+	if err != nil {
+		return err
+	}
+
+	rules, err := loadRules(env.fsys, opts.rules)
+	if err != nil {
+		return err
+	}
+
+	stripHolder, err := newHolderStripper(opts.stripHolder)
+	if err != nil {
+		return err
+	}
+
+	secrets := append(secretFlags(opts), headerValues(hook.headers)...)
+
+	logs := newLogTail(logTailLines)
+
+	logger, err := logging.New(io.MultiWriter(env.stderr, logs), logLevel(opts), opts.logFormat, secrets...)
+	if err != nil {
+		return fmt.Errorf("configuring logs: %w", err)
+	}
+
+	// Errors and warnings also reach the report and notifications, redacted alike.
+	redactor := logging.NewRedactor(secrets...)
+
+	if opts.runID == "" {
+		opts.runID = logging.NewRunID()
+	}
+
+	logger = logger.With(logging.RunIDKey, opts.runID)
+
+	// Notifications get their own client, as they can go to self-hosted endpoints
+	// signed by another CA than YNAB.
+	notifyCA := cmp.Or(opts.webhookCACert, opts.caCert)
+
+	notifyClient, err := withTLS(env.httpClient, env.fsys, notifyCA, opts.insecureSkipVerify)
+	if err != nil {
+		return fmt.Errorf("configuring notifications TLS: %w", err)
+	}
+
+	env.httpClient, err = withTLS(env.httpClient, env.fsys, opts.caCert, opts.insecureSkipVerify)
+	if err != nil {
+		return fmt.Errorf("configuring TLS: %w", err)
+	}
+
+	// Middlewares go last, withTLS needs the bare transport.
+	env.httpClient = lclynab.WrapClient(env.httpClient, env.middlewares...)
+	notifyClient = lclynab.WrapClient(notifyClient, env.middlewares...)
+
+	if opts.insecureSkipVerify {
+		logger.Warn("TLS certificates are not verified")
+	}
+
+	if opts.undoRun != "" || opts.undoLast {
+		return undo(ctx, opts, env)
+	}
+
+	// A dry run doesn't call YNAB, it goes on with the names.
+	if !opts.dryRun {
+		if err := resolveIDs(ctx, lclynab.NewClient(opts.token, env.httpClient), opts, env.stdout); err != nil {
+			return fmt.Errorf("resolving names: %w", err)
+		}
+	}
+
+	state := &runState{
+		logger:       logger,
+		timings:      timing.New(env.now),
+		redactor:     redactor,
+		warnings:     &warningCollector{logger: logger, redactor: redactor},
+		result:       newResult(opts.runID, env.now(), opts.filename, opts.budgetID, opts.accountID),
+		webhook:      hook,
+		notifyClient: notifyClient,
+		notifiers:    newNotifiers(opts, notifyClient, env.fsys, logger, logs),
+		messages:     messages{lang: resolveLang(opts.lang, env.getenv)},
+		caps:         caps,
+		accounts:     accounts,
+		rules:        rules,
+		stripHolder:  stripHolder,
+	}
+	res, timings := state.result, state.timings
+
+	stdout := env.stdout
+	if opts.output == outputJSON {
+		// Human-readable lines move to stderr, stdout only gets the JSON result.
+		env.stdout = env.stderr
+	}
+
+	err = pushFile(ctx, opts, env, state)
+	if err != nil && !opts.dryRun && !opts.diff {
+		notifyFailure(ctx, opts, env, state, err)
+	}
+
+	res.finish(env.now(), timings.Spans(), state.warnings.lines, err, redactor)
+
+	if env.onResult != nil {
+		env.onResult(res)
+	}
+
+	if opts.timings {
+		_, _ = fmt.Fprintln(env.stdout, "timings:")
+		_ = timings.WriteTable(env.stdout)
+	}
+
+	if opts.output == outputJSON {
+		if outputErr := writeResult(stdout, res); outputErr != nil {
+			return errors.Join(err, outputErr)
+		}
+	}
+
+	if opts.report != "" {
+		if reportErr := writeReport(opts.report, stdout, res); reportErr != nil {
+			return errors.Join(err, reportErr)
+		}
+	}
+
+	return state.messages.localize(err)
+}
+
+// logLevel returns the level of -log-level, debug with -v.
+func logLevel(opts *options) string {
+	if opts.verbose {
+		return "debug"
+	}
+
+	return opts.logLevel
+}
+
+// runState gathers what a run records along the way.
+type runState struct {
+	logger    *slog.Logger
+	redactor  *logging.Redactor
+	timings   *timing.Recorder
+	warnings  *warningCollector
+	result    *Result
+	webhook   *webhook
+	notifiers []notifier
+	// notifyClient calls the webhook and notifiers, with their own TLS options.
+	notifyClient *http.Client
+
+	// amounts formats the amounts printed for people.
+	amounts amountFormat
+	// messages words the lines printed for people.
+	messages  messages
+	converted bool
+	skipped   []skippedRow
+	caps      []capRule
+	// accounts gives the account of files pushed without -a.
+	accounts accountMap
+	rules    []categoryRule
+	// stripHolder removes the account holders ending the payees of LCL exports, nil to keep them.
+	stripHolder func(payee string) string
+	// capsExceeded holds the monthly caps exceeded by the file, as errCapExceeded.
+	capsExceeded error
+	duplicates   []Transaction
+	webhookSent  bool
+	notified     bool
+	// phase is the step the run is in, reported when it fails.
+	phase string
+}
+
+// start records that the run entered phase and times it until the returned function is called.
+func (s *runState) start(phase string) func() {
+	s.phase = phase
+
+	return s.timings.Start(phase)
+}
+
+func pushFile(ctx context.Context, opts *options, env env, state *runState) error {
+	logger, res := state.logger, state.result
+
+	if opts.preflight && !opts.dryRun {
+		if err := runPreflight(ctx, env, state); err != nil {
+			return err
+		}
+	}
+
+	stopConversion := state.start("conversion")
+	defer stopConversion()
+
+	progress := newProgressLine(opts, env)
+	defer progress.done()
+
+	file, err := openInput(env, opts.filename)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	// A forced encoding is decoded before sniffing, so that detection sees UTF-8.
+	reader := bufio.NewReader(decode(&contextReader{ctx: ctx, reader: file}, opts.encoding))
+
+	inputFormat, err := selectFormat(opts, reader)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("converting file", "path", opts.filename, "format", inputFormat.name)
+
+	var truncated io.Writer
+	if opts.verbose {
+		truncated = env.stdout
+	}
+
+	imp := inputFormat.newImporter(importerOptions{
+		includePending: opts.includePending,
+		currencyFilter: opts.currencyFilter,
+		warnings:       state.warnings,
+		truncated:      truncated,
+		skipped: func(row skippedRow) {
+			res.Counts.Filtered++
+			state.skipped = append(state.skipped, row)
+		},
+		progress:    progress.converting(),
+		stripHolder: state.stripHolder,
+		hashIDs:     opts.idStrategy == importIDHash,
+		foldRates:   opts.foldRates,
+	})
+
+	transactions, reconciled, err := imp.convert(ctx, reader, cmp.Or(opts.accountID, unmappedAccountID))
+
+	progress.done()
+
+	if err != nil {
+		return fmt.Errorf("converting to YNAB transactions: %w", err)
+	}
+
+	if opts.accountID == "" {
+		if err := mapAccount(opts, state, transactions, reconciled.accountRef); err != nil {
+			return err
+		}
+	}
+
+	sortTransactions(transactions, opts.sortOrder)
+	stopConversion()
+
+	// A dry run doesn't call YNAB, not even to get the names.
+	if opts.resolveNames && !opts.dryRun {
+		budgetName, accountName, err := resolveNames(ctx, env.httpClient, env.fsys, newCachePolicy(opts, env),
+			opts.statePath, opts.token, opts.budgetID, opts.accountID)
+		if err != nil {
+			state.warnings.warn("resolving names failed", err)
+		}
+
+		res.BudgetName = cmp.Or(budgetName, res.BudgetName)
+		res.AccountName = cmp.Or(accountName, res.AccountName)
+	}
+
+	if opts.useBudgetFormat && !opts.dryRun {
+		currency, err := resolveCurrencyFormat(ctx, env.httpClient, env.fsys, newCachePolicy(opts, env),
+			opts.statePath, opts.token, opts.budgetID)
+		if err != nil {
+			state.warnings.warn("getting the budget currency format failed", err)
+		} else {
+			state.amounts.currency = &currency
+		}
+	}
+
+	// A salt asks for the transactions to be created again, and the demo shows how YNAB
+	// reports duplicates: neither filters the transactions pushed by previous runs.
+	if importIDSalt(opts, env.now()) == "" && !opts.demo {
+		imported, err := loadImportedIDs(env.fsys, opts.statePath, opts.accountID)
+		if err != nil {
+			state.warnings.warn("loading the import IDs of previous runs failed", err)
+		}
+
+		var removed []Transaction
+
+		transactions, removed = filterImported(transactions, imported)
+		for _, t := range removed {
+			res.Counts.Filtered++
+			state.skipped = append(state.skipped, skippedRow{
+				reason: skipImported, date: t.Date.String(), amount: signedAmountString(t.Amount), payee: t.PayeeName,
+			})
+		}
+	}
+
+	res.Counts.Converted = len(transactions)
+	res.SkippedByReason = countSkips(state.skipped)
+	res.Reconciled = reconciled.milliunits
+	res.ReconciledDate = reconciled.date.String()
+	res.Currency = cmp.Or(inputFormat.currency, opts.currencyFilter)
+	state.converted = true
+
+	if salt := importIDSalt(opts, env.now()); salt != "" {
+		saltImportIDs(transactions, salt)
+
+		res.ImportIDSalt = salt
+
+		_, _ = fmt.Fprintln(env.stdout, state.messages.importIDSalt(salt))
+	}
+
+	logger.Debug("converted transactions", "count", len(transactions),
+		"reconciled", reconciled.milliunits, "reconciled_date", reconciled.date.String())
+
+	// Before the categorizer, whose categories win over the rules.
+	categorized := 0
+
+	for i := range transactions {
+		if applyRules(&transactions[i], state.rules) {
+			categorized++
+		}
+	}
+
+	if len(state.rules) > 0 {
+		logger.Debug("categorized by rules", "count", categorized)
+	}
+
+	if opts.categorizeCmd != "" {
+		stopCategorize := state.start("categorization")
+		categorizer := &categorizer{
+			command:  strings.Fields(opts.categorizeCmd),
+			timeout:  opts.categorizeTimeout,
+			batch:    opts.categorizeBatch,
+			warnings: state.warnings,
+		}
+		categorizer.categorize(ctx, transactions)
+		stopCategorize()
+	}
+
+	// After the categorizer, whose categories win over the suggestions.
+	if opts.suggestCategories && !opts.dryRun {
+		stopSuggest := state.start("category suggestions")
+		history, err := resolveCategoryHistory(ctx, env.httpClient, env.fsys,
+			opts.statePath, opts.token, opts.budgetID, opts.accountID)
+
+		if err != nil {
+			state.warnings.warn("fetching the history for category suggestions failed", err)
+		} else {
+			if opts.verbose {
+				_, _ = fmt.Fprintln(env.stdout, "suggested categories:")
+			}
+
+			applied := suggestCategories(env.stdout, transactions, history.suggestions(opts.suggestMinOccurrences), opts.verbose)
+			logger.Debug("suggested categories", "count", applied)
+		}
+
+		stopSuggest()
+	}
+
+	if opts.detectRefunds > 0 {
+		links := detectRefunds(transactions, opts.detectRefunds, opts.refundCategory)
+		printRefunds(env.stdout, links, state.amounts)
+
+		for _, refund := range links.ambiguous {
+			state.warnings.warn("refund left as is", fmt.Errorf("%w: %v %v %v",
+				errAmbiguousRefund, refund.Date, state.amounts.signed(refund.Amount), refund.PayeeName))
+		}
+	}
+
+	// After the categorizer and the suggestions, as rules can match categories.
+	state.capsExceeded = printCaps(env.stdout, state, checkCaps(transactions, state.caps))
+
+	if opts.verbose {
+		_, _ = fmt.Fprintln(env.stdout, state.messages.transactionsHeader())
+		_ = renderTable(env.stdout, transactions, state.amounts, opts.noTruncate)
+		_, _ = fmt.Fprintln(env.stdout)
+	}
+
+	asOf := ""
+	if !reconciled.date.IsZero() {
+		asOf = reconciled.date.String()
+	}
+
+	_, _ = fmt.Fprintln(env.stdout, state.messages.reconciled(state.amounts.amount(reconciled.milliunits), asOf))
+	printSkips(env.stdout, state.messages, state.skipped, opts.verbose)
+
+	res.Transactions = transactions
+
+	if opts.diff {
+		return diffFile(ctx, opts, env, state, transactions)
+	}
+
+	if opts.dryRun {
+		return dryRun(opts, env, state, transactions)
+	}
+
+	logger.Debug("pushing transactions", "budget_id", opts.budgetID)
+
+	stopPush := state.start("api call")
+	progress.pushing(len(transactions))
+	synced, err := retryPush(ctx, env.httpClient, logger, transactions, opts)
+
+	progress.done()
+	stopPush()
+
+	if err != nil {
+		if apiErr := new(lclynab.APIError); errors.As(err, &apiErr) {
+			logger.Error("YNAB rejected the transactions",
+				"status", apiErr.Status, "error_id", apiErr.ID, "error_name", apiErr.Name, "hint", ynabHint(apiErr))
+		}
+
+		return err
+	}
+
+	res.Counts.Pushed = synced.Counts.Pushed
+	res.Counts.Duplicates = synced.Counts.Duplicates
+	state.duplicates = synced.Duplicates
+
+	if len(synced.Created) > 0 {
+		err := recordRun(env.fsys, opts.statePath, pushedRun{
+			RunID: opts.runID, PushedAt: env.now(), BudgetID: opts.budgetID, Created: synced.Created,
+		})
+		if err != nil {
+			state.warnings.warn("recording the run for -undo-run failed", err)
+		}
+	}
+
+	if err := recordImportedIDs(env.fsys, opts.statePath, opts.accountID, synced); err != nil {
+		state.warnings.warn("recording the import IDs failed", err)
+	}
+
+	_, _ = fmt.Fprintln(env.stdout, state.messages.pushed(synced.Counts.Pushed))
+	_, _ = fmt.Fprintln(env.stdout, state.messages.duplicates(synced.Counts.Duplicates))
+
+	if opts.verbose && synced.Counts.Duplicates > 0 {
+		_, _ = fmt.Fprintln(env.stdout, state.messages.duplicatesHeader())
+		_ = renderTable(env.stdout, state.duplicates, state.amounts, opts.noTruncate)
+	}
+
+	// A file of duplicates had something to push, though YNAB created nothing.
+	outcome := checkOutcome(opts, len(synced.Transactions), synced.Counts.Duplicates)
+
+	if opts.driftAlert > 0 {
+		stopDrift := state.start("drift check")
+		outcome = errors.Join(outcome, checkDrift(ctx, opts, env, state))
+
+		stopDrift()
+	}
+
+	if opts.failOnCap {
+		outcome = errors.Join(outcome, state.capsExceeded)
+	}
+
+	data := newWebhookData(res, outcome, state, opts.accountID, env.now())
+	notificationFailed := false
+
+	if url := state.webhook.urlFor(data.Status); url != "" {
+		logger.Debug("sending webhook")
+
+		stopWebhook := state.start("webhook")
+		state.webhookSent = true
+		err := state.webhook.send(ctx, state.notifyClient, logger, url, data)
+
+		stopWebhook()
+
+		if err != nil {
+			if opts.strictWebhook {
+				return fmt.Errorf("sending webhook: %w", err)
+			}
+
+			state.warnings.warn("sending webhook failed", err)
+
+			notificationFailed = true
+		}
+	}
+
+	state.notified = true
+	res.Notifications = notifyAll(ctx, state, opts.notifyTimeout, data)
+
+	for _, outcome := range res.Notifications {
+		if outcome.Status == notifyFailed {
+			notificationFailed = true
+		}
+	}
+
+	if notificationFailed {
+		return errors.Join(outcome, errNotificationFailed)
+	}
+
+	return outcome
+}
+
+// notifyFailure sends the failure notifications for a run that failed before reaching them.
+// Each delivery is attempted once with a short timeout of its own, and its failure is
+// only a warning, so that it doesn't delay or mask the original error.
+func notifyFailure(ctx context.Context, opts *options, env env, state *runState, runErr error) {
+	ctx = context.WithoutCancel(ctx)
+	data := newWebhookData(state.result, runErr, state, opts.accountID, env.now())
+
+	if !state.webhookSent && (opts.webhookFailure != "" || opts.webhookAlways && opts.webhook != "") {
+		state.logger.Debug("sending failure webhook")
+
+		hook := *state.webhook
+		hook.attempts = 1
+
+		webhookCtx, cancel := context.WithTimeout(ctx, failureWebhookTimeout)
+		err := hook.send(webhookCtx, state.notifyClient, state.logger, hook.urlFor(data.Status), data)
+
+		cancel()
+
+		if err != nil {
+			state.warnings.warn("sending failure webhook failed", err)
+		}
+	}
+
+	if state.notified {
+		return
+	}
+
+	timeout := failureWebhookTimeout
+	if opts.notifyTimeout > 0 {
+		timeout = min(timeout, opts.notifyTimeout)
+	}
+
+	state.result.Notifications = notifyAll(ctx, state, timeout, data)
+}
+
+// checkOutcome returns the error to report for a push that went through.
+func checkOutcome(opts *options, transactionCount, duplicateCount int) error {
+	switch {
+	case transactionCount == 0:
+		return errNothingToPush
+	case opts.maxDuplicates > 0 && duplicateCount > opts.maxDuplicates:
+		return fmt.Errorf("%w: %d, want at most %d", errTooManyDuplicates, duplicateCount, opts.maxDuplicates)
+	default:
+		return nil
+	}
+}
+
+// newFlagSet returns the flags of the command, bound to opts.
+func newFlagSet(opts *options) *flag.FlagSet {
+	flagset := flag.NewFlagSet("", flag.ExitOnError)
+	flagset.Usage = func() {
+		_, _ = fmt.Fprintln(flagset.Output(), "Usage: push [flags] [file]")
+		flagset.PrintDefaults()
+		_, _ = fmt.Fprint(flagset.Output(), ExitCodesHelp)
+	}
+	flagset.StringVar(&opts.configPath, "config", config.DefaultPath,
+		"TOML or YAML file of default flag values, the flags and environment variables winning over it")
+	flagset.StringVar(&opts.syncAccount, "sync-account", "",
+		"Account of sync -accounts being pushed: its section of the config file wins over the rest, "+
+			"whose webhook is left to the one sync sends for all the accounts")
+	flagset.StringVar(&opts.filename, "f", "", "CSV file to parse, - for stdin (default stdin when piped)")
+	flagset.StringVar(&opts.budgetID, "b", "",
+		"Budget ID, last-used, default, or name when not a UUID (default $"+envBudgetID+")")
+	flagset.StringVar(&opts.accountID, "a", "", "Account ID, or name when not a UUID (default $"+envAccountID+")")
+	flagset.StringVar(&opts.accountMap, "account-map", "",
+		"JSON file mapping the account references of LCL exports, or their end, to account IDs, used without -a")
+	flagset.StringVar(&opts.token, "t", "", "Token (default $"+envToken+")")
+	flagset.StringVar(&opts.tokenFile, "token-file", "",
+		"File holding the token, like a systemd credential or a Docker secret, instead of -t")
+	flagset.DurationVar(&opts.pushBackoff, "push-backoff", defaultPushBackoff,
+		"Delay before retrying a push on rate limits, 5xx and timeouts, doubled after each failure")
+	flagset.StringVar(&opts.webhook, "w", "", "Home Assistant webhook URL, alias of -webhook-success")
+	flagset.StringVar(&opts.webhook, "webhook-success", "", "Webhook URL called when the run succeeds")
+	flagset.StringVar(&opts.webhookFailure, "webhook-failure", "",
+		"Webhook URL called with the error and the failed phase when the run fails")
+	flagset.Var(&opts.webhookHeaders, "webhook-header",
+		`Header added to the webhook request as "Name: value", repeatable; `+
+			"a value of env:VAR is read from VAR or the file in VAR_FILE")
+	flagset.StringVar(&opts.webhookTemplate, "webhook-template", "",
+		"Go template rendering the webhook body from the run result (default: a JSON summary of the run)")
+	flagset.StringVar(&opts.webhookContentType, "webhook-content-type", defaultWebhookContentType,
+		"Content-Type of the webhook body")
+	flagset.IntVar(&opts.webhookAttempts, "webhook-attempts", defaultWebhookAttempts,
+		"Number of webhook delivery attempts on connection errors, timeouts and 5xx")
+	flagset.DurationVar(&opts.webhookBackoff, "webhook-backoff", defaultWebhookBackoff,
+		"Delay before the second webhook attempt, doubled after each failure")
+	flagset.DurationVar(&opts.webhookTimeout, "webhook-timeout", apiTimeout,
+		"Timeout of each webhook attempt, independent from the YNAB call (0 disables it)")
+	flagset.DurationVar(&opts.notifyTimeout, "notify-timeout", apiTimeout,
+		"Timeout of each notification channel, independent from the others (0 disables it)")
+	flagset.BoolVar(&opts.webhookAlways, "webhook-always", false,
+		"Also send the webhook when the run fails, with status error")
+	flagset.StringVar(&opts.haURL, "ha-url", "", "Home Assistant base URL, to update sensors through its REST API")
+	flagset.StringVar(&opts.haToken, "ha-token", "", "Home Assistant long-lived access token")
+	flagset.StringVar(&opts.haEntityPrefix, "ha-entity-prefix", defaultHAEntityPrefix,
+		"Prefix of the Home Assistant sensors: sensor.<prefix>_reconciled and sensor.<prefix>_status")
+	flagset.StringVar(&opts.mqttURL, "mqtt-url", "",
+		"MQTT broker URL to publish the result to, e.g. tcp://host:1883 or ssl://host:8883")
+	flagset.StringVar(&opts.mqttUsername, "mqtt-username", "", "MQTT username")
+	flagset.StringVar(&opts.mqttPassword, "mqtt-password", "", "MQTT password")
+	flagset.StringVar(&opts.mqttTopic, "mqtt-topic", defaultMQTTTopic, "Base MQTT topic of the state messages")
+	flagset.StringVar(&opts.mqttCAFile, "mqtt-ca-file", "",
+		"PEM file of the CA trusted for ssl:// brokers, instead of the system ones")
+	flagset.StringVar(&opts.ntfyURL, "ntfy-url", "", "ntfy topic URL notified when the run fails")
+	flagset.StringVar(&opts.ntfyToken, "ntfy-token", "", "ntfy access token")
+	flagset.BoolVar(&opts.ntfyOnSuccess, "ntfy-on-success", false, "Also notify ntfy with a summary when the run succeeds")
+	flagset.StringVar(&opts.telegramToken, "telegram-token", "", "Telegram bot token sending a summary of the run")
+	flagset.StringVar(&opts.telegramChatID, "telegram-chat-id", "", "Telegram chat receiving the summary")
+	flagset.BoolVar(&opts.telegramFailuresOnly, "telegram-failures-only", false, "Only send the Telegram summary on failure")
+	flagset.BoolVar(&opts.telegramDetails, "telegram-details", false, "List the duplicate transactions in the Telegram summary")
+	flagset.StringVar(&opts.smtpHost, "smtp-host", "", "SMTP server emailing a report when the run fails")
+	flagset.IntVar(&opts.smtpPort, "smtp-port", defaultSMTPPort, "SMTP server port")
+	flagset.StringVar(&opts.smtpTLS, "smtp-tls", smtpTLSStart,
+		fmt.Sprintf("SMTP TLS mode: %v, %v or %v", smtpTLSStart, smtpTLSImplicit, smtpTLSNone))
+	flagset.StringVar(&opts.smtpUsername, "smtp-username", "", "SMTP username")
+	flagset.StringVar(&opts.smtpPassword, "smtp-password", "", "SMTP password")
+	flagset.StringVar(&opts.emailFrom, "email-from", "", "Sender of the report emails")
+	flagset.StringVar(&opts.emailTo, "email-to", "", "Comma-separated recipients of the report emails")
+	flagset.BoolVar(&opts.emailOnSuccess, "email-on-success", false, "Also email a report when the run succeeds")
+	flagset.StringVar(&opts.discordWebhook, "discord-webhook", "",
+		"Discord webhook URL receiving an embed summarizing the run, retried like -w")
+	flagset.StringVar(&opts.slackWebhook, "slack-webhook", "",
+		"Slack incoming webhook URL receiving a Block Kit summary of the run, retried like -w")
+	flagset.StringVar(&opts.gotifyURL, "gotify-url", "", "Gotify server URL receiving a summary of the run")
+	flagset.StringVar(&opts.gotifyToken, "gotify-token", "", "Gotify application token")
+	flagset.BoolVar(&opts.strictWebhook, "strict-webhook", false, "Fail the run when the webhook fails")
+	flagset.StringVar(&opts.webhookCACert, "webhook-ca-cert", "",
+		"PEM CA certificate trusted by the webhook and notifiers instead of -ca-cert")
+	flagset.StringVar(&opts.caCert, "ca-cert", "",
+		"PEM CA certificate trusted, in addition to the system ones, by every HTTPS call")
+	flagset.BoolVar(&opts.insecureSkipVerify, "insecure-skip-verify", false,
+		"Don't verify TLS certificates, for testing only")
+	flagset.StringVar(&opts.rules, "rules", "",
+		`JSON file of ordered rules [{"pattern": "regexp", "category_id": "id"}], the first matching the memo `+
+			"setting the category")
+	flagset.Var(&opts.stripHolder, "strip-holder",
+		"Regexp of an account holder removed from the end of LCL payees, like \"M OU MME MARTIN\", repeatable; "+
+			`"common" removes the usual "M OU MME …" and "MLLE …" forms`)
+	flagset.StringVar(&opts.categorizeCmd, "categorize-cmd", "",
+		"Command called with each transaction as JSON to override its fields")
+	flagset.DurationVar(&opts.categorizeTimeout, "categorize-timeout", defaultCategorizeTimeout,
+		"Timeout of each categorizer invocation, 0 to disable")
+	flagset.BoolVar(&opts.categorizeBatch, "categorize-batch", false,
+		"Run the categorizer once with one JSON transaction per line")
+	flagset.BoolVar(&opts.suggestCategories, "suggest-categories", false,
+		"Categorize transactions without a category as their payee mostly is in YNAB, leaving them unapproved")
+	flagset.IntVar(&opts.suggestMinOccurrences, "suggest-min-occurrences", defaultSuggestMinOccurrences,
+		"Times a payee must have a category in YNAB for -suggest-categories to use it")
+	flagset.StringVar(&opts.importIDSalt, "import-id-salt", "",
+		"Mix this value into import IDs so that YNAB creates the transactions again, requires -yes")
+	flagset.BoolVar(&opts.forceNewIDs, "force-new-import-ids", false,
+		"Like -import-id-salt with a salt derived from the current time, requires -yes")
+	flagset.StringVar(&opts.idStrategy, "import-id-strategy", importIDCounter,
+		"Import IDs of LCL exports: counter, from the amount, date and rank, or hash, from a SHA-256 of the line")
+	flagset.BoolVar(&opts.foldRates, "fold-exchange-rates", false,
+		"Add the exchange rate lines LCL writes after payments in another currency to the memo of their "+
+			"payment, rather than only skipping them")
+	flagset.IntVar(&opts.detectRefunds, "detect-refunds", 0,
+		"Link inflows to an outflow of the same payee and amount up to this many days earlier, 0 to disable")
+	flagset.BoolVar(&opts.refundCategory, "refund-category", false,
+		"With -detect-refunds, give refunds the category of their charge")
+	flagset.BoolVar(&opts.yes, "yes", false, "Confirm operations that can create duplicates in YNAB")
+	flagset.BoolVar(&opts.dryRun, "dry-run", false,
+		"Print the transactions and the notifications as they would be sent, without calling YNAB nor any channel; "+
+			"with -cleanup, list what it would delete")
+	flagset.BoolVar(&opts.dryRun, "n", false, "Shorthand for -dry-run")
+	flagset.StringVar(&opts.compareReport, "compare-report", "",
+		"With -dry-run, compare the transactions with those of this JSON report and fail when they differ")
+	flagset.BoolVar(&opts.diff, "diff", false,
+		"Compare the file with the YNAB transactions of the period it covers instead of pushing it")
+	flagset.StringVar(&opts.undoRun, "undo-run", "",
+		"Delete the transactions created by the run with this ID instead of pushing, see -run-id")
+	flagset.BoolVar(&opts.undoLast, "undo-last", false, "Like -undo-run for the last run that created transactions")
+	flagset.BoolVar(&opts.force, "force", false,
+		"With -undo-run, also delete transactions modified in YNAB since the push")
+	flagset.BoolVar(&opts.verify, "verify", false,
+		"Check the flags, the token, the budget and account and the webhook without pushing, then exit")
+	flagset.BoolVar(&opts.verifyWebhook, "verify-webhook", false, "With -verify, also send a test webhook")
+	flagset.BoolVar(&opts.listBudgets, "list-budgets", false,
+		"Print the name, ID and last change of the budgets the token can read, and exit")
+	flagset.BoolVar(&opts.listAccounts, "list-accounts", false,
+		"Print the name, type, ID and cleared balance of the accounts of the budget, and exit")
+	flagset.BoolVar(&opts.preflight, "preflight", false,
+		"Check that the YNAB API can be reached, and warn when the clock is off from it, before converting")
+	flagset.BoolVar(&opts.update, "update", false,
+		"Replace this executable with the latest release once its checksum is verified, then exit")
+	flagset.BoolVar(&opts.checkOnly, "check-only", false, "With -update, only tell whether a release is newer")
+	flagset.BoolVar(&opts.stats, "stats", false,
+		"Print totals, types, top payees and largest transactions of the file without calling YNAB, then exit")
+	flagset.IntVar(&opts.maxDuplicates, "max-duplicates", 0,
+		"Fail when YNAB reports more duplicates than this, 0 to disable")
+	flagset.Float64Var(&opts.driftAlert, "drift-alert", 0,
+		"Fail when the YNAB cleared balance drifts from the bank by more than this amount in euros, 0 to disable")
+	flagset.StringVar(&opts.caps, "caps", "",
+		"JSON file of monthly caps in euros by payee or category, warning about the months the file exceeds")
+	flagset.BoolVar(&opts.failOnCap, "fail-on-cap", false, "Fail after the push when a monthly cap of -caps is exceeded")
+	flagset.IntVar(&opts.progressEvery, "progress", 0,
+		"Print a progress line on stderr every this many lines of the export, 0 to disable")
+	flagset.BoolVar(&opts.demo, "demo", false,
+		"Push to a fake YNAB budget served in process, -t, -b and -a being optional, to try the tool")
+	flagset.StringVar(&opts.demoDir, "demo-dir", "",
+		"Directory keeping the fake budget and state of -demo between runs (default in the temp dir)")
+	flagset.BoolVar(&opts.quiet, "q", false, "Don't show the progress of the conversion and the push")
+	flagset.BoolVar(&opts.verbose, "v", false, "Verbose output, implies -log-level debug")
+	flagset.BoolVar(&opts.noTruncate, "no-truncate", false, "Print full payees and memos in transaction tables")
+	flagset.StringVar(&opts.profile, "profile", paths.DefaultProfile, "Profile namespacing the state and config locations")
+	flagset.BoolVar(&opts.printPaths, "print-paths", false, "Print the resolved file locations and exit")
+	flagset.StringVar(&opts.runID, "run-id", "",
+		"ID correlating the logs, report and notifications of this run (default: random)")
+	flagset.StringVar(&opts.statePath, "state", "",
+		"State file remembering data between runs, like the import IDs pushed not to send them again "+
+			"(default in the state dir)")
+	flagset.StringVar(&opts.watch, "watch", "",
+		"Directory to watch, pushing each new or modified file matching -watch-glob until interrupted")
+	flagset.StringVar(&opts.watchGlob, "watch-glob", defaultWatchGlob, "Pattern of the file names pushed by -watch")
+	flagset.DurationVar(&opts.watchInterval, "watch-interval", defaultWatchInterval,
+		"Delay between two listings of the -watch directory")
+	flagset.DurationVar(&opts.watchSettle, "watch-settle", defaultWatchSettle,
+		"How long a watched file must keep the same size before it's pushed")
+	flagset.BoolVar(&opts.resolveNames, "resolve-names", false,
+		"Include the budget and account names in reports and notifications, cached in the state file")
+	flagset.BoolVar(&opts.useBudgetFormat, "use-budget-format", false,
+		"Print amounts in the currency format of the budget, cached in the state file")
+	flagset.DurationVar(&opts.cacheTTL, "cache-ttl", defaultCacheTTL,
+		"How long the names and currency formats cached in the state file are used, 0 for ever")
+	flagset.BoolVar(&opts.noCache, "no-cache", false, "Get the names and currency formats from YNAB, ignoring the cache")
+	flagset.DurationVar(&opts.retentionReports.MaxAge, "retention-reports-max-age", 0,
+		"Age above which the reports -watch names after their run beside -report are deleted, 0 to keep them")
+	flagset.IntVar(&opts.retentionReports.MaxCount, "retention-reports-max-count", 0,
+		"Number of most recent reports of -watch kept beside -report, 0 to keep them all")
+	flagset.DurationVar(&opts.retentionDemoMaxAge, "retention-demo-max-age", 0,
+		"Age above which the fake budget and state of -demo in the temp dir are deleted, 0 to keep them")
+	flagset.DurationVar(&opts.retentionWatchedMaxAge, "retention-watched-max-age", 0,
+		"Age above which the -watch files gone from their directory are forgotten by the state file, 0 for never")
+	flagset.BoolVar(&opts.cleanup, "cleanup", false,
+		"Delete the artifacts the retention flags expire, then exit; they are also deleted at the end of each push")
+	flagset.StringVar(&opts.logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	flagset.StringVar(&opts.logFormat, "log-format", logging.FormatText, "Log format: text or json")
+	flagset.BoolVar(&opts.timings, "timings", false, "Print the duration of each phase")
+	flagset.StringVar(&opts.report, "report", "",
+		"Write a JSON run report to this path, - for stdout; with -watch the run ID is added before the extension")
+	flagset.StringVar(&opts.output, "output", outputText, "Output mode: text or json")
+	flagset.BoolVar(&opts.jsonOutput, "json", false,
+		"Print only the reconciled balance, the pushed and duplicate counts and the transactions as JSON, "+
+			"or the error; -stats prints its JSON")
+	flagset.StringVar(&opts.lang, "lang", "",
+		"Language of the summary lines: en or fr (default from LC_ALL, LC_MESSAGES or LANG, else en)")
+	flagset.StringVar(&opts.format, "format", "",
+		fmt.Sprintf("Input format (%v), detected from the file when omitted", strings.Join(defaultFormats().names(), ", ")))
+	flagset.StringVar(&opts.encoding, "encoding", encodingAuto,
+		fmt.Sprintf("Charset of the input (%v), auto only skips a byte order mark", strings.Join(encodingNames(), ", ")))
+	flagset.StringVar(&opts.sortOrder, "sort", sortNone,
+		"Order of the pushed transactions: none keeps the file order, date-asc or date-desc")
+	flagset.BoolVar(&opts.includePending, "include-pending", false, "Import pending Revolut rows as uncleared")
+	flagset.StringVar(&opts.currencyFilter, "currency-filter", "EUR", "Only import Revolut rows in this currency")
+
+	return flagset
+}
+
+// parseFlags reads the command line. The token and IDs left out fall back to the
+// environment variables of env, and stdin is the input when no file is given and
+// it's piped.
+func parseFlags(args []string, env env) (*options, error) {
+	opts := &options{}
+
+	flagset := newFlagSet(opts)
+
+	positional, err := parseInterspersed(flagset, args)
+	if err != nil {
+		return nil, fmt.Errorf("parsing flags: %w", err)
+	}
+
+	if opts.printPaths || opts.update {
+		return opts, nil
+	}
+
+	if opts.demo {
+		setDemoDefaults(opts)
+	}
+
+	opts.token = cmp.Or(opts.token, env.variable(envToken))
+	opts.budgetID = cmp.Or(opts.budgetID, env.variable(envBudgetID))
+	opts.accountID = cmp.Or(opts.accountID, env.variable(envAccountID))
+
+	if err := applyConfig(flagset, opts, env); err != nil {
+		return nil, err
+	}
+
+	if opts.tokenFile != "" {
+		if flagGiven(flagset, "t") {
+			return nil, fmt.Errorf("%w: -t and -token-file", errConflictingToken)
+		}
+
+		fsys := env.fsys
+		if fsys == nil {
+			fsys = osFS{}
+		}
+
+		if opts.token, err = readTokenFile(fsys, opts.tokenFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkRetention(opts); err != nil {
+		return nil, err
+	}
+
+	// Cleaning up needs no credentials.
+	if opts.cleanup {
+		return opts, nil
+	}
+
+	if opts.jsonOutput && flagGiven(flagset, "output") {
+		return nil, fmt.Errorf("%w: -json and -output %v", errConflictingMode, opts.output)
+	}
+
+	if opts.watch != "" {
+		if err := checkWatchFlags(opts, positional); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.listBudgets {
+		if opts.token == "" {
+			return nil, fmt.Errorf("%w: -t", errRequiredFlag)
+		}
+
+		return opts, nil
+	}
+
+	if opts.listAccounts {
+		if opts.token == "" || opts.budgetID == "" {
+			return nil, fmt.Errorf("%w: -t and -b", errRequiredFlag)
+		}
+
+		return opts, nil
+	}
+
+	if opts.undoRun != "" || opts.undoLast {
+		return checkUndoFlags(opts)
+	}
+
+	if opts.verify {
+		return checkVerifyFlags(opts)
+	}
+
+	switch {
+	case len(positional) > 1:
+		return nil, fmt.Errorf("%w: %v", errTooManyFiles, strings.Join(positional, ", "))
+	case len(positional) == 1 && opts.filename != "":
+		return nil, fmt.Errorf("%w: -f and %v", errConflictingFile, positional[0])
+	case len(positional) == 1:
+		opts.filename = positional[0]
+	case opts.filename == "" && env.stdinPiped && opts.watch == "":
+		opts.filename = stdinName
+	}
+
+	if opts.stats {
+		return checkStatsFlags(opts)
+	}
+
+	switch {
+	case opts.filename == "" && opts.watch == "":
+		return nil, fmt.Errorf("%w: -f or a positional file", errRequiredFlag)
+	case opts.budgetID == "":
+		return nil, fmt.Errorf("%w: -b", errRequiredFlag)
+	case opts.accountID == "" && opts.accountMap == "":
+		return nil, fmt.Errorf("%w: -a or -account-map", errRequiredFlag)
+	case opts.token == "":
+		return nil, fmt.Errorf("%w: -t", errRequiredFlag)
+	}
+
+	if opts.runID != "" {
+		if err := logging.CheckRunID(opts.runID); err != nil {
+			return nil, err //nolint:wrapcheck // already explicit
+		}
+	}
+
+	if err := checkEncoding(opts.encoding); err != nil {
+		return nil, err
+	}
+
+	if err := checkSort(opts.sortOrder); err != nil {
+		return nil, err
+	}
+
+	if err := checkLang(opts.lang); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opts.output != outputText && opts.output != outputJSON:
+		return nil, fmt.Errorf("%w: %q, want %v or %v", errUnknownOutput, opts.output, outputText, outputJSON)
+	case opts.output == outputJSON && opts.report == "-":
+		return nil, fmt.Errorf("%w: -output json and -report -", errConflictingOutput)
+	case opts.jsonOutput && opts.report == "-":
+		return nil, fmt.Errorf("%w: -json and -report -", errConflictingOutput)
+	}
+
+	switch {
+	case opts.haURL != "" && opts.haToken == "":
+		return nil, fmt.Errorf("%w: -ha-token with -ha-url", errRequiredFlag)
+	case opts.gotifyURL != "" && opts.gotifyToken == "":
+		return nil, fmt.Errorf("%w: -gotify-token with -gotify-url", errRequiredFlag)
+	case opts.telegramToken != "" && opts.telegramChatID == "":
+		return nil, fmt.Errorf("%w: -telegram-chat-id with -telegram-token", errRequiredFlag)
+	case opts.smtpHost != "" && (opts.emailFrom == "" || opts.emailTo == ""):
+		return nil, fmt.Errorf("%w: -email-from and -email-to with -smtp-host", errRequiredFlag)
+	case opts.compareReport != "" && !opts.dryRun:
+		return nil, fmt.Errorf("%w: -dry-run with -compare-report", errRequiredFlag)
+	case opts.failOnCap && opts.caps == "":
+		return nil, fmt.Errorf("%w: -caps with -fail-on-cap", errRequiredFlag)
+	case opts.smtpTLS != smtpTLSStart && opts.smtpTLS != smtpTLSImplicit && opts.smtpTLS != smtpTLSNone:
+		return nil, fmt.Errorf("%w: %q", errUnknownSMTPTLS, opts.smtpTLS)
+	case opts.idStrategy != importIDCounter && opts.idStrategy != importIDHash:
+		return nil, fmt.Errorf("%w: %q, want %v or %v", errUnknownImportIDStrategy, opts.idStrategy,
+			importIDCounter, importIDHash)
+	}
+
+	if opts.webhookTimeout < 0 {
+		return nil, fmt.Errorf("%w: -webhook-timeout %v, want 0 or more", errInvalidTimeout, opts.webhookTimeout)
+	}
+
+	if opts.notifyTimeout < 0 {
+		return nil, fmt.Errorf("%w: -notify-timeout %v, want 0 or more", errInvalidTimeout, opts.notifyTimeout)
+	}
+
+	if opts.driftAlert < 0 {
+		return nil, fmt.Errorf("%w: -drift-alert %v, want 0 or more", errInvalidDrift, opts.driftAlert)
+	}
+
+	if opts.detectRefunds < 0 {
+		return nil, fmt.Errorf("%w: -detect-refunds %d, want 0 or more", errInvalidWindow, opts.detectRefunds)
+	}
+
+	if opts.progressEvery < 0 {
+		return nil, fmt.Errorf("%w: -progress %d, want 0 or more", errInvalidProgress, opts.progressEvery)
+	}
+
+	if opts.cacheTTL < 0 {
+		return nil, fmt.Errorf("%w: -cache-ttl %v, want 0 or more", errInvalidCacheTTL, opts.cacheTTL)
+	}
+
+	if opts.webhookAttempts < 1 {
+		return nil, fmt.Errorf("%w: -webhook-attempts %d, want at least 1", errInvalidAttempts, opts.webhookAttempts)
+	}
+
+	switch {
+	case opts.importIDSalt != "" && opts.forceNewIDs:
+		return nil, fmt.Errorf("%w: -import-id-salt and -force-new-import-ids", errConflictingSalt)
+	case (opts.importIDSalt != "" || opts.forceNewIDs) && !opts.yes:
+		return nil, fmt.Errorf("%w: new import IDs make YNAB create every transaction again, pass -yes", errNotConfirmed)
+	}
+
+	if opts.format != "" {
+		if _, err := defaultFormats().lookup(opts.format); err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
+
+// applyConfig applies the config file to flagset. With -sync-account, the section of the
+// account wins over the rest of the file, whose webhook flags configure the webhook sync
+// sends for all the accounts instead.
+func applyConfig(flagset *flag.FlagSet, opts *options, env env) error {
+	if opts.syncAccount == "" {
+		err := config.ApplyFile(flagset, env.fsys, opts.configPath, env.variable, configAliases())
+
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	values, path, err := config.LoadFile(env.fsys, opts.configPath, env.variable)
+	if err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	aliases := configAliases()
+
+	for key := range values {
+		name, ok := aliases[key]
+		if !ok {
+			name = strings.ReplaceAll(key, "_", "-")
+		}
+
+		if IsWebhookFlag(name) {
+			delete(values, key)
+		}
+	}
+
+	maps.Copy(values, config.Account(values, opts.syncAccount))
+
+	if err := config.Apply(flagset, values, aliases); err != nil {
+		return fmt.Errorf("config %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// flagGiven reports whether the flag name was given on the command line.
+func flagGiven(flagset *flag.FlagSet, name string) bool {
+	given := false
+
+	flagset.Visit(func(f *flag.Flag) {
+		given = given || f.Name == name
+	})
+
+	return given
+}
+
+// readTokenFile returns the token in the file at path, without the surrounding
+// whitespace and newlines.
+func readTokenFile(fsys fs.FS, path string) (string, error) {
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidTokenFile, err)
+	}
+
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("%w: %v is empty", errInvalidTokenFile, path)
+	}
+
+	return token, nil
+}
+
+// secretFlags returns the flags whose values must not leave the program.
+func secretFlags(opts *options) []string {
+	return []string{
+		opts.token, opts.webhook, opts.webhookFailure, opts.haToken, opts.mqttPassword, opts.ntfyToken,
+		opts.telegramToken, opts.smtpPassword, opts.discordWebhook, opts.slackWebhook,
+		opts.gotifyToken,
+	}
+}
+
+// checkUndoFlags validates the flags of -undo-run and -undo-last, which need no file:
+// the budget is recorded with the run.
+func checkUndoFlags(opts *options) (*options, error) {
+	switch {
+	case opts.undoRun != "" && opts.undoLast:
+		return nil, fmt.Errorf("%w: -undo-run and -undo-last", errConflictingUndo)
+	case opts.token == "":
+		return nil, fmt.Errorf("%w: -t", errRequiredFlag)
+	}
+
+	return opts, nil
+}
+
+// checkVerifyFlags validates the flags of -verify, which needs no file.
+func checkVerifyFlags(opts *options) (*options, error) {
+	switch {
+	case opts.budgetID == "":
+		return nil, fmt.Errorf("%w: -b", errRequiredFlag)
+	case opts.accountID == "":
+		return nil, fmt.Errorf("%w: -a", errRequiredFlag)
+	case opts.token == "":
+		return nil, fmt.Errorf("%w: -t", errRequiredFlag)
+	}
+
+	return opts, nil
+}
+
+// checkStatsFlags validates the flags of -stats, which only reads the file.
+func checkStatsFlags(opts *options) (*options, error) {
+	if opts.filename == "" {
+		return nil, fmt.Errorf("%w: -f or a positional file", errRequiredFlag)
+	}
+
+	if opts.output != outputText && opts.output != outputJSON {
+		return nil, fmt.Errorf("%w: %q, want %v or %v", errUnknownOutput, opts.output, outputText, outputJSON)
+	}
+
+	if err := checkEncoding(opts.encoding); err != nil {
+		return nil, err
+	}
+
+	if opts.format != "" {
+		if _, err := defaultFormats().lookup(opts.format); err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
+
+// contextReader stops reading once its context is done,
+// so that a long conversion can be interrupted.
+type contextReader struct {
+	ctx    context.Context //nolint:containedctx // scoped to a single run
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("cancelled: %w", err)
+	}
+
+	return r.reader.Read(p) //nolint:wrapcheck // plain io.Reader passthrough
+}
+
+// parseInterspersed parses flags placed before and after positional arguments,
+// which the flag package alone stops at, and returns the positional arguments.
+func parseInterspersed(flagset *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := flagset.Parse(args); err != nil {
+			return nil, err //nolint:wrapcheck // wrapped by the caller
+		}
+
+		if flagset.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, flagset.Arg(0))
+		args = flagset.Args()[1:]
+	}
+}
+
+// selectFormat returns the format requested on the command line, or detects it from the input.
+func selectFormat(opts *options, reader *bufio.Reader) (format, error) {
+	formats := defaultFormats()
+
+	if opts.format != "" {
+		return formats.lookup(opts.format)
+	}
+
+	head, err := reader.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return format{}, fmt.Errorf("reading file: %w", err)
+	}
+
+	return formats.detect(opts.filename, head)
+}
+
+// importIDSalt returns the salt requested on the command line, if any.
+func importIDSalt(opts *options, now time.Time) string {
+	if opts.forceNewIDs {
+		return now.UTC().Format("20060102T150405Z")
+	}
+
+	return opts.importIDSalt
+}
+
+// saltImportIDs replaces the "YNAB" prefix of the import IDs with a short hash of salt,
+// so that re-pushing the same file creates new transactions. The hash keeps the IDs
+// within the 36 characters YNAB accepts.
+func saltImportIDs(transactions []Transaction, salt string) {
+	sum := sha256.Sum256([]byte(salt))
+	prefix := hex.EncodeToString(sum[:4])
+
+	for i := range transactions {
+		transactions[i].ImportID = prefix + strings.TrimPrefix(transactions[i].ImportID, "YNAB")
+	}
+}
+
+// push creates the converted transactions in YNAB through lclynab.Sync.
+func push(
+	ctx context.Context,
+	client *http.Client,
+	transactions []Transaction,
+	opts *options,
+) (*lclynab.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	//nolint:wrapcheck // already explicit
+	return lclynab.Sync(ctx, lclynab.SyncOptions{
+		Client:       lclynab.NewClient(opts.token, client),
+		BudgetID:     opts.budgetID,
+		AccountID:    opts.accountID,
+		Transactions: transactions,
+	})
+}
+
+// ynabHint suggests the flag to check for an error response of YNAB.
+func ynabHint(apiErr *lclynab.APIError) string {
+	switch {
+	case lclynab.IsAuth(apiErr):
+		return "check the token given with -t"
+	case lclynab.IsRateLimited(apiErr):
+		return "wait for the rate limit to reset before retrying"
+	case apiErr.Status == http.StatusNotFound:
+		return "check the budget given with -b"
+	case apiErr.Status >= http.StatusInternalServerError:
+		return "YNAB failed, retry later"
+	default:
+		return "check the account given with -a"
+	}
+}
+
+func reconciledString(amnt int) string {
+	return fmt.Sprintf("%.2f", float64(amnt)/milliUnit)
+}
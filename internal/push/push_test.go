@@ -0,0 +1,953 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/config"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+// mustDate parses a YYYY-MM-DD date of a test case.
+func mustDate(s string) lclynab.Date {
+	date, err := lclynab.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return date
+}
+
+// ptr returns a pointer to v, for the optional fields of a test case.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func Test_run(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		ctx  context.Context //nolint:containedctx
+		args []string
+	}
+
+	tests := []struct {
+		name         string
+		args         args
+		wantStdout   string
+		wantErr      bool
+		wantExitCode int
+		clientFunc   func() *http.Client
+	}{
+		{
+			name: "one positive transaction",
+			args: args{
+				context.Background(),
+				[]string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv"},
+			},
+			clientFunc: func() *http.Client {
+				transport := httpmock.NewMockTransport()
+				transport.RegisterResponder(
+					http.MethodPost,
+					"/v1/budgets/"+testBudgetID+"/transactions",
+					createdResponder(http.StatusOK),
+				)
+
+				return &http.Client{Transport: transport}
+			},
+			wantStdout: `reconciled: 100.06€ as of 2024-11-29
+successfully pushed 1 transaction(s)
+found 0 duplicate(s)
+`,
+			wantErr: false,
+		},
+		{
+			name: "cancelled",
+			args: args{
+				cancelledContext(),
+				[]string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv"},
+			},
+			clientFunc: func() *http.Client {
+				return &http.Client{Transport: httpmock.NewMockTransport()}
+			},
+			wantStdout:   "",
+			wantErr:      true,
+			wantExitCode: exitCancelled,
+		},
+		{
+			name: "nothing to push",
+			args: args{
+				context.Background(),
+				[]string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "./testdata/footer-only.csv"},
+			},
+			clientFunc: func() *http.Client {
+				return &http.Client{Transport: httpmock.NewMockTransport()}
+			},
+			wantStdout: `reconciled: 100.06€ as of 2024-11-29
+successfully pushed 0 transaction(s)
+found 0 duplicate(s)
+`,
+			wantErr:      true,
+			wantExitCode: exitNothingToPush,
+		},
+		{
+			name: "auth error",
+			args: args{
+				context.Background(),
+				[]string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "./testdata/one-positive.csv"},
+			},
+			clientFunc: func() *http.Client {
+				transport := httpmock.NewMockTransport()
+				transport.RegisterResponder(
+					http.MethodPost,
+					"/v1/budgets/"+testBudgetID+"/transactions",
+					httpmock.NewStringResponder(
+						http.StatusUnauthorized,
+						`{"error": {"id": "401", "name": "unauthorized", "detail": "Unauthorized"}}`,
+					),
+				)
+
+				return &http.Client{Transport: transport}
+			},
+			wantStdout:   "reconciled: 100.06€ as of 2024-11-29\n",
+			wantErr:      true,
+			wantExitCode: exitAuth,
+		},
+		{
+			name: "rate limited",
+			args: args{
+				context.Background(),
+				[]string{
+					"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-push-backoff", "1ms",
+					"./testdata/one-positive.csv",
+				},
+			},
+			clientFunc: func() *http.Client {
+				transport := httpmock.NewMockTransport()
+				transport.RegisterResponder(
+					http.MethodPost,
+					"/v1/budgets/"+testBudgetID+"/transactions",
+					httpmock.NewStringResponder(
+						http.StatusTooManyRequests,
+						`{"error": {"id": "429", "name": "too_many_requests", "detail": "Too many requests"}}`,
+					),
+				)
+
+				return &http.Client{Transport: transport}
+			},
+			wantStdout:   "reconciled: 100.06€ as of 2024-11-29\n",
+			wantErr:      true,
+			wantExitCode: exitRateLimited,
+		},
+		{
+			name: "too many duplicates",
+			args: args{
+				context.Background(),
+				[]string{
+					"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-max-duplicates", "1",
+					"./testdata/one-positive.csv",
+				},
+			},
+			clientFunc: func() *http.Client {
+				transport := httpmock.NewMockTransport()
+				transport.RegisterResponder(
+					http.MethodPost,
+					"/v1/budgets/"+testBudgetID+"/transactions",
+					createdResponder(http.StatusOK, "1", "2"),
+				)
+
+				return &http.Client{Transport: transport}
+			},
+			wantStdout: `reconciled: 100.06€ as of 2024-11-29
+successfully pushed 0 transaction(s)
+found 2 duplicate(s)
+`,
+			wantErr:      true,
+			wantExitCode: exitTooManyDuplicates,
+		},
+		{
+			name: "failing webhook",
+			args: args{
+				context.Background(),
+				[]string{
+					"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+					"-w", "https://ha.example/api/webhook/ynab", "-webhook-backoff", "1ms",
+				},
+			},
+			clientFunc: func() *http.Client {
+				transport := httpmock.NewMockTransport()
+				transport.RegisterResponder(
+					http.MethodPost,
+					"/v1/budgets/"+testBudgetID+"/transactions",
+					createdResponder(http.StatusOK),
+				)
+				transport.RegisterResponder(
+					http.MethodPost,
+					"https://ha.example/api/webhook/ynab",
+					httpmock.NewStringResponder(http.StatusServiceUnavailable, ""),
+				)
+
+				return &http.Client{Transport: transport}
+			},
+			wantStdout: `reconciled: 100.06€ as of 2024-11-29
+successfully pushed 1 transaction(s)
+found 0 duplicate(s)
+`,
+			wantErr:      true,
+			wantExitCode: exitNotificationFailed,
+		},
+		{
+			name: "failing webhook in strict mode",
+			args: args{
+				context.Background(),
+				[]string{
+					"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+					"-w", "https://ha.example/api/webhook/ynab", "-strict-webhook", "-webhook-backoff", "1ms",
+				},
+			},
+			clientFunc: func() *http.Client {
+				transport := httpmock.NewMockTransport()
+				transport.RegisterResponder(
+					http.MethodPost,
+					"/v1/budgets/"+testBudgetID+"/transactions",
+					createdResponder(http.StatusOK),
+				)
+				transport.RegisterResponder(
+					http.MethodPost,
+					"https://ha.example/api/webhook/ynab",
+					httpmock.NewStringResponder(http.StatusServiceUnavailable, ""),
+				)
+
+				return &http.Client{Transport: transport}
+			},
+			wantStdout: `reconciled: 100.06€ as of 2024-11-29
+successfully pushed 1 transaction(s)
+found 0 duplicate(s)
+`,
+			wantErr:      true,
+			wantExitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := &bytes.Buffer{}
+			client := tt.clientFunc()
+
+			err := run(tt.args.ctx, tt.args.args, env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: client,
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("run() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if gotStdout := stdout.String(); gotStdout != tt.wantStdout {
+				t.Errorf("run() gotStdout = %v, want %v", gotStdout, tt.wantStdout)
+			}
+
+			if err != nil {
+				if got := ExitCode(err); got != tt.wantExitCode {
+					t.Errorf("ExitCode(run()) = %v, want %v", got, tt.wantExitCode)
+				}
+			}
+		})
+	}
+}
+
+func Test_parseFlags(t *testing.T) {
+	t.Parallel()
+
+	required := []string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantFilename string
+		wantErr      error
+	}{
+		{
+			name:         "positional only",
+			args:         append([]string{"statement.csv"}, required...),
+			wantFilename: "statement.csv",
+			wantErr:      nil,
+		},
+		{
+			name:         "positional after flags",
+			args:         append(append([]string{}, required...), "statement.csv"),
+			wantFilename: "statement.csv",
+			wantErr:      nil,
+		},
+		{
+			name:         "flag only",
+			args:         append([]string{"-f", "statement.csv"}, required...),
+			wantFilename: "statement.csv",
+			wantErr:      nil,
+		},
+		{
+			name:         "both",
+			args:         append([]string{"-f", "statement.csv", "other.csv"}, required...),
+			wantFilename: "",
+			wantErr:      errConflictingFile,
+		},
+		{
+			name:         "neither",
+			args:         required,
+			wantFilename: "",
+			wantErr:      errRequiredFlag,
+		},
+		{
+			name:         "several positional",
+			args:         append([]string{"statement.csv", "other.csv"}, required...),
+			wantFilename: "",
+			wantErr:      errTooManyFiles,
+		},
+		{
+			name:         "salt without confirmation",
+			args:         append([]string{"statement.csv", "-import-id-salt", "again"}, required...),
+			wantFilename: "",
+			wantErr:      errNotConfirmed,
+		},
+		{
+			name:         "salt confirmed",
+			args:         append([]string{"statement.csv", "-import-id-salt", "again", "-yes"}, required...),
+			wantFilename: "statement.csv",
+			wantErr:      nil,
+		},
+		{
+			name:         "hash import IDs",
+			args:         append([]string{"statement.csv", "-import-id-strategy", "hash"}, required...),
+			wantFilename: "statement.csv",
+			wantErr:      nil,
+		},
+		{
+			name:         "unknown import ID strategy",
+			args:         append([]string{"statement.csv", "-import-id-strategy", "uuid"}, required...),
+			wantFilename: "",
+			wantErr:      errUnknownImportIDStrategy,
+		},
+		{
+			name:         "json",
+			args:         append([]string{"statement.csv", "-json"}, required...),
+			wantFilename: "statement.csv",
+			wantErr:      nil,
+		},
+		{
+			name:         "json with json output",
+			args:         append([]string{"statement.csv", "-json", "-output", "json"}, required...),
+			wantFilename: "",
+			wantErr:      errConflictingMode,
+		},
+		{
+			name:         "json with text output",
+			args:         append([]string{"statement.csv", "-json", "-output", "text"}, required...),
+			wantFilename: "",
+			wantErr:      errConflictingMode,
+		},
+		{
+			name:         "json with report to stdout",
+			args:         append([]string{"statement.csv", "-json", "-report", "-"}, required...),
+			wantFilename: "",
+			wantErr:      errConflictingOutput,
+		},
+		{
+			name:         "salt given twice",
+			args:         append([]string{"statement.csv", "-import-id-salt", "again", "-force-new-import-ids", "-yes"}, required...),
+			wantFilename: "",
+			wantErr:      errConflictingSalt,
+		},
+		{
+			name:         "negative webhook timeout",
+			args:         append([]string{"statement.csv", "-webhook-timeout", "-1s"}, required...),
+			wantFilename: "",
+			wantErr:      errInvalidTimeout,
+		},
+		{
+			name:         "negative drift alert",
+			args:         append([]string{"statement.csv", "-drift-alert", "-5"}, required...),
+			wantFilename: "",
+			wantErr:      errInvalidDrift,
+		},
+		{
+			name:         "unknown encoding",
+			args:         append([]string{"statement.csv", "-encoding", "ebcdic"}, required...),
+			wantFilename: "",
+			wantErr:      errUnknownEncoding,
+		},
+		{
+			name:         "unknown sort order",
+			args:         append([]string{"statement.csv", "-sort", "amount"}, required...),
+			wantFilename: "",
+			wantErr:      errUnknownSort,
+		},
+		{
+			name:         "negative progress",
+			args:         append([]string{"statement.csv", "-progress", "-1"}, required...),
+			wantFilename: "",
+			wantErr:      errInvalidProgress,
+		},
+		{
+			name:         "print paths skips required flags",
+			args:         []string{"-print-paths"},
+			wantFilename: "",
+			wantErr:      nil,
+		},
+		{
+			name:         "cleanup skips required flags",
+			args:         []string{"-cleanup", "-dry-run"},
+			wantFilename: "",
+			wantErr:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseFlags(tt.args, env{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got.filename != tt.wantFilename {
+				t.Errorf("parseFlags() filename = %v, want %v", got.filename, tt.wantFilename)
+			}
+
+			required := !got.printPaths && !got.cleanup
+			if required && (got.token != "tok" || got.budgetID != testBudgetID || got.accountID != testAccountID) {
+				t.Errorf("parseFlags() got = %+v, want required flags set", got)
+			}
+		})
+	}
+}
+
+func Test_parseFlags_environment(t *testing.T) {
+	t.Parallel()
+
+	variables := map[string]string{
+		envToken:     "env-tok",
+		envBudgetID:  "env-bud",
+		envAccountID: "env-acc",
+	}
+	getenv := func(name string) string { return variables[name] }
+
+	got, err := parseFlags([]string{"-b", "flag-bud", "statement.csv"}, env{getenv: getenv})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+
+	if got.token != "env-tok" || got.budgetID != "flag-bud" || got.accountID != "env-acc" {
+		t.Errorf("parseFlags() = %v, %v, %v, want env-tok, flag-bud, env-acc", got.token, got.budgetID, got.accountID)
+	}
+
+	_, err = parseFlags([]string{"-b", "flag-bud", "statement.csv"}, env{getenv: func(string) string { return "" }})
+	if !errors.Is(err, errRequiredFlag) {
+		t.Errorf("parseFlags() error = %v, want %v", err, errRequiredFlag)
+	}
+}
+
+func Test_parseFlags_config(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	content := "token: conf-tok\nbudget_id: conf-bud\naccount_id: conf-acc\ndrift-alert: 5\n"
+
+	if err := os.WriteFile(filepath.Join(home, ".lcl-ynab.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	variables := map[string]string{"HOME": home, envAccountID: "env-acc"}
+	getenv := func(name string) string { return variables[name] }
+
+	got, err := parseFlags([]string{"-b", "flag-bud", "statement.csv"}, env{getenv: getenv})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+
+	if got.token != "conf-tok" || got.budgetID != "flag-bud" || got.accountID != "env-acc" || got.driftAlert != 5 {
+		t.Errorf("parseFlags() = %v, %v, %v, %v, want conf-tok, flag-bud, env-acc, 5",
+			got.token, got.budgetID, got.accountID, got.driftAlert)
+	}
+
+	// Without a file at the default path, the flags are required as before.
+	emptyHome := t.TempDir()
+
+	_, err = parseFlags([]string{"statement.csv"}, env{getenv: func(name string) string {
+		return map[string]string{"HOME": emptyHome}[name]
+	}})
+	if !errors.Is(err, errRequiredFlag) {
+		t.Errorf("parseFlags() without config error = %v, want %v", err, errRequiredFlag)
+	}
+
+	_, err = parseFlags([]string{"-config", filepath.Join(home, "missing.yaml"), "statement.csv"}, env{getenv: getenv})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("parseFlags() with a missing -config error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func Test_parseFlags_configFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"conf/push.toml": {Data: []byte("token = \"fs-tok\"\nbudget_id = \"fs-bud\"\n")}}
+
+	got, err := parseFlags([]string{"-config", "conf/push.toml", "-a", testAccountID, "statement.csv"}, env{fsys: fsys})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+
+	if got.token != "fs-tok" || got.budgetID != "fs-bud" {
+		t.Errorf("parseFlags() = %v, %v, want fs-tok, fs-bud", got.token, got.budgetID)
+	}
+}
+
+func Test_run_printPathsEnv(t *testing.T) {
+	t.Parallel()
+
+	base := t.TempDir()
+	variables := map[string]string{
+		"XDG_CONFIG_HOME": filepath.Join(base, "config"),
+		"XDG_STATE_HOME":  filepath.Join(base, "state"),
+		"XDG_CACHE_HOME":  filepath.Join(base, "cache"),
+	}
+	stdout := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{"-print-paths", "-profile", "work"}, env{
+		stdout: stdout,
+		getenv: func(name string) string { return variables[name] },
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	want := filepath.Join(base, "state", "lcl-ynab", "work", "push-state.json")
+	if !strings.Contains(stdout.String(), want) {
+		t.Errorf("run() printed %q, want the state file %v", stdout.String(), want)
+	}
+}
+
+// sharedConfig configures both push and download.
+const sharedConfig = `token = "conf-tok"
+budget_id = "conf-bud"
+account_id = "conf-acc"
+identifier = "0123456789"
+password = "123456"
+headless = true
+
+[retention]
+screenshots_max_age = "720h"
+`
+
+func Test_parseFlags_sharedConfig(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(sharedConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := parseFlags([]string{"-config", path, "statement.csv"}, env{})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+
+	if got.token != "conf-tok" || got.budgetID != "conf-bud" || got.accountID != "conf-acc" {
+		t.Errorf("parseFlags() = %v, %v, %v, want conf-tok, conf-bud, conf-acc", got.token, got.budgetID, got.accountID)
+	}
+
+	if err := os.WriteFile(path, []byte(sharedConfig+"tokn = \"typo\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys of no command still fail.
+	_, err = parseFlags([]string{"-config", path, "statement.csv"}, env{})
+	if !errors.Is(err, config.ErrUnknownKey) {
+		t.Errorf("parseFlags() error = %v, want %v", err, config.ErrUnknownKey)
+	}
+}
+
+func TestConfigKeys(t *testing.T) {
+	t.Parallel()
+
+	var keys []string
+
+	newFlagSet(&options{}).VisitAll(func(f *flag.Flag) { keys = append(keys, f.Name) })
+
+	for key := range configAliases() {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	if want := config.Keys(config.CommandPush); !slices.Equal(keys, want) {
+		t.Errorf("flags and aliases = %q, want the keys of the config %q", keys, want)
+	}
+}
+
+func Test_parseFlags_partialConfig(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	dir := filepath.Join(home, ".config", "lcl-ynab")
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte("token = \"conf-tok\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	getenv := func(name string) string { return map[string]string{"HOME": home}[name] }
+
+	got, err := parseFlags([]string{"-b", "flag-bud", "-a", "flag-acc", "statement.csv"}, env{getenv: getenv})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+
+	if got.token != "conf-tok" || got.budgetID != "flag-bud" || got.accountID != "flag-acc" {
+		t.Errorf("parseFlags() = %v, %v, %v, want conf-tok, flag-bud, flag-acc", got.token, got.budgetID, got.accountID)
+	}
+
+	// The budget is still required once the config is applied.
+	_, err = parseFlags([]string{"-a", "flag-acc", "statement.csv"}, env{getenv: getenv})
+	if !errors.Is(err, errRequiredFlag) || !strings.Contains(err.Error(), "-b") {
+		t.Errorf("parseFlags() without budget error = %v, want %v: -b", err, errRequiredFlag)
+	}
+}
+
+func Test_parseFlags_tokenFile(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"token":       {Data: []byte("  file-tok\n\n")},
+		"empty-token": {Data: []byte("\n")},
+	}
+	getenv := func(name string) string { return map[string]string{envToken: "env-tok"}[name] }
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantToken string
+		wantErr   error
+	}{
+		{name: "file", args: []string{"-token-file", "token"}, wantToken: "file-tok"},
+		{name: "empty", args: []string{"-token-file", "empty-token"}, wantErr: errInvalidTokenFile},
+		{name: "missing", args: []string{"-token-file", "missing"}, wantErr: errInvalidTokenFile},
+		{name: "both", args: []string{"-token-file", "token", "-t", "flag-tok"}, wantErr: errConflictingToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseFlags(append([]string{"-b", testBudgetID, "-a", testAccountID, "statement.csv"}, tt.args...),
+				env{fsys: fsys, getenv: getenv})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("parseFlags() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.token != tt.wantToken {
+				t.Errorf("parseFlags() token = %q, want %q", got.token, tt.wantToken)
+			}
+		})
+	}
+}
+
+func Test_saltImportIDs(t *testing.T) {
+	t.Parallel()
+
+	transactions := []Transaction{
+		{ImportID: "YNAB:-1234560:2024-11-30:1"},
+		{ImportID: "YNAB:-1234560:2024-11-30:2"},
+	}
+
+	saltImportIDs(transactions, "again")
+
+	first, second := transactions[0].ImportID, transactions[1].ImportID
+	if first == "YNAB:-1234560:2024-11-30:1" || first[8:] != ":-1234560:2024-11-30:1" || first[:8] != second[:8] {
+		t.Errorf("saltImportIDs() = %v, %v, want the YNAB prefix replaced by the same hash", first, second)
+	}
+
+	if len(first) > 36 { //nolint:mnd // YNAB limit
+		t.Errorf("saltImportIDs() = %v, longer than 36 characters", first)
+	}
+
+	other := []Transaction{{ImportID: "YNAB:-1234560:2024-11-30:1"}}
+	saltImportIDs(other, "once more")
+
+	if other[0].ImportID == first {
+		t.Errorf("saltImportIDs() = %v for two different salts", first)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "generic", err: errRequiredFlag, want: 1},
+		{name: "cancelled", err: fmt.Errorf("pushing to YNAB: %w", context.Canceled), want: exitCancelled},
+		{name: "notification failed", err: errNotificationFailed, want: exitNotificationFailed},
+		{name: "nothing to push", err: errNothingToPush, want: exitNothingToPush},
+		{
+			name: "auth",
+			err:  fmt.Errorf("pushing to YNAB: %w", &lclynab.APIError{Status: http.StatusUnauthorized}),
+			want: exitAuth,
+		},
+		{
+			name: "rate limited",
+			err:  fmt.Errorf("pushing to YNAB: %w", &lclynab.APIError{Status: http.StatusTooManyRequests}),
+			want: exitRateLimited,
+		},
+		{
+			name: "other API error",
+			err:  fmt.Errorf("pushing to YNAB: %w", &lclynab.APIError{Status: http.StatusNotFound}),
+			want: exitGeneric,
+		},
+		{
+			name: "duplicates and notification failed",
+			err:  errors.Join(errTooManyDuplicates, errNotificationFailed),
+			want: exitTooManyDuplicates,
+		},
+		{
+			name: "drift and notification failed",
+			err:  errors.Join(fmt.Errorf("%w: +5.01\u20ac", errDriftExceeded), errNotificationFailed),
+			want: exitDriftExceeded,
+		},
+		{
+			name: "discrepancies",
+			err:  fmt.Errorf("%w: 2 discrepancies", errDiscrepancies),
+			want: exitDiscrepancies,
+		},
+		{
+			name: "cap exceeded",
+			err:  fmt.Errorf("%w: carrefour in 2024-10", errCapExceeded),
+			want: exitCapExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ynabHint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{status: http.StatusBadRequest, want: "check the account given with -a"},
+		{status: http.StatusUnauthorized, want: "check the token given with -t"},
+		{status: http.StatusNotFound, want: "check the budget given with -b"},
+		{status: http.StatusTooManyRequests, want: "wait for the rate limit to reset before retrying"},
+		{status: http.StatusServiceUnavailable, want: "YNAB failed, retry later"},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			t.Parallel()
+
+			if got := ynabHint(&lclynab.APIError{Status: tt.status}); got != tt.want {
+				t.Errorf("ynabHint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	return ctx
+}
+
+// testBudgetID and testAccountID are IDs of the YNAB form, which push takes as IDs
+// rather than names to resolve.
+const (
+	testBudgetID  = "5f3b1d2c-8a4e-4c6f-9b2a-1e7d3c5a9f80"
+	testAccountID = "a1c2e3f4-0b6d-4e8a-9c1f-3b5d7e9a2c64"
+)
+
+// createdResponder answers a push as YNAB does: an ID for each transaction sent, the
+// duplicates among them, and the import IDs of the duplicates.
+func createdResponder(status int, duplicates ...string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		var payload struct {
+			Transactions []Transaction `json:"transactions"`
+		}
+
+		// The body may have been read by the test already.
+		body := req.Body
+		if req.GetBody != nil {
+			if copied, err := req.GetBody(); err == nil {
+				body = copied
+			}
+		}
+
+		if err := json.NewDecoder(body).Decode(&payload); err != nil {
+			return nil, err
+		}
+
+		created := lclynab.CreatedTransactions{TransactionIDs: []string{}, DuplicateImportIDs: duplicates}
+		for i := range payload.Transactions {
+			created.TransactionIDs = append(created.TransactionIDs, fmt.Sprintf("t-%d", i+1))
+		}
+
+		return httpmock.NewJsonResponse(status, map[string]any{"data": created})
+	}
+}
+
+func fixedNow() time.Time {
+	return time.Date(2024, 11, 30, 3, 0, 0, 0, time.UTC)
+}
+
+// testGetenv is an environment whose HOME is a temporary directory, keeping the default
+// state and config files of the tests away from those of the user.
+func testGetenv(t *testing.T) func(string) string {
+	t.Helper()
+
+	home := t.TempDir()
+
+	return func(name string) string {
+		if name == "HOME" {
+			return home
+		}
+
+		return ""
+	}
+}
+
+func Test_run_jsonLogs(t *testing.T) {
+	t.Parallel()
+
+	const webhook = "https://ha.example/api/webhook/s3cr3t-id"
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK),
+	)
+	transport.RegisterResponder(http.MethodPost, webhook, httpmock.NewStringResponder(http.StatusBadGateway, ""))
+
+	stderr := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{
+		"-t", "s3cr3t-token", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-w", webhook, "-webhook-backoff", "1ms", "-log-format", "json", "-log-level", "debug",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     stderr,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if !errors.Is(err, errNotificationFailed) {
+		t.Fatalf("run() error = %v, want %v", err, errNotificationFailed)
+	}
+
+	if strings.Contains(stderr.String(), "s3cr3t") {
+		t.Errorf("logs leak a secret:\n%s", stderr)
+	}
+
+	var messages []string
+
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		var record struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line is not JSON: %v\n%s", err, line)
+		}
+
+		messages = append(messages, record.Level+" "+record.Msg)
+	}
+
+	want := []string{
+		"DEBUG converting file",
+		"DEBUG converted transactions",
+		"DEBUG pushing transactions",
+		"DEBUG sending webhook",
+		"WARN webhook failed, retrying",
+		"WARN webhook failed, retrying",
+		"WARN sending webhook failed",
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("log messages = %v, want %v", messages, want)
+	}
+}
+
+func BenchmarkConvert(b *testing.B) {
+	input := largeLCLExport(50000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if _, _, err := convert(context.Background(), strings.NewReader(input), "acc-id", importerOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// largeLCLExport returns an LCL export of n transactions over a year, alternating
+// card payments, which carry their own date, and transfers.
+func largeLCLExport(n int) string {
+	var export strings.Builder
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range n {
+		date := day.AddDate(0, 0, i%365)
+		if i%2 == 0 {
+			fmt.Fprintf(&export, "%v;-%d,%02d;Carte;;CB  MERCH %d          %v;;0;Divers\n",
+				date.Format("02/01/2006"), i%200, i%100, i%50, date.AddDate(0, 0, -1).Format("02/01/06"))
+		} else {
+			fmt.Fprintf(&export, "%v;%d,%02d;Virement;;;VIREMENT M JEAN MARTIN %d;;\n",
+				date.Format("02/01/2006"), i%900, i%100, i%20)
+		}
+	}
+
+	export.WriteString("31/12/2024;100,06;;01234 123456A")
+
+	return export.String()
+}
@@ -0,0 +1,201 @@
+package push
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// Day formats of QIF dates, the year written with four or two digits.
+const (
+	qifDateFormat      = "2/1/2006"
+	qifShortDateFormat = "2/1/06"
+)
+
+var (
+	errInvalidQIF = errors.New("invalid QIF")
+	errMixedQIF   = errors.New("QIF file holds several accounts")
+)
+
+//nolint:gochecknoglobals // constant list
+var qifAccountTypes = []string{"Bank", "Cash", "CCard", "Oth A", "Oth L"}
+
+// qifTransaction holds the fields of a QIF record push reads.
+type qifTransaction struct {
+	date, amount, payee, memo string
+}
+
+func qifFormat() format {
+	return format{
+		name:       formatQIF,
+		extensions: []string{".qif"},
+		sniff: func(head []byte) bool {
+			head = trimBOM(head)
+
+			return bytes.HasPrefix(head, []byte("!Type:")) || bytes.HasPrefix(head, []byte("!Account"))
+		},
+		newImporter: func(opts importerOptions) importer {
+			return importerFunc(func(ctx context.Context, reader io.Reader, accountID string) ([]Transaction, balance, error) {
+				return convertQIF(ctx, reader, accountID, opts)
+			})
+		},
+	}
+}
+
+// convertQIF reads the bank section of a QIF file and turns its D, T, P and M lines into
+// YNAB transactions. QIF has no balance, so the reconciled one is left zero. A file
+// listing several accounts or sections fails with errMixedQIF rather than merging them.
+func convertQIF(
+	ctx context.Context,
+	reader io.Reader,
+	accountID string,
+	opts importerOptions,
+) ([]Transaction, balance, error) {
+	if reader == nil {
+		return nil, balance{}, nil
+	}
+
+	var (
+		transactions []Transaction
+		current      qifTransaction
+		sections     int
+		accounts     int
+		line         int
+		read         int
+	)
+
+	importIDs := make(map[string]int)
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+
+		if line == 1 {
+			text = string(trimBOM([]byte(text)))
+		}
+
+		if text == "" {
+			continue
+		}
+
+		code, value := text[0], strings.TrimSpace(text[1:])
+
+		switch code {
+		case '!':
+			kind, isType := strings.CutPrefix(value, "Type:")
+
+			switch {
+			case value == "Account":
+				accounts++
+			case !isType:
+				continue
+			case !slices.Contains(qifAccountTypes, kind):
+				return nil, balance{}, fmt.Errorf("%w: line %d: unsupported section %q", errInvalidQIF, line, kind)
+			default:
+				sections++
+			}
+
+			if accounts > 1 || sections > 1 {
+				return nil, balance{}, fmt.Errorf("%w: line %d starts another one, export accounts separately",
+					errMixedQIF, line)
+			}
+		case '^':
+			if accounts > 0 && sections == 0 {
+				// The end of an account description.
+				current = qifTransaction{}
+
+				continue
+			}
+
+			read++
+
+			if err := ctx.Err(); err != nil {
+				return nil, balance{}, fmt.Errorf("after transaction %d: %w", read, err)
+			}
+
+			transaction, err := convertQIFTransaction(current, accountID, importIDs)
+			if err != nil {
+				return nil, balance{}, fmt.Errorf("transaction %d: %w", read, err)
+			}
+
+			transactions = append(transactions, transaction)
+			current = qifTransaction{}
+
+			if opts.progress != nil {
+				opts.progress(lclynab.Progress{Lines: line, Transactions: len(transactions)})
+			}
+		default:
+			current.set(code, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, balance{}, fmt.Errorf("reading QIF: %w", err)
+	}
+
+	return transactions, balance{}, nil
+}
+
+func (t *qifTransaction) set(code byte, value string) {
+	switch code {
+	case 'D':
+		t.date = value
+	case 'T':
+		t.amount = value
+	case 'P':
+		t.payee = value
+	case 'M':
+		t.memo = value
+	}
+}
+
+func convertQIFTransaction(t qifTransaction, accountID string, importIDs map[string]int) (Transaction, error) {
+	day, err := parseQIFDate(t.date)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	amount, err := parseQIFAmount(t.amount)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	//nolint:wrapcheck // already explicit
+	return lclynab.NewTransaction(accountID, day, int(amount),
+		lclynab.WithPayee(t.payee),
+		lclynab.WithMemo(cmp.Or(t.memo, t.payee)),
+		lclynab.WithCleared(lclynab.ClearedCleared),
+		lclynab.WithImportID(lclynab.ImportID(int(amount), day, importIDs)),
+	)
+}
+
+// parseQIFDate reads a date like 29/10/2024 or 29/10/24.
+func parseQIFDate(s string) (lclynab.Date, error) {
+	for _, layout := range []string{qifDateFormat, qifShortDateFormat} {
+		if day, err := time.Parse(layout, s); err == nil {
+			return lclynab.NewDate(day), nil
+		}
+	}
+
+	return lclynab.Date{}, fmt.Errorf("%w date: %q, want dd/mm/yyyy or dd/mm/yy", errInvalidQIF, s)
+}
+
+// parseQIFAmount reads an amount like -12,50 or 1,234.56, where a comma followed
+// by a point separates thousands.
+func parseQIFAmount(s string) (lclynab.Milliunits, error) {
+	if strings.Contains(s, ".") {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	return lclynab.ParseAmount(s) //nolint:wrapcheck // already explicit
+}
@@ -0,0 +1,130 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//nolint:funlen // mostly test cases in list
+func Test_convertQIF(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.ReadFile("./testdata/lcl.qif")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const withAccount = "!Account\nNCompte courant\nTBank\n^\n!Type:Bank\nD31/10/2024\nT-12.50\nPCB BOULANGERIE\n^\n"
+
+	tests := []struct {
+		name             string
+		input            string
+		wantTransactions []Transaction
+		wantErr          error
+	}{
+		{
+			name:  "lcl",
+			input: string(fixture),
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-29"),
+					Amount:    80000,
+					PayeeName: "VIREMENT M JEAN MARTIN",
+					Memo:      "VIREMENT M JEAN MARTIN OU MME",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:80000:2024-10-29:1",
+				},
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-31"),
+					Amount:    -12500,
+					PayeeName: "CB BOULANGERIE",
+					Memo:      "CB BOULANGERIE",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:-12500:2024-10-31:1",
+				},
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-31"),
+					Amount:    -1234560,
+					PayeeName: "CB MEUBLES",
+					Memo:      "CB MEUBLES 30/10",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:-1234560:2024-10-31:1",
+				},
+			},
+		},
+		{
+			name:  "account header",
+			input: withAccount,
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-31"),
+					Amount:    -12500,
+					PayeeName: "CB BOULANGERIE",
+					Memo:      "CB BOULANGERIE",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:-12500:2024-10-31:1",
+				},
+			},
+		},
+		{
+			name:    "two accounts",
+			input:   withAccount + strings.Replace(withAccount, "Compte courant", "Livret A", 1),
+			wantErr: errMixedQIF,
+		},
+		{
+			name:    "two sections",
+			input:   string(fixture) + "!Type:CCard\nD01/11/2024\nT-5.00\nPCB PRESSE\n^\n",
+			wantErr: errMixedQIF,
+		},
+		{
+			name:    "investments",
+			input:   "!Type:Invst\nD29/10/2024\nNBuy\n^\n",
+			wantErr: errInvalidQIF,
+		},
+		{
+			name:    "invalid date",
+			input:   "!Type:Bank\nD2024-10-29\nT80.00\n^\n",
+			wantErr: errInvalidQIF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transactions, reconciled, err := convertQIF(context.Background(), strings.NewReader(tt.input), "acc-id",
+				importerOptions{warnings: io.Discard})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("convertQIF() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(transactions, tt.wantTransactions) {
+				t.Errorf("convertQIF() transactions = %+v, want %+v", transactions, tt.wantTransactions)
+			}
+
+			if reconciled != (balance{}) {
+				t.Errorf("convertQIF() reconciled = %+v, want none", reconciled)
+			}
+		})
+	}
+}
+
+func Test_parseQIFDate(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"29/10/2024", "29/10/24"} {
+		got, err := parseQIFDate(s)
+		if err != nil || !got.Equal(mustDate("2024-10-29")) {
+			t.Errorf("parseQIFDate(%q) = %v, %v, want 2024-10-29", s, got, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// refundDateFormat is how memos refer to the day of the other transaction.
+const refundDateFormat = "02/01"
+
+var errAmbiguousRefund = errors.New("several charges match the refund")
+
+// refundLinks is what detectRefunds found.
+type refundLinks struct {
+	linked int
+	// ambiguous are refunds matching several charges, left untouched.
+	ambiguous []Transaction
+}
+
+// detectRefunds links each inflow to the outflow of the same payee and absolute amount
+// dated at most window days before, annotating both memos. With withCategory, a refund
+// without a category gets the one of its charge. An outflow is refunded at most once.
+func detectRefunds(transactions []Transaction, window int, withCategory bool) refundLinks {
+	var links refundLinks
+
+	refunded := make([]bool, len(transactions))
+
+	for i, refund := range transactions {
+		if refund.Amount <= 0 {
+			continue
+		}
+
+		candidates := refundCandidates(transactions, refunded, refund, window)
+
+		switch {
+		case len(candidates) > 1:
+			links.ambiguous = append(links.ambiguous, refund)
+
+			continue
+		case len(candidates) == 0:
+			continue
+		}
+
+		charge := transactions[candidates[0]]
+
+		refundOpts := []lclynab.TransactionOption{
+			lclynab.WithMemo(annotate(refund.Memo, "remboursement de l'achat du "+charge.Date.Format(refundDateFormat))),
+		}
+		if withCategory && refund.CategoryID == nil && charge.CategoryID != nil {
+			refundOpts = append(refundOpts, lclynab.WithCategory(*charge.CategoryID))
+		}
+
+		updatedRefund, err := refund.With(refundOpts...)
+		if err != nil {
+			continue
+		}
+
+		updatedCharge, err := charge.With(
+			lclynab.WithMemo(annotate(charge.Memo, "remboursé le "+refund.Date.Format(refundDateFormat))))
+		if err != nil {
+			continue
+		}
+
+		transactions[i], transactions[candidates[0]] = updatedRefund, updatedCharge
+		refunded[candidates[0]] = true
+		links.linked++
+	}
+
+	return links
+}
+
+// refundCandidates returns the indexes of the outflows refund could be refunding.
+func refundCandidates(transactions []Transaction, refunded []bool, refund Transaction, window int) []int {
+	var candidates []int
+
+	payee := normalizePayee(refund.PayeeName)
+
+	for j, charge := range transactions {
+		if refunded[j] || charge.Amount != -refund.Amount || normalizePayee(charge.PayeeName) != payee {
+			continue
+		}
+
+		if days := daysBetween(refund.Date, charge.Date); days >= 0 && days <= window {
+			candidates = append(candidates, j)
+		}
+	}
+
+	return candidates
+}
+
+// annotate appends note to memo.
+func annotate(memo, note string) string {
+	if memo == "" {
+		return note
+	}
+
+	return memo + " - " + note
+}
+
+func printRefunds(w io.Writer, links refundLinks, amounts amountFormat) {
+	_, _ = fmt.Fprintf(w, "refunds: %d linked, %d ambiguous\n", links.linked, len(links.ambiguous))
+
+	for _, refund := range links.ambiguous {
+		_, _ = fmt.Fprintf(w, "  %v  %v  %v: several charges match, left as is\n",
+			refund.Date, amounts.signed(refund.Amount), refund.PayeeName)
+	}
+}
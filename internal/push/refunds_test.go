@@ -0,0 +1,167 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_detectRefunds(t *testing.T) {
+	t.Parallel()
+
+	charge := Transaction{Date: mustDate("2024-10-12"), Amount: -45000, PayeeName: "CB  DECATHLON", Memo: "CB DECATHLON"}
+	refund := Transaction{Date: mustDate("2024-10-15"), Amount: 45000, PayeeName: "CB DECATHLON", Memo: "CB DECATHLON"}
+
+	tests := []struct {
+		name         string
+		transactions []Transaction
+		window       int
+		withCategory bool
+		want         []Transaction
+		wantLinked   int
+		wantAmbig    int
+	}{
+		{
+			name:         "matched",
+			transactions: []Transaction{charge, refund},
+			window:       30,
+			want: []Transaction{
+				withMemo(charge, "CB DECATHLON - remboursé le 15/10"),
+				withMemo(refund, "CB DECATHLON - remboursement de l'achat du 12/10"),
+			},
+			wantLinked: 1,
+		},
+		{
+			name:         "matched with category",
+			transactions: []Transaction{withCategoryID(charge, "cat-sport"), refund},
+			window:       30,
+			withCategory: true,
+			want: []Transaction{
+				withMemo(withCategoryID(charge, "cat-sport"), "CB DECATHLON - remboursé le 15/10"),
+				withMemo(withCategoryID(refund, "cat-sport"), "CB DECATHLON - remboursement de l'achat du 12/10"),
+			},
+			wantLinked: 1,
+		},
+		{
+			name:         "outside the window",
+			transactions: []Transaction{charge, refund},
+			window:       2,
+			want:         []Transaction{charge, refund},
+		},
+		{
+			name: "other amount or payee",
+			transactions: []Transaction{
+				charge, {Date: refund.Date, Amount: 40000, PayeeName: "CB DECATHLON"},
+				{Date: refund.Date, Amount: 45000, PayeeName: "CB INTERSPORT"},
+			},
+			window: 30,
+			want: []Transaction{
+				charge, {Date: refund.Date, Amount: 40000, PayeeName: "CB DECATHLON"},
+				{Date: refund.Date, Amount: 45000, PayeeName: "CB INTERSPORT"},
+			},
+		},
+		{
+			name:         "ambiguous",
+			transactions: []Transaction{charge, withMemo(charge, "second"), refund},
+			window:       30,
+			want:         []Transaction{charge, withMemo(charge, "second"), refund},
+			wantAmbig:    1,
+		},
+		{
+			name:         "each charge refunded once",
+			transactions: []Transaction{charge, refund, refund},
+			window:       30,
+			want: []Transaction{
+				withMemo(charge, "CB DECATHLON - remboursé le 15/10"),
+				withMemo(refund, "CB DECATHLON - remboursement de l'achat du 12/10"),
+				refund,
+			},
+			wantLinked: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			links := detectRefunds(tt.transactions, tt.window, tt.withCategory)
+
+			if links.linked != tt.wantLinked || len(links.ambiguous) != tt.wantAmbig {
+				t.Errorf("detectRefunds() = %d linked, %d ambiguous, want %d and %d",
+					links.linked, len(links.ambiguous), tt.wantLinked, tt.wantAmbig)
+			}
+
+			if !reflect.DeepEqual(tt.transactions, tt.want) {
+				t.Errorf("transactions = %+v, want %+v", tt.transactions, tt.want)
+			}
+		})
+	}
+}
+
+func withMemo(transaction Transaction, memo string) Transaction {
+	transaction.Memo = memo
+
+	return transaction
+}
+
+func withCategoryID(transaction Transaction, categoryID string) Transaction {
+	transaction.CategoryID = &categoryID
+
+	return transaction
+}
+
+func Test_run_detectRefunds(t *testing.T) {
+	t.Parallel()
+
+	var payload struct {
+		Transactions []struct {
+			Memo string `json:"memo"`
+		} `json:"transactions"`
+	}
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+				t.Error(err)
+			}
+
+			return createdResponder(http.StatusOK)(req)
+		})
+
+	stdout := &bytes.Buffer{}
+
+	// refunds.csv holds a charge of 45 and its refund three days later.
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/refunds.csv",
+		"-state", filepath.Join(t.TempDir(), "state.json"), "-detect-refunds", "30",
+	}, env{stdout: stdout, stderr: io.Discard, httpClient: &http.Client{Transport: transport}, now: fixedNow})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if want := "refunds: 1 linked, 0 ambiguous\n"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("stdout = \n%s\nwant it to contain %q", stdout, want)
+	}
+
+	var memos []string
+	for _, transaction := range payload.Transactions {
+		memos = append(memos, transaction.Memo)
+	}
+
+	want := []string{
+		"CB  DECATHLON      11/10/24 - remboursé le 14/10",
+		"CB  DECATHLON      14/10/24 - remboursement de l'achat du 11/10",
+	}
+	if !reflect.DeepEqual(memos, want) {
+		t.Errorf("memos = %q, want %q", memos, want)
+	}
+}
@@ -0,0 +1,241 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/atomicfile"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/timing"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// reportSchema is bumped on any incompatible change to the result JSON.
+const reportSchema = 1
+
+const (
+	statusOK                 = "ok"
+	statusError              = "error"
+	statusCancelled          = "cancelled"
+	statusNotificationFailed = "notification_failed"
+	statusEmpty              = "empty"
+)
+
+// Result is the outcome of a run, as written to the JSON report.
+type Result struct {
+	Schema    int       `json:"schema"`
+	RunID     string    `json:"run_id"`
+	Status    string    `json:"status"`
+	DryRun    bool      `json:"dry_run,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	InputFile string    `json:"input_file"`
+	Counts    counts    `json:"counts"`
+	// SkippedByReason breaks Counts.Filtered down.
+	SkippedByReason map[skipReason]int `json:"skipped_by_reason,omitempty"`
+	Reconciled      int                `json:"reconciled_milliunits"`
+	Currency        string             `json:"currency,omitempty"`
+	ReconciledDate  string             `json:"reconciled_date"`
+	Drift           *int               `json:"drift_milliunits,omitempty"`
+	DriftExceeded   bool               `json:"drift_exceeded,omitempty"`
+	Timings         []timing.Span      `json:"timings"`
+	Warnings        []string           `json:"warnings"`
+	// Notifications are the outcomes of the notification channels, in the order they were notified.
+	Notifications []notification `json:"notifications,omitempty"`
+
+	// BudgetName and AccountName fall back to the IDs when names aren't resolved.
+	BudgetName  string `json:"budget_name"`
+	AccountName string `json:"account_name"`
+
+	// ImportIDSalt is recorded so that a salted push can be reproduced.
+	ImportIDSalt string `json:"import_id_salt,omitempty"`
+
+	// Transactions are the converted ones, as pushed or as a dry run would push them.
+	Transactions []Transaction `json:"transactions,omitempty"`
+}
+
+// counts are shared with lclynab.Sync results.
+type counts = lclynab.Counts
+
+func newResult(runID string, startedAt time.Time, inputFile, budgetID, accountID string) *Result {
+	return &Result{
+		Schema:      reportSchema,
+		RunID:       runID,
+		StartedAt:   startedAt,
+		InputFile:   inputFile,
+		BudgetName:  budgetID,
+		AccountName: accountID,
+	}
+}
+
+// finish records the end of the run, with the secrets in its error redacted.
+func (r *Result) finish(
+	endedAt time.Time,
+	timings []timing.Span,
+	warnings []string,
+	err error,
+	redactor *logging.Redactor,
+) {
+	r.EndedAt = endedAt
+	r.Timings = timings
+	r.Warnings = warnings
+
+	if r.Timings == nil {
+		r.Timings = []timing.Span{}
+	}
+
+	if r.Warnings == nil {
+		r.Warnings = []string{}
+	}
+
+	r.Status = statusOf(err)
+
+	if err != nil {
+		r.Error = redactor.Redact(err.Error())
+	}
+}
+
+// statusOf returns the status reported for a run ending with err.
+func statusOf(err error) string {
+	switch {
+	case err == nil:
+		return statusOK
+	case errors.Is(err, context.Canceled):
+		return statusCancelled
+	case errors.Is(err, errDriftExceeded):
+		// A drift is a failure, even when nothing was pushed or a notification failed too.
+		return statusError
+	case errors.Is(err, errNotificationFailed):
+		return statusNotificationFailed
+	case errors.Is(err, errNothingToPush):
+		return statusEmpty
+	default:
+		return statusError
+	}
+}
+
+// writeReport writes the result as JSON to path, or to stdout when path is "-".
+func writeReport(path string, stdout io.Writer, res *Result) error {
+	if path == "-" {
+		return writeResult(stdout, res)
+	}
+
+	data, err := encodeResult(res)
+	if err != nil {
+		return err
+	}
+
+	const perm = 0o644
+	if err := atomicfile.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	return nil
+}
+
+// writeResult writes the result as JSON to w.
+func writeResult(w io.Writer, res *Result) error {
+	data, err := encodeResult(res)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing result: %w", err)
+	}
+
+	return nil
+}
+
+// jsonReporter stands for stdout with -json: it swallows the lines written for people and,
+// once the run is over, writes its outcome as a single JSON object.
+type jsonReporter struct {
+	w io.Writer
+	// result is set when the run gets to report one.
+	result *Result
+}
+
+// jsonSummary is what -json prints for a run that didn't fail.
+type jsonSummary struct {
+	Reconciled   json.Number   `json:"reconciled"`
+	Pushed       int           `json:"pushed"`
+	Duplicates   int           `json:"duplicates"`
+	Transactions []Transaction `json:"transactions"`
+}
+
+// jsonError is what -json prints for a failed run.
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+func (r *jsonReporter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// flush writes the summary of the run ending with err, or the error when it failed.
+// Runs with nothing to push or a failed notification still get their summary.
+func (r *jsonReporter) flush(err error) error {
+	var out any
+
+	switch status := statusOf(err); {
+	case r.result == nil && err != nil:
+		out = jsonError{Error: err.Error()}
+	case status == statusError || status == statusCancelled:
+		out = jsonError{Error: r.result.Error}
+	default:
+		summary := jsonSummary{Transactions: []Transaction{}}
+		if r.result != nil {
+			summary.Reconciled = json.Number(reconciledString(r.result.Reconciled))
+			summary.Pushed = r.result.Counts.Pushed
+			summary.Duplicates = r.result.Counts.Duplicates
+			summary.Transactions = append(summary.Transactions, r.result.Transactions...)
+		}
+
+		out = summary
+	}
+
+	if err := json.NewEncoder(r.w).Encode(out); err != nil {
+		return fmt.Errorf("writing result: %w", err)
+	}
+
+	return nil
+}
+
+func encodeResult(res *Result) ([]byte, error) {
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding result: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// warningCollector logs warnings written by importers and keeps them, redacted, for the report.
+type warningCollector struct {
+	logger   *slog.Logger
+	redactor *logging.Redactor
+	lines    []string
+}
+
+func (w *warningCollector) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		msg := strings.TrimPrefix(string(line), "warning: ")
+		w.logger.Warn(msg)
+		w.lines = append(w.lines, w.redactor.Redact(msg))
+	}
+
+	return len(p), nil
+}
+
+func (w *warningCollector) warn(msg string, err error) {
+	w.logger.Warn(msg, "error", err)
+	w.lines = append(w.lines, w.redactor.Redact(fmt.Sprintf("%v: %v", msg, err)))
+}
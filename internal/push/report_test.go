@@ -0,0 +1,290 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_report(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK, "1234"),
+	)
+
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv", "-report", reportPath,
+		"-run-id", "test-run",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	assertGolden(t, reportPath, "./testdata/report.golden.json")
+}
+
+func Test_run_reportOnFailure(t *testing.T) {
+	t.Parallel()
+
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/missing.csv", "-report", reportPath,
+		"-run-id", "test-run",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: httpmock.NewMockTransport()},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err == nil {
+		t.Fatal("run() error = nil, want error")
+	}
+
+	assertGolden(t, reportPath, "./testdata/report-failure.golden.json")
+}
+
+func Test_run_reportRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK),
+	)
+	// No responder for the webhook: its error quotes the URL.
+
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv", "-report", reportPath,
+		"-w", "https://hooks.example/ynab?key=s3cr3t", "-webhook-attempts", "1",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if !errors.Is(err, errNotificationFailed) {
+		t.Fatalf("run() error = %v, want errNotificationFailed", err)
+	}
+
+	content, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(content), "s3cr3t") || !strings.Contains(string(content), logging.Redacted) {
+		t.Errorf("report = %s, want the webhook URL redacted", content)
+	}
+}
+
+func Test_run_reportRunID(t *testing.T) {
+	t.Parallel()
+
+	const webhook = "https://hooks.example/ynab"
+
+	var notified string
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK),
+	)
+	transport.RegisterResponder(http.MethodPost, webhook, func(req *http.Request) (*http.Response, error) {
+		var body struct {
+			RunID string `json:"run_id"`
+		}
+
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		notified = body.RunID
+
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	stderr := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-report", reportPath, "-w", webhook, "-log-level", "debug",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     stderr,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	content, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report Result
+	if err := json.Unmarshal(content, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without -run-id, a random ID still ties the report, the notification and the logs together.
+	if report.RunID == "" || notified != report.RunID {
+		t.Errorf("run IDs = %q in the report, %q in the webhook, want the same one", report.RunID, notified)
+	}
+
+	if !strings.Contains(stderr.String(), "run_id="+report.RunID) {
+		t.Errorf("stderr = %v, want the run ID in the logs", stderr)
+	}
+}
+
+func assertGolden(t *testing.T, gotPath, goldenPath string) {
+	t.Helper()
+
+	got, err := os.ReadFile(gotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("%v = \n%s\nwant\n%s", gotPath, got, want)
+	}
+}
+
+func Test_run_outputJSON(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK, "1234"),
+	)
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv", "-output", "json", "-v",
+	}, env{
+		stdout:     stdout,
+		stderr:     stderr,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("stdout is not valid JSON: %v\n%s", err, stdout)
+	}
+
+	for _, key := range []string{"schema", "status", "counts", "reconciled_milliunits", "timings", "warnings"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("stdout JSON is missing %q: %s", key, stdout)
+		}
+	}
+
+	if gotCounts, _ := got["counts"].(map[string]any); gotCounts["duplicates"] != 1.0 {
+		t.Errorf("stdout JSON counts = %v, want 1 duplicate", gotCounts)
+	}
+
+	if !strings.Contains(stderr.String(), "transactions:") {
+		t.Errorf("stderr = %q, want verbose output", stderr)
+	}
+}
+
+func Test_run_jsonError(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+		httpmock.NewStringResponder(http.StatusUnauthorized,
+			`{"error": {"id": "401", "name": "unauthorized", "detail": "Unauthorized"}}`))
+
+	stdout := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv", "-json",
+	}, env{
+		stdout:     stdout,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err == nil {
+		t.Fatal("run() error = nil, want the push to fail")
+	}
+
+	want := `{"error":"pushing to YNAB: YNAB authentication failed: 401 unauthorized - Unauthorized"}` + "\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %s, want %s", stdout, want)
+	}
+}
+
+func Test_run_json(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK))
+
+	stdout := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv", "-json",
+		"-v", "-timings",
+	}, env{
+		stdout:     stdout,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	want := `{"reconciled":100.06,"pushed":1,"duplicates":0,"transactions":[{"account_id":"` + testAccountID +
+		`","date":"2024-10-29","amount":80000,"payee_name":"VIREMENT M JEAN MARTIN OU",` +
+		`"memo":"VIREMENT M JEAN MARTIN OU","cleared":"cleared","import_id":"YNAB:80000:2024-10-29:1"}]}` + "\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %s, want %s", stdout, want)
+	}
+}
@@ -0,0 +1,138 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+var (
+	errUnknownName   = errors.New("no such name")
+	errAmbiguousName = errors.New("ambiguous name")
+)
+
+// Values of -b and -a taken as IDs rather than names: the UUIDs of YNAB, and for budgets
+// its "last-used" and "default" aliases. Anything else, like "Compte courant" or "livret",
+// is a name.
+var (
+	budgetIDRegexp  = regexp.MustCompile(`^(?:[0-9a-fA-F]{8}(?:-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}|last-used|default)$`)
+	accountIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}(?:-[0-9a-fA-F]{4}){3}-[0-9a-fA-F]{12}$`)
+)
+
+// named is a budget or an account, as matched by name.
+type named struct {
+	id, name string
+}
+
+// resolveIDs replaces the budget and account names given with -b and -a with their IDs,
+// matched case-insensitively. With verbose, the IDs found are printed to w.
+func resolveIDs(ctx context.Context, client *lclynab.Client, opts *options, w io.Writer) error {
+	budgetIsName := !budgetIDRegexp.MatchString(opts.budgetID)
+	accountIsName := opts.accountID != "" && !accountIDRegexp.MatchString(opts.accountID)
+
+	if !budgetIsName && !accountIsName {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	budgets, err := client.ListBudgets(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	budgetIndex := slices.IndexFunc(budgets, func(b lclynab.Budget) bool { return b.ID == opts.budgetID })
+
+	if budgetIsName {
+		candidates := make([]named, 0, len(budgets))
+		for _, budget := range budgets {
+			candidates = append(candidates, named{id: budget.ID, name: budget.Name})
+		}
+
+		match, err := matchName("budget", opts.budgetID, candidates)
+		if err != nil {
+			return err
+		}
+
+		printResolved(w, opts.verbose, "budget", opts.budgetID, match.id)
+
+		opts.budgetID = match.id
+		budgetIndex = slices.IndexFunc(budgets, func(b lclynab.Budget) bool { return b.ID == match.id })
+	}
+
+	if !accountIsName {
+		return nil
+	}
+
+	// The "last-used" and "default" budgets aren't listed under these IDs.
+	var accounts []lclynab.Account
+	if budgetIndex >= 0 {
+		accounts = budgets[budgetIndex].Accounts
+	} else if accounts, err = client.ListAccounts(ctx, opts.budgetID); err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	candidates := make([]named, 0, len(accounts))
+
+	for _, account := range accounts {
+		if !account.Deleted {
+			candidates = append(candidates, named{id: account.ID, name: account.Name})
+		}
+	}
+
+	match, err := matchName("account", opts.accountID, candidates)
+	if err != nil {
+		return err
+	}
+
+	printResolved(w, opts.verbose, "account", opts.accountID, match.id)
+
+	opts.accountID = match.id
+
+	return nil
+}
+
+// matchName returns the candidate named name, ignoring case and surrounding spaces. The
+// error lists the candidates when none or several match.
+func matchName(kind, name string, candidates []named) (named, error) {
+	var matches []named
+
+	for _, candidate := range candidates {
+		if strings.EqualFold(strings.TrimSpace(candidate.name), strings.TrimSpace(name)) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return named{}, fmt.Errorf("%w: %v %q, want one of %v", errUnknownName, kind, name, describe(candidates))
+	default:
+		return named{}, fmt.Errorf("%w: %d %ss named %q: %v, give the ID",
+			errAmbiguousName, len(matches), kind, name, describe(matches))
+	}
+}
+
+// describe lists the candidates with their IDs.
+func describe(candidates []named) string {
+	parts := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		parts = append(parts, fmt.Sprintf("%q (%v)", candidate.name, candidate.id))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func printResolved(w io.Writer, verbose bool, kind, name, id string) {
+	if verbose {
+		_, _ = fmt.Fprintf(w, "%v %q: %v\n", kind, name, id)
+	}
+}
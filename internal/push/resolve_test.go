@@ -0,0 +1,140 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+// familleID is the ID of the "Famille" budget of resolveTransport.
+const familleID = "0d6e2b8c-3f1a-4c7e-9a5b-6e8f1d2c4b7a"
+
+func resolveTransport() *httpmock.MockTransport {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets", httpmock.NewStringResponder(http.StatusOK,
+		`{"data": {"budgets": [
+			{"id": "`+familleID+`", "name": "Famille", "accounts": [
+				{"id": "acc-courant", "name": "Compte courant LCL"},
+				{"id": "acc-old", "name": "Livret A", "deleted": true},
+				{"id": "acc-livret", "name": "Livret A"}
+			]},
+			{"id": "bud-vacances-1", "name": "Vacances", "accounts": []},
+			{"id": "bud-vacances-2", "name": "vacances ", "accounts": []}
+		]}}`))
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/last-used/accounts", httpmock.NewStringResponder(
+		http.StatusOK, `{"data": {"accounts": [{"id": "acc-last", "name": "Compte joint"}]}}`))
+
+	return transport
+}
+
+func Test_resolveIDs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		budget      string
+		account     string
+		wantBudget  string
+		wantAccount string
+		wantErr     error
+		wantErrText string
+		wantCalls   int
+	}{
+		{
+			name: "IDs", budget: testBudgetID, account: testAccountID,
+			wantBudget: testBudgetID, wantAccount: testAccountID, wantCalls: 0,
+		},
+		{
+			name: "names", budget: "Famille", account: "Compte Courant LCL",
+			wantBudget: familleID, wantAccount: "acc-courant", wantCalls: 1,
+		},
+		{
+			name: "deleted account left out", budget: familleID, account: "Livret A",
+			wantBudget: familleID, wantAccount: "acc-livret", wantCalls: 1,
+		},
+		{
+			name: "last-used budget", budget: "last-used", account: "Compte joint",
+			wantBudget: "last-used", wantAccount: "acc-last", wantCalls: 2,
+		},
+		{
+			name: "default budget", budget: "default", account: testAccountID,
+			wantBudget: "default", wantAccount: testAccountID, wantCalls: 0,
+		},
+		{
+			name: "lowercase names", budget: "famille", account: "livret a",
+			wantBudget: familleID, wantAccount: "acc-livret", wantCalls: 1,
+		},
+		{
+			name: "account named like a budget alias", budget: familleID, account: "default",
+			wantErr: errUnknownName, wantErrText: `"Compte courant LCL" (acc-courant)`, wantCalls: 1,
+		},
+		{
+			name: "ambiguous", budget: "Vacances", account: testAccountID,
+			wantErr: errAmbiguousName, wantErrText: `"Vacances" (bud-vacances-1), "vacances " (bud-vacances-2)`,
+			wantCalls: 1,
+		},
+		{
+			name: "missing", budget: "Famille", account: "Compte épargne",
+			wantErr:     errUnknownName,
+			wantErrText: `"Compte courant LCL" (acc-courant), "Livret A" (acc-livret)`,
+			wantCalls:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := resolveTransport()
+			client := lclynab.NewClient("tok", &http.Client{Transport: transport})
+			opts := &options{budgetID: tt.budget, accountID: tt.account}
+
+			err := resolveIDs(context.Background(), client, opts, &bytes.Buffer{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveIDs() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), tt.wantErrText) {
+				t.Errorf("resolveIDs() error = %v, want it to list %v", err, tt.wantErrText)
+			}
+
+			if err == nil && (opts.budgetID != tt.wantBudget || opts.accountID != tt.wantAccount) {
+				t.Errorf("resolved %v and %v, want %v and %v", opts.budgetID, opts.accountID, tt.wantBudget, tt.wantAccount)
+			}
+
+			if got := transport.GetTotalCallCount(); got != tt.wantCalls {
+				t.Errorf("YNAB got %d calls, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func Test_run_resolveNames(t *testing.T) {
+	t.Parallel()
+
+	transport := resolveTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+familleID+"/transactions",
+		httpmock.NewStringResponder(http.StatusCreated, `{"data": {"transaction_ids": ["t-1"]}}`))
+
+	var stdout bytes.Buffer
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", "Famille", "-a", "Compte courant LCL", "-f", "./testdata/one-positive.csv", "-v",
+		"-state", t.TempDir() + "/state.json",
+	}, env{stdout: &stdout, stderr: &bytes.Buffer{}, httpClient: &http.Client{Transport: transport}, now: fixedNow})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	for _, want := range []string{`budget "Famille": ` + familleID, `account "Compte courant LCL": acc-courant`} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+const (
+	// pushRetries is the number of retries after a transient YNAB failure.
+	pushRetries        = 3
+	defaultPushBackoff = 500 * time.Millisecond
+	// maxPushRetryAfter is the longest Retry-After a push waits for, giving up beyond.
+	maxPushRetryAfter = time.Minute
+)
+
+//nolint:gochecknoglobals // constant list
+var transientStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// retryPush pushes the transactions, retrying up to pushRetries times on rate limits,
+// 5xx gateway errors and network timeouts. The delay starts at opts.pushBackoff and
+// doubles after each failure, unless YNAB gives a Retry-After. Retrying is safe since
+// YNAB reports transactions already created by a previous attempt as duplicates of
+// their import ID. An error that isn't retried is returned as is.
+func retryPush(
+	ctx context.Context,
+	client *http.Client,
+	logger *slog.Logger,
+	transactions []Transaction,
+	opts *options,
+) (*lclynab.Result, error) {
+	delay := opts.pushBackoff
+
+	for attempt := 1; ; attempt++ {
+		synced, err := push(ctx, client, transactions, opts)
+		if err == nil {
+			return synced, nil
+		}
+
+		wait, ok := transientWait(ctx, err, delay)
+
+		switch {
+		case ctx.Err() != nil:
+			return nil, fmt.Errorf("after %d attempt(s): %w", attempt, errors.Join(err, ctx.Err()))
+		case attempt == 1 && !ok:
+			return nil, err
+		case attempt > pushRetries || !ok:
+			return nil, fmt.Errorf("after %d attempt(s): %w", attempt, err)
+		}
+
+		logger.Warn("push failed, retrying", "attempt", attempt, "delay", wait, "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil, fmt.Errorf("after %d attempt(s): %w", attempt, errors.Join(err, ctx.Err()))
+		case <-timer.C:
+		}
+
+		delay *= 2
+	}
+}
+
+// transientWait reports whether err is worth retrying and how long to wait first:
+// the Retry-After of YNAB when given, delay otherwise.
+func transientWait(ctx context.Context, err error, delay time.Duration) (time.Duration, bool) {
+	if ctx.Err() != nil {
+		return 0, false
+	}
+
+	if apiErr := new(lclynab.APIError); errors.As(err, &apiErr) {
+		if !slices.Contains(transientStatuses, apiErr.Status) || apiErr.RetryAfter > maxPushRetryAfter {
+			return 0, false
+		}
+
+		return max(delay, apiErr.RetryAfter), true
+	}
+
+	// The timeout of the attempt, the parent context still being alive.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return delay, true
+	}
+
+	if netErr := net.Error(nil); errors.As(err, &netErr) && netErr.Timeout() {
+		return delay, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,196 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+//nolint:funlen // mostly test cases in list
+func Test_retryPush(t *testing.T) {
+	t.Parallel()
+
+	const (
+		synced      = `{"data": {"duplicate_import_ids": []}}`
+		rateLimited = `{"error": {"id": "429", "name": "too_many_requests", "detail": "Too many requests"}}`
+	)
+
+	retryAfter := func(seconds string) httpmock.Responder {
+		return httpmock.NewStringResponder(http.StatusTooManyRequests, rateLimited).HeaderSet(http.Header{
+			"Retry-After": {seconds},
+		})
+	}
+
+	tests := []struct {
+		name         string
+		responses    []httpmock.Responder
+		wantAttempts int
+		wantErr      string
+	}{
+		{
+			name: "unavailable then success",
+			responses: []httpmock.Responder{
+				httpmock.NewStringResponder(http.StatusServiceUnavailable, ""),
+				httpmock.NewStringResponder(http.StatusOK, synced),
+			},
+			wantAttempts: 2,
+		},
+		{
+			name: "timeout then success",
+			responses: []httpmock.Responder{
+				httpmock.NewErrorResponder(context.DeadlineExceeded),
+				httpmock.NewStringResponder(http.StatusOK, synced),
+			},
+			wantAttempts: 2,
+		},
+		{
+			name: "rate limited then success",
+			responses: []httpmock.Responder{
+				retryAfter("0"),
+				httpmock.NewStringResponder(http.StatusOK, synced),
+			},
+			wantAttempts: 2,
+		},
+		{
+			name:         "client error is not retried",
+			responses:    []httpmock.Responder{httpmock.NewStringResponder(http.StatusBadRequest, "")},
+			wantAttempts: 1,
+			wantErr:      "pushing to YNAB",
+		},
+		{
+			name:         "connection error is not retried",
+			responses:    []httpmock.Responder{httpmock.NewErrorResponder(errors.New("connection refused"))},
+			wantAttempts: 1,
+			wantErr:      "pushing to YNAB",
+		},
+		{
+			name:         "Retry-After too long",
+			responses:    []httpmock.Responder{retryAfter("3600")},
+			wantAttempts: 1,
+			wantErr:      "pushing to YNAB",
+		},
+		{
+			name:         "retries exhausted",
+			responses:    []httpmock.Responder{httpmock.NewStringResponder(http.StatusBadGateway, "")},
+			wantAttempts: pushRetries + 1,
+			wantErr:      "after 4 attempt(s): pushing to YNAB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			attempts := 0
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(
+				http.MethodPost,
+				"/v1/budgets/"+testBudgetID+"/transactions",
+				func(req *http.Request) (*http.Response, error) {
+					responder := tt.responses[min(attempts, len(tt.responses)-1)]
+					attempts++
+
+					return responder(req)
+				},
+			)
+
+			opts := &options{token: "tok", budgetID: testBudgetID, accountID: testAccountID, pushBackoff: time.Millisecond}
+
+			_, err := retryPush(context.Background(), &http.Client{Transport: transport}, logging.Discard(),
+				[]Transaction{{AccountID: testAccountID, Date: mustDate("2024-10-29"), Amount: 80000}}, opts)
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("retryPush() error = %v, want none", err)
+			case tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)):
+				t.Errorf("retryPush() error = %v, want %q", err, tt.wantErr)
+			}
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("retryPush() attempts = %v, want %v", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func Test_retryPush_cancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := 0
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			attempts++
+
+			// Cancelled while waiting for the retry, which would take an hour.
+			time.AfterFunc(10*time.Millisecond, cancel)
+
+			return httpmock.NewStringResponse(http.StatusServiceUnavailable, ""), nil
+		},
+	)
+
+	opts := &options{token: "tok", budgetID: testBudgetID, accountID: testAccountID, pushBackoff: time.Hour}
+
+	_, err := retryPush(ctx, &http.Client{Transport: transport}, logging.Discard(),
+		[]Transaction{{AccountID: testAccountID, Date: mustDate("2024-10-29"), Amount: 80000}}, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("retryPush() error = %v, want %v", err, context.Canceled)
+	}
+
+	if attempts != 1 {
+		t.Errorf("retryPush() attempts = %v, want 1", attempts)
+	}
+}
+
+func Test_transientWait(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		err      error
+		wantWait time.Duration
+		wantOK   bool
+	}{
+		{name: "unavailable", err: &lclynab.APIError{Status: 503}, wantWait: time.Second, wantOK: true},
+		{name: "gateway timeout", err: &lclynab.APIError{Status: 504}, wantWait: time.Second, wantOK: true},
+		{
+			name:     "Retry-After longer than the backoff",
+			err:      &lclynab.APIError{Status: 429, RetryAfter: 30 * time.Second},
+			wantWait: 30 * time.Second,
+			wantOK:   true,
+		},
+		{
+			name:     "Retry-After shorter than the backoff",
+			err:      &lclynab.APIError{Status: 429, RetryAfter: time.Millisecond},
+			wantWait: time.Second,
+			wantOK:   true,
+		},
+		{name: "Retry-After too long", err: &lclynab.APIError{Status: 429, RetryAfter: time.Hour}},
+		{name: "not found", err: &lclynab.APIError{Status: 404}},
+		{name: "timeout", err: context.DeadlineExceeded, wantWait: time.Second, wantOK: true},
+		{name: "other", err: errors.New("connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			wait, ok := transientWait(context.Background(), tt.err, time.Second)
+			if wait != tt.wantWait || ok != tt.wantOK {
+				t.Errorf("transientWait() = %v, %v, want %v, %v", wait, ok, tt.wantWait, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+const (
+	revolutDateFormat = "2006-01-02 15:04:05"
+	revolutCompleted  = "COMPLETED"
+)
+
+// Columns of a Revolut statement export.
+const (
+	revolutType = iota
+	revolutProduct
+	revolutStartedDate
+	revolutCompletedDate
+	revolutDescription
+	revolutAmount
+	revolutFee
+	revolutCurrency
+	revolutState
+	revolutBalance
+	revolutColumns
+)
+
+type revolutOptions struct {
+	includePending bool
+	currency       string
+	skipped        func(skippedRow)
+	progress       func(lclynab.Progress)
+}
+
+func (o revolutOptions) skip(reason skipReason, record []string) {
+	if o.skipped != nil {
+		o.skipped(skippedRow{
+			reason: reason,
+			date:   record[revolutDate(record)],
+			amount: record[revolutAmount],
+			payee:  record[revolutDescription],
+		})
+	}
+}
+
+func (o revolutOptions) report(lines, transactions int) {
+	if o.progress != nil {
+		o.progress(lclynab.Progress{Lines: lines, Transactions: transactions})
+	}
+}
+
+func revolutFormat() format {
+	return format{
+		name:       formatRevolut,
+		extensions: []string{".csv"},
+		sniff: func(head []byte) bool {
+			return bytes.HasPrefix(trimBOM(head), []byte("Type,Product,Started Date,"))
+		},
+		newImporter: func(opts importerOptions) importer {
+			return importerFunc(func(ctx context.Context, reader io.Reader, accountID string) ([]Transaction, balance, error) {
+				return convertRevolut(ctx, reader, accountID, revolutOptions{
+					includePending: opts.includePending,
+					currency:       opts.currencyFilter,
+					skipped:        opts.skipped,
+					progress:       opts.progress,
+				}, opts.warnings)
+			})
+		},
+	}
+}
+
+func convertRevolut(
+	ctx context.Context,
+	reader io.Reader,
+	accountID string,
+	opts revolutOptions,
+	warnings io.Writer,
+) ([]Transaction, balance, error) {
+	if reader == nil {
+		return nil, balance{}, nil
+	}
+
+	transformer := unicode.BOMOverride(encoding.Nop.NewDecoder())
+
+	csvReader := csv.NewReader(transform.NewReader(reader, transformer))
+	csvReader.FieldsPerRecord = revolutColumns
+
+	var (
+		transactions []Transaction
+		reconciled   balance
+		header       = true
+		lines        int
+	)
+
+	importIDs := make(map[string]int)
+	done := ctx.Done()
+
+	// The post statement reports the progress after each line, skipped rows included.
+	for ; ; opts.report(lines, len(transactions)) {
+		select {
+		case <-done:
+			return nil, balance{}, fmt.Errorf("after line %d: %w", lines, ctx.Err())
+		default:
+		}
+
+		record, err := csvReader.Read()
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, balance{}, fmt.Errorf("reading csv line: %w", err)
+		}
+
+		lines++
+
+		if header {
+			header = false
+			continue
+		}
+
+		if record[revolutCurrency] != opts.currency {
+			_, _ = fmt.Fprintf(warnings, "warning: skipping %v row in %v: %v\n",
+				record[revolutCurrency], record[revolutDate(record)], record[revolutDescription])
+			opts.skip(skipCurrency, record)
+
+			continue
+		}
+
+		completed := record[revolutState] == revolutCompleted
+		if !completed && !opts.includePending {
+			opts.skip(skipPending, record)
+
+			continue
+		}
+
+		cleared := lclynab.ClearedCleared
+		if !completed {
+			cleared = lclynab.ClearedUncleared
+		}
+
+		transaction, err := convertRevolutLine(record, accountID, cleared, importIDs)
+		if err != nil {
+			return nil, balance{}, fmt.Errorf("converting line: %w", err)
+		}
+
+		transactions = append(transactions, transaction)
+
+		if completed && record[revolutBalance] != "" {
+			amount, err := lclynab.ParseAmount(record[revolutBalance])
+			if err != nil {
+				return nil, balance{}, fmt.Errorf("parsing balance: %w", err)
+			}
+
+			reconciled = balance{milliunits: int(amount), date: transaction.Date}
+		}
+	}
+
+	return transactions, reconciled, nil
+}
+
+func convertRevolutLine(
+	record []string,
+	accountID, cleared string,
+	importIDs map[string]int,
+) (Transaction, error) {
+	date, err := time.Parse(revolutDateFormat, record[revolutDate(record)])
+	if err != nil {
+		return Transaction{}, fmt.Errorf("parsing date: %w", err)
+	}
+
+	amount, err := lclynab.ParseAmount(record[revolutAmount])
+	if err != nil {
+		return Transaction{}, err //nolint:wrapcheck // already explicit
+	}
+
+	fee, err := lclynab.ParseAmount(record[revolutFee])
+	if err != nil {
+		return Transaction{}, fmt.Errorf("fee: %w", err)
+	}
+
+	amount -= fee
+
+	day := lclynab.NewDate(date)
+
+	//nolint:wrapcheck // already explicit
+	return lclynab.NewTransaction(accountID, day, int(amount),
+		lclynab.WithPayee(record[revolutDescription]),
+		lclynab.WithMemo(record[revolutDescription]),
+		lclynab.WithCleared(cleared),
+		lclynab.WithImportID(lclynab.ImportID(int(amount), day, importIDs)),
+	)
+}
+
+// revolutDate returns the column holding the date to use for the record:
+// the completion date, or the start date for rows that haven't completed yet.
+func revolutDate(record []string) int {
+	if record[revolutCompletedDate] == "" {
+		return revolutStartedDate
+	}
+
+	return revolutCompletedDate
+}
@@ -0,0 +1,188 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//nolint:funlen // mostly test cases in list
+func Test_convertRevolut(t *testing.T) {
+	t.Parallel()
+
+	const header = "Type,Product,Started Date,Completed Date,Description,Amount,Fee,Currency,State,Balance\n"
+
+	type args struct {
+		reader    io.Reader
+		accountID string
+		opts      revolutOptions
+	}
+
+	tests := []struct {
+		name             string
+		args             args
+		wantTransactions []Transaction
+		wantReconciled   balance
+		wantWarnings     string
+		wantErr          bool
+	}{
+		{
+			name:             "nil reader",
+			args:             args{nil, "acc-id", revolutOptions{currency: "EUR"}},
+			wantTransactions: nil,
+			wantReconciled:   balance{},
+			wantErr:          false,
+		},
+		{
+			name:             "header only",
+			args:             args{strings.NewReader(header), "acc-id", revolutOptions{currency: "EUR"}},
+			wantTransactions: nil,
+			wantReconciled:   balance{},
+			wantErr:          false,
+		},
+		{
+			name: "fee folded into amount",
+			args: args{strings.NewReader(header +
+				"EXCHANGE,Current,2024-10-29 10:00:00,2024-10-29 10:00:00,Exchanged to USD,-10.00,0.15,EUR,COMPLETED,68.53\n",
+			), "acc-id", revolutOptions{currency: "EUR"}},
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-29"),
+					Amount:    -10150,
+					PayeeName: "Exchanged to USD",
+					Memo:      "Exchanged to USD",
+					Cleared:   "cleared",
+					ImportID:  "YNAB:-10150:2024-10-29:1",
+				},
+			},
+			wantReconciled: balance{milliunits: 68530, date: mustDate("2024-10-29")},
+			wantErr:        false,
+		},
+		{
+			name: "pending skipped by default",
+			args: args{strings.NewReader(header +
+				"CARD_PAYMENT,Current,2024-10-30 19:45:00,,Restaurant,-12.00,0.00,EUR,PENDING,\n",
+			), "acc-id", revolutOptions{currency: "EUR"}},
+			wantTransactions: nil,
+			wantReconciled:   balance{},
+			wantErr:          false,
+		},
+		{
+			name: "pending included as uncleared",
+			args: args{strings.NewReader(header +
+				"CARD_PAYMENT,Current,2024-10-30 19:45:00,,Restaurant,-12.00,0.00,EUR,PENDING,\n",
+			), "acc-id", revolutOptions{includePending: true, currency: "EUR"}},
+			wantTransactions: []Transaction{
+				{
+					AccountID: "acc-id",
+					Date:      mustDate("2024-10-30"),
+					Amount:    -12000,
+					PayeeName: "Restaurant",
+					Memo:      "Restaurant",
+					Cleared:   "uncleared",
+					ImportID:  "YNAB:-12000:2024-10-30:1",
+				},
+			},
+			wantReconciled: balance{},
+			wantErr:        false,
+		},
+		{
+			name: "other currency skipped with warning",
+			args: args{strings.NewReader(header +
+				"CARD_PAYMENT,Current,2024-10-28 12:00:00,2024-10-29 08:00:00,Coffee Shop,-3.50,0.50,USD,COMPLETED,\n",
+			), "acc-id", revolutOptions{currency: "EUR"}},
+			wantTransactions: nil,
+			wantReconciled:   balance{},
+			wantWarnings:     "warning: skipping USD row in 2024-10-29 08:00:00: Coffee Shop\n",
+			wantErr:          false,
+		},
+		{
+			name: "invalid date",
+			args: args{strings.NewReader(header +
+				"TOPUP,Current,27/10/2024,27/10/2024,Top-up,100.00,0.00,EUR,COMPLETED,100.00\n",
+			), "acc-id", revolutOptions{currency: "EUR"}},
+			wantTransactions: nil,
+			wantReconciled:   balance{},
+			wantErr:          true,
+		},
+		{
+			name:             "wrong column count",
+			args:             args{strings.NewReader(header + "TOPUP,Current\n"), "acc-id", revolutOptions{currency: "EUR"}},
+			wantTransactions: nil,
+			wantReconciled:   balance{},
+			wantErr:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			warnings := &bytes.Buffer{}
+
+			got, gotReconciled, err := convertRevolut(context.Background(),
+				tt.args.reader, tt.args.accountID, tt.args.opts, warnings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("convertRevolut() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.wantTransactions) {
+				t.Errorf("convertRevolut() got = %v, want %v", got, tt.wantTransactions)
+			}
+
+			if gotReconciled != tt.wantReconciled {
+				t.Errorf("convertRevolut() gotReconciled = %v, want %v", gotReconciled, tt.wantReconciled)
+			}
+
+			if gotWarnings := warnings.String(); gotWarnings != tt.wantWarnings {
+				t.Errorf("convertRevolut() gotWarnings = %v, want %v", gotWarnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func Test_convertRevolut_testdata(t *testing.T) {
+	t.Parallel()
+
+	file, err := os.Open("./testdata/revolut.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	skipped := 0
+
+	got, gotReconciled, err := convertRevolut(context.Background(), file, "acc-id", revolutOptions{
+		currency: "EUR",
+		skipped:  func(skippedRow) { skipped++ },
+	}, io.Discard)
+	if err != nil {
+		t.Fatalf("convertRevolut() error = %v", err)
+	}
+
+	wantAmounts := []int{100000, -21320, -10150}
+
+	gotAmounts := make([]int, 0, len(got))
+	for _, transaction := range got {
+		gotAmounts = append(gotAmounts, transaction.Amount)
+	}
+
+	if !reflect.DeepEqual(gotAmounts, wantAmounts) {
+		t.Errorf("convertRevolut() amounts = %v, want %v", gotAmounts, wantAmounts)
+	}
+
+	if wantReconciled := (balance{milliunits: 68530, date: mustDate("2024-10-29")}); gotReconciled != wantReconciled {
+		t.Errorf("convertRevolut() gotReconciled = %v, want %v", gotReconciled, wantReconciled)
+	}
+
+	if wantSkipped := 2; skipped != wantSkipped {
+		t.Errorf("convertRevolut() skipped = %v, want %v", skipped, wantSkipped)
+	}
+}
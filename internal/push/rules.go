@@ -0,0 +1,66 @@
+package push
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+)
+
+var errInvalidRules = errors.New("invalid rules")
+
+// categoryRule sets the category of the transactions whose memo matches its pattern.
+type categoryRule struct {
+	// Pattern is a regular expression, see https://pkg.go.dev/regexp/syntax.
+	Pattern    string `json:"pattern"`
+	CategoryID string `json:"category_id"`
+
+	regexp *regexp.Regexp
+}
+
+// loadRules reads the ordered rules of the JSON file at path in fsys, none when path is empty.
+func loadRules(fsys fs.FS, path string) ([]categoryRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules: %w", err)
+	}
+
+	var rules []categoryRule
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return nil, fmt.Errorf("%w %v: %w", errInvalidRules, path, err)
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.CategoryID == "" {
+			return nil, fmt.Errorf("%w %v: rule %d has no category_id", errInvalidRules, path, i+1)
+		}
+
+		rule.regexp, err = regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w %v: rule %d: %w", errInvalidRules, path, i+1, err)
+		}
+	}
+
+	return rules, nil
+}
+
+// applyRules sets the category of t to the one of the first rule matching its memo,
+// and reports whether a rule matched. t is left as is when none does.
+func applyRules(t *Transaction, rules []categoryRule) bool {
+	for _, rule := range rules {
+		if rule.regexp.MatchString(t.Memo) {
+			categoryID := rule.CategoryID
+			t.CategoryID = &categoryID
+
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+package push
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_loadRules(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"rules.json":       {Data: []byte(`[{"pattern": "(?i)boulangerie", "category_id": "cat-food"}]`)},
+		"bad-regexp.json":  {Data: []byte(`[{"pattern": "CB (BOULANGERIE", "category_id": "cat-food"}]`)},
+		"no-category.json": {Data: []byte(`[{"pattern": "BOULANGERIE"}]`)},
+		"object.json":      {Data: []byte(`{"pattern": "BOULANGERIE", "category_id": "cat-food"}`)},
+	}
+
+	tests := []struct {
+		path    string
+		want    int
+		wantErr error
+	}{
+		{path: "", want: 0},
+		{path: "rules.json", want: 1},
+		{path: "bad-regexp.json", wantErr: errInvalidRules},
+		{path: "no-category.json", wantErr: errInvalidRules},
+		{path: "object.json", wantErr: errInvalidRules},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := loadRules(fsys, tt.path)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("loadRules() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(got) != tt.want {
+				t.Errorf("loadRules() = %d rules, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_applyRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := loadRules(fstest.MapFS{"rules.json": {Data: []byte(`[
+		{"pattern": "^CB CARREFOUR", "category_id": "cat-groceries"},
+		{"pattern": "^CB ", "category_id": "cat-card"},
+		{"pattern": "(?i)free mobile", "category_id": "cat-phone"}
+	]`)}}, "rules.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		memo string
+		want string
+	}{
+		{memo: "CB CARREFOUR 28/10/24", want: "cat-groceries"},
+		{memo: "CB BOULANGERIE 28/10/24", want: "cat-card"},
+		{memo: "PRLV SEPA Free Mobile", want: "cat-phone"},
+		{memo: "VIREMENT M JEAN MARTIN", want: ""},
+	}
+
+	for _, tt := range tests {
+		transaction := Transaction{Memo: tt.memo}
+
+		matched := applyRules(&transaction, rules)
+		if matched != (tt.want != "") {
+			t.Errorf("applyRules(%q) = %v, want %v", tt.memo, matched, tt.want != "")
+		}
+
+		got := ""
+		if transaction.CategoryID != nil {
+			got = *transaction.CategoryID
+		}
+
+		if got != tt.want {
+			t.Errorf("applyRules(%q) category = %q, want %q", tt.memo, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package push
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// skipReason says why a row of the export isn't converted, as reported in JSON.
+type skipReason string
+
+// Reasons to skip a row, in the order of the breakdown.
+const (
+	skipPending    skipReason = "pending"
+	skipCurrency   skipReason = "other_currency"
+	skipRate       skipReason = "exchange_rate"
+	skipRateFolded skipReason = "exchange_rate_folded"
+	skipImported   skipReason = "already_imported"
+)
+
+func skipReasons() []skipReason {
+	return []skipReason{skipPending, skipCurrency, skipRate, skipRateFolded, skipImported}
+}
+
+// skippedRow is a row left out by a filter, its fields as the export wrote them.
+type skippedRow struct {
+	reason skipReason
+	date   string
+	amount string
+	payee  string
+}
+
+// countSkips returns the number of rows skipped for each reason, nil when none was.
+func countSkips(rows []skippedRow) map[skipReason]int {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	counts := make(map[skipReason]int)
+	for _, row := range rows {
+		counts[row.reason]++
+	}
+
+	return counts
+}
+
+// printSkips prints how many rows were skipped by reason and, when verbose, which ones.
+func printSkips(w io.Writer, msgs messages, rows []skippedRow, verbose bool) {
+	counts := countSkips(rows)
+	if counts == nil {
+		return
+	}
+
+	parts := make([]string, 0, len(counts))
+
+	for _, reason := range skipReasons() {
+		if counts[reason] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %v", counts[reason], msgs.skipReason(reason)))
+		}
+	}
+
+	_, _ = fmt.Fprintln(w, msgs.skipped(strings.Join(parts, ", ")))
+
+	if !verbose {
+		return
+	}
+
+	for _, reason := range skipReasons() {
+		if counts[reason] == 0 {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(w, "  %v:\n", msgs.skipReason(reason))
+
+		for _, row := range rows {
+			if row.reason == reason {
+				_, _ = fmt.Fprintf(w, "    %v  %v  %v\n", row.date, row.amount, row.payee)
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_run_skipBreakdown(t *testing.T) {
+	t.Parallel()
+
+	// revolut.csv has 5 rows: 3 completed in EUR, 1 in USD and 1 pending.
+	const rows = 5
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout string
+		wantCounts map[skipReason]int
+	}{
+		{
+			name:       "pending and currency",
+			wantStdout: "skipped: 1 pending, 1 in another currency\n",
+			wantCounts: map[skipReason]int{skipPending: 1, skipCurrency: 1},
+		},
+		{
+			name: "verbose",
+			args: []string{"-v"},
+			wantStdout: "skipped: 1 pending, 1 in another currency\n" +
+				"  pending:\n    2024-10-30 19:45:00  -12.00  Restaurant\n" +
+				"  in another currency:\n    2024-10-29 08:00:00  -3.50  Coffee Shop\n",
+			wantCounts: map[skipReason]int{skipPending: 1, skipCurrency: 1},
+		},
+		{
+			name:       "pending included",
+			args:       []string{"-include-pending"},
+			wantStdout: "skipped: 1 in another currency\n",
+			wantCounts: map[skipReason]int{skipCurrency: 1},
+		},
+		{
+			name:       "french",
+			args:       []string{"-lang", "fr"},
+			wantStdout: "lignes ignorées : 1 en attente, 1 dans une autre devise\n",
+			wantCounts: map[skipReason]int{skipPending: 1, skipCurrency: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reportPath := filepath.Join(t.TempDir(), "report.json")
+			stdout := &bytes.Buffer{}
+
+			err := run(context.Background(), append([]string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/revolut.csv", "-dry-run",
+				"-report", reportPath,
+			}, tt.args...), env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: httpmock.NewMockTransport()},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+
+			data, err := os.ReadFile(reportPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var report Result
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(report.SkippedByReason, tt.wantCounts) {
+				t.Errorf("skipped_by_reason = %v, want %v", report.SkippedByReason, tt.wantCounts)
+			}
+
+			skipped := 0
+			for _, n := range report.SkippedByReason {
+				skipped += n
+			}
+
+			if skipped != report.Counts.Filtered || report.Counts.Converted+skipped != rows {
+				t.Errorf("converted %d + skipped %d (filtered %d), want %d rows",
+					report.Counts.Converted, skipped, report.Counts.Filtered, rows)
+			}
+		})
+	}
+}
+
+func Test_run_exchangeRates(t *testing.T) {
+	t.Parallel()
+
+	// foreign.csv has 3 payments and 2 exchange rate lines, the second one booked a day
+	// after the payment before it.
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout string
+		wantCounts map[skipReason]int
+	}{
+		{
+			name:       "skipped",
+			wantStdout: "skipped: 2 exchange rate\n",
+			wantCounts: map[skipReason]int{skipRate: 2},
+		},
+		{
+			name:       "folded",
+			args:       []string{"-fold-exchange-rates"},
+			wantStdout: "skipped: 1 exchange rate, 1 exchange rate folded into a memo\n",
+			wantCounts: map[skipReason]int{skipRate: 1, skipRateFolded: 1},
+		},
+		{
+			name: "french verbose",
+			args: []string{"-fold-exchange-rates", "-lang", "fr", "-v"},
+			wantStdout: "lignes ignorées : 1 taux de change, 1 taux de change ajouté au mémo\n" +
+				"  taux de change:\n    2024-11-05  -1.18  10,50 GBP 1 GBP = 1,181 EUR\n" +
+				"  taux de change ajouté au mémo:\n    2024-11-02  -0.93  50,00 USD 1 USD = 0,931 EUR\n",
+			wantCounts: map[skipReason]int{skipRate: 1, skipRateFolded: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			reportPath := filepath.Join(t.TempDir(), "report.json")
+			stdout := &bytes.Buffer{}
+
+			err := run(context.Background(), append([]string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/foreign.csv", "-dry-run",
+				"-report", reportPath,
+			}, tt.args...), env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: httpmock.NewMockTransport()},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+
+			data, err := os.ReadFile(reportPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var report Result
+			if err := json.Unmarshal(data, &report); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(report.SkippedByReason, tt.wantCounts) {
+				t.Errorf("skipped_by_reason = %v, want %v", report.SkippedByReason, tt.wantCounts)
+			}
+
+			if report.Counts.Converted != 3 || report.Counts.Filtered != 2 {
+				t.Errorf("counts = %+v, want 3 converted and 2 filtered", report.Counts)
+			}
+		})
+	}
+}
+
+func Test_convert_foldRates(t *testing.T) {
+	t.Parallel()
+
+	file, err := os.Open("./testdata/foreign.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	transactions, _, err := convert(context.Background(), file, "acc-id", importerOptions{foldRates: true})
+	if err != nil {
+		t.Fatalf("convert() error = %v", err)
+	}
+
+	if len(transactions) != 3 {
+		t.Fatalf("convert() = %d transactions, want 3", len(transactions))
+	}
+
+	if want := "CB  SHOP NEW YORK     31/10/24 50,00 USD 1 USD = 0,931 EUR"; transactions[0].Memo != want {
+		t.Errorf("memo = %q, want %q", transactions[0].Memo, want)
+	}
+
+	if transactions[0].Amount != -46550 {
+		t.Errorf("amount = %d, want the payment untouched", transactions[0].Amount)
+	}
+}
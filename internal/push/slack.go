@@ -0,0 +1,172 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Slack Block Kit limits, in characters.
+const (
+	slackHeaderLen = 150
+	slackTextLen   = 3000
+)
+
+// slackEscaper escapes the characters mrkdwn reserves.
+var slackEscaper = strings.NewReplacer( //nolint:gochecknoglobals // constant replacer
+	"&", "&amp;", "<", "&lt;", ">", "&gt;",
+)
+
+// slack posts the run result to a Slack incoming webhook as Block Kit blocks.
+// Delivery goes through the retry policy of the generic webhook.
+type slack struct {
+	client   *http.Client
+	logger   *slog.Logger
+	url      string
+	profile  string
+	delivery *webhook
+}
+
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+func newSlack(opts *options, client *http.Client, logger *slog.Logger) *slack {
+	return &slack{
+		client:   client,
+		logger:   logger,
+		url:      opts.slackWebhook,
+		profile:  opts.profile,
+		delivery: newDelivery(opts),
+	}
+}
+
+func (s *slack) name() string {
+	return "slack"
+}
+
+func (s *slack) notify(ctx context.Context, data webhookData) error {
+	messages, err := s.render(data)
+	if err != nil {
+		return err
+	}
+
+	return s.delivery.deliver(ctx, s.client, s.logger, s.url, messages[0].body)
+}
+
+func (s *slack) render(data webhookData) ([]message, error) {
+	body, err := json.Marshal(slackPayload(data, s.profile))
+	if err != nil {
+		return nil, fmt.Errorf("encoding Slack message: %w", err)
+	}
+
+	return []message{{target: "webhook", body: body}}, nil
+}
+
+// slackPayload renders the run result as blocks, with a plain text summary
+// used by Slack in notifications.
+func slackPayload(data webhookData, profile string) slackMessage {
+	failed := data.Status == statusError || data.Status == statusCancelled
+
+	emoji := ":white_check_mark:"
+	if failed {
+		emoji = ":x:"
+	}
+
+	summary := fmt.Sprintf("YNAB import %v: pushed %d, duplicates %d", data.Status, data.Pushed, data.Duplicates)
+	fields := []slackText{
+		mrkdwn(fmt.Sprintf("*Pushed*\n%d", data.Pushed)),
+		mrkdwn(fmt.Sprintf("*Duplicates*\n%d", data.Duplicates)),
+		mrkdwn(fmt.Sprintf("*Skipped*\n%d", data.Skipped)),
+	}
+
+	if data.Reconciled != "" {
+		summary += fmt.Sprintf(", reconciled %v€", data.Reconciled)
+		fields = append([]slackText{mrkdwn(fmt.Sprintf("*Reconciled*\n%v€", data.Reconciled))}, fields...)
+	}
+
+	header := fmt.Sprintf("%v YNAB import %v: %v", emoji, data.Status, data.AccountName)
+	blocks := []slackBlock{
+		{
+			Type: "header",
+			Text: &slackText{
+				Type:  "plain_text",
+				Text:  truncateRunes(header, slackHeaderLen),
+				Emoji: true,
+			},
+		},
+		{Type: "section", Fields: fields},
+	}
+
+	if data.Error != "" {
+		failure := fmt.Sprintf("*Failed during %v*\n%v", data.Phase, escapeMrkdwn(data.Error))
+		text := mrkdwn(truncateRunes(failure, slackTextLen))
+		blocks = append(blocks, slackBlock{Type: "section", Text: &text})
+	}
+
+	if len(data.DuplicateTransactions) > 0 {
+		text := mrkdwn(duplicatesDetail(data.DuplicateTransactions))
+		blocks = append(blocks, slackBlock{Type: "section", Text: &text})
+	}
+
+	footer := fmt.Sprintf("%v · profile %v", data.SentAt.Format(time.RFC3339), profile)
+	if data.RunID != "" {
+		footer += " · run " + data.RunID
+	}
+
+	blocks = append(blocks, slackBlock{Type: "context", Elements: []slackText{mrkdwn(footer)}})
+
+	return slackMessage{Text: summary, Blocks: blocks}
+}
+
+// duplicatesDetail lists the duplicates, one per line, cut on a line boundary
+// with a count of the omitted ones to stay within the text limit.
+func duplicatesDetail(transactions []Transaction) string {
+	// moreLen leaves room for the count of omitted duplicates.
+	const moreLen = len("\n…and 99999 more")
+
+	var text strings.Builder
+
+	text.WriteString("*Duplicates*")
+
+	for i, transaction := range transactions {
+		line := fmt.Sprintf("\n%v %v %v",
+			transaction.Date, signedAmountString(transaction.Amount), escapeMrkdwn(transaction.PayeeName))
+
+		if text.Len()+len(line)+moreLen > slackTextLen {
+			fmt.Fprintf(&text, "\n…and %d more", len(transactions)-i)
+
+			break
+		}
+
+		text.WriteString(line)
+	}
+
+	return text.String()
+}
+
+func mrkdwn(text string) slackText {
+	return slackText{Type: "mrkdwn", Text: text}
+}
+
+func escapeMrkdwn(s string) string {
+	return slackEscaper.Replace(s)
+}
@@ -0,0 +1,145 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_slackPayload(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		data   webhookData
+		golden string
+	}{
+		{
+			name: "success with duplicates",
+			data: webhookData{
+				Status:      statusOK,
+				Reconciled:  "100.06",
+				Pushed:      3,
+				Duplicates:  1,
+				Skipped:     2,
+				AccountName: "Checking",
+				SentAt:      fixedNow(),
+				DuplicateTransactions: []Transaction{
+					{Date: mustDate("2024-11-29"), Amount: -12340, PayeeName: "Bread & <Butter>"},
+				},
+			},
+			golden: "./testdata/slack.golden",
+		},
+		{
+			name: "failure",
+			data: webhookData{
+				Status:      statusError,
+				Error:       "pushing to YNAB: rate limit (429)",
+				Phase:       "api call",
+				AccountName: "Checking",
+				SentAt:      fixedNow(),
+			},
+			golden: "./testdata/slack-failure.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := json.MarshalIndent(slackPayload(tt.data, "home"), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got)+"\n" != string(want) {
+				t.Errorf("slackPayload() = \n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func Test_duplicatesDetail(t *testing.T) {
+	t.Parallel()
+
+	duplicates := make([]Transaction, 500)
+	for i := range duplicates {
+		duplicates[i] = Transaction{Date: mustDate("2024-11-29"), Amount: -1000, PayeeName: "CARTE X1234 BOULANGERIE DU COIN"}
+	}
+
+	got := duplicatesDetail(duplicates)
+
+	if n := utf8.RuneCountInString(got); n > slackTextLen {
+		t.Errorf("duplicatesDetail() length = %d, want at most %d", n, slackTextLen)
+	}
+
+	lines := strings.Split(got, "\n")
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, "\u2026and ") || !strings.HasSuffix(last, " more") {
+		t.Errorf("duplicatesDetail() last line = %q, want the count of omitted duplicates", last)
+	}
+
+	// The title, the listed duplicates and the count of the others.
+	if listed := len(lines) - 2; !strings.Contains(got, "and "+strconv.Itoa(len(duplicates)-listed)+" more") {
+		t.Errorf("duplicatesDetail() lists %d duplicates, want the count of the %d others", listed, len(duplicates)-listed)
+	}
+}
+
+func Test_run_slack(t *testing.T) {
+	t.Parallel()
+
+	const slackURL = "https://hooks.slack.example/services/T000/B000/s3cr3t"
+
+	var body []byte
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK),
+	)
+	transport.RegisterResponder(http.MethodPost, slackURL, func(req *http.Request) (*http.Response, error) {
+		body, _ = io.ReadAll(req.Body)
+
+		return httpmock.NewStringResponse(http.StatusOK, "ok"), nil
+	})
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-slack-webhook", slackURL,
+	}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	var message slackMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		t.Fatalf("decoding Slack message: %v", err)
+	}
+
+	if want := "YNAB import ok: pushed 1, duplicates 0, reconciled 100.06\u20ac"; message.Text != want {
+		t.Errorf("text = %q, want %q", message.Text, want)
+	}
+
+	if got := message.Blocks[len(message.Blocks)-1].Elements[0].Text; !strings.Contains(got, "profile default") {
+		t.Errorf("context = %q, want the profile", got)
+	}
+}
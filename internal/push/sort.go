@@ -0,0 +1,40 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Orders of -sort.
+const (
+	sortNone     = "none"
+	sortDateAsc  = "date-asc"
+	sortDateDesc = "date-desc"
+)
+
+var errUnknownSort = errors.New("unknown sort order")
+
+func checkSort(order string) error {
+	switch order {
+	case sortNone, sortDateAsc, sortDateDesc:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q, want %v, %v or %v", errUnknownSort, order, sortNone, sortDateAsc, sortDateDesc)
+	}
+}
+
+// sortTransactions orders transactions by date, keeping the file order of same-day ones.
+// Import IDs were assigned in file order during the conversion, so they don't depend on it.
+func sortTransactions(transactions []Transaction, order string) {
+	switch order {
+	case sortDateAsc:
+		slices.SortStableFunc(transactions, func(a, b Transaction) int {
+			return a.Date.Compare(b.Date.Time)
+		})
+	case sortDateDesc:
+		slices.SortStableFunc(transactions, func(a, b Transaction) int {
+			return b.Date.Compare(a.Date.Time)
+		})
+	}
+}
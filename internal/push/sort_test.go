@@ -0,0 +1,123 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_sortTransactions(t *testing.T) {
+	t.Parallel()
+
+	input := func() []Transaction {
+		return []Transaction{
+			{Date: mustDate("2024-10-29"), ImportID: "a"},
+			{Date: mustDate("2024-10-28"), ImportID: "b"},
+			{Date: mustDate("2024-10-29"), ImportID: "c"},
+			{Date: mustDate("2024-10-30"), ImportID: "d"},
+		}
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{order: sortNone, want: []string{"a", "b", "c", "d"}},
+		{order: sortDateAsc, want: []string{"b", "a", "c", "d"}},
+		{order: sortDateDesc, want: []string{"d", "a", "c", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			t.Parallel()
+
+			transactions := input()
+			sortTransactions(transactions, tt.order)
+
+			if got := importIDs(transactions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortTransactions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := checkSort("amount"); !errors.Is(err, errUnknownSort) {
+		t.Errorf("checkSort() error = %v, want %v", err, errUnknownSort)
+	}
+}
+
+func Test_run_sortKeepsImportIDs(t *testing.T) {
+	t.Parallel()
+
+	export := "30/10/2024;-5;Carte;;CB  BAKERY          29/10/24;;0;Divers\n" +
+		"29/10/2024;-5;Carte;;CB  BAKERY          28/10/24;;0;Divers\n" +
+		"31/10/2024;-5;Carte;;CB  BAKERY          29/10/24;;0;Divers\n" +
+		"29/11/2024;100,06;;01234 123456A\n"
+
+	pushed := func(order string) []string {
+		var payload struct {
+			Transactions []Transaction `json:"transactions"`
+		}
+
+		transport := httpmock.NewMockTransport()
+		transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+			func(req *http.Request) (*http.Response, error) {
+				if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+					return nil, err //nolint:wrapcheck // test responder
+				}
+
+				return createdResponder(http.StatusCreated)(req)
+			})
+
+		err := run(context.Background(), []string{
+			"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "statement.csv", "-sort", order,
+		}, env{
+			stdout:     io.Discard,
+			stderr:     io.Discard,
+			httpClient: &http.Client{Transport: transport},
+			now:        fixedNow,
+			fsys:       fstest.MapFS{"statement.csv": {Data: []byte(export)}},
+			getenv:     testGetenv(t),
+		})
+		if err != nil {
+			t.Fatalf("run(-sort %v) error = %v", order, err)
+		}
+
+		return importIDs(payload.Transactions)
+	}
+
+	unsorted := pushed(sortNone)
+	want := []string{"YNAB:-5000:2024-10-29:1", "YNAB:-5000:2024-10-28:1", "YNAB:-5000:2024-10-29:2"}
+
+	if !reflect.DeepEqual(unsorted, want) {
+		t.Errorf("unsorted import IDs = %v, want %v", unsorted, want)
+	}
+
+	sorted := pushed(sortDateAsc)
+	if want := []string{want[1], want[0], want[2]}; !reflect.DeepEqual(sorted, want) {
+		t.Errorf("sorted import IDs = %v, want %v", sorted, want)
+	}
+
+	slices.Sort(unsorted)
+	slices.Sort(sorted)
+
+	if !reflect.DeepEqual(sorted, unsorted) {
+		t.Errorf("import IDs differ with sorting: %v, want %v", sorted, unsorted)
+	}
+}
+
+func importIDs(transactions []Transaction) []string {
+	ids := make([]string, 0, len(transactions))
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.ImportID)
+	}
+
+	return ids
+}
@@ -0,0 +1,191 @@
+package push
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+const (
+	statsTopPayees = 10
+	statsLargest   = 5
+	// statsNoType groups the lines whose type is empty.
+	statsNoType = "(none)"
+)
+
+var errStatsFormat = errors.New("-stats only reads LCL exports")
+
+// statementStats summarizes a statement, amounts in milliunits.
+type statementStats struct {
+	First    lclynab.Date `json:"first"`
+	Last     lclynab.Date `json:"last"`
+	Inflows  statsTotal   `json:"inflows"`
+	Outflows statsTotal   `json:"outflows"`
+	// ByType is by decreasing count.
+	ByType []statsGroup `json:"by_type"`
+	// TopPayees are the payees spending the most, outflows only.
+	TopPayees []statsGroup `json:"top_payees"`
+	// Largest are the transactions of the largest absolute amounts.
+	Largest []statsTransaction `json:"largest"`
+}
+
+type statsTotal struct {
+	Count int `json:"count"`
+	Total int `json:"total"`
+}
+
+type statsGroup struct {
+	Name string `json:"name"`
+	statsTotal
+}
+
+type statsTransaction struct {
+	Date   lclynab.Date `json:"date"`
+	Amount int          `json:"amount"`
+	Payee  string       `json:"payee"`
+	Type   string       `json:"type"`
+}
+
+// stats parses the file given with -stats and prints its summary. YNAB isn't called.
+func stats(ctx context.Context, opts *options, env env) error {
+	file, err := openInput(env, opts.filename)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(decode(&contextReader{ctx: ctx, reader: file}, opts.encoding))
+
+	inputFormat, err := selectFormat(opts, reader)
+	if err != nil {
+		return err
+	}
+
+	if inputFormat.name != formatLCL {
+		return fmt.Errorf("%w, not %v", errStatsFormat, inputFormat.name)
+	}
+
+	statement, err := lclynab.ParseContext(ctx, reader, lclynab.ParseOptions{})
+	if err != nil {
+		return fmt.Errorf("parsing file: %w", err)
+	}
+
+	summary := computeStats(statement.Transactions)
+
+	if opts.output == outputJSON || opts.jsonOutput {
+		encoder := json.NewEncoder(env.stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(summary); err != nil {
+			return fmt.Errorf("writing stats: %w", err)
+		}
+
+		return nil
+	}
+
+	return printStats(env.stdout, summary)
+}
+
+func computeStats(transactions []lclynab.StatementTransaction) statementStats {
+	summary := statementStats{ByType: []statsGroup{}, TopPayees: []statsGroup{}, Largest: []statsTransaction{}}
+	byType := map[string]*statsGroup{}
+	byPayee := map[string]*statsGroup{}
+
+	for i, t := range transactions {
+		date, amount := lclynab.NewDate(t.Date), int(t.Amount)
+
+		if i == 0 || date.Before(summary.First) {
+			summary.First = date
+		}
+
+		if i == 0 || date.After(summary.Last) {
+			summary.Last = date
+		}
+
+		if amount >= 0 {
+			summary.Inflows.add(amount)
+		} else {
+			summary.Outflows.add(amount)
+			group(byPayee, t.Payee).add(amount)
+		}
+
+		group(byType, cmp.Or(t.Type, statsNoType)).add(amount)
+
+		summary.Largest = append(summary.Largest, statsTransaction{
+			Date: date, Amount: amount, Payee: t.Payee, Type: t.Type,
+		})
+	}
+
+	for _, g := range byType {
+		summary.ByType = append(summary.ByType, *g)
+	}
+
+	slices.SortFunc(summary.ByType, func(a, b statsGroup) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Name, b.Name))
+	})
+
+	for _, g := range byPayee {
+		summary.TopPayees = append(summary.TopPayees, *g)
+	}
+
+	slices.SortFunc(summary.TopPayees, func(a, b statsGroup) int {
+		return cmp.Or(cmp.Compare(a.Total, b.Total), cmp.Compare(a.Name, b.Name))
+	})
+
+	summary.TopPayees = summary.TopPayees[:min(len(summary.TopPayees), statsTopPayees)]
+
+	slices.SortStableFunc(summary.Largest, func(a, b statsTransaction) int {
+		return cmp.Compare(abs(b.Amount), abs(a.Amount))
+	})
+
+	summary.Largest = summary.Largest[:min(len(summary.Largest), statsLargest)]
+
+	return summary
+}
+
+func (t *statsTotal) add(amount int) {
+	t.Count++
+	t.Total += amount
+}
+
+func group(groups map[string]*statsGroup, name string) *statsGroup {
+	if groups[name] == nil {
+		groups[name] = &statsGroup{Name: name}
+	}
+
+	return groups[name]
+}
+
+func printStats(w io.Writer, summary statementStats) error {
+	amounts := amountFormat{}
+
+	_, _ = fmt.Fprintf(w, "period: %v to %v\n", summary.First, summary.Last)
+	_, _ = fmt.Fprintf(w, "inflows: %d, %v\n", summary.Inflows.Count, amounts.signed(summary.Inflows.Total))
+	_, _ = fmt.Fprintf(w, "outflows: %d, %v\n", summary.Outflows.Count, amounts.signed(summary.Outflows.Total))
+
+	_, _ = fmt.Fprintln(w, "by type:")
+	for _, g := range summary.ByType {
+		_, _ = fmt.Fprintf(w, "  %v: %d, %v\n", g.Name, g.Count, amounts.signed(g.Total))
+	}
+
+	_, _ = fmt.Fprintln(w, "top payees:")
+	for _, g := range summary.TopPayees {
+		_, _ = fmt.Fprintf(w, "  %v: %d, %v\n", g.Name, g.Count, amounts.signed(g.Total))
+	}
+
+	_, _ = fmt.Fprintln(w, "largest:")
+	for _, t := range summary.Largest {
+		if _, err := fmt.Fprintf(w, "  %v  %v  %v\n", t.Date, amounts.signed(t.Amount), t.Payee); err != nil {
+			return fmt.Errorf("writing stats: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+func Test_computeStats(t *testing.T) {
+	t.Parallel()
+
+	file, err := os.Open("./testdata/stats.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	statement, err := lclynab.Parse(file, lclynab.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := computeStats(statement.Transactions)
+
+	want := statementStats{
+		First:    mustDate("2024-10-01"),
+		Last:     mustDate("2024-10-20"),
+		Inflows:  statsTotal{Count: 2, Total: 1520000},
+		Outflows: statsTotal{Count: 6, Total: -1000800},
+		ByType: []statsGroup{
+			{Name: "Carte", statsTotal: statsTotal{Count: 4, Total: -97600}},
+			{Name: statsNoType, statsTotal: statsTotal{Count: 1, Total: -3200}},
+			{Name: "Cheque", statsTotal: statsTotal{Count: 1, Total: -30000}},
+			{Name: "Prelevement", statsTotal: statsTotal{Count: 1, Total: -850000}},
+			{Name: "Virement", statsTotal: statsTotal{Count: 1, Total: 1500000}},
+		},
+		TopPayees: []statsGroup{
+			{Name: "PRLV SEPA LOYER", statsTotal: statsTotal{Count: 1, Total: -850000}},
+			{Name: "CB  LECLERC", statsTotal: statsTotal{Count: 2, Total: -105100}},
+			{Name: "CHEQUE 1234567", statsTotal: statsTotal{Count: 1, Total: -30000}},
+			{Name: "CB  BAKERY", statsTotal: statsTotal{Count: 1, Total: -12500}},
+			{Name: "FRAIS TENUE DE COMPTE", statsTotal: statsTotal{Count: 1, Total: -3200}},
+		},
+		Largest: []statsTransaction{
+			{Date: mustDate("2024-10-01"), Amount: 1500000, Payee: "VIREMENT SALAIRE ACME", Type: "Virement"},
+			{Date: mustDate("2024-10-10"), Amount: -850000, Payee: "PRLV SEPA LOYER", Type: "Prelevement"},
+			{Date: mustDate("2024-10-06"), Amount: -60000, Payee: "CB  LECLERC", Type: "Carte"},
+			{Date: mustDate("2024-10-01"), Amount: -45100, Payee: "CB  LECLERC", Type: "Carte"},
+			{Date: mustDate("2024-10-12"), Amount: -30000, Payee: "CHEQUE 1234567", Type: "Cheque"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("computeStats() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_computeStats_empty(t *testing.T) {
+	t.Parallel()
+
+	got := computeStats(nil)
+	if got.Inflows.Count != 0 || len(got.ByType) != 0 || got.ByType == nil || !got.First.IsZero() {
+		t.Errorf("computeStats(nil) = %+v, want empty aggregates", got)
+	}
+}
+
+func Test_run_stats(t *testing.T) {
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+
+	// No token, budget nor account: YNAB isn't called.
+	err := run(context.Background(), []string{"-stats", "./testdata/stats.csv"},
+		env{stdout: stdout, stderr: io.Discard, now: fixedNow, getenv: testGetenv(t)})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	want, err := os.ReadFile("./testdata/stats.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout.String() != string(want) {
+		t.Errorf("stdout = \n%s\nwant\n%s", stdout, want)
+	}
+}
+
+func Test_run_statsJSON(t *testing.T) {
+	t.Parallel()
+
+	stdout := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{"-stats", "-output", "json", "-f", "./testdata/stats.csv"},
+		env{stdout: stdout, stderr: io.Discard, now: fixedNow, getenv: testGetenv(t)})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	var got statementStats
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, stdout)
+	}
+
+	if got.Outflows.Total != -1000800 || len(got.TopPayees) != 5 || got.Last != mustDate("2024-10-20") {
+		t.Errorf("stats = %+v", got)
+	}
+}
+
+func Test_run_statsRevolut(t *testing.T) {
+	t.Parallel()
+
+	err := run(context.Background(), []string{"-stats", "./testdata/revolut.csv"},
+		env{stdout: io.Discard, stderr: io.Discard, now: fixedNow, getenv: testGetenv(t)})
+	if !errors.Is(err, errStatsFormat) {
+		t.Errorf("run() error = %v, want errStatsFormat", err)
+	}
+}
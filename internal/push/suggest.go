@@ -0,0 +1,177 @@
+package push
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+const defaultSuggestMinOccurrences = 3
+
+// categoryHistory caches the categorized transactions of an account, to learn categories from.
+type categoryHistory struct {
+	// ServerKnowledge fetches only what changed since the last run.
+	ServerKnowledge int64 `json:"server_knowledge"`
+	// Transactions are by ID.
+	Transactions map[string]categorizedTransaction `json:"transactions"`
+}
+
+type categorizedTransaction struct {
+	PayeeName    string `json:"payee_name"`
+	CategoryID   string `json:"category_id"`
+	CategoryName string `json:"category_name"`
+}
+
+// merge applies the changes of a page to the history.
+func (h *categoryHistory) merge(page lclynab.TransactionsPage) {
+	if h.Transactions == nil {
+		h.Transactions = map[string]categorizedTransaction{}
+	}
+
+	for _, t := range page.Transactions {
+		if t.Deleted || t.CategoryID == "" {
+			delete(h.Transactions, t.ID)
+
+			continue
+		}
+
+		h.Transactions[t.ID] = categorizedTransaction{
+			PayeeName: t.PayeeName, CategoryID: t.CategoryID, CategoryName: t.CategoryName,
+		}
+	}
+
+	h.ServerKnowledge = page.ServerKnowledge
+}
+
+// categorySuggestion is the category a payee most often has in the history.
+type categorySuggestion struct {
+	categoryID   string
+	categoryName string
+	// count is how many times the payee has the category, out of total.
+	count, total int
+}
+
+// suggestions returns the most frequent category of each payee, by normalized payee,
+// when it occurs at least minOccurrences times. Ties go to the smallest category ID.
+func (h *categoryHistory) suggestions(minOccurrences int) map[string]categorySuggestion {
+	counts := map[string]map[string]int{}
+	categoryNames := map[string]string{}
+
+	for _, t := range h.Transactions {
+		payee := normalizePayee(t.PayeeName)
+		if payee == "" {
+			continue
+		}
+
+		if counts[payee] == nil {
+			counts[payee] = map[string]int{}
+		}
+
+		counts[payee][t.CategoryID]++
+		categoryNames[t.CategoryID] = t.CategoryName
+	}
+
+	suggested := map[string]categorySuggestion{}
+
+	for payee, categories := range counts {
+		var best categorySuggestion
+
+		for categoryID, count := range categories {
+			best.total += count
+
+			if count > best.count || count == best.count && categoryID < best.categoryID {
+				best.categoryID, best.count = categoryID, count
+			}
+		}
+
+		if best.count >= minOccurrences {
+			best.categoryName = categoryNames[best.categoryID]
+			suggested[payee] = best
+		}
+	}
+
+	return suggested
+}
+
+// normalizePayee lets payees match regardless of case and spacing.
+func normalizePayee(payee string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(payee), " "))
+}
+
+// suggestCategories sets the category the history suggests on transactions without one,
+// leaving them unapproved for review, and prints them in verbose mode.
+func suggestCategories(
+	w io.Writer,
+	transactions []Transaction,
+	suggested map[string]categorySuggestion,
+	verbose bool,
+) int {
+	applied := 0
+
+	for i, transaction := range transactions {
+		suggestion, ok := suggested[normalizePayee(transaction.PayeeName)]
+		if transaction.CategoryID != nil || !ok {
+			continue
+		}
+
+		updated, err := transaction.With(lclynab.WithCategory(suggestion.categoryID), lclynab.WithApproved(false))
+		if err != nil {
+			continue
+		}
+
+		transactions[i] = updated
+		applied++
+
+		if verbose {
+			_, _ = fmt.Fprintf(w, "  %v  %v: %v (%d/%d)\n", transaction.Date, transaction.PayeeName,
+				cmp.Or(suggestion.categoryName, suggestion.categoryID), suggestion.count, suggestion.total)
+		}
+	}
+
+	return applied
+}
+
+// resolveCategoryHistory returns the history of the account, updated from YNAB with what
+// changed since it was saved in the state file.
+func resolveCategoryHistory(
+	ctx context.Context,
+	client *http.Client,
+	fsys fs.FS,
+	statePath, token, budgetID, accountID string,
+) (categoryHistory, error) {
+	previous := &pushState{}
+	if err := state.LoadFS(fsys, statePath, previous); err != nil {
+		return categoryHistory{}, err //nolint:wrapcheck // already explicit
+	}
+
+	history := previous.CategoryHistories[accountID]
+
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	page, err := lclynab.NewClient(token, client).
+		FetchTransactions(ctx, budgetID, accountID, lclynab.Date{}, history.ServerKnowledge)
+	if err != nil {
+		return categoryHistory{}, err //nolint:wrapcheck // already explicit
+	}
+
+	history.merge(page)
+
+	if previous.CategoryHistories == nil {
+		previous.CategoryHistories = map[string]categoryHistory{}
+	}
+
+	previous.CategoryHistories[accountID] = history
+	if err := state.Save(statePath, previous); err != nil {
+		return categoryHistory{}, err //nolint:wrapcheck // already explicit
+	}
+
+	return history, nil
+}
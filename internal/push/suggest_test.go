@@ -0,0 +1,191 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_categoryHistory_suggestions(t *testing.T) {
+	t.Parallel()
+
+	history := &categoryHistory{}
+	history.merge(lclynab.TransactionsPage{ServerKnowledge: 5, Transactions: []lclynab.SavedTransaction{
+		{ID: "1", PayeeName: "CB LECLERC", CategoryID: "groceries", CategoryName: "Groceries"},
+		{ID: "2", PayeeName: "cb  leclerc", CategoryID: "groceries", CategoryName: "Groceries"},
+		{ID: "3", PayeeName: "CB LECLERC", CategoryID: "groceries", CategoryName: "Groceries"},
+		{ID: "4", PayeeName: "CB LECLERC", CategoryID: "home", CategoryName: "Home"},
+		{ID: "5", PayeeName: "CB BAKERY", CategoryID: "food", CategoryName: "Food"},
+		{ID: "6", PayeeName: "CB BAKERY", CategoryID: "food", CategoryName: "Food"},
+		{ID: "7", PayeeName: "TIE", CategoryID: "b", CategoryName: "B"},
+		{ID: "8", PayeeName: "TIE", CategoryID: "a", CategoryName: "A"},
+		{ID: "9", PayeeName: "CB BAKERY", CategoryID: ""},
+	}})
+
+	// A later page deletes one and recategorizes another.
+	history.merge(lclynab.TransactionsPage{ServerKnowledge: 6, Transactions: []lclynab.SavedTransaction{
+		{ID: "3", Deleted: true},
+		{ID: "4", PayeeName: "CB LECLERC", CategoryID: "groceries", CategoryName: "Groceries"},
+	}})
+
+	tests := []struct {
+		name           string
+		minOccurrences int
+		want           map[string]categorySuggestion
+	}{
+		{
+			name:           "default threshold",
+			minOccurrences: defaultSuggestMinOccurrences,
+			want: map[string]categorySuggestion{
+				"CB LECLERC": {categoryID: "groceries", categoryName: "Groceries", count: 3, total: 3},
+			},
+		},
+		{
+			name:           "low threshold",
+			minOccurrences: 1,
+			want: map[string]categorySuggestion{
+				"CB LECLERC": {categoryID: "groceries", categoryName: "Groceries", count: 3, total: 3},
+				"CB BAKERY":  {categoryID: "food", categoryName: "Food", count: 2, total: 2},
+				"TIE":        {categoryID: "a", categoryName: "A", count: 1, total: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := history.suggestions(tt.minOccurrences); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suggestions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if history.ServerKnowledge != 6 {
+		t.Errorf("ServerKnowledge = %v, want 6", history.ServerKnowledge)
+	}
+}
+
+func Test_run_suggestCategories(t *testing.T) {
+	t.Parallel()
+
+	// diff.csv holds payments to CB  MERCH and CB  BAKERY, among others.
+	const history = `{"data": {"server_knowledge": 7, "transactions": [
+		{"id": "h-1", "payee_name": "CB MERCH", "category_id": "cat-groceries", "category_name": "Groceries"},
+		{"id": "h-2", "payee_name": "CB MERCH", "category_id": "cat-groceries", "category_name": "Groceries"},
+		{"id": "h-3", "payee_name": "CB MERCH", "category_id": "cat-groceries", "category_name": "Groceries"},
+		{"id": "h-4", "payee_name": "CB MERCH", "category_id": "cat-home", "category_name": "Home"},
+		{"id": "h-5", "payee_name": "CB BAKERY", "category_id": "cat-food", "category_name": "Food"}
+	]}}`
+
+	var (
+		queries []string
+		payload struct {
+			Transactions []map[string]any `json:"transactions"`
+		}
+	)
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/accounts/"+testAccountID+"/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			queries = append(queries, req.URL.RawQuery)
+			if req.URL.Query().Has("last_knowledge_of_server") {
+				return httpmock.NewStringResponse(http.StatusOK, `{"data": {"server_knowledge": 7, "transactions": []}}`), nil
+			}
+
+			return httpmock.NewStringResponse(http.StatusOK, history), nil
+		})
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+				t.Error(err)
+			}
+
+			return createdResponder(http.StatusOK)(req)
+		})
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	stdout := &bytes.Buffer{}
+	args := []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/diff.csv",
+		"-state", statePath, "-suggest-categories", "-v",
+	}
+	environment := env{
+		stdout:     stdout,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	}
+
+	if err := run(context.Background(), args, environment); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if want := "  2024-10-28  CB  MERCH: Groceries (3/4)\n"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("stdout = \n%s\nwant it to contain %q", stdout, want)
+	}
+
+	categories := map[string]any{}
+	for _, transaction := range payload.Transactions {
+		if transaction["category_id"] != nil {
+			categories[transaction["payee_name"].(string)] = []any{transaction["category_id"], transaction["approved"]}
+		}
+	}
+
+	if want := map[string]any{"CB  MERCH": []any{"cat-groceries", false}}; !reflect.DeepEqual(categories, want) {
+		t.Errorf("categorized = %v, want %v", categories, want)
+	}
+
+	saved := &pushState{}
+	if err := state.Load(statePath, saved); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := saved.CategoryHistories[testAccountID]; got.ServerKnowledge != 7 || len(got.Transactions) != 5 {
+		t.Errorf("saved history = %+v, want the 5 transactions at knowledge 7", got)
+	}
+
+	// The next run only asks for what changed.
+	queries = nil
+
+	if err := run(context.Background(), args, environment); err != nil {
+		t.Fatalf("second run() error = %v", err)
+	}
+
+	if want := []string{"last_knowledge_of_server=7"}; !reflect.DeepEqual(queries, want) {
+		t.Errorf("queries = %q, want %q", queries, want)
+	}
+}
+
+func Test_suggestCategories_explicitWins(t *testing.T) {
+	t.Parallel()
+
+	transactions := []Transaction{
+		{PayeeName: "CB MERCH", CategoryID: ptr("cat-explicit")},
+		{PayeeName: "CB MERCH"},
+	}
+	suggested := map[string]categorySuggestion{"CB MERCH": {categoryID: "cat-groceries", count: 3, total: 3}}
+
+	if applied := suggestCategories(io.Discard, transactions, suggested, false); applied != 1 {
+		t.Errorf("suggestCategories() = %v, want 1", applied)
+	}
+
+	if got := *transactions[0].CategoryID; got != "cat-explicit" {
+		t.Errorf("explicit category = %v, want cat-explicit", got)
+	}
+
+	if got := *transactions[1].CategoryID; got != "cat-groceries" || *transactions[1].Approved {
+		t.Errorf("suggested category = %v, approved %v, want cat-groceries unapproved", got, *transactions[1].Approved)
+	}
+}
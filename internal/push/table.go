@@ -0,0 +1,129 @@
+package push
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+const (
+	payeeColumnWidth = 24
+	memoColumnWidth  = 32
+	columnSeparator  = "  "
+	ellipsis         = "…"
+)
+
+// renderTable prints the transactions as aligned columns, their amounts in format.
+// Unless noTruncate is set, payees and memos are cut to a fixed display width.
+func renderTable(w io.Writer, transactions []Transaction, format amountFormat, noTruncate bool) error {
+	rows := [][]string{{"DATE", "AMOUNT", "PAYEE", "MEMO", "IMPORT ID"}}
+
+	for _, transaction := range transactions {
+		payee, memo := transaction.PayeeName, transaction.Memo
+		if !noTruncate {
+			payee = truncate(payee, payeeColumnWidth)
+			memo = truncate(memo, memoColumnWidth)
+		}
+
+		rows = append(rows, []string{
+			transaction.Date.String(),
+			format.signed(transaction.Amount),
+			payee,
+			memo,
+			transaction.ImportID,
+		})
+	}
+
+	widths := make([]int, len(rows[0]))
+
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	const amountColumn = 1
+
+	for _, row := range rows {
+		var line strings.Builder
+
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString(columnSeparator)
+			}
+
+			padding := strings.Repeat(" ", widths[i]-displayWidth(cell))
+
+			switch {
+			case i == amountColumn:
+				line.WriteString(padding + cell)
+			case i == len(row)-1:
+				line.WriteString(cell)
+			default:
+				line.WriteString(cell + padding)
+			}
+		}
+
+		if _, err := fmt.Fprintln(w, line.String()); err != nil {
+			return fmt.Errorf("writing table: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// signedAmountString formats amount in euros, for the notifications.
+func signedAmountString(amount int) string {
+	return amountFormat{}.signed(amount)
+}
+
+// truncate cuts s so that it is at most maxWidth columns wide on a terminal.
+func truncate(s string, maxWidth int) string {
+	if displayWidth(s) <= maxWidth {
+		return s
+	}
+
+	var (
+		out   strings.Builder
+		total int
+	)
+
+	for _, r := range s {
+		w := runeWidth(r)
+		if total+w > maxWidth-displayWidth(ellipsis) {
+			break
+		}
+
+		total += w
+
+		out.WriteRune(r)
+	}
+
+	return out.String() + ellipsis
+}
+
+// displayWidth returns the number of terminal columns s occupies.
+func displayWidth(s string) int {
+	total := 0
+	for _, r := range s {
+		total += runeWidth(r)
+	}
+
+	return total
+}
+
+func runeWidth(r rune) int {
+	if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r) {
+		return 0
+	}
+
+	switch width.LookupRune(r).Kind() { //nolint:exhaustive // everything else is one column wide
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2 //nolint:mnd // wide characters take two columns
+	default:
+		return 1
+	}
+}
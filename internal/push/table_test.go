@@ -0,0 +1,106 @@
+package push
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func Test_renderTable(t *testing.T) {
+	t.Parallel()
+
+	transactions := []Transaction{
+		{
+			Date:      mustDate("2024-10-29"),
+			Amount:    80000,
+			PayeeName: "VIREMENT M JEAN MARTIN OU",
+			Memo:      "VIREMENT M JEAN MARTIN OU",
+			ImportID:  "YNAB:80000:2024-10-29:1",
+		},
+		{
+			Date:      mustDate("2024-10-28"),
+			Amount:    -21320,
+			PayeeName: "CAFÉ DE LA GARE",
+			Memo:      "CB  CAFÉ DE LA GARE          28/10/24",
+			ImportID:  "YNAB:-21320:2024-10-28:1",
+		},
+		{
+			Date:      mustDate("2024-10-27"),
+			Amount:    -1234560,
+			PayeeName: "CAFE\u0301 DE LA GARE",
+			Memo:      "PRLV SEPA ÉLECTRICITÉ DE FRANCE ECH/271024 ID EMETTEUR/FR00ZZZ000000 MDT/000000000",
+			ImportID:  "YNAB:-1234560:2024-10-27:1",
+		},
+		{
+			Date:      mustDate("2024-10-26"),
+			Amount:    -5000,
+			PayeeName: "",
+			Memo:      "",
+			ImportID:  "YNAB:-5000:2024-10-26:1",
+		},
+		{
+			Date:      mustDate("2024-10-25"),
+			Amount:    0,
+			PayeeName: "東京 SHOP",
+			Memo:      "東京 SHOP",
+			ImportID:  "YNAB:0:2024-10-25:1",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		noTruncate bool
+		golden     string
+	}{
+		{name: "truncated", noTruncate: false, golden: "./testdata/table.golden"},
+		{name: "not truncated", noTruncate: true, golden: "./testdata/table-no-truncate.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := &bytes.Buffer{}
+			if err := renderTable(got, transactions, amountFormat{}, tt.noTruncate); err != nil {
+				t.Fatalf("renderTable() error = %v", err)
+			}
+
+			want, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.String() != string(want) {
+				t.Errorf("renderTable() = \n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func Test_truncate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		s        string
+		maxWidth int
+		want     string
+	}{
+		{name: "short", s: "CB MERCH", maxWidth: 10, want: "CB MERCH"},
+		{name: "exact", s: "CB MERCH12", maxWidth: 10, want: "CB MERCH12"},
+		{name: "long", s: "CB MERCH123", maxWidth: 10, want: "CB MERCH1…"},
+		{name: "combining accent", s: "CAFE\u0301 CAFE\u0301", maxWidth: 9, want: "CAFE\u0301 CAFE\u0301"},
+		{name: "combining accent cut", s: "CAFE\u0301 CAFE\u0301", maxWidth: 6, want: "CAFE\u0301 …"},
+		{name: "wide characters", s: "東京東京", maxWidth: 6, want: "東京…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := truncate(tt.s, tt.maxWidth); got != tt.want {
+				t.Errorf("truncate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/carlmjohnson/requests"
+)
+
+const (
+	telegramAPI = "https://api.telegram.org"
+	// telegramMaxLen is the maximum length of a Telegram message.
+	telegramMaxLen = 4096
+)
+
+// telegramEscaper escapes the characters MarkdownV2 reserves.
+var telegramEscaper = strings.NewReplacer( //nolint:gochecknoglobals // constant replacer
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// telegram sends a summary of the run through a Telegram bot.
+type telegram struct {
+	client       *http.Client
+	token        string
+	chatID       string
+	failuresOnly bool
+	details      bool
+}
+
+func newTelegram(opts *options, client *http.Client) *telegram {
+	return &telegram{
+		client:       client,
+		token:        opts.telegramToken,
+		chatID:       opts.telegramChatID,
+		failuresOnly: opts.telegramFailuresOnly,
+		details:      opts.telegramDetails,
+	}
+}
+
+func (t *telegram) name() string {
+	return "telegram"
+}
+
+type telegramMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+func (t *telegram) notify(ctx context.Context, data webhookData) error {
+	for _, msg := range t.messages(data) {
+		if err := t.send(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *telegram) render(data webhookData) ([]message, error) {
+	var messages []message
+
+	for _, msg := range t.messages(data) {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("encoding Telegram message: %w", err)
+		}
+
+		messages = append(messages, message{target: "chat " + t.chatID, body: body})
+	}
+
+	return messages, nil
+}
+
+// messages returns the summary split into as many messages as Telegram needs.
+func (t *telegram) messages(data webhookData) []telegramMessage {
+	failed := data.Status == statusError || data.Status == statusCancelled
+	if !failed && t.failuresOnly {
+		return nil
+	}
+
+	var messages []telegramMessage
+
+	for _, text := range splitMessage(t.format(data), telegramMaxLen) {
+		messages = append(messages, telegramMessage{ChatID: t.chatID, Text: text, ParseMode: "MarkdownV2"})
+	}
+
+	return messages
+}
+
+// format renders the summary as MarkdownV2.
+func (t *telegram) format(data webhookData) string {
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "*YNAB import %v*\n", escapeMarkdown(data.Status))
+
+	if data.AccountName != "" {
+		fmt.Fprintf(&text, "Account: %v \\(%v\\)\n", escapeMarkdown(data.AccountName), escapeMarkdown(data.BudgetName))
+	}
+
+	fmt.Fprintf(&text, "Pushed: %d\nDuplicates: %d\n", data.Pushed, data.Duplicates)
+
+	if data.Reconciled != "" {
+		fmt.Fprintf(&text, "Reconciled: %v€\n", escapeMarkdown(data.Reconciled))
+	}
+
+	if data.Error != "" {
+		fmt.Fprintf(&text, "Failed during %v: %v\n", escapeMarkdown(data.Phase), escapeMarkdown(data.Error))
+	}
+
+	if t.details && len(data.DuplicateTransactions) > 0 {
+		text.WriteString("\n*Duplicates*\n")
+
+		for _, transaction := range data.DuplicateTransactions {
+			fmt.Fprintf(&text, "%v %v %v\n",
+				escapeMarkdown(transaction.Date.String()),
+				escapeMarkdown(signedAmountString(transaction.Amount)),
+				escapeMarkdown(transaction.PayeeName))
+		}
+	}
+
+	return strings.TrimSuffix(text.String(), "\n")
+}
+
+func (t *telegram) send(ctx context.Context, msg telegramMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	err := requests.URL(telegramAPI).
+		Pathf("/bot%s/sendMessage", t.token).
+		Client(t.client).
+		BodyJSON(msg).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("sending Telegram message: %w", err)
+	}
+
+	return nil
+}
+
+func escapeMarkdown(s string) string {
+	return telegramEscaper.Replace(s)
+}
+
+// splitMessage splits text into messages of at most maxLen characters,
+// preferably between lines and never inside an escape sequence.
+func splitMessage(text string, maxLen int) []string {
+	var messages []string
+
+	for utf8.RuneCountInString(text) > maxLen {
+		cut := byteOffset(text, maxLen)
+
+		if newline := strings.LastIndexByte(text[:cut], '\n'); newline > 0 {
+			cut = newline
+		} else {
+			for cut > 0 && escapedAt(text, cut) {
+				cut--
+			}
+		}
+
+		messages = append(messages, text[:cut])
+		text = strings.TrimPrefix(text[cut:], "\n")
+	}
+
+	return append(messages, text)
+}
+
+// byteOffset returns the byte offset of the n-th rune of s.
+func byteOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+
+		n--
+	}
+
+	return len(s)
+}
+
+// escapedAt reports whether the byte at i is escaped by an odd number of backslashes before it.
+func escapedAt(s string, i int) bool {
+	backslashes := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		backslashes++
+	}
+
+	return backslashes%2 == 1
+}
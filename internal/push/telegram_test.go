@@ -0,0 +1,163 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_escapeMarkdown(t *testing.T) {
+	t.Parallel()
+
+	got := escapeMarkdown(`CB CARREFOUR (1/2) - 12.50€ *promo* [x] a_b \ok!`)
+	want := `CB CARREFOUR \(1/2\) \- 12\.50` + "\u20ac" + ` \*promo\* \[x\] a\_b \\ok\!`
+
+	if got != want {
+		t.Errorf("escapeMarkdown() = %v, want %v", got, want)
+	}
+}
+
+func Test_splitMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		text   string
+		maxLen int
+		want   []string
+	}{
+		{name: "short", text: "one\ntwo", maxLen: 10, want: []string{"one\ntwo"}},
+		{name: "between lines", text: "one\ntwo\nthree", maxLen: 8, want: []string{"one\ntwo", "three"}},
+		{name: "long line", text: "abcdefghij", maxLen: 4, want: []string{"abcd", "efgh", "ij"}},
+		{name: "escape kept whole", text: `abc\.def`, maxLen: 4, want: []string{"abc", `\.de`, "f"}},
+		{name: "runes", text: "\u20ac\u20ac\u20ac", maxLen: 2, want: []string{"\u20ac\u20ac", "\u20ac"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := splitMessage(tt.text, tt.maxLen); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_telegram_notify(t *testing.T) {
+	t.Parallel()
+
+	duplicates := make([]Transaction, 200)
+	for i := range duplicates {
+		duplicates[i] = Transaction{Date: mustDate("2024-10-29"), Amount: -12500, PayeeName: "CB CARREFOUR (MARKET) 29/10"}
+	}
+
+	tests := []struct {
+		name         string
+		failuresOnly bool
+		details      bool
+		data         webhookData
+		wantTexts    []string
+		wantMessages int
+	}{
+		{
+			name: "success",
+			data: webhookData{Status: statusOK, Pushed: 3, Duplicates: 1, Reconciled: "100.06"},
+			wantTexts: []string{
+				"*YNAB import ok*\nPushed: 3\nDuplicates: 1\nReconciled: 100\\.06\u20ac",
+			},
+			wantMessages: 1,
+		},
+		{
+			name: "failure",
+			data: webhookData{Status: statusError, Phase: "api call", Error: "pushing to YNAB: rate limit (429)"},
+			wantTexts: []string{
+				"*YNAB import error*\nPushed: 0\nDuplicates: 0\n" +
+					"Failed during api call: pushing to YNAB: rate limit \\(429\\)",
+			},
+			wantMessages: 1,
+		},
+		{
+			name:         "success with failures only",
+			failuresOnly: true,
+			data:         webhookData{Status: statusOK},
+			wantTexts:    nil,
+			wantMessages: 0,
+		},
+		{
+			name:         "long duplicates list",
+			details:      true,
+			data:         webhookData{Status: statusOK, Duplicates: len(duplicates), DuplicateTransactions: duplicates},
+			wantTexts:    nil,
+			wantMessages: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var texts []string
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(
+				http.MethodPost,
+				"https://api.telegram.org/bot123:secret/sendMessage",
+				func(req *http.Request) (*http.Response, error) {
+					var message struct {
+						ChatID    string `json:"chat_id"`
+						Text      string `json:"text"`
+						ParseMode string `json:"parse_mode"`
+					}
+					if err := json.NewDecoder(req.Body).Decode(&message); err != nil {
+						t.Errorf("decoding message: %v", err)
+					}
+
+					if message.ChatID != "42" || message.ParseMode != "MarkdownV2" {
+						t.Errorf("message = %+v, want chat 42 in MarkdownV2", message)
+					}
+
+					texts = append(texts, message.Text)
+
+					return httpmock.NewStringResponse(http.StatusOK, `{"ok": true}`), nil
+				},
+			)
+
+			bot := &telegram{
+				client:       &http.Client{Transport: transport},
+				token:        "123:secret",
+				chatID:       "42",
+				failuresOnly: tt.failuresOnly,
+				details:      tt.details,
+			}
+
+			if err := bot.notify(context.Background(), tt.data); err != nil {
+				t.Fatalf("notify() error = %v", err)
+			}
+
+			if len(texts) != tt.wantMessages {
+				t.Fatalf("sent %d message(s), want %d", len(texts), tt.wantMessages)
+			}
+
+			if tt.wantTexts != nil && !reflect.DeepEqual(texts, tt.wantTexts) {
+				t.Errorf("sent %q, want %q", texts, tt.wantTexts)
+			}
+
+			for _, text := range texts {
+				if utf8.RuneCountInString(text) > telegramMaxLen {
+					t.Errorf("message of %d characters, want at most %d", utf8.RuneCountInString(text), telegramMaxLen)
+				}
+
+				if tt.details && !strings.HasSuffix(text, `CB CARREFOUR \(MARKET\) 29/10`) {
+					t.Errorf("message split inside a line: %q", text[len(text)-40:])
+				}
+			}
+		})
+	}
+}
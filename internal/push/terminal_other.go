@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package push
+
+import "os"
+
+// terminalWidth reports no terminal where the window size can't be asked:
+// progress is then printed as plain lines.
+func terminalWidth(*os.File) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package push
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalWidth returns the number of columns of the terminal f is, asked on each
+// call so that a resize is seen. ok is false when f isn't a terminal.
+func terminalWidth(f *os.File) (int, bool) {
+	var size struct {
+		rows, cols, x, y uint16
+	}
+
+	//nolint:gosec // the size is written by the kernel, as TIOCGWINSZ documents
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 || size.cols == 0 {
+		return 0, false
+	}
+
+	return int(size.cols), true
+}
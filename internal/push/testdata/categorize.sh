@@ -0,0 +1,18 @@
+#!/bin/sh
+# Test categorizer: groceries for MERCH, nothing for anything else.
+# With "batch" as first argument, answers one line per input line.
+answer() {
+	case "$1" in
+	*MERCH*) echo '{"category_id": "cat-groceries", "payee_name": "Merch", "flag_color": "green"}' ;;
+	*) echo '{}' ;;
+	esac
+}
+
+if [ "$1" = "batch" ]; then
+	while read -r line; do
+		answer "$line"
+	done
+	exit 0
+fi
+
+answer "$(cat)"
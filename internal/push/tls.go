@@ -0,0 +1,73 @@
+package push
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+)
+
+var errCustomTransport = errors.New("TLS options need the standard HTTP transport")
+
+// withTLS returns a copy of client trusting the certificates of caFile, read from fsys,
+// in addition to the system roots, or not verifying certificates at all when insecure
+// is set. The client is returned as is when neither is requested.
+func withTLS(client *http.Client, fsys fs.FS, caFile string, insecure bool) (*http.Client, error) {
+	if caFile == "" && !insecure {
+		return client, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecure, //nolint:gosec // explicitly requested with -insecure-skip-verify
+	}
+
+	if caFile != "" {
+		pool, err := loadSystemCA(fsys, caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	var transport *http.Transport
+
+	switch base := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // always a *http.Transport
+	case *http.Transport:
+		transport = base.Clone()
+	default:
+		return nil, fmt.Errorf("%w, got %T", errCustomTransport, base)
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	configured := *client
+	configured.Transport = transport
+
+	return &configured, nil
+}
+
+// loadSystemCA returns the system roots with the certificates of path added,
+// so that public endpoints keep working next to the self-hosted ones.
+func loadSystemCA(fsys fs.FS, path string) (*x509.CertPool, error) {
+	pem, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%w in %v", errInvalidCA, path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,130 @@
+package push
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_withTLS(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(server.Close)
+
+	caFile := writeCA(t, server)
+
+	tests := []struct {
+		name     string
+		caFile   string
+		insecure bool
+		wantErr  bool
+	}{
+		{name: "system roots", caFile: "", insecure: false, wantErr: true},
+		{name: "ca cert", caFile: caFile, insecure: false, wantErr: false},
+		{name: "insecure", caFile: "", insecure: true, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, err := withTLS(&http.Client{}, osFS{}, tt.caFile, tt.insecure)
+			if err != nil {
+				t.Fatalf("withTLS() error = %v", err)
+			}
+
+			resp, err := client.Get(server.URL) //nolint:noctx // test server
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Get() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_withTLSErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		client  *http.Client
+		caFile  string
+		wantErr error
+	}{
+		{name: "missing file", client: &http.Client{}, caFile: "./testdata/missing.pem", wantErr: os.ErrNotExist},
+		{name: "not a certificate", client: &http.Client{}, caFile: "./testdata/one-positive.csv", wantErr: errInvalidCA},
+		{
+			name:    "custom transport",
+			client:  &http.Client{Transport: httpmock.NewMockTransport()},
+			caFile:  "",
+			wantErr: errCustomTransport,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := withTLS(tt.client, osFS{}, tt.caFile, true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("withTLS() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_run_webhookCACert(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls++
+	}))
+	t.Cleanup(server.Close)
+
+	// The conversion fails before YNAB is called, only the failure webhook goes out.
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/missing.csv",
+		"-webhook-failure", server.URL, "-webhook-ca-cert", writeCA(t, server),
+	}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("run() error = %v, want %v", err, os.ErrNotExist)
+	}
+
+	if calls != 1 {
+		t.Errorf("webhook calls = %v, want 1", calls)
+	}
+}
+
+// writeCA writes the certificate of server as a PEM file and returns its path.
+func writeCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
@@ -0,0 +1,6 @@
+package push
+
+import "github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+
+// Transaction is a YNAB transaction, see https://api.ynab.com/v1#/Transactions/createTransaction.
+type Transaction = lclynab.Transaction
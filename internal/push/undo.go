@@ -0,0 +1,150 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// maxRecordedRuns bounds the runs remembered for -undo-run in the state file.
+const maxRecordedRuns = 20
+
+var (
+	errUnknownRun     = errors.New("no recorded run to undo")
+	errAlreadyUndone  = errors.New("run already undone")
+	errUndoIncomplete = errors.New("some transactions were not deleted")
+)
+
+// Outcomes of undoing a transaction.
+const (
+	undoDeleted  = "deleted"
+	undoModified = "skipped, modified since the push"
+	undoGone     = "skipped, already deleted"
+	undoFailed   = "failed"
+)
+
+// pushedRun is a run that created transactions, remembered so that it can be undone.
+type pushedRun struct {
+	RunID    string    `json:"run_id"`
+	PushedAt time.Time `json:"pushed_at"`
+	BudgetID string    `json:"budget_id"`
+	// Created are the transactions as YNAB saved them, to tell whether they changed since.
+	Created  []lclynab.SavedTransaction `json:"created"`
+	UndoneAt time.Time                  `json:"undone_at,omitzero"`
+}
+
+// recordRun remembers the transactions a run created, forgetting the oldest runs.
+func recordRun(fsys fs.FS, statePath string, run pushedRun) error {
+	previous := &pushState{}
+	if err := state.LoadFS(fsys, statePath, previous); err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	previous.Runs = append(previous.Runs, run)
+	if len(previous.Runs) > maxRecordedRuns {
+		previous.Runs = slices.Clone(previous.Runs[len(previous.Runs)-maxRecordedRuns:])
+	}
+
+	return state.Save(statePath, previous) //nolint:wrapcheck // already explicit
+}
+
+// undo deletes the transactions created by the run given with -undo-run, or the last one
+// with -undo-last. Transactions modified in YNAB since the push are kept unless -force.
+func undo(ctx context.Context, opts *options, env env) error {
+	previous := &pushState{}
+	if err := state.LoadFS(env.fsys, opts.statePath, previous); err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	i := len(previous.Runs) - 1
+	if !opts.undoLast {
+		i = slices.IndexFunc(previous.Runs, func(run pushedRun) bool { return run.RunID == opts.undoRun })
+	}
+
+	if i < 0 {
+		return fmt.Errorf("%w: %q", errUnknownRun, opts.undoRun)
+	}
+
+	run := &previous.Runs[i]
+	if !run.UndoneAt.IsZero() {
+		return fmt.Errorf("%w: %v on %v", errAlreadyUndone, run.RunID, run.UndoneAt.Format(time.DateTime))
+	}
+
+	_, _ = fmt.Fprintf(env.stdout, "undoing run %v of %v: %d transaction(s)\n",
+		run.RunID, run.PushedAt.Format(time.DateTime), len(run.Created))
+
+	client := lclynab.NewClient(opts.token, env.httpClient)
+	failed := 0
+
+	for _, created := range run.Created {
+		outcome, err := undoTransaction(ctx, client, run.BudgetID, created, opts.force)
+		if err != nil {
+			outcome = fmt.Sprintf("%v: %v", outcome, err)
+		}
+
+		if outcome != undoDeleted && outcome != undoGone {
+			failed++
+		}
+
+		_, _ = fmt.Fprintf(env.stdout, "  %v  %v  %v  %v\n",
+			created.Date, signedAmountString(created.Amount), created.PayeeName, outcome)
+	}
+
+	// Once attempted, a run is not undone again: what was kept was kept on purpose.
+	run.UndoneAt = env.now()
+	if err := state.Save(opts.statePath, previous); err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", errUndoIncomplete, failed, len(run.Created))
+	}
+
+	return nil
+}
+
+// undoTransaction deletes one created transaction, unless YNAB shows it changed since.
+func undoTransaction(
+	ctx context.Context,
+	client *lclynab.Client,
+	budgetID string,
+	created lclynab.SavedTransaction,
+	force bool,
+) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	current, err := client.GetTransaction(ctx, budgetID, created.ID)
+	switch {
+	case err != nil:
+		return undoFailed, err //nolint:wrapcheck // already explicit
+	case current.Deleted:
+		return undoGone, nil
+	case !force && modified(created, current):
+		return undoModified, nil
+	}
+
+	if err := client.DeleteTransaction(ctx, budgetID, created.ID); err != nil {
+		return undoFailed, err //nolint:wrapcheck // already explicit
+	}
+
+	return undoDeleted, nil
+}
+
+// modified reports whether a transaction changed in YNAB since it was created.
+func modified(created, current lclynab.SavedTransaction) bool {
+	return created.Amount != current.Amount ||
+		!created.Date.Equal(current.Date) ||
+		created.Memo != current.Memo ||
+		created.PayeeID != current.PayeeID ||
+		created.CategoryID != current.CategoryID ||
+		created.FlagColor != current.FlagColor ||
+		created.Cleared != current.Cleared ||
+		created.Approved != current.Approved
+}
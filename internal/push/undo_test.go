@@ -0,0 +1,207 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+// undoTransport serves the current state of t-1, unchanged since the push, t-2, recategorized
+// since, and t-3, already deleted, and records the deleted transactions.
+func undoTransport(deleted *[]string, mu *sync.Mutex) *httpmock.MockTransport {
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/transactions/t-1",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"transaction": {"id": "t-1", "date": "2024-10-29",
+			"amount": 80000, "payee_name": "Transfer", "cleared": "cleared"}}}`))
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/transactions/t-2",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"transaction": {"id": "t-2", "date": "2024-10-28",
+			"amount": -21320, "payee_name": "Merch", "cleared": "cleared", "category_id": "cat-groceries"}}}`))
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/transactions/t-3",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"transaction": {"id": "t-3", "deleted": true}}}`))
+	transport.RegisterRegexpResponder(http.MethodDelete, regexp.MustCompile(`/transactions/t-\d$`),
+		func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			*deleted = append(*deleted, req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:])
+
+			return httpmock.NewStringResponse(http.StatusOK, `{"data": {}}`), nil
+		})
+
+	return transport
+}
+
+func writeUndoState(t *testing.T) string {
+	t.Helper()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	err := state.Save(statePath, &pushState{Runs: []pushedRun{{
+		RunID:    "run-1",
+		PushedAt: fixedNow(),
+		BudgetID: testBudgetID,
+		Created: []lclynab.SavedTransaction{
+			{ID: "t-1", Date: mustDate("2024-10-29"), Amount: 80000, PayeeName: "Transfer", Cleared: "cleared"},
+			{ID: "t-2", Date: mustDate("2024-10-28"), Amount: -21320, PayeeName: "Merch", Cleared: "cleared"},
+			{ID: "t-3", Date: mustDate("2024-10-28"), Amount: -1000, PayeeName: "Bakery", Cleared: "cleared"},
+		},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return statePath
+}
+
+func Test_run_undo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantErr     error
+		wantDeleted []string
+	}{
+		{
+			name:        "modified kept",
+			args:        []string{"-undo-run", "run-1"},
+			wantErr:     errUndoIncomplete,
+			wantDeleted: []string{"t-1"},
+		},
+		{
+			name:        "forced",
+			args:        []string{"-undo-last", "-force"},
+			wantErr:     nil,
+			wantDeleted: []string{"t-1", "t-2"},
+		},
+		{
+			name:        "unknown run",
+			args:        []string{"-undo-run", "run-2"},
+			wantErr:     errUnknownRun,
+			wantDeleted: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu      sync.Mutex
+				deleted []string
+			)
+
+			statePath := writeUndoState(t)
+			environment := env{
+				stdout:     io.Discard,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: undoTransport(&deleted, &mu)},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			}
+
+			err := run(context.Background(), append([]string{"-t", "tok", "-state", statePath}, tt.args...), environment)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !slices.Equal(deleted, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+
+			if tt.wantErr == errUnknownRun {
+				return
+			}
+
+			err = run(context.Background(), append([]string{"-t", "tok", "-state", statePath}, tt.args...), environment)
+			if !errors.Is(err, errAlreadyUndone) {
+				t.Errorf("second run() error = %v, want errAlreadyUndone", err)
+			}
+		})
+	}
+}
+
+func Test_run_undoOutput(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+
+	stdout := &bytes.Buffer{}
+
+	_ = run(context.Background(), []string{"-t", "tok", "-state", writeUndoState(t), "-undo-last"}, env{
+		stdout:     stdout,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: undoTransport(&deleted, &mu)},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+
+	want := "undoing run run-1 of 2024-11-30 03:00:00: 3 transaction(s)\n" +
+		"  2024-10-29  +80.00€  Transfer  deleted\n" +
+		"  2024-10-28  -21.32€  Merch  skipped, modified since the push\n" +
+		"  2024-10-28  -1.00€  Bakery  skipped, already deleted\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = \n%s\nwant\n%s", stdout, want)
+	}
+}
+
+func Test_run_undoRecorded(t *testing.T) {
+	t.Parallel()
+
+	var deleted []string
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+		httpmock.NewStringResponder(http.StatusCreated, `{"data": {"transaction_ids": ["t-1"], "transactions": [
+			{"id": "t-1", "date": "2024-10-29", "amount": 80000, "cleared": "cleared"}]}}`))
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/transactions/t-1",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"transaction":
+			{"id": "t-1", "date": "2024-10-29", "amount": 80000, "cleared": "cleared"}}}`))
+	transport.RegisterResponder(http.MethodDelete, "/v1/budgets/"+testBudgetID+"/transactions/t-1",
+		func(*http.Request) (*http.Response, error) {
+			deleted = append(deleted, "t-1")
+
+			return httpmock.NewStringResponse(http.StatusOK, `{"data": {}}`), nil
+		})
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	environment := env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-state", statePath, "-run-id", "run-1",
+	}, environment)
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	err = run(context.Background(), []string{"-t", "tok", "-state", statePath, "-undo-run", "run-1"}, environment)
+	if err != nil {
+		t.Fatalf("undo run() error = %v", err)
+	}
+
+	if !slices.Equal(deleted, []string{"t-1"}) {
+		t.Errorf("deleted = %v, want the created transaction", deleted)
+	}
+}
@@ -0,0 +1,239 @@
+package push
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/atomicfile"
+	"github.com/carlmjohnson/requests"
+)
+
+const (
+	latestReleaseURL = "https://api.github.com/repos/Crocmagnon/lcl-ynab-go/releases/latest"
+	checksumsAsset   = "checksums.txt"
+	updateTimeout    = 5 * time.Minute
+	executablePerm   = 0o755
+)
+
+// version is the release the binary was built from, set with
+// -ldflags "-X github.com/Crocmagnon/lcl-ynab-go/internal/push.version=v1.2.3".
+//
+//nolint:gochecknoglobals // set at build time
+var version = ""
+
+var (
+	errMissingAsset      = errors.New("release asset not found")
+	errChecksumMismatch  = errors.New("checksum mismatch")
+	errReadOnlyInstall   = errors.New("the executable can't be replaced")
+	errUnknownExecutable = errors.New("the path of the executable is unknown")
+)
+
+// release is the part of a GitHub release -update reads.
+type release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+func (r release) asset(name string) (releaseAsset, error) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, nil
+		}
+	}
+
+	return releaseAsset{}, fmt.Errorf("%w: %v in %v", errMissingAsset, name, r.TagName)
+}
+
+// buildVersion returns the version embedded at build time, or the module version
+// for a go install, or "dev".
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	return "dev"
+}
+
+// update replaces the running executable with the latest release for this OS and
+// architecture once its checksum matches. With -check-only, it only tells.
+func update(ctx context.Context, opts *options, env env) error {
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	var latest release
+
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	err := requests.URL(latestReleaseURL).
+		Client(env.httpClient).
+		Accept("application/vnd.github+json").
+		ToJSON(&latest).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("getting the latest release: %w", err)
+	}
+
+	current := buildVersion()
+	if !newerVersion(latest.TagName, current) {
+		_, _ = fmt.Fprintf(env.stdout, "push %v is up to date\n", current)
+
+		return nil
+	}
+
+	_, _ = fmt.Fprintf(env.stdout, "update available: %v -> %v\n", current, latest.TagName)
+
+	if opts.checkOnly {
+		return nil
+	}
+
+	executable, err := env.executablePath()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUnknownExecutable, err)
+	}
+
+	binary, err := downloadRelease(ctx, env, latest, fmt.Sprintf("push-%v-%v", runtime.GOOS, runtime.GOARCH))
+	if err != nil {
+		return err
+	}
+
+	if err := atomicfile.WriteFile(executable, binary, executablePerm); err != nil {
+		if errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS) {
+			return fmt.Errorf("%w: %v is in a read-only location, download %v by hand: %w",
+				errReadOnlyInstall, executable, latest.TagName, err)
+		}
+
+		return fmt.Errorf("replacing %v: %w", executable, err)
+	}
+
+	_, _ = fmt.Fprintf(env.stdout, "updated %v to %v\n", executable, latest.TagName)
+
+	return nil
+}
+
+// downloadRelease returns the asset of the release, checked against the checksums file.
+func downloadRelease(ctx context.Context, env env, latest release, name string) ([]byte, error) {
+	binaryAsset, err := latest.asset(name)
+	if err != nil {
+		return nil, err
+	}
+
+	sumsAsset, err := latest.asset(checksumsAsset)
+	if err != nil {
+		return nil, err
+	}
+
+	var checksums, binary bytes.Buffer
+
+	for _, download := range []struct {
+		asset releaseAsset
+		to    *bytes.Buffer
+	}{{asset: sumsAsset, to: &checksums}, {asset: binaryAsset, to: &binary}} {
+		//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+		err := requests.URL(download.asset.URL).Client(env.httpClient).ToBytesBuffer(download.to).Fetch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("downloading %v: %w", download.asset.Name, err)
+		}
+	}
+
+	want, err := checksumOf(checksums.Bytes(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(binary.Bytes())
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("%w: %v is %v, want %v", errChecksumMismatch, name, got, want)
+	}
+
+	return binary.Bytes(), nil
+}
+
+// checksumOf finds the SHA-256 of name in a checksums file, as written by sha256sum.
+func checksumOf(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %v in %v", errMissingAsset, name, checksumsAsset)
+}
+
+// newerVersion reports whether latest, like v1.2.3, is after current.
+// A development build is always behind.
+func newerVersion(latest, current string) bool {
+	latestParts, ok := versionParts(latest)
+	if !ok {
+		return false
+	}
+
+	currentParts, ok := versionParts(current)
+	if !ok {
+		return true
+	}
+
+	for i := range latestParts {
+		if latestParts[i] != currentParts[i] {
+			return latestParts[i] > currentParts[i]
+		}
+	}
+
+	return false
+}
+
+// versionParts returns the major, minor and patch numbers of a version like v1.2.3.
+// Pre-release and build suffixes are ignored.
+func versionParts(v string) ([3]int, bool) {
+	var parts [3]int
+
+	v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "-")
+	v, _, _ = strings.Cut(v, "+")
+
+	fields := strings.Split(v, ".")
+	if len(fields) != len(parts) {
+		return parts, false
+	}
+
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+
+		parts[i] = n
+	}
+
+	return parts, true
+}
+
+// executablePath returns the path of the running executable, replaced in tests.
+func (e env) executablePath() (string, error) {
+	if e.executable != nil {
+		return e.executable()
+	}
+
+	return os.Executable() //nolint:wrapcheck // wrapped by the caller
+}
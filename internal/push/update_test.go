@@ -0,0 +1,157 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+const releaseBinary = "#!/bin/sh\necho new push\n"
+
+// releaseTransport serves a v9.0.0 release with the binary of this platform and checksums,
+// the checksum of the binary being wrong with badChecksum.
+func releaseTransport(badChecksum bool) *httpmock.MockTransport {
+	name := fmt.Sprintf("push-%v-%v", runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256([]byte(releaseBinary))
+
+	checksum := hex.EncodeToString(sum[:])
+	if badChecksum {
+		checksum = hex.EncodeToString(make([]byte, sha256.Size))
+	}
+
+	const downloads = "https://github.com/Crocmagnon/lcl-ynab-go/releases/download/v9.0.0/"
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, latestReleaseURL,
+		httpmock.NewStringResponder(http.StatusOK, fmt.Sprintf(`{"tag_name": "v9.0.0", "assets": [
+			{"name": %q, "browser_download_url": %q},
+			{"name": "checksums.txt", "browser_download_url": %q}]}`,
+			name, downloads+name, downloads+"checksums.txt")))
+	transport.RegisterResponder(http.MethodGet, downloads+name,
+		httpmock.NewStringResponder(http.StatusOK, releaseBinary))
+	transport.RegisterResponder(http.MethodGet, downloads+"checksums.txt",
+		httpmock.NewStringResponder(http.StatusOK, fmt.Sprintf("%v  push-other-os\n%v  %v\n", checksum, checksum, name)))
+
+	return transport
+}
+
+func Test_run_update(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		args        []string
+		badChecksum bool
+		wantErr     error
+		wantBinary  string
+	}{
+		{name: "updated", args: nil, wantErr: nil, wantBinary: releaseBinary},
+		{name: "check only", args: []string{"-check-only"}, wantErr: nil, wantBinary: "old push"},
+		{name: "bad checksum", badChecksum: true, wantErr: errChecksumMismatch, wantBinary: "old push"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			executable := filepath.Join(t.TempDir(), "push")
+			if err := os.WriteFile(executable, []byte("old push"), executablePerm); err != nil {
+				t.Fatal(err)
+			}
+
+			stdout := &bytes.Buffer{}
+
+			err := run(context.Background(), append([]string{"-update"}, tt.args...), env{
+				stdout:     stdout,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: releaseTransport(tt.badChecksum)},
+				now:        fixedNow,
+				executable: func() (string, error) { return executable, nil },
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got, err := os.ReadFile(executable)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.wantBinary {
+				t.Errorf("executable = %q, want %q", got, tt.wantBinary)
+			}
+
+			if want := "update available: dev -> v9.0.0\n"; !bytes.HasPrefix(stdout.Bytes(), []byte(want)) {
+				t.Errorf("stdout = %q, want it to start with %q", stdout, want)
+			}
+		})
+	}
+}
+
+func Test_run_updateReadOnly(t *testing.T) {
+	t.Parallel()
+
+	if os.Geteuid() == 0 {
+		t.Skip("root writes to read-only directories")
+	}
+
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "push")
+
+	if err := os.WriteFile(executable, []byte("old push"), executablePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chmod(dir, 0o555); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = os.Chmod(dir, 0o755) })
+
+	err := run(context.Background(), []string{"-update"}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: releaseTransport(false)},
+		now:        fixedNow,
+		executable: func() (string, error) { return executable, nil },
+		getenv:     testGetenv(t),
+	})
+	if !errors.Is(err, errReadOnlyInstall) {
+		t.Errorf("run() error = %v, want errReadOnlyInstall", err)
+	}
+}
+
+func Test_newerVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{latest: "v1.2.0", current: "v1.1.9", want: true},
+		{latest: "v1.10.0", current: "v1.9.0", want: true},
+		{latest: "v1.2.0", current: "v1.2.0", want: false},
+		{latest: "v1.2.0", current: "v1.2.0-3-gabcdef-dirty", want: false},
+		{latest: "v1.2.0", current: "v2.0.0", want: false},
+		{latest: "v1.2.0", current: "dev", want: true},
+		{latest: "nightly", current: "v1.0.0", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := newerVersion(tt.latest, tt.current); got != tt.want {
+			t.Errorf("newerVersion(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
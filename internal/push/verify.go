@@ -0,0 +1,191 @@
+package push
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// statusTest is the status of the webhook sent by -verify-webhook.
+const statusTest = "test"
+
+var (
+	errVerifyFailed     = errors.New("verification failed")
+	errAccountClosed    = errors.New("account is closed")
+	errAccountDeleted   = errors.New("account is deleted")
+	errInvalidURL       = errors.New("invalid URL")
+	errEarlierCheckFail = errors.New("skipped, an earlier check failed")
+	errEmptyCommand     = errors.New("empty command")
+)
+
+// verifyCheck is one line of -verify.
+type verifyCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// verify runs the checks of the configuration in order, printing a line for each.
+// Nothing is pushed and the state is left alone. Any failure ends with errVerifyFailed.
+func verify(ctx context.Context, opts *options, env env) error {
+	redactor := logging.NewRedactor(secretFlags(opts)...)
+
+	var (
+		hook         *webhook
+		ynabClient   = lclynab.WrapClient(env.httpClient, env.middlewares...)
+		notifyClient = ynabClient
+	)
+
+	checks := []verifyCheck{
+		{name: "webhook headers and template", run: func(context.Context) error {
+			var err error
+
+			hook, err = newWebhook(opts, env.fsys, os.LookupEnv)
+			if err == nil {
+				redactor = logging.NewRedactor(append(secretFlags(opts), headerValues(hook.headers)...)...)
+			}
+
+			return err
+		}},
+		{name: "TLS configuration", run: func(context.Context) error {
+			client, err := withTLS(env.httpClient, env.fsys, opts.caCert, opts.insecureSkipVerify)
+			if err != nil {
+				return err
+			}
+
+			notify, err := withTLS(env.httpClient, env.fsys, cmp.Or(opts.webhookCACert, opts.caCert), opts.insecureSkipVerify)
+			if err != nil {
+				return err
+			}
+
+			ynabClient = lclynab.WrapClient(client, env.middlewares...)
+			notifyClient = lclynab.WrapClient(notify, env.middlewares...)
+
+			return nil
+		}},
+		{name: "YNAB token", run: func(ctx context.Context) error {
+			return checkToken(ctx, ynabClient, opts.token)
+		}},
+		{name: "budget and account", run: func(ctx context.Context) error {
+			return checkAccount(ctx, ynabClient, opts.token, opts.budgetID, opts.accountID)
+		}},
+	}
+
+	if opts.categorizeCmd != "" {
+		checks = append(checks, verifyCheck{name: "categorizer command", run: func(context.Context) error {
+			return checkCommand(opts.categorizeCmd)
+		}})
+	}
+
+	for _, webhookURL := range []struct{ name, url string }{
+		{name: "webhook URL", url: opts.webhook},
+		{name: "failure webhook URL", url: opts.webhookFailure},
+	} {
+		if webhookURL.url != "" {
+			checks = append(checks, verifyCheck{name: webhookURL.name, run: func(context.Context) error {
+				return checkURL(webhookURL.url)
+			}})
+		}
+	}
+
+	if opts.verifyWebhook {
+		checks = append(checks, verifyCheck{name: "test webhook", run: func(ctx context.Context) error {
+			if hook == nil {
+				return errEarlierCheckFail
+			}
+
+			data := webhookData{
+				Status:    statusTest,
+				RunID:     cmp.Or(opts.runID, logging.NewRunID()),
+				Account:   opts.accountID,
+				StartedAt: env.now(),
+				SentAt:    env.now(),
+			}
+
+			return hook.send(ctx, notifyClient, logging.Discard(), cmp.Or(opts.webhook, opts.webhookFailure), data)
+		}})
+	}
+
+	failed := 0
+
+	for _, check := range checks {
+		if err := check.run(ctx); err != nil {
+			failed++
+
+			_, _ = fmt.Fprintf(env.stdout, "✗ %v: %v\n", check.name, redactor.Redact(err.Error()))
+
+			continue
+		}
+
+		_, _ = fmt.Fprintf(env.stdout, "✓ %v\n", check.name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d checks", errVerifyFailed, failed, len(checks))
+	}
+
+	return nil
+}
+
+// checkToken asks YNAB who the token belongs to.
+func checkToken(ctx context.Context, client *http.Client, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	_, err := lclynab.NewClient(token, client).GetUser(ctx)
+
+	return err //nolint:wrapcheck // already explicit
+}
+
+// checkAccount makes sure the account exists in the budget and is open.
+func checkAccount(ctx context.Context, client *http.Client, token, budgetID, accountID string) error {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+
+	account, err := lclynab.NewClient(token, client).GetAccount(ctx, budgetID, accountID)
+
+	switch {
+	case err != nil:
+		return err //nolint:wrapcheck // already explicit
+	case account.Deleted:
+		return fmt.Errorf("%w: %v", errAccountDeleted, account.Name)
+	case account.Closed:
+		return fmt.Errorf("%w: %v", errAccountClosed, account.Name)
+	}
+
+	return nil
+}
+
+// checkCommand makes sure the program of a command line can be found.
+func checkCommand(command string) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return errEmptyCommand
+	}
+
+	_, err := exec.LookPath(fields[0])
+
+	return err //nolint:wrapcheck // already explicit
+}
+
+// checkURL makes sure a webhook URL is absolute HTTP(S).
+func checkURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("%w: want an absolute http or https URL", errInvalidURL)
+	}
+
+	return nil
+}
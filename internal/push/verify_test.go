@@ -0,0 +1,222 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_checkToken(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "valid", status: http.StatusOK, wantErr: false},
+		{name: "revoked", status: http.StatusUnauthorized, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodGet, "/v1/user",
+				httpmock.NewStringResponder(tt.status, `{"data": {"user": {"id": "user-id"}}}`))
+
+			err := checkToken(context.Background(), &http.Client{Transport: transport}, "tok")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_checkAccount(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		account string
+		wantErr error
+	}{
+		{name: "open", status: http.StatusOK, account: `{"name": "Checking"}`, wantErr: nil},
+		{
+			name: "closed", status: http.StatusOK, account: `{"name": "Checking", "closed": true}`,
+			wantErr: errAccountClosed,
+		},
+		{
+			name: "deleted", status: http.StatusOK, account: `{"name": "Checking", "deleted": true}`,
+			wantErr: errAccountDeleted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/accounts/"+testAccountID,
+				httpmock.NewStringResponder(tt.status, `{"data": {"account": `+tt.account+`}}`))
+
+			err := checkAccount(context.Background(), &http.Client{Transport: transport}, "tok", testBudgetID, testAccountID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkAccount() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_checkAccount_unknown(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/accounts/"+testAccountID,
+		httpmock.NewStringResponder(http.StatusNotFound, `{"error": {"id": "404.2", "name": "resource_not_found"}}`))
+
+	client := &http.Client{Transport: transport}
+	if err := checkAccount(context.Background(), client, "tok", testBudgetID, testAccountID); err == nil {
+		t.Error("checkAccount() error = nil, want the account not found")
+	}
+}
+
+func Test_checkCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		command string
+		wantErr bool
+	}{
+		{name: "found", command: "./testdata/categorize.sh batch", wantErr: false},
+		{name: "missing", command: "./testdata/missing.sh", wantErr: true},
+		{name: "empty", command: " ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := checkCommand(tt.command); (err != nil) != tt.wantErr {
+				t.Errorf("checkCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_checkURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{name: "https", raw: "https://ha.example.com/api/webhook/id", wantErr: false},
+		{name: "relative", raw: "/api/webhook/id", wantErr: true},
+		{name: "other scheme", raw: "ftp://ha.example.com/", wantErr: true},
+		{name: "unparsable", raw: "https://ha example.com/%zz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := checkURL(tt.raw); (err != nil) != tt.wantErr {
+				t.Errorf("checkURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_run_verify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		account string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "ok",
+			args:    []string{"-w", "https://ha.example.com/api/webhook/secret-id", "-verify-webhook"},
+			account: `{"name": "Checking"}`,
+			want: "✓ webhook headers and template\n✓ TLS configuration\n✓ YNAB token\n✓ budget and account\n" +
+				"✓ webhook URL\n✓ test webhook\n",
+			wantErr: nil,
+		},
+		{
+			name: "failing",
+			args: []string{
+				"-webhook-failure", "ha.example.com/secret-id", "-webhook-header", "X-Token: env:MISSING_VAR",
+			},
+			account: `{"name": "Checking", "closed": true}`,
+			want: "✗ webhook headers and template: invalid header X-Token: " +
+				"environment variable not set: MISSING_VAR or MISSING_VAR_FILE\n" +
+				"✓ TLS configuration\n✓ YNAB token\n✗ budget and account: account is closed: Checking\n" +
+				"✗ failure webhook URL: invalid URL: want an absolute http or https URL\n",
+			wantErr: errVerifyFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sent map[string]any
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodGet, "/v1/user",
+				httpmock.NewStringResponder(http.StatusOK, `{"data": {"user": {"id": "user-id"}}}`))
+			transport.RegisterResponder(http.MethodGet, "/v1/budgets/"+testBudgetID+"/accounts/"+testAccountID,
+				httpmock.NewStringResponder(http.StatusOK, `{"data": {"account": `+tt.account+`}}`))
+			transport.RegisterResponder(http.MethodPost, "https://ha.example.com/api/webhook/secret-id",
+				func(req *http.Request) (*http.Response, error) {
+					if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+						t.Error(err)
+					}
+
+					return httpmock.NewStringResponse(http.StatusOK, ""), nil
+				})
+
+			stdout := &bytes.Buffer{}
+
+			args := append([]string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-verify"}, tt.args...)
+
+			err := run(context.Background(), args,
+				env{
+					stdout:     stdout,
+					stderr:     io.Discard,
+					httpClient: &http.Client{Transport: transport},
+					now:        fixedNow,
+					getenv:     testGetenv(t),
+				})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if stdout.String() != tt.want {
+				t.Errorf("stdout = \n%s\nwant\n%s", stdout, tt.want)
+			}
+
+			if tt.wantErr == nil && sent["status"] != statusTest {
+				t.Errorf("sent webhook = %v, want a test status", sent)
+			}
+
+			if calls := transport.GetCallCountInfo()["POST /v1/budgets/"+testBudgetID+"/transactions"]; calls != 0 {
+				t.Errorf("pushed %d time(s), want nothing pushed", calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+)
+
+const (
+	defaultWatchGlob     = "*.csv"
+	defaultWatchInterval = 2 * time.Second
+	defaultWatchSettle   = 5 * time.Second
+)
+
+var (
+	errConflictingWatch  = errors.New("flag conflicts with -watch")
+	errInvalidWatchGlob  = errors.New("invalid -watch-glob")
+	errInvalidWatchDelay = errors.New("invalid watch delay")
+)
+
+// watchedFile is a file of a -watch directory, as it was when pushed.
+type watchedFile struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	RunID   string    `json:"run_id,omitempty"`
+}
+
+// same reports whether f and other have the same size and modification time.
+func (f watchedFile) same(other watchedFile) bool {
+	return f.Size == other.Size && f.ModTime.Equal(other.ModTime)
+}
+
+// watchedPath is a file of the watched directory ready to be pushed.
+type watchedPath struct {
+	path string
+	file watchedFile
+}
+
+// pendingFile is a changed file waiting for its size to settle.
+type pendingFile struct {
+	file  watchedFile
+	since time.Time
+}
+
+// watcher lists a directory on every poll, rather than relying on file system events,
+// which network mounts and synced folders don't always deliver.
+type watcher struct {
+	fsys   fs.FS
+	dir    string
+	glob   string
+	settle time.Duration
+	now    func() time.Time
+	// pending are the changed files, by path, with when they were last seen changing.
+	pending map[string]pendingFile
+	// skipped are the files handled but not recorded as pushed, by a failed run or
+	// -dry-run, by path. They are pushed again once they change or push restarts.
+	skipped map[string]watchedFile
+}
+
+func newWatcher(opts *options, env env) *watcher {
+	return &watcher{
+		fsys:    env.fsys,
+		dir:     opts.watch,
+		glob:    opts.watchGlob,
+		settle:  opts.watchSettle,
+		now:     env.now,
+		pending: make(map[string]pendingFile),
+		skipped: make(map[string]watchedFile),
+	}
+}
+
+// poll lists the files matching the glob that differ from their pushed version and
+// kept the same size and modification time for the settle delay, by name.
+func (w *watcher) poll(pushed map[string]watchedFile) ([]watchedPath, error) {
+	entries, err := fs.ReadDir(w.fsys, w.dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing %v: %w", w.dir, err)
+	}
+
+	var (
+		now   = w.now()
+		ready []watchedPath
+		seen  = make(map[string]bool, len(entries))
+	)
+
+	for _, entry := range entries {
+		if matched, _ := path.Match(w.glob, entry.Name()); entry.IsDir() || !matched {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// Removed since listed.
+			continue
+		}
+
+		name := filepath.Join(w.dir, entry.Name())
+		file := watchedFile{Size: info.Size(), ModTime: info.ModTime()}
+		seen[name] = true
+
+		if pushed[name].same(file) || w.skipped[name].same(file) {
+			delete(w.pending, name)
+
+			continue
+		}
+
+		pending, ok := w.pending[name]
+		if !ok || !pending.file.same(file) {
+			w.pending[name] = pendingFile{file: file, since: now}
+
+			continue
+		}
+
+		if now.Sub(pending.since) >= w.settle {
+			ready = append(ready, watchedPath{path: name, file: file})
+			delete(w.pending, name)
+		}
+	}
+
+	for name := range w.pending {
+		if !seen[name] {
+			delete(w.pending, name)
+		}
+	}
+
+	return ready, nil
+}
+
+// watch pushes the files of the -watch directory as they appear or change, each with
+// its own run ID, report and notifications, until ctx is cancelled. A failing file or
+// listing is logged and doesn't stop watching.
+func watch(ctx context.Context, opts *options, env env) error {
+	logger, err := logging.New(env.stderr, logLevel(opts), opts.logFormat, secretFlags(opts)...)
+	if err != nil {
+		return fmt.Errorf("configuring logs: %w", err)
+	}
+
+	w := newWatcher(opts, env)
+
+	logger.Info("watching", "dir", opts.watch, "glob", opts.watchGlob)
+
+	ticker := time.NewTicker(opts.watchInterval)
+	defer ticker.Stop()
+
+	for {
+		w.pushReady(ctx, opts, env, logger)
+
+		if !opts.dryRun {
+			if err := cleanup(io.Discard, logger, opts, env.fsys, env.now(), false); err != nil {
+				logger.Warn("expired artifacts left", "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("stopped watching")
+
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// pushReady runs push on each file the poll finds ready, recording the pushed ones in the state file.
+func (w *watcher) pushReady(ctx context.Context, opts *options, env env, logger *slog.Logger) {
+	previous := &pushState{}
+	if err := state.LoadFS(env.fsys, opts.statePath, previous); err != nil {
+		logger.Error("reading the state failed", "error", err)
+
+		return
+	}
+
+	ready, err := w.poll(previous.Watched)
+	if err != nil {
+		logger.Error("listing the watched directory failed", "error", err)
+
+		return
+	}
+
+	for _, file := range ready {
+		if ctx.Err() != nil {
+			return
+		}
+
+		fileOpts := *opts
+		fileOpts.filename = file.path
+		fileOpts.runID = logging.NewRunID()
+		fileOpts.report = watchReportPath(opts.report, fileOpts.runID)
+
+		logger.Info("pushing watched file", "path", file.path, logging.RunIDKey, fileOpts.runID)
+
+		if err := runFile(ctx, &fileOpts, env); err != nil {
+			logger.Error("pushing watched file failed", "path", file.path, logging.RunIDKey, fileOpts.runID, "error", err)
+			w.skipped[file.path] = file.file
+
+			continue
+		}
+
+		if opts.dryRun || opts.diff {
+			w.skipped[file.path] = file.file
+
+			continue
+		}
+
+		file.file.RunID = fileOpts.runID
+		if err := recordWatched(env.fsys, opts.statePath, file); err != nil {
+			logger.Warn("recording the pushed file failed, it will be pushed again", "path", file.path, "error", err)
+			w.skipped[file.path] = file.file
+		}
+	}
+}
+
+// recordWatched remembers that file was pushed.
+func recordWatched(fsys fs.FS, statePath string, file watchedPath) error {
+	previous := &pushState{}
+	if err := state.LoadFS(fsys, statePath, previous); err != nil {
+		return err //nolint:wrapcheck // already explicit
+	}
+
+	if previous.Watched == nil {
+		previous.Watched = make(map[string]watchedFile)
+	}
+
+	previous.Watched[file.path] = file.file
+
+	return state.Save(statePath, previous) //nolint:wrapcheck // already explicit
+}
+
+// watchReportPath returns the -report path of a watched file's run: report.json
+// becomes report-<run ID>.json, so that runs don't overwrite each other's report.
+func watchReportPath(report, runID string) string {
+	if report == "" || report == "-" {
+		return report
+	}
+
+	ext := filepath.Ext(report)
+
+	return strings.TrimSuffix(report, ext) + "-" + runID + ext
+}
+
+// checkWatchFlags validates the flags of -watch, which pushes the files of a directory.
+func checkWatchFlags(opts *options, positional []string) error {
+	switch {
+	case opts.filename != "" || len(positional) > 0:
+		return fmt.Errorf("%w: -f or a positional file", errConflictingWatch)
+	case opts.undoRun != "" || opts.undoLast:
+		return fmt.Errorf("%w: -undo-run and -undo-last", errConflictingWatch)
+	case opts.verify || opts.stats:
+		return fmt.Errorf("%w: -verify and -stats", errConflictingWatch)
+	case opts.jsonOutput:
+		return fmt.Errorf("%w: -json, it prints a single run", errConflictingWatch)
+	case opts.runID != "":
+		return fmt.Errorf("%w: -run-id, each file gets its own", errConflictingWatch)
+	}
+
+	if _, err := path.Match(opts.watchGlob, ""); err != nil {
+		return fmt.Errorf("%w %q: %w", errInvalidWatchGlob, opts.watchGlob, err)
+	}
+
+	if opts.watchInterval <= 0 || opts.watchSettle < 0 {
+		return fmt.Errorf("%w: -watch-interval %v and -watch-settle %v, want more than 0 and 0 or more",
+			errInvalidWatchDelay, opts.watchInterval, opts.watchSettle)
+	}
+
+	return nil
+}
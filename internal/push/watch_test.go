@@ -0,0 +1,227 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_watcher_poll(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	statement := filepath.Join(dir, "statement.csv")
+	write := func(name, content string) {
+		t.Helper()
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := fixedNow()
+	w := newWatcher(&options{watch: dir, watchGlob: "*.csv", watchSettle: 5 * time.Second}, env{
+		fsys:   osFS{},
+		now:    func() time.Time { return now },
+		getenv: testGetenv(t),
+	})
+
+	poll := func(pushed map[string]watchedFile) []string {
+		t.Helper()
+
+		ready, err := w.poll(pushed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var paths []string
+		for _, file := range ready {
+			paths = append(paths, file.path)
+		}
+
+		return paths
+	}
+
+	write("statement.csv", "29/10/2024;80;")
+	write("notes.txt", "not a statement")
+
+	if got := poll(nil); got != nil {
+		t.Errorf("poll() of a new file = %v, want it pending", got)
+	}
+
+	// Still being written.
+	now = now.Add(5 * time.Second)
+	write("statement.csv", "29/10/2024;80;\n29/10/2024;-12,50;")
+
+	if got := poll(nil); got != nil {
+		t.Errorf("poll() of a growing file = %v, want it pending", got)
+	}
+
+	now = now.Add(4 * time.Second)
+	if got := poll(nil); got != nil {
+		t.Errorf("poll() before the settle delay = %v, want it pending", got)
+	}
+
+	now = now.Add(time.Second)
+
+	got := poll(nil)
+	if len(got) != 1 || got[0] != statement {
+		t.Fatalf("poll() once settled = %v, want %v", got, statement)
+	}
+
+	info, err := os.Stat(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushed := map[string]watchedFile{statement: {Size: info.Size(), ModTime: info.ModTime()}}
+
+	now = now.Add(time.Minute)
+	if got := poll(pushed); got != nil {
+		t.Errorf("poll() of a pushed file = %v, want none", got)
+	}
+}
+
+func Test_run_watch(t *testing.T) {
+	t.Parallel()
+
+	dir, out := t.TempDir(), t.TempDir()
+	statePath := filepath.Join(out, "state.json")
+
+	statement, err := os.ReadFile("./testdata/one-positive.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The broken file comes first and must not stop the watcher.
+	for name, content := range map[string][]byte{"a-broken.csv": []byte("not a statement"), "b-ok.csv": statement} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pushes := 0
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			pushes++
+
+			return createdResponder(http.StatusOK)(req)
+		},
+	)
+
+	watchDir := func(ctx context.Context) error {
+		return run(ctx, []string{
+			"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-watch", dir, "-watch-interval", "1ms", "-watch-settle", "0",
+			"-state", statePath, "-report", filepath.Join(out, "report.json"),
+		}, env{
+			stdout:     io.Discard,
+			stderr:     io.Discard,
+			httpClient: &http.Client{Transport: transport},
+			now:        fixedNow,
+			getenv:     testGetenv(t),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Stops watching once the file is recorded as pushed.
+	go func() {
+		defer cancel()
+
+		for ctx.Err() == nil {
+			pushed := &pushState{}
+			if state.Load(statePath, pushed) == nil && len(pushed.Watched) > 0 {
+				return
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	if err := watchDir(ctx); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	pushed := &pushState{}
+	if err := state.Load(statePath, pushed); err != nil {
+		t.Fatal(err)
+	}
+
+	file, ok := pushed.Watched[filepath.Join(dir, "b-ok.csv")]
+	if !ok || len(pushed.Watched) != 1 || pushes != 1 {
+		t.Fatalf("watched = %+v after %d pushes, want only b-ok.csv pushed once", pushed.Watched, pushes)
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "report-"+file.RunID+".json")); err != nil {
+		t.Errorf("report of run %v: %v", file.RunID, err)
+	}
+
+	// Watching again doesn't push the file twice.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := watchDir(ctx); err != nil {
+		t.Fatalf("run() again error = %v", err)
+	}
+
+	if pushes != 1 {
+		t.Errorf("pushes after watching again = %d, want 1", pushes)
+	}
+}
+
+func Test_parseFlags_watch(t *testing.T) {
+	t.Parallel()
+
+	required := []string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-watch", "inbox"}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{name: "no file needed", args: nil},
+		{name: "file", args: []string{"statement.csv"}, wantErr: errConflictingWatch},
+		{name: "run ID", args: []string{"-run-id", "nightly"}, wantErr: errConflictingWatch},
+		{name: "glob", args: []string{"-watch-glob", "[.csv"}, wantErr: errInvalidWatchGlob},
+		{name: "interval", args: []string{"-watch-interval", "0"}, wantErr: errInvalidWatchDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			args := append(append([]string{}, required...), tt.args...)
+			if _, err := parseFlags(args, env{stdinPiped: true}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseFlags() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_watchReportPath(t *testing.T) {
+	t.Parallel()
+
+	for report, want := range map[string]string{
+		"":                  "",
+		"-":                 "-",
+		"report.json":       "report-0f49abc8.json",
+		"reports/last":      "reports/last-0f49abc8",
+		"out.d/report.json": "out.d/report-0f49abc8.json",
+	} {
+		if got := watchReportPath(report, "0f49abc8"); got != want {
+			t.Errorf("watchReportPath(%q) = %q, want %q", report, got, want)
+		}
+	}
+}
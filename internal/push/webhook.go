@@ -0,0 +1,381 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+const (
+	headerEnvPrefix  = "env:"
+	headerFileSuffix = "_FILE"
+
+	// defaultWebhookTemplate keeps the historical "reconciled" field first,
+	// so that existing consumers keep working. The balance fields are left out
+	// when the file couldn't be converted.
+	defaultWebhookTemplate = `{{"{"}}{{with .Reconciled}}"reconciled":{{json .}},` +
+		`"reconciled_milliunits":{{$.ReconciledMilliunits}},"currency":{{json $.Currency}},` +
+		`"reconciled_date":{{json $.ReconciledDate}},{{end}}` +
+		`"status":{{json .Status}},` +
+		`"pushed":{{.Pushed}},"duplicates":{{.Duplicates}},"skipped":{{.Skipped}},` +
+		`"account":{{json .Account}},"budget_name":{{json .BudgetName}},"account_name":{{json .AccountName}},` +
+		`"timestamp":{{json .SentAt}},"run_id":{{json .RunID}}` +
+		`{{with .Drift}},"drift_milliunits":{{.}},"drift_exceeded":{{$.DriftExceeded}}{{end}}` +
+		`{{with .Error}},"error":{{json .}},"phase":{{json $.Phase}}{{end}}}`
+	defaultWebhookContentType = "application/json"
+
+	defaultWebhookAttempts = 3
+	defaultWebhookBackoff  = time.Second
+)
+
+var (
+	errInvalidHeader = errors.New("invalid header")
+	errUnsetVariable = errors.New("environment variable not set")
+)
+
+// webhook is the notification sent once transactions are pushed.
+type webhook struct {
+	successURL  string
+	failureURL  string
+	headers     http.Header
+	template    *template.Template
+	contentType string
+	attempts    int
+	backoff     time.Duration
+	// timeout bounds each attempt, zero meaning no timeout.
+	timeout time.Duration
+}
+
+// webhookData is what webhook templates are rendered with.
+// Reconciled is empty when the file couldn't be converted.
+type webhookData struct {
+	Status                string
+	Error                 string
+	Phase                 string
+	Reconciled            string
+	ReconciledMilliunits  int
+	Currency              string
+	RunID                 string
+	ReconciledDate        string
+	Pushed                int
+	Duplicates            int
+	Skipped               int
+	Drift                 *int
+	DriftExceeded         bool
+	Account               string
+	BudgetName            string
+	AccountName           string
+	InputFile             string
+	StartedAt             time.Time
+	SentAt                time.Time
+	DuplicateTransactions []Transaction
+}
+
+// newWebhookData exposes the run result, so far, to webhook templates.
+func newWebhookData(res *Result, err error, state *runState, account string, sentAt time.Time) webhookData {
+	data := webhookData{
+		Status:                statusOf(err),
+		ReconciledMilliunits:  res.Reconciled,
+		Currency:              res.Currency,
+		RunID:                 res.RunID,
+		ReconciledDate:        res.ReconciledDate,
+		Pushed:                res.Counts.Pushed,
+		Duplicates:            res.Counts.Duplicates,
+		Skipped:               res.Counts.Filtered,
+		Drift:                 res.Drift,
+		DriftExceeded:         res.DriftExceeded,
+		Account:               account,
+		BudgetName:            res.BudgetName,
+		AccountName:           res.AccountName,
+		InputFile:             res.InputFile,
+		StartedAt:             res.StartedAt,
+		SentAt:                sentAt,
+		DuplicateTransactions: state.duplicates,
+	}
+
+	if state.converted {
+		data.Reconciled = reconciledString(res.Reconciled)
+	}
+
+	if data.Status == statusError {
+		data.Error = state.redactor.Redact(err.Error())
+		data.Phase = state.phase
+	}
+
+	return data
+}
+
+// newWebhook validates the webhook flags, so that mistakes are reported
+// before anything is pushed.
+func newWebhook(opts *options, fsys fs.FS, lookupEnv func(string) (string, bool)) (*webhook, error) {
+	headers, err := resolveHeaders(opts.webhookHeaders, fsys, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := parseWebhookTemplate(fsys, opts.webhookTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook{
+		successURL:  opts.webhook,
+		failureURL:  opts.webhookFailure,
+		headers:     headers,
+		template:    tmpl,
+		contentType: opts.webhookContentType,
+		attempts:    opts.webhookAttempts,
+		backoff:     opts.webhookBackoff,
+		timeout:     opts.webhookTimeout,
+	}, nil
+}
+
+// newDelivery returns a webhook carrying only the retry policy of the flags,
+// for channels that render their own JSON body.
+func newDelivery(opts *options) *webhook {
+	return &webhook{
+		contentType: defaultWebhookContentType,
+		attempts:    opts.webhookAttempts,
+		backoff:     opts.webhookBackoff,
+		timeout:     opts.webhookTimeout,
+	}
+}
+
+// urlFor returns the URL to call for a run ending with status, empty when there is none.
+// Failures go to the success URL when no failure URL is configured.
+func (w *webhook) urlFor(status string) string {
+	switch status {
+	case statusError, statusCancelled:
+		if w.failureURL != "" {
+			return w.failureURL
+		}
+	}
+
+	return w.successURL
+}
+
+// send delivers the webhook rendered with data, webhookData or accountsData, retrying with an exponential backoff
+// on connection errors, timeouts and server errors.
+func (w *webhook) send(
+	ctx context.Context,
+	client *http.Client,
+	logger *slog.Logger,
+	url string,
+	data any,
+) error {
+	body, err := w.render(data)
+	if err != nil {
+		return err
+	}
+
+	return w.deliver(ctx, client, logger, url, body)
+}
+
+// deliver posts body to url with the retry policy of the webhook.
+func (w *webhook) deliver(
+	ctx context.Context,
+	client *http.Client,
+	logger *slog.Logger,
+	url string,
+	body []byte,
+) error {
+	var err error
+
+	delay := w.backoff
+
+	for attempt := 1; ; attempt++ {
+		err = w.post(ctx, client, url, body)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= w.attempts || !retryable(ctx, err) {
+			return fmt.Errorf("after %d attempt(s): %w", attempt, err)
+		}
+
+		logger.Warn("webhook failed, retrying", "attempt", attempt, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return fmt.Errorf("after %d attempt(s): %w", attempt, errors.Join(err, ctx.Err()))
+		case <-timer.C:
+		}
+
+		delay *= 2
+	}
+}
+
+func (w *webhook) post(ctx context.Context, client *http.Client, url string, body []byte) error {
+	if w.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, w.timeout)
+		defer cancel()
+	}
+
+	err := requests.URL(url).
+		Client(client).
+		Method(http.MethodPost).
+		Config(func(rb *requests.Builder) {
+			for name, values := range w.headers {
+				rb.Header(name, values...)
+			}
+		}).
+		ContentType(w.contentType).
+		BodyBytes(body).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("sending webhook: %w", err)
+	}
+
+	return nil
+}
+
+// retryable reports whether a failed delivery is worth another attempt:
+// client errors won't go away by themselves, and neither will a cancelled run.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if respErr := new(requests.ResponseError); errors.As(err, &respErr) {
+		return respErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return true
+}
+
+func (w *webhook) render(data any) ([]byte, error) {
+	var body bytes.Buffer
+	if err := w.template.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("rendering webhook template: %w", err)
+	}
+
+	return body.Bytes(), nil
+}
+
+// parseWebhookTemplate parses the template at path in fsys, or the default one when path is empty.
+func parseWebhookTemplate(fsys fs.FS, path string) (*template.Template, error) {
+	return parseTemplate(fsys, path, defaultWebhookTemplate, webhookData{})
+}
+
+// parseTemplate parses the template at path in fsys, or defaultText when path is empty,
+// checking that it renders sample.
+func parseTemplate(fsys fs.FS, path, defaultText string, sample any) (*template.Template, error) {
+	name, text := "default", defaultText
+
+	if path != "" {
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("reading webhook template: %w", err)
+		}
+
+		name, text = filepath.Base(path), string(content)
+	}
+
+	tmpl, err := template.New(name).Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing webhook template: %w", err)
+	}
+
+	// Rendering once catches references to unknown fields.
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return nil, fmt.Errorf("checking webhook template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// toJSON is available in webhook templates to quote values.
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encoding %v: %w", v, err)
+	}
+
+	return string(data), nil
+}
+
+// headerFlags collects repeated -webhook-header flags.
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+// resolveHeaders parses "Name: value" headers. A value of the form env:NAME is read from
+// the NAME environment variable or, when it's unset, from the file named by NAME_FILE,
+// so that tokens stay out of the command line.
+func resolveHeaders(raw []string, fsys fs.FS, lookupEnv func(string) (string, bool)) (http.Header, error) {
+	headers := http.Header{}
+
+	for _, line := range raw {
+		name, value, found := strings.Cut(line, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+		if !found || name == "" {
+			return nil, fmt.Errorf("%w: %q, want \"Name: value\"", errInvalidHeader, line)
+		}
+
+		if variable, ok := strings.CutPrefix(value, headerEnvPrefix); ok {
+			resolved, err := lookupHeaderValue(variable, fsys, lookupEnv)
+			if err != nil {
+				return nil, fmt.Errorf("%w %v: %w", errInvalidHeader, name, err)
+			}
+
+			value = resolved
+		}
+
+		headers.Add(name, value)
+	}
+
+	return headers, nil
+}
+
+func lookupHeaderValue(variable string, fsys fs.FS, lookupEnv func(string) (string, bool)) (string, error) {
+	if value, ok := lookupEnv(variable); ok {
+		return value, nil
+	}
+
+	path, ok := lookupEnv(variable + headerFileSuffix)
+	if !ok {
+		return "", fmt.Errorf("%w: %v or %v%v", errUnsetVariable, variable, variable, headerFileSuffix)
+	}
+
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return "", fmt.Errorf("reading %v%v: %w", variable, headerFileSuffix, err)
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// headerValues returns every header value, to be redacted from logs.
+func headerValues(headers http.Header) []string {
+	var values []string
+	for _, v := range headers {
+		values = append(values, v...)
+	}
+
+	return values
+}
@@ -0,0 +1,559 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/logging"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+	"github.com/jarcoal/httpmock"
+)
+
+func Test_resolveHeaders(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"secrets/token": {Data: []byte("Bearer from-file\n")}}
+
+	environ := map[string]string{
+		"HA_TOKEN":       "Bearer from-env",
+		"HA_TOKEN_FILE":  "/does/not/matter",
+		"FILE_ONLY_FILE": "secrets/token",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := environ[key]
+
+		return value, ok
+	}
+
+	tests := []struct {
+		name    string
+		raw     []string
+		want    http.Header
+		wantErr error
+	}{
+		{
+			name:    "literal",
+			raw:     []string{"X-Api-Key: abc", "x-other:  spaced "},
+			want:    http.Header{"X-Api-Key": {"abc"}, "X-Other": {"spaced"}},
+			wantErr: nil,
+		},
+		{
+			name:    "repeated",
+			raw:     []string{"X-Tag: one", "X-Tag: two"},
+			want:    http.Header{"X-Tag": {"one", "two"}},
+			wantErr: nil,
+		},
+		{
+			name:    "from env",
+			raw:     []string{"Authorization: env:HA_TOKEN"},
+			want:    http.Header{"Authorization": {"Bearer from-env"}},
+			wantErr: nil,
+		},
+		{
+			name:    "from file",
+			raw:     []string{"Authorization: env:FILE_ONLY"},
+			want:    http.Header{"Authorization": {"Bearer from-file"}},
+			wantErr: nil,
+		},
+		{
+			name:    "unset variable",
+			raw:     []string{"Authorization: env:MISSING"},
+			want:    nil,
+			wantErr: errUnsetVariable,
+		},
+		{
+			name:    "missing colon",
+			raw:     []string{"Authorization Bearer x"},
+			want:    nil,
+			wantErr: errInvalidHeader,
+		},
+		{
+			name:    "missing name",
+			raw:     []string{": value"},
+			want:    nil,
+			wantErr: errInvalidHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := resolveHeaders(tt.raw, fsys, lookupEnv)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			for name := range tt.want {
+				if strings.Join(got.Values(name), ",") != strings.Join(tt.want.Values(name), ",") {
+					t.Errorf("resolveHeaders() %v = %v, want %v", name, got.Values(name), tt.want.Values(name))
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("resolveHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webhook_render(t *testing.T) {
+	t.Parallel()
+
+	drift := -1500
+	data := webhookData{
+		Status:               statusOK,
+		Reconciled:           "100.06",
+		ReconciledMilliunits: 100060,
+		Currency:             "EUR",
+		ReconciledDate:       "2024-11-29",
+		Pushed:               3,
+		Duplicates:           1,
+		Skipped:              2,
+		Drift:                &drift,
+		Account:              "acc-id",
+		BudgetName:           "Personal",
+		AccountName:          "Checking",
+		InputFile:            "statement.csv",
+		RunID:                "test-run",
+		StartedAt:            fixedNow(),
+		SentAt:               fixedNow().Add(2 * time.Second),
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		golden   string
+	}{
+		{name: "default", template: "", golden: "./testdata/webhook-default.golden"},
+		{name: "home assistant", template: "./testdata/webhook-ha.tmpl", golden: "./testdata/webhook-ha.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl, err := parseWebhookTemplate(osFS{}, tt.template)
+			if err != nil {
+				t.Fatalf("parseWebhookTemplate() error = %v", err)
+			}
+
+			got, err := (&webhook{template: tmpl}).render(data)
+			if err != nil {
+				t.Fatalf("render() error = %v", err)
+			}
+
+			want, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != string(want) {
+				t.Errorf("render() = \n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func Test_parseWebhookTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "valid", template: `{{.Status}}`, wantErr: false},
+		{name: "syntax error", template: `{{.Status`, wantErr: true},
+		{name: "unknown field", template: `{{.Balance}}`, wantErr: true},
+		{name: "unknown function", template: `{{yaml .Status}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fsys := fstest.MapFS{"webhook.tmpl": {Data: []byte(tt.template)}}
+
+			if _, err := parseWebhookTemplate(fsys, "webhook.tmpl"); (err != nil) != tt.wantErr {
+				t.Errorf("parseWebhookTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := parseWebhookTemplate(fstest.MapFS{}, "missing.tmpl"); err == nil {
+		t.Errorf("parseWebhookTemplate() of a missing file succeeded")
+	}
+}
+
+func Test_run_webhookHeaders(t *testing.T) {
+	t.Parallel()
+
+	const webhook = "https://ha.example/api/webhook/ynab"
+
+	var (
+		received http.Header
+		body     []byte
+	)
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		createdResponder(http.StatusOK),
+	)
+	transport.RegisterResponder(http.MethodPost, webhook, func(req *http.Request) (*http.Response, error) {
+		received = req.Header.Clone()
+		body, _ = io.ReadAll(req.Body)
+
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	stderr := &bytes.Buffer{}
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-w", webhook, "-log-level", "debug",
+		"-webhook-header", "Authorization: Bearer s3cr3t",
+		"-webhook-header", "X-Source: lcl-ynab",
+		"-run-id", "test-run",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     stderr,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	if got := received.Get("Authorization"); got != "Bearer s3cr3t" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer s3cr3t")
+	}
+
+	if got := received.Get("X-Source"); got != "lcl-ynab" {
+		t.Errorf("X-Source = %q, want %q", got, "lcl-ynab")
+	}
+
+	if got := received.Get("Content-Type"); got != defaultWebhookContentType {
+		t.Errorf("Content-Type = %q, want %q", got, defaultWebhookContentType)
+	}
+
+	if got, want := string(body), `{"reconciled":"100.06","reconciled_milliunits":100060,"currency":"EUR",`+
+		`"reconciled_date":"2024-11-29","status":"ok","pushed":1,"duplicates":0,"skipped":0,`+
+		`"account":"`+testAccountID+`","budget_name":"`+testBudgetID+`","account_name":"`+testAccountID+`",`+
+		`"timestamp":"2024-11-30T03:00:00Z",`+
+		`"run_id":"test-run"}`; got != want {
+		t.Errorf("body = %v, want %v", got, want)
+	}
+
+	if strings.Contains(stderr.String(), "s3cr3t") {
+		t.Errorf("logs leak a header value:\n%s", stderr)
+	}
+}
+
+func Test_webhook_send_retries(t *testing.T) {
+	t.Parallel()
+
+	const url = "https://ha.example/api/webhook/ynab"
+
+	tests := []struct {
+		name         string
+		responses    []httpmock.Responder
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name: "two failures then success",
+			responses: []httpmock.Responder{
+				httpmock.NewErrorResponder(errors.New("connection refused")),
+				httpmock.NewStringResponder(http.StatusServiceUnavailable, ""),
+				httpmock.NewStringResponder(http.StatusOK, ""),
+			},
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name: "client error is not retried",
+			responses: []httpmock.Responder{
+				httpmock.NewStringResponder(http.StatusNotFound, ""),
+			},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name: "retries exhausted",
+			responses: []httpmock.Responder{
+				httpmock.NewStringResponder(http.StatusBadGateway, ""),
+				httpmock.NewStringResponder(http.StatusBadGateway, ""),
+				httpmock.NewStringResponder(http.StatusBadGateway, ""),
+			},
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			attempts := 0
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodPost, url, func(req *http.Request) (*http.Response, error) {
+				responder := tt.responses[min(attempts, len(tt.responses)-1)]
+				attempts++
+
+				return responder(req)
+			})
+
+			tmpl, err := parseWebhookTemplate(osFS{}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hook := &webhook{
+				template:    tmpl,
+				contentType: defaultWebhookContentType,
+				attempts:    defaultWebhookAttempts,
+				backoff:     time.Millisecond,
+			}
+
+			err = hook.send(context.Background(), &http.Client{Transport: transport}, logging.Discard(), url, webhookData{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("send() attempts = %v, want %v", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func Test_run_webhookAlways(t *testing.T) {
+	t.Parallel()
+
+	const webhook = "https://ha.example/api/webhook/ynab"
+
+	tests := []struct {
+		name       string
+		file       string
+		pushStatus int
+		wantErr    error
+		wantPrefix string
+	}{
+		{
+			name:       "conversion failure",
+			file:       "./testdata/missing.csv",
+			pushStatus: http.StatusOK,
+			wantErr:    os.ErrNotExist,
+			wantPrefix: `{"status":"error","pushed":0,"duplicates":0,"skipped":0,"account":"` + testAccountID + `",` +
+				`"budget_name":"` + testBudgetID + `","account_name":"` + testAccountID + `","timestamp":"2024-11-30T03:00:00Z",` +
+				`"run_id":"test-run",` +
+				`"error":"opening file: open ./testdata/missing.csv: no such file or directory","phase":"conversion"}`,
+		},
+		{
+			name:       "push failure",
+			file:       "./testdata/one-positive.csv",
+			pushStatus: http.StatusUnauthorized,
+			wantErr:    lclynab.ErrUnauthorized,
+			wantPrefix: `{"reconciled":"100.06","reconciled_milliunits":100060,"currency":"EUR",` +
+				`"reconciled_date":"2024-11-29","status":"error","pushed":0,"duplicates":0,"skipped":0,` +
+				`"account":"` + testAccountID + `",` +
+				`"budget_name":"` + testBudgetID + `","account_name":"` + testAccountID + `","timestamp":"2024-11-30T03:00:00Z",` +
+				`"run_id":"test-run","error":"pushing to YNAB: YNAB authentication failed`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var body []byte
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(
+				http.MethodPost,
+				"/v1/budgets/"+testBudgetID+"/transactions",
+				createdResponder(tt.pushStatus),
+			)
+			transport.RegisterResponder(http.MethodPost, webhook, func(req *http.Request) (*http.Response, error) {
+				body, _ = io.ReadAll(req.Body)
+
+				return httpmock.NewStringResponse(http.StatusOK, ""), nil
+			})
+
+			err := run(context.Background(), []string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", tt.file, "-w", webhook, "-webhook-always",
+				"-run-id", "test-run",
+			}, env{
+				stdout:     io.Discard,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !strings.HasPrefix(string(body), tt.wantPrefix) {
+				t.Errorf("webhook body = %s, want prefix %s", body, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func Test_run_webhookFailureURL(t *testing.T) {
+	t.Parallel()
+
+	const (
+		success = "https://ha.example/api/webhook/ynab"
+		failure = "https://ha.example/api/webhook/page-me"
+	)
+
+	var body []byte
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(
+		http.MethodPost,
+		"/v1/budgets/"+testBudgetID+"/transactions",
+		httpmock.NewStringResponder(http.StatusInternalServerError, ""),
+	)
+	transport.RegisterResponder(http.MethodPost, success, httpmock.NewStringResponder(http.StatusOK, ""))
+	transport.RegisterResponder(http.MethodPost, failure, func(req *http.Request) (*http.Response, error) {
+		body, _ = io.ReadAll(req.Body)
+
+		return httpmock.NewStringResponse(http.StatusOK, ""), nil
+	})
+
+	err := run(context.Background(), []string{
+		"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+		"-webhook-success", success, "-webhook-failure", failure, "-push-backoff", "1ms",
+	}, env{
+		stdout:     io.Discard,
+		stderr:     io.Discard,
+		httpClient: &http.Client{Transport: transport},
+		now:        fixedNow,
+		getenv:     testGetenv(t),
+	})
+	if err == nil {
+		t.Fatal("run() succeeded, want the push error")
+	}
+
+	calls := transport.GetCallCountInfo()
+	if calls["POST "+success] != 0 || calls["POST "+failure] != 1 {
+		t.Errorf("webhook calls = %v, want only the failure URL", calls)
+	}
+
+	for _, want := range []string{
+		`"status":"error"`, `"error":"after 4 attempt(s): pushing to YNAB: `, `"phase":"api call"`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("failure body = %s, want %s", body, want)
+		}
+	}
+}
+
+func Test_run_webhookTimeout(t *testing.T) {
+	t.Parallel()
+
+	const webhook = "https://ha.example/api/webhook/ynab"
+
+	// delayed answers after delay, or fails when the request context ends first.
+	delayed := func(delay time.Duration, next httpmock.Responder) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			select {
+			case <-time.After(delay):
+				return next(req)
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+	}
+
+	tests := []struct {
+		name         string
+		timeout      string
+		webhookDelay time.Duration
+		wantErr      error
+		wantDeadline bool
+	}{
+		{
+			name:         "slow push leaves the webhook its own budget",
+			timeout:      "600ms",
+			webhookDelay: 200 * time.Millisecond,
+			wantErr:      nil,
+			wantDeadline: true,
+		},
+		{
+			name:         "slow webhook times out",
+			timeout:      "50ms",
+			webhookDelay: time.Second,
+			wantErr:      errNotificationFailed,
+			wantDeadline: true,
+		},
+		{
+			name:         "zero disables the timeout",
+			timeout:      "0",
+			webhookDelay: 0,
+			wantErr:      nil,
+			wantDeadline: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Set by the responder, which may outlive the call once the webhook times out.
+			var hasDeadline atomic.Bool
+
+			transport := httpmock.NewMockTransport()
+			// The push alone takes most of the webhook timeout.
+			transport.RegisterResponder(
+				http.MethodPost,
+				"/v1/budgets/"+testBudgetID+"/transactions",
+				delayed(500*time.Millisecond, createdResponder(http.StatusOK)),
+			)
+			transport.RegisterResponder(http.MethodPost, webhook, func(req *http.Request) (*http.Response, error) {
+				_, ok := req.Context().Deadline()
+				hasDeadline.Store(ok)
+
+				return delayed(tt.webhookDelay, httpmock.NewStringResponder(http.StatusOK, ""))(req)
+			})
+
+			err := run(context.Background(), []string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-f", "./testdata/one-positive.csv",
+				"-w", webhook, "-webhook-timeout", tt.timeout, "-webhook-attempts", "1",
+			}, env{
+				stdout:     io.Discard,
+				stderr:     io.Discard,
+				httpClient: &http.Client{Transport: transport},
+				now:        fixedNow,
+				getenv:     testGetenv(t),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := hasDeadline.Load(); got != tt.wantDeadline {
+				t.Errorf("webhook deadline set = %v, want %v", got, tt.wantDeadline)
+			}
+		})
+	}
+}
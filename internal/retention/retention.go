@@ -0,0 +1,138 @@
+// Package retention deletes the artifacts the commands generate, like screenshots,
+// once they are too old or too many.
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+var errInvalidPolicy = errors.New("invalid retention policy")
+
+// Policy bounds the artifacts of a kind. Its zero value keeps them all.
+type Policy struct {
+	// MaxAge is the age above which an artifact is deleted, 0 for no limit.
+	MaxAge time.Duration
+	// MaxCount is the number of most recent artifacts kept, 0 for no limit.
+	MaxCount int
+}
+
+// Check returns an error for a negative bound.
+func (p Policy) Check() error {
+	if p.MaxAge < 0 || p.MaxCount < 0 {
+		return fmt.Errorf("%w: max age %v and max count %d, want 0 or more", errInvalidPolicy, p.MaxAge, p.MaxCount)
+	}
+
+	return nil
+}
+
+// Artifact is a kind of generated file: those of Dir whose name matches Pattern,
+// a filepath.Match pattern. Subdirectories aren't looked into.
+type Artifact struct {
+	Name    string
+	Dir     string
+	Pattern string
+	Policy  Policy
+}
+
+// Removal is a file Clean deleted, or would delete in a dry run.
+type Removal struct {
+	Artifact string
+	Path     string
+	ModTime  time.Time
+}
+
+// Clean deletes the files of each artifact its policy expires at now, the oldest
+// first, and returns them. A dry run only returns them. A missing directory has
+// nothing to clean.
+func Clean(artifacts []Artifact, now time.Time, dryRun bool) ([]Removal, error) {
+	var removals []Removal
+
+	for _, artifact := range artifacts {
+		expired, err := expiredFiles(artifact, now)
+		if err != nil {
+			return removals, err
+		}
+
+		for _, removal := range expired {
+			if !dryRun {
+				if err := os.Remove(removal.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return removals, fmt.Errorf("removing %v: %w", artifact.Name, err)
+				}
+			}
+
+			removals = append(removals, removal)
+		}
+	}
+
+	return removals, nil
+}
+
+// expiredFiles lists the files of artifact its policy expires, the oldest first.
+func expiredFiles(artifact Artifact, now time.Time) ([]Removal, error) {
+	if artifact.Policy == (Policy{}) {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(artifact.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("listing %v: %w", artifact.Name, err)
+	}
+
+	var files []Removal
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		ok, err := filepath.Match(artifact.Pattern, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("matching %v: %w", artifact.Name, err)
+		}
+
+		if !ok {
+			continue
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("reading %v: %w", artifact.Name, err)
+		}
+
+		files = append(files, Removal{
+			Artifact: artifact.Name, Path: filepath.Join(artifact.Dir, entry.Name()), ModTime: info.ModTime(),
+		})
+	}
+
+	// Newest first, so that the count keeps the head.
+	slices.SortFunc(files, func(a, b Removal) int { return b.ModTime.Compare(a.ModTime) })
+
+	var expired []Removal
+
+	for i, file := range files {
+		tooMany := artifact.Policy.MaxCount > 0 && i >= artifact.Policy.MaxCount
+		tooOld := artifact.Policy.MaxAge > 0 && now.Sub(file.ModTime) > artifact.Policy.MaxAge
+
+		if tooMany || tooOld {
+			expired = append(expired, file)
+		}
+	}
+
+	slices.Reverse(expired)
+
+	return expired, nil
+}
@@ -0,0 +1,174 @@
+package retention
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func fixedNow() time.Time {
+	return time.Date(2024, 11, 30, 3, 0, 0, 0, time.UTC)
+}
+
+// artifactTree writes files aged by the given number of days under a temporary directory.
+func artifactTree(t *testing.T, ages map[string]int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, days := range ages {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		modTime := fixedNow().Add(-time.Duration(days) * 24 * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+// remaining lists the files left under dir, relative to it.
+func remaining(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var names []string
+
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, path)
+		names = append(names, name)
+
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+func TestClean(t *testing.T) {
+	t.Parallel()
+
+	ages := map[string]int{
+		"screenshot-a.png":        1,
+		"screenshot-b.png":        10,
+		"screenshot-c.png":        40,
+		"screenshot-d.png":        90,
+		"notes.txt":               400,
+		"nested/screenshot-e.png": 400,
+	}
+
+	tests := []struct {
+		name        string
+		policy      Policy
+		dryRun      bool
+		wantRemoved []string
+	}{
+		{
+			name:        "no policy",
+			wantRemoved: nil,
+		},
+		{
+			name:        "max age",
+			policy:      Policy{MaxAge: 30 * 24 * time.Hour},
+			wantRemoved: []string{"screenshot-d.png", "screenshot-c.png"},
+		},
+		{
+			name:        "max count",
+			policy:      Policy{MaxCount: 1},
+			wantRemoved: []string{"screenshot-d.png", "screenshot-c.png", "screenshot-b.png"},
+		},
+		{
+			name:        "both",
+			policy:      Policy{MaxAge: 60 * 24 * time.Hour, MaxCount: 3},
+			wantRemoved: []string{"screenshot-d.png"},
+		},
+		{
+			name:        "dry run",
+			policy:      Policy{MaxCount: 2},
+			dryRun:      true,
+			wantRemoved: []string{"screenshot-d.png", "screenshot-c.png"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := artifactTree(t, ages)
+
+			removals, err := Clean([]Artifact{
+				{Name: "screenshots", Dir: dir, Pattern: "screenshot-*.png", Policy: tt.policy},
+			}, fixedNow(), tt.dryRun)
+			if err != nil {
+				t.Fatalf("Clean() error = %v", err)
+			}
+
+			var removed []string
+
+			for _, removal := range removals {
+				if removal.Artifact != "screenshots" {
+					t.Errorf("removal artifact = %q, want screenshots", removal.Artifact)
+				}
+
+				removed = append(removed, filepath.Base(removal.Path))
+			}
+
+			if !slices.Equal(removed, tt.wantRemoved) {
+				t.Errorf("Clean() removed %v, want %v", removed, tt.wantRemoved)
+			}
+
+			want := []string{"nested/screenshot-e.png", "notes.txt", "screenshot-a.png", "screenshot-b.png",
+				"screenshot-c.png", "screenshot-d.png"}
+			if !tt.dryRun {
+				want = slices.DeleteFunc(want, func(name string) bool { return slices.Contains(tt.wantRemoved, name) })
+			}
+
+			if got := remaining(t, dir); !slices.Equal(got, want) {
+				t.Errorf("left %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestClean_missingDir(t *testing.T) {
+	t.Parallel()
+
+	removals, err := Clean([]Artifact{{
+		Name: "screenshots", Dir: filepath.Join(t.TempDir(), "none"), Pattern: "*", Policy: Policy{MaxCount: 1},
+	}}, fixedNow(), false)
+	if err != nil || removals != nil {
+		t.Errorf("Clean() = %v, %v, want nothing", removals, err)
+	}
+}
+
+func TestPolicy_Check(t *testing.T) {
+	t.Parallel()
+
+	if err := (Policy{MaxAge: time.Hour, MaxCount: 3}).Check(); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+
+	for _, policy := range []Policy{{MaxAge: -time.Hour}, {MaxCount: -1}} {
+		if err := policy.Check(); !errors.Is(err, errInvalidPolicy) {
+			t.Errorf("%+v.Check() error = %v, want %v", policy, err, errInvalidPolicy)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Package state persists small JSON documents between runs.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/atomicfile"
+)
+
+const (
+	perm    = 0o600
+	dirPerm = 0o700
+)
+
+// Load decodes the JSON file at path into v.
+// A missing file is not an error and leaves v untouched.
+func Load(path string, v any) error {
+	data, err := os.ReadFile(path)
+
+	return decode(path, data, err, v)
+}
+
+// LoadFS is Load reading path from fsys.
+func LoadFS(fsys fs.FS, path string, v any) error {
+	data, err := fs.ReadFile(fsys, path)
+
+	return decode(path, data, err, v)
+}
+
+// decode decodes data, read from path with err, into v.
+func decode(path string, data []byte, err error, v any) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("reading state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decoding state %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// Save atomically writes v as JSON to path, creating its directory if needed.
+func Save(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+
+	if err := atomicfile.WriteFile(path, append(data, '\n'), perm); err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+
+	return nil
+}
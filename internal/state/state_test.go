@@ -0,0 +1,82 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type document struct {
+	LastSuccess time.Time `json:"last_success"`
+}
+
+func TestSaveLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "profile", "state.json")
+	want := document{LastSuccess: time.Date(2024, 11, 29, 3, 0, 0, 0, time.UTC)}
+
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var got document
+	if err := Load(path, &got); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !got.LastSuccess.Equal(want.LastSuccess) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestLoad_missing(t *testing.T) {
+	t.Parallel()
+
+	got := document{LastSuccess: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := Load(filepath.Join(t.TempDir(), "missing.json"), &got); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got.LastSuccess.Year() != 2024 {
+		t.Errorf("Load() changed the document: %v", got)
+	}
+}
+
+func TestLoad_invalid(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got document
+	if err := Load(path, &got); err == nil {
+		t.Error("Load() error = nil, want error")
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"state.json":   {Data: []byte(`{"last_success": "2024-11-29T03:00:00Z"}`)},
+		"invalid.json": {Data: []byte(`{`)},
+	}
+
+	var got document
+	if err := LoadFS(fsys, "state.json", &got); err != nil || got.LastSuccess.Day() != 29 {
+		t.Errorf("LoadFS() = %v, %v, want the saved document", got, err)
+	}
+
+	if err := LoadFS(fsys, "missing.json", &got); err != nil {
+		t.Errorf("LoadFS() of a missing file error = %v, want nil", err)
+	}
+
+	if err := LoadFS(fsys, "invalid.json", &got); err == nil {
+		t.Error("LoadFS() error = nil, want error")
+	}
+}
@@ -0,0 +1,327 @@
+// Package sync downloads LCL statements, then pushes them to YNAB, as the sync command.
+package sync
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/download"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/push"
+)
+
+// downloadPrefix names the download flags whose name push gives another meaning.
+const downloadPrefix = "download-"
+
+var (
+	errDownloadFailed     = errors.New("download failed, nothing pushed")
+	errUnexpectedArgs     = errors.New("unexpected arguments")
+	errInvalidAccounts    = errors.New("invalid accounts")
+	errConflictingAccount = errors.New("account given twice")
+)
+
+// sharedFlags are the flags of both commands meaning the same to them, given to both.
+// The others of both commands go to push, the download ones being prefixed with
+// downloadPrefix.
+//
+//nolint:gochecknoglobals // constant lookup table
+var sharedFlags = map[string]bool{
+	"cleanup": true, "config": true, "log-format": true, "log-level": true, "preflight": true,
+	"print-paths": true, "profile": true, "run-id": true, "timings": true,
+}
+
+// ownFlags are the flags sync sets itself: the statements it downloads and pushes.
+//
+//nolint:gochecknoglobals // constant lookup table
+var ownFlags = map[string]bool{"o": true, "accounts": true, "f": true, "sync-account": true}
+
+// Env is what sync depends on, replaced in tests.
+type Env struct {
+	Stdout     io.Writer
+	Stderr     io.Writer
+	HTTPClient *http.Client
+	Getenv     func(string) string
+	// Launch starts the browser of the download.
+	Launch download.Launcher
+}
+
+// Main runs the sync command with the arguments and environment of the process, the
+// browser being started by launch, then exits with the code push gives the outcome.
+func Main(launch download.Launcher) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := Run(ctx, os.Args[1:], Env{
+		Stdout:     os.Stdout,
+		Stderr:     os.Stderr,
+		HTTPClient: http.DefaultClient,
+		Getenv:     os.Getenv,
+		Launch:     launch,
+	})
+
+	stop()
+
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, push.ErrorText(err))
+		os.Exit(push.ExitCode(err))
+	}
+}
+
+// options are the arguments sync gives each command, and the flags it reads itself.
+type options struct {
+	downloadArgs []string
+	pushArgs     []string
+	// webhookArgs configure the webhook: that of the push of a single account, the one
+	// sent for them all with -accounts.
+	webhookArgs []string
+	// accounts are the name:account pairs of -accounts.
+	accounts string
+	// format is the export format of the download.
+	format  string
+	cleanup bool
+	// dryRun is the -dry-run of push, which lists what -cleanup would delete.
+	dryRun  bool
+	account bool
+}
+
+// statement is a file downloaded then pushed.
+type statement struct {
+	// name is the text of the LCL account entry, empty for the first account.
+	name string
+	// account is the -a of the push, empty for the one of the command line.
+	account string
+	file    string
+}
+
+// Run downloads the statements to a temporary directory, then pushes each one. The
+// push is skipped when the download fails, and the files are deleted either way.
+// With -accounts, one webhook sums up the pushes of the accounts.
+func Run(ctx context.Context, args []string, env Env) error {
+	opts, err := parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "lcl-ynab-sync-")
+	if err != nil {
+		return fmt.Errorf("creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	statements, downloadArgs, err := plan(opts, dir)
+	if err != nil {
+		return err
+	}
+
+	if opts.cleanup && opts.dryRun {
+		downloadArgs = append(downloadArgs, "-dry-run")
+	}
+
+	pushEnv := push.Env{Stdout: env.Stdout, Stderr: env.Stderr, HTTPClient: env.HTTPClient, Getenv: env.Getenv}
+
+	// Mistakes in the push flags are reported before the download, which takes a while.
+	if !opts.cleanup {
+		for _, statement := range statements {
+			if err := push.Check(pushArgs(opts, statement), pushEnv); err != nil {
+				return err //nolint:wrapcheck // already explicit
+			}
+		}
+
+		if opts.accounts != "" {
+			if err := push.CheckAccounts(webhookArgs(opts), pushEnv); err != nil {
+				return err //nolint:wrapcheck // already explicit
+			}
+		}
+	}
+
+	err = download.Run(ctx, downloadArgs, download.Env{
+		Stdout: env.Stdout, Stderr: env.Stderr, Getenv: env.Getenv, Launch: env.Launch,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", errDownloadFailed, err)
+	}
+
+	if opts.cleanup {
+		return push.Run(ctx, opts.pushArgs, pushEnv) //nolint:wrapcheck // already explicit
+	}
+
+	var (
+		errs    []error
+		results []push.AccountResult
+	)
+
+	for _, statement := range statements {
+		if statement.name != "" {
+			_, _ = fmt.Fprintf(env.Stdout, "%v:\n", statement.name)
+		}
+
+		result := push.AccountResult{Name: statement.name}
+		pushEnv.OnResult = func(res *push.Result) { result.Result = res }
+
+		if err := push.Run(ctx, pushArgs(opts, statement), pushEnv); err != nil {
+			result.Err = err
+
+			if statement.name != "" {
+				err = fmt.Errorf("%v: %w", statement.name, err)
+			}
+
+			errs = append(errs, err)
+		}
+
+		results = append(results, result)
+	}
+
+	if opts.accounts != "" {
+		pushEnv.OnResult = nil
+		errs = append(errs, push.NotifyAccounts(ctx, webhookArgs(opts), results, pushEnv))
+	}
+
+	return errors.Join(errs...)
+}
+
+// plan returns the statements to download to dir, with the arguments of the download.
+func plan(opts *options, dir string) ([]statement, []string, error) {
+	if opts.accounts == "" {
+		file := filepath.Join(dir, "statement."+opts.format)
+
+		return []statement{{file: file}}, append(opts.downloadArgs, "-o", file), nil
+	}
+
+	if opts.account {
+		return nil, nil, fmt.Errorf("%w: -a and -accounts", errConflictingAccount)
+	}
+
+	var (
+		statements []statement
+		targets    []string
+	)
+
+	for i, pair := range strings.Split(opts.accounts, ",") {
+		name, account, ok := strings.Cut(pair, ":")
+		name, account = strings.TrimSpace(name), strings.TrimSpace(account)
+
+		if !ok || name == "" || account == "" {
+			return nil, nil, fmt.Errorf("%w: %q, want name:account", errInvalidAccounts, pair)
+		}
+
+		file := filepath.Join(dir, fmt.Sprintf("statement-%d.%v", i+1, opts.format))
+		statements = append(statements, statement{name: name, account: account, file: file})
+		targets = append(targets, name+":"+file)
+	}
+
+	return statements, append(opts.downloadArgs, "-accounts", strings.Join(targets, ",")), nil
+}
+
+// pushArgs are the arguments of the push of statement. The push of an account of
+// -accounts leaves the webhook to sync, unless its section of the config file has one.
+func pushArgs(opts *options, statement statement) []string {
+	args := append([]string{}, opts.pushArgs...)
+	if statement.name == "" {
+		args = append(args, opts.webhookArgs...)
+	} else {
+		args = append(args, "-sync-account", statement.name)
+	}
+
+	if statement.account != "" {
+		args = append(args, "-a", statement.account)
+	}
+
+	return append(args, "-f", statement.file)
+}
+
+// webhookArgs are the arguments of the webhook sent for all the accounts of -accounts.
+func webhookArgs(opts *options) []string {
+	return append(append([]string{}, opts.pushArgs...), opts.webhookArgs...)
+}
+
+// parseFlags reads the command line, made of the flags of the download and push
+// commands, and sorts them out between the two.
+func parseFlags(args []string) (*options, error) {
+	opts := &options{}
+
+	downloadFlags, pushFlags := download.Flags(), push.Flags()
+
+	flagset := flag.NewFlagSet("", flag.ExitOnError)
+	flagset.Usage = func() {
+		_, _ = fmt.Fprintln(flagset.Output(), "Usage: sync [flags]")
+		flagset.PrintDefaults()
+		_, _ = fmt.Fprint(flagset.Output(), push.ExitCodesHelp)
+	}
+
+	flagset.StringVar(&opts.accounts, "accounts", "",
+		"Comma-separated name:account pairs downloading each LCL account whose entry has the text name, "+
+			"then pushing it to the YNAB account, by ID or name, instead of the first one to -a")
+
+	downloadFlags.VisitAll(func(f *flag.Flag) {
+		switch {
+		case ownFlags[f.Name]:
+		case sharedFlags[f.Name]:
+			flagset.Var(&route{Value: f.Value, name: f.Name, to: []*[]string{&opts.downloadArgs, &opts.pushArgs}},
+				f.Name, f.Usage)
+		case pushFlags.Lookup(f.Name) != nil:
+			flagset.Var(&route{Value: f.Value, name: f.Name, to: []*[]string{&opts.downloadArgs}},
+				downloadPrefix+f.Name, f.Usage)
+		default:
+			flagset.Var(&route{Value: f.Value, name: f.Name, to: []*[]string{&opts.downloadArgs}}, f.Name, f.Usage)
+		}
+	})
+
+	pushFlags.VisitAll(func(f *flag.Flag) {
+		switch {
+		case ownFlags[f.Name] || sharedFlags[f.Name]:
+		case push.IsWebhookFlag(f.Name):
+			flagset.Var(&route{Value: f.Value, name: f.Name, to: []*[]string{&opts.webhookArgs}}, f.Name, f.Usage)
+		default:
+			flagset.Var(&route{Value: f.Value, name: f.Name, to: []*[]string{&opts.pushArgs}}, f.Name, f.Usage)
+		}
+	})
+
+	if err := flagset.Parse(args); err != nil {
+		return nil, fmt.Errorf("parsing flags: %w", err)
+	}
+
+	if flagset.NArg() > 0 {
+		return nil, fmt.Errorf("%w: %v", errUnexpectedArgs, strings.Join(flagset.Args(), " "))
+	}
+
+	opts.format = flagset.Lookup(downloadPrefix + "format").Value.String()
+	opts.cleanup = flagset.Lookup("cleanup").Value.String() == "true"
+	opts.dryRun = flagset.Lookup("dry-run").Value.String() == "true"
+	flagset.Visit(func(f *flag.Flag) { opts.account = opts.account || f.Name == "a" })
+
+	return opts, nil
+}
+
+// route is a flag of sync handed to the commands it belongs to, as they name it.
+type route struct {
+	flag.Value
+
+	name string
+	// to are the arguments of the commands.
+	to []*[]string
+}
+
+func (r *route) Set(value string) error {
+	if err := r.Value.Set(value); err != nil {
+		return err //nolint:wrapcheck // reported by the flag package
+	}
+
+	for _, args := range r.to {
+		*args = append(*args, "-"+r.name+"="+value)
+	}
+
+	return nil
+}
+
+// IsBoolFlag lets boolean flags go without a value, as the commands accept them.
+func (r *route) IsBoolFlag() bool {
+	boolFlag, ok := r.Value.(interface{ IsBoolFlag() bool })
+
+	return ok && boolFlag.IsBoolFlag()
+}
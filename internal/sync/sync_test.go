@@ -0,0 +1,390 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/download"
+	"github.com/Crocmagnon/lcl-ynab-go/internal/push"
+	"github.com/jarcoal/httpmock"
+)
+
+// IDs of the YNAB form, which push takes as IDs rather than names to resolve.
+const (
+	testBudgetID  = "5f3b1d2c-8a4e-4c6f-9b2a-1e7d3c5a9f80"
+	testAccountID = "a1c2e3f4-0b6d-4e8a-9c1f-3b5d7e9a2c64"
+	testLivretID  = "7c9e1a3b-5d2f-4b6a-8e0c-9f1d3b5a7c28"
+
+	// testAccounts are the -accounts of two LCL accounts.
+	testAccounts = "Courant:" + testAccountID + ",Livret:" + testLivretID
+)
+
+var errFakeLogin = errors.New("login failed")
+
+// fakeBrowser saves the test statement, or fails to log in, and records the files
+// it was asked to write.
+type fakeBrowser struct {
+	failLogin bool
+	launched  bool
+	saved     []string
+}
+
+func (b *fakeBrowser) launch(download.LaunchOptions) (download.Browser, func(), error) {
+	b.launched = true
+
+	return b, func() {}, nil
+}
+
+func (b *fakeBrowser) Login(string, string) error {
+	if b.failLogin {
+		return errFakeLogin
+	}
+
+	return nil
+}
+
+func (b *fakeBrowser) OpenExport(string) error                    { return nil }
+func (b *fakeBrowser) FillExport(time.Time, time.Time, int) error { return nil }
+func (b *fakeBrowser) Back() error                                { return nil }
+func (b *fakeBrowser) Screenshot() ([]byte, error)                { return nil, nil }
+
+func (b *fakeBrowser) Save(outputFile string) error {
+	b.saved = append(b.saved, outputFile)
+
+	content, err := os.ReadFile("./testdata/statement.csv")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(outputFile, content, 0o600)
+}
+
+// testEnv is an environment with the LCL credentials and a temporary HOME, pushing
+// to transport.
+func testEnv(t *testing.T, stdout *bytes.Buffer, transport http.RoundTripper, browser *fakeBrowser) Env {
+	t.Helper()
+
+	variables := map[string]string{"LCL_IDENTIFIER": "0123456789", "LCL_PASSWORD": "123456", "HOME": t.TempDir()}
+
+	return Env{
+		Stdout:     stdout,
+		Stderr:     &bytes.Buffer{},
+		HTTPClient: &http.Client{Transport: transport},
+		Getenv:     func(key string) string { return variables[key] },
+		Launch:     browser.launch,
+	}
+}
+
+// stateArgs keep the state files and screenshots of a run in dir.
+func stateArgs(dir string) []string {
+	return []string{
+		"-state", filepath.Join(dir, "push-state.json"),
+		"-download-state", filepath.Join(dir, "download-state.json"),
+		"-screenshots", dir,
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	const webhook = "https://ha.example/api/webhook/ynab"
+
+	tests := []struct {
+		name         string
+		args         []string
+		failLogin    bool
+		status       int
+		wantErr      error
+		wantExitCode int
+		wantLaunched bool
+		wantPushes   int
+		wantWebhooks int
+		wantStdout   string
+	}{
+		{
+			name:         "pushed",
+			args:         []string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID},
+			status:       http.StatusCreated,
+			wantLaunched: true,
+			wantPushes:   1,
+			wantStdout:   "successfully pushed 1 transaction(s)",
+		},
+		{
+			name:         "download failed",
+			args:         []string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID},
+			failLogin:    true,
+			wantErr:      errFakeLogin,
+			wantExitCode: 1,
+			wantLaunched: true,
+		},
+		{
+			name:         "push failed",
+			args:         []string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID},
+			status:       http.StatusUnauthorized,
+			wantExitCode: 4, // the authentication error of push
+			wantLaunched: true,
+			wantPushes:   1,
+		},
+		{
+			name:         "push flags checked first",
+			args:         []string{"-b", testBudgetID, "-a", testAccountID},
+			wantExitCode: 1,
+		},
+		{
+			name:         "accounts",
+			args:         []string{"-t", "tok", "-b", testBudgetID, "-accounts", testAccounts},
+			status:       http.StatusCreated,
+			wantLaunched: true,
+			wantPushes:   2,
+			wantStdout:   "Livret:\n",
+		},
+		{
+			name:         "webhook",
+			args:         []string{"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-w", webhook},
+			status:       http.StatusCreated,
+			wantLaunched: true,
+			wantPushes:   1,
+			wantWebhooks: 1,
+		},
+		{
+			name: "one webhook for the accounts",
+			args: []string{
+				"-t", "tok", "-b", testBudgetID, "-accounts", testAccounts, "-w", webhook,
+			},
+			status:       http.StatusCreated,
+			wantLaunched: true,
+			wantPushes:   2,
+			wantWebhooks: 1,
+		},
+		{
+			name: "account given twice",
+			args: []string{
+				"-t", "tok", "-b", testBudgetID, "-a", testAccountID, "-accounts", "Courant:" + testAccountID,
+			},
+			wantErr:      errConflictingAccount,
+			wantExitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+				httpmock.NewStringResponder(tt.status, `{"data": {"transaction_ids": ["t-1"]}}`))
+			transport.RegisterResponder(http.MethodPost, webhook, httpmock.NewStringResponder(http.StatusOK, ""))
+
+			var stdout bytes.Buffer
+
+			browser := &fakeBrowser{failLogin: tt.failLogin}
+			args := append(stateArgs(t.TempDir()), tt.args...)
+
+			err := Run(context.Background(), args, testEnv(t, &stdout, transport, browser))
+			if tt.wantExitCode == 0 && err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if tt.wantExitCode != 0 && push.ExitCode(err) != tt.wantExitCode {
+				t.Errorf("Run() error = %v, exit code %d, want %d", err, push.ExitCode(err), tt.wantExitCode)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.failLogin && !errors.Is(err, errDownloadFailed) {
+				t.Errorf("Run() error = %v, want %v", err, errDownloadFailed)
+			}
+
+			if browser.launched != tt.wantLaunched {
+				t.Errorf("browser launched = %v, want %v", browser.launched, tt.wantLaunched)
+			}
+
+			webhooks := transport.GetCallCountInfo()["POST "+webhook]
+			if got := transport.GetTotalCallCount() - webhooks; got != tt.wantPushes {
+				t.Errorf("YNAB got %d calls, want %d", got, tt.wantPushes)
+			}
+
+			if webhooks != tt.wantWebhooks {
+				t.Errorf("webhook got %d calls, want %d", webhooks, tt.wantWebhooks)
+			}
+
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantStdout)
+			}
+
+			for _, file := range browser.saved {
+				if _, err := os.Stat(file); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("statement %v left behind: %v", file, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_parseFlags(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseFlags([]string{
+		"-config", "sync.toml", "-headless", "-days", "7", "-download-format", "ofx", "-download-state", "dl.json",
+		"-state", "push.json", "-format", "ofx", "-t", "tok", "-v", "-webhook-header", "A: 1", "-webhook-header", "B: 2",
+	})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+
+	wantDownload := []string{"-config=sync.toml", "-headless=true", "-days=7", "-format=ofx", "-state=dl.json"}
+	if !slices.Equal(opts.downloadArgs, wantDownload) {
+		t.Errorf("download args = %q, want %q", opts.downloadArgs, wantDownload)
+	}
+
+	wantPush := []string{"-config=sync.toml", "-state=push.json", "-format=ofx", "-t=tok", "-v=true"}
+	if !slices.Equal(opts.pushArgs, wantPush) {
+		t.Errorf("push args = %q, want %q", opts.pushArgs, wantPush)
+	}
+
+	wantWebhook := []string{"-webhook-header=A: 1", "-webhook-header=B: 2"}
+	if !slices.Equal(opts.webhookArgs, wantWebhook) {
+		t.Errorf("webhook args = %q, want %q", opts.webhookArgs, wantWebhook)
+	}
+
+	if opts.format != "ofx" {
+		t.Errorf("format = %q, want ofx", opts.format)
+	}
+
+	if _, err := parseFlags([]string{"statement.csv"}); !errors.Is(err, errUnexpectedArgs) {
+		t.Errorf("parseFlags() error = %v, want %v", err, errUnexpectedArgs)
+	}
+}
+
+func TestRun_sharedConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "token = \"tok\"\nbudget_id = \"" + testBudgetID + "\"\naccount_id = \"" + testAccountID + "\"\n" +
+		"identifier = \"0123456789\"\npassword = \"123456\"\nheadless = true\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+		httpmock.NewStringResponder(http.StatusCreated, `{"data": {"transaction_ids": ["t-1"]}}`))
+
+	var stdout bytes.Buffer
+
+	env := testEnv(t, &stdout, transport, &fakeBrowser{})
+	home := t.TempDir()
+	env.Getenv = func(name string) string {
+		if name == "HOME" {
+			return home
+		}
+
+		return ""
+	}
+
+	if err := Run(context.Background(), append(stateArgs(dir), "-config", path), env); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if got := transport.GetTotalCallCount(); got != 1 {
+		t.Errorf("YNAB got %d calls, want 1", got)
+	}
+}
+
+func TestRun_accountWebhook(t *testing.T) {
+	t.Parallel()
+
+	const (
+		all    = "https://ha.example/api/webhook/all"
+		livret = "https://ha.example/api/webhook/livret"
+	)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := "webhook = \"" + all + "\"\n\n[accounts.Livret]\nwebhook = \"" + livret + "\"\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/"+testBudgetID+"/transactions",
+		httpmock.NewStringResponder(http.StatusCreated, `{"data": {"transaction_ids": ["t-1"]}}`))
+	transport.RegisterResponder(http.MethodPost, all, httpmock.NewStringResponder(http.StatusOK, ""))
+	transport.RegisterResponder(http.MethodPost, livret, httpmock.NewStringResponder(http.StatusOK, ""))
+
+	args := append(stateArgs(dir), "-config", path, "-t", "tok", "-b", testBudgetID,
+		"-accounts", testAccounts)
+
+	if err := Run(context.Background(), args, testEnv(t, &bytes.Buffer{}, transport, &fakeBrowser{})); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	calls := transport.GetCallCountInfo()
+	if calls["POST "+all] != 1 || calls["POST "+livret] != 1 {
+		t.Errorf("webhook calls = %v, want one for all the accounts and one for Livret", calls)
+	}
+}
+
+func TestRun_cleanup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+
+	for name, age := range map[string]time.Duration{"run-0123abcd.json": time.Hour, "run-4567cdef.json": 48 * time.Hour} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	args := append(stateArgs(dir),
+		"-cleanup", "-report", filepath.Join(dir, "run.json"), "-retention-reports-max-count", "1")
+	old := filepath.Join(dir, "run-4567cdef.json")
+
+	var stdout bytes.Buffer
+
+	browser := &fakeBrowser{}
+	env := testEnv(t, &stdout, httpmock.NewMockTransport(), browser)
+
+	if err := Run(context.Background(), append(args, "-dry-run"), env); err != nil {
+		t.Fatalf("Run(-dry-run) error = %v", err)
+	}
+
+	if want := "would remove reports " + old; !strings.Contains(stdout.String(), want) {
+		t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+	}
+
+	if _, err := os.Stat(old); err != nil {
+		t.Errorf("dry run removed %v: %v", old, err)
+	}
+
+	if err := Run(context.Background(), args, env); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if _, err := os.Stat(old); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("%v left behind: %v", old, err)
+	}
+
+	if browser.launched {
+		t.Error("browser launched, want only the cleanup")
+	}
+}
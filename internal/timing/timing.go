@@ -0,0 +1,75 @@
+// Package timing records wall-clock durations of the named phases of a run.
+package timing
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Span is the duration of one phase.
+type Span struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Recorder collects spans in the order they are started.
+// The zero value is not usable, use New.
+type Recorder struct {
+	now   func() time.Time
+	spans []Span
+}
+
+// New returns a Recorder reading time from now, usually time.Now.
+func New(now func() time.Time) *Recorder {
+	return &Recorder{now: now}
+}
+
+// Start begins the named span and returns the function ending it.
+// Calling the returned function more than once has no further effect.
+func (r *Recorder) Start(name string) func() {
+	start := r.now()
+	index := len(r.spans)
+	r.spans = append(r.spans, Span{Name: name})
+
+	stopped := false
+
+	return func() {
+		if stopped {
+			return
+		}
+
+		stopped = true
+		r.spans[index].Duration = r.now().Sub(start)
+	}
+}
+
+// Spans returns the recorded spans.
+func (r *Recorder) Spans() []Span {
+	return r.spans
+}
+
+// WriteTable prints the spans as an aligned table followed by their total.
+func (r *Recorder) WriteTable(w io.Writer) error {
+	const padding = 2
+
+	tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+
+	var total time.Duration
+
+	_, _ = fmt.Fprintln(tw, "phase\tduration")
+
+	for _, span := range r.spans {
+		total += span.Duration
+		_, _ = fmt.Fprintf(tw, "%v\t%v\n", span.Name, span.Duration.Round(time.Millisecond))
+	}
+
+	_, _ = fmt.Fprintf(tw, "total\t%v\n", total.Round(time.Millisecond))
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("writing timings: %w", err)
+	}
+
+	return nil
+}
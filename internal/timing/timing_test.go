@@ -0,0 +1,66 @@
+package timing
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Date(2024, 11, 29, 3, 0, 0, 0, time.UTC)}
+	recorder := New(clock.Now)
+
+	stop := recorder.Start("conversion")
+	clock.Advance(12 * time.Millisecond)
+	stop()
+	clock.Advance(time.Second)
+	stop()
+
+	stop = recorder.Start("api call")
+	clock.Advance(1500 * time.Millisecond)
+	stop()
+
+	want := []Span{
+		{Name: "conversion", Duration: 12 * time.Millisecond},
+		{Name: "api call", Duration: 1500 * time.Millisecond},
+	}
+
+	got := recorder.Spans()
+	if len(got) != len(want) {
+		t.Fatalf("Spans() = %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Spans()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if err := recorder.WriteTable(out); err != nil {
+		t.Fatalf("WriteTable() error = %v", err)
+	}
+
+	wantTable := `phase       duration
+conversion  12ms
+api call    1.5s
+total       1.512s
+`
+	if out.String() != wantTable {
+		t.Errorf("WriteTable() = %q, want %q", out.String(), wantTable)
+	}
+}
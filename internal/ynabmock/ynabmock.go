@@ -0,0 +1,383 @@
+// Package ynabmock serves enough of the YNAB API to try the tools without a token:
+// one budget with one account, where transactions are created with duplicate
+// detection by import ID, listed, got and deleted. It backs -demo and tests.
+package ynabmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/Crocmagnon/lcl-ynab-go/internal/state"
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+// IDs of the fake budget and account, UUIDs like the real ones for push to take them
+// as IDs rather than names.
+const (
+	BudgetID  = "de000000-0000-4000-8000-000000000001"
+	AccountID = "de000000-0000-4000-8000-000000000002"
+	UserID    = "demo-user"
+)
+
+// Names of the fake budget and account.
+const (
+	BudgetName  = "Demo budget"
+	AccountName = "Demo checking"
+)
+
+// Server is a fake YNAB API, its routes under /v1 like the real one.
+// Any bearer token is accepted.
+type Server struct {
+	mux *http.ServeMux
+	// path is where the data persists between runs, empty to keep it in memory.
+	path string
+
+	mu   sync.Mutex
+	data data
+}
+
+// data is what the server remembers.
+type data struct {
+	ServerKnowledge int64         `json:"server_knowledge"`
+	Transactions    []transaction `json:"transactions"`
+}
+
+// transaction is a saved transaction with the server knowledge of its last change.
+type transaction struct {
+	lclynab.SavedTransaction
+
+	Knowledge int64 `json:"knowledge"`
+}
+
+// New returns a server loading and saving its data at path, or keeping it in
+// memory when path is empty.
+func New(path string) (*Server, error) {
+	s := &Server{mux: http.NewServeMux(), path: path}
+
+	if path != "" {
+		if err := state.Load(path, &s.data); err != nil {
+			return nil, fmt.Errorf("loading the demo data: %w", err)
+		}
+	}
+
+	s.mux.HandleFunc("GET /v1/user", s.getUser)
+	s.mux.HandleFunc("GET /v1/budgets", s.listBudgets)
+	s.mux.HandleFunc("GET /v1/budgets/{budget}/settings", s.getSettings)
+	s.mux.HandleFunc("GET /v1/budgets/{budget}/accounts", s.listAccounts)
+	s.mux.HandleFunc("GET /v1/budgets/{budget}/accounts/{account}", s.getAccount)
+	s.mux.HandleFunc("GET /v1/budgets/{budget}/accounts/{account}/transactions", s.listTransactions)
+	s.mux.HandleFunc("POST /v1/budgets/{budget}/transactions", s.createTransactions)
+	s.mux.HandleFunc("GET /v1/budgets/{budget}/transactions/{transaction}", s.getTransaction)
+	s.mux.HandleFunc("DELETE /v1/budgets/{budget}/transactions/{transaction}", s.deleteTransaction)
+
+	return s, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		writeError(w, http.StatusUnauthorized, "401", "unauthorized", "Unauthorized")
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, _ *http.Request) {
+	writeData(w, map[string]any{"user": lclynab.User{ID: UserID}})
+}
+
+func (s *Server) listBudgets(w http.ResponseWriter, _ *http.Request) {
+	writeData(w, map[string]any{"budgets": []lclynab.Budget{
+		{ID: BudgetID, Name: BudgetName, Accounts: []lclynab.Account{s.account()}},
+	}})
+}
+
+func (s *Server) getSettings(w http.ResponseWriter, r *http.Request) {
+	if !checkBudget(w, r) {
+		return
+	}
+
+	writeData(w, map[string]any{"settings": lclynab.BudgetSettings{CurrencyFormat: lclynab.CurrencyFormat{
+		ISOCode:          "EUR",
+		ExampleFormat:    "123 456,78",
+		DecimalDigits:    2, //nolint:mnd // cents
+		DecimalSeparator: ",",
+		GroupSeparator:   " ",
+		CurrencySymbol:   "€",
+		DisplaySymbol:    true,
+	}}})
+}
+
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
+	if !checkBudget(w, r) {
+		return
+	}
+
+	writeData(w, map[string]any{"accounts": []lclynab.Account{s.account()}})
+}
+
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request) {
+	if !checkBudget(w, r) || !checkAccount(w, r) {
+		return
+	}
+
+	writeData(w, map[string]any{"account": s.account()})
+}
+
+func (s *Server) listTransactions(w http.ResponseWriter, r *http.Request) {
+	if !checkBudget(w, r) || !checkAccount(w, r) {
+		return
+	}
+
+	var since lclynab.Date
+
+	if param := r.URL.Query().Get("since_date"); param != "" {
+		var err error
+		if since, err = lclynab.ParseDate(param); err != nil {
+			writeError(w, http.StatusBadRequest, "400", "bad_request", "since_date: "+err.Error())
+
+			return
+		}
+	}
+
+	lastKnowledge, _ := strconv.ParseInt(r.URL.Query().Get("last_knowledge_of_server"), 10, 64)
+
+	page := lclynab.TransactionsPage{Transactions: []lclynab.SavedTransaction{}, ServerKnowledge: s.data.ServerKnowledge}
+
+	for _, t := range s.data.Transactions {
+		switch {
+		case t.Date.Before(since), t.Knowledge <= lastKnowledge:
+			continue
+		case t.Deleted && lastKnowledge == 0:
+			continue
+		}
+
+		page.Transactions = append(page.Transactions, t.SavedTransaction)
+	}
+
+	writeData(w, page)
+}
+
+func (s *Server) createTransactions(w http.ResponseWriter, r *http.Request) {
+	if !checkBudget(w, r) {
+		return
+	}
+
+	var payload struct {
+		Transactions []lclynab.Transaction `json:"transactions"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "400", "bad_request", err.Error())
+
+		return
+	}
+
+	created := lclynab.CreatedTransactions{
+		TransactionIDs:     []string{},
+		DuplicateImportIDs: []string{},
+		Transactions:       []lclynab.SavedTransaction{},
+	}
+
+	s.data.ServerKnowledge++
+
+	for _, t := range payload.Transactions {
+		// Like YNAB, the IDs returned include those of the duplicates.
+		if id, ok := s.knownImportID(t.ImportID); ok {
+			created.TransactionIDs = append(created.TransactionIDs, id)
+			created.DuplicateImportIDs = append(created.DuplicateImportIDs, t.ImportID)
+
+			continue
+		}
+
+		saved := saveTransaction(t, fmt.Sprintf("demo-%d", len(s.data.Transactions)+1))
+		s.data.Transactions = append(s.data.Transactions,
+			transaction{SavedTransaction: saved, Knowledge: s.data.ServerKnowledge})
+		created.TransactionIDs = append(created.TransactionIDs, saved.ID)
+		created.Transactions = append(created.Transactions, saved)
+	}
+
+	if !s.save(w) {
+		return
+	}
+
+	created.ServerKnowledge = s.data.ServerKnowledge
+	writeDataStatus(w, http.StatusCreated, created)
+}
+
+func (s *Server) getTransaction(w http.ResponseWriter, r *http.Request) {
+	if !checkBudget(w, r) {
+		return
+	}
+
+	t := s.find(r.PathValue("transaction"))
+	if t == nil {
+		writeError(w, http.StatusNotFound, "404.2", "resource_not_found", "Resource not found")
+
+		return
+	}
+
+	writeData(w, map[string]any{"transaction": t.SavedTransaction})
+}
+
+func (s *Server) deleteTransaction(w http.ResponseWriter, r *http.Request) {
+	if !checkBudget(w, r) {
+		return
+	}
+
+	t := s.find(r.PathValue("transaction"))
+	if t == nil {
+		writeError(w, http.StatusNotFound, "404.2", "resource_not_found", "Resource not found")
+
+		return
+	}
+
+	s.data.ServerKnowledge++
+	t.Deleted, t.Knowledge = true, s.data.ServerKnowledge
+
+	if !s.save(w) {
+		return
+	}
+
+	writeData(w, map[string]any{"transaction": t.SavedTransaction})
+}
+
+// account returns the fake account, its balances summing its transactions.
+func (s *Server) account() lclynab.Account {
+	account := lclynab.Account{ID: AccountID, Name: AccountName}
+
+	for _, t := range s.data.Transactions {
+		if t.Deleted {
+			continue
+		}
+
+		account.Balance += t.Amount
+
+		if t.Cleared != lclynab.ClearedUncleared {
+			account.ClearedBalance += t.Amount
+		}
+	}
+
+	return account
+}
+
+// knownImportID returns the ID of the transaction not deleted having importID, if any.
+func (s *Server) knownImportID(importID string) (string, bool) {
+	if importID == "" {
+		return "", false
+	}
+
+	for _, t := range s.data.Transactions {
+		if !t.Deleted && t.ImportID == importID {
+			return t.ID, true
+		}
+	}
+
+	return "", false
+}
+
+// find returns the transaction with id, nil when there is none or it is deleted.
+func (s *Server) find(id string) *transaction {
+	for i := range s.data.Transactions {
+		if t := &s.data.Transactions[i]; t.ID == id && !t.Deleted {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// save persists the data, answering an error on failure.
+func (s *Server) save(w http.ResponseWriter) bool {
+	if s.path == "" {
+		return true
+	}
+
+	if err := state.Save(s.path, s.data); err != nil {
+		writeError(w, http.StatusInternalServerError, "500", "internal_server_error", err.Error())
+
+		return false
+	}
+
+	return true
+}
+
+// saveTransaction is t as YNAB would save it, with default values filled.
+func saveTransaction(t lclynab.Transaction, id string) lclynab.SavedTransaction {
+	saved := lclynab.SavedTransaction{
+		ID:        id,
+		AccountID: t.AccountID,
+		Date:      t.Date,
+		Amount:    t.Amount,
+		PayeeName: t.PayeeName,
+		Memo:      t.Memo,
+		Cleared:   t.Cleared,
+		ImportID:  t.ImportID,
+	}
+
+	if t.PayeeID != nil {
+		saved.PayeeID = *t.PayeeID
+	}
+
+	if t.CategoryID != nil {
+		saved.CategoryID = *t.CategoryID
+	}
+
+	if t.Approved != nil {
+		saved.Approved = *t.Approved
+	}
+
+	if t.FlagColor != nil {
+		saved.FlagColor = *t.FlagColor
+	}
+
+	if saved.Cleared == "" {
+		saved.Cleared = lclynab.ClearedUncleared
+	}
+
+	return saved
+}
+
+func checkBudget(w http.ResponseWriter, r *http.Request) bool {
+	if r.PathValue("budget") != BudgetID {
+		writeError(w, http.StatusNotFound, "404.2", "resource_not_found", "Resource not found")
+
+		return false
+	}
+
+	return true
+}
+
+func checkAccount(w http.ResponseWriter, r *http.Request) bool {
+	if r.PathValue("account") != AccountID {
+		writeError(w, http.StatusNotFound, "404.2", "resource_not_found", "Resource not found")
+
+		return false
+	}
+
+	return true
+}
+
+func writeData(w http.ResponseWriter, data any) {
+	writeDataStatus(w, http.StatusOK, data)
+}
+
+func writeDataStatus(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func writeError(w http.ResponseWriter, status int, id, name, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"id": id, "name": name, "detail": detail}})
+}
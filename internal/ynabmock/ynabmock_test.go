@@ -0,0 +1,131 @@
+package ynabmock
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+func newClient(t *testing.T, path string) *lclynab.Client {
+	t.Helper()
+
+	server, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	client := lclynab.NewClient("demo-token", httpServer.Client())
+	client.BaseURL = httpServer.URL + "/v1"
+
+	return client
+}
+
+func demoTransaction(importID string, amount int) lclynab.Transaction {
+	return lclynab.Transaction{
+		AccountID: AccountID,
+		Date:      lclynab.NewDate(time.Date(2024, time.November, 29, 0, 0, 0, 0, time.UTC)),
+		Amount:    amount,
+		PayeeName: "Boulangerie",
+		Cleared:   lclynab.ClearedCleared,
+		ImportID:  importID,
+	}
+}
+
+func TestServer_duplicatesPersist(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "demo.json")
+
+	created, err := newClient(t, path).CreateTransactionsDetail(ctx, BudgetID, []lclynab.Transaction{
+		demoTransaction("a", -1500), demoTransaction("b", 20000),
+	})
+	if err != nil {
+		t.Fatalf("CreateTransactionsDetail() error = %v", err)
+	}
+
+	if len(created.TransactionIDs) != 2 || len(created.DuplicateImportIDs) != 0 {
+		t.Errorf("first push created %v, duplicates %v, want 2 and none", created.TransactionIDs, created.DuplicateImportIDs)
+	}
+
+	// A new server reads what the first one saved.
+	client := newClient(t, path)
+
+	created, err = client.CreateTransactionsDetail(ctx, BudgetID, []lclynab.Transaction{
+		demoTransaction("b", 20000), demoTransaction("c", -500),
+	})
+	if err != nil {
+		t.Fatalf("CreateTransactionsDetail() error = %v", err)
+	}
+
+	// The IDs include that of the duplicate, like YNAB's.
+	if len(created.TransactionIDs) != 2 || len(created.DuplicateImportIDs) != 1 || created.DuplicateImportIDs[0] != "b" {
+		t.Errorf("second push got IDs %v, duplicates %v, want 2 and [b]", created.TransactionIDs, created.DuplicateImportIDs)
+	}
+
+	account, err := client.GetAccount(ctx, BudgetID, AccountID)
+	if err != nil {
+		t.Fatalf("GetAccount() error = %v", err)
+	}
+
+	if account.ClearedBalance != 18000 || account.Name != AccountName {
+		t.Errorf("GetAccount() = %+v, want %v cleared at 18000", account, AccountName)
+	}
+}
+
+func TestServer_deleteAndDelta(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	client := newClient(t, "")
+
+	created, err := client.CreateTransactionsDetail(ctx, BudgetID, []lclynab.Transaction{demoTransaction("a", -1500)})
+	if err != nil {
+		t.Fatalf("CreateTransactionsDetail() error = %v", err)
+	}
+
+	if err := client.DeleteTransaction(ctx, BudgetID, created.TransactionIDs[0]); err != nil {
+		t.Fatalf("DeleteTransaction() error = %v", err)
+	}
+
+	page, err := client.ListTransactions(ctx, BudgetID, AccountID, lclynab.Date{}, 0)
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+
+	if len(page.Transactions) != 0 {
+		t.Errorf("ListTransactions() = %v, want none once deleted", page.Transactions)
+	}
+
+	delta, err := client.ListTransactions(ctx, BudgetID, AccountID, lclynab.Date{}, created.ServerKnowledge)
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+
+	if len(delta.Transactions) != 1 || !delta.Transactions[0].Deleted {
+		t.Errorf("ListTransactions(delta) = %v, want the deleted transaction", delta.Transactions)
+	}
+
+	_, err = client.GetTransaction(ctx, BudgetID, created.TransactionIDs[0])
+	if apiErr := new(lclynab.APIError); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Errorf("GetTransaction() error = %v, want a 404", err)
+	}
+}
+
+func TestServer_unknownBudget(t *testing.T) {
+	t.Parallel()
+
+	_, err := newClient(t, "").GetAccount(context.Background(), "other", AccountID)
+	if apiErr := new(lclynab.APIError); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Errorf("GetAccount() error = %v, want a 404", err)
+	}
+}
@@ -0,0 +1,418 @@
+package lclynab
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// DefaultBaseURL is the URL of the YNAB API.
+const DefaultBaseURL = "https://api.youneedabudget.com/v1"
+
+// Cleared statuses of a transaction.
+const (
+	ClearedCleared   = "cleared"
+	ClearedUncleared = "uncleared"
+)
+
+// Transaction is a transaction as YNAB creates it,
+// see https://api.ynab.com/v1#/Transactions/createTransaction.
+// NewTransaction builds one checking the rules of the API.
+//
+// The optional fields that YNAB fills on its own are pointers: nil leaves the field
+// out of the payload so that YNAB decides, a pointer to the empty value sends it
+// explicitly cleared.
+type Transaction struct {
+	AccountID string `json:"account_id,omitempty"`
+	Date      Date   `json:"date,omitzero"`
+	Amount    int    `json:"amount,omitempty"`
+	// PayeeID is the payee, nil to match PayeeName or let YNAB create it.
+	PayeeID   *string `json:"payee_id,omitempty"`
+	PayeeName string  `json:"payee_name,omitempty"`
+	// CategoryID is the category, nil to let YNAB pick the payee's usual one
+	// and empty to leave the transaction uncategorized.
+	CategoryID *string `json:"category_id,omitempty"`
+	Memo       string  `json:"memo,omitempty"`
+	Cleared    string  `json:"cleared,omitempty"`
+	// Approved is whether the transaction is approved, nil for YNAB's default: unapproved.
+	Approved *bool `json:"approved,omitempty"`
+	// FlagColor is the flag, nil to send none and empty to explicitly remove it.
+	FlagColor *string `json:"flag_color,omitempty"`
+	ImportID  string  `json:"import_id,omitempty"`
+
+	Subtransactions []Subtransaction `json:"subtransactions,omitempty"`
+}
+
+// MarshalJSON sends the explicitly empty PayeeID, CategoryID and FlagColor as null,
+// which YNAB reads as cleared where it would reject an empty string.
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	// Same fields in the same order, only the nullable ones change type.
+	type payload struct {
+		AccountID       string           `json:"account_id,omitempty"`
+		Date            Date             `json:"date,omitzero"`
+		Amount          int              `json:"amount,omitempty"`
+		PayeeID         *nullString      `json:"payee_id,omitempty"`
+		PayeeName       string           `json:"payee_name,omitempty"`
+		CategoryID      *nullString      `json:"category_id,omitempty"`
+		Memo            string           `json:"memo,omitempty"`
+		Cleared         string           `json:"cleared,omitempty"`
+		Approved        *bool            `json:"approved,omitempty"`
+		FlagColor       *nullString      `json:"flag_color,omitempty"`
+		ImportID        string           `json:"import_id,omitempty"`
+		Subtransactions []Subtransaction `json:"subtransactions,omitempty"`
+	}
+
+	//nolint:wrapcheck // the fields always marshal
+	return json.Marshal(payload{
+		AccountID:       t.AccountID,
+		Date:            t.Date,
+		Amount:          t.Amount,
+		PayeeID:         (*nullString)(t.PayeeID),
+		PayeeName:       t.PayeeName,
+		CategoryID:      (*nullString)(t.CategoryID),
+		Memo:            t.Memo,
+		Cleared:         t.Cleared,
+		Approved:        t.Approved,
+		FlagColor:       (*nullString)(t.FlagColor),
+		ImportID:        t.ImportID,
+		Subtransactions: t.Subtransactions,
+	})
+}
+
+// nullString is a string marshaled as null when empty.
+type nullString string
+
+func (s nullString) MarshalJSON() ([]byte, error) {
+	if s == "" {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(string(s)) //nolint:wrapcheck // strings always marshal
+}
+
+// Budget is a budget with its accounts.
+type Budget struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Accounts []Account `json:"accounts"`
+	// LastModifiedOn is when the budget last changed, zero when YNAB doesn't say.
+	LastModifiedOn time.Time `json:"last_modified_on"`
+}
+
+// Account is an account of a budget, its balances in milliunits.
+type Account struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Balance        int    `json:"balance"`
+	ClearedBalance int    `json:"cleared_balance"`
+	Closed         bool   `json:"closed"`
+	Deleted        bool   `json:"deleted"`
+	// Type is the kind of account as YNAB names it, e.g. "checking" or "creditCard",
+	// OnBudget set for the accounts whose transactions are budgeted.
+	Type     string `json:"type"`
+	OnBudget bool   `json:"on_budget"`
+}
+
+// User is the YNAB user the token belongs to.
+type User struct {
+	ID string `json:"id"`
+}
+
+// SavedTransaction is a transaction as YNAB returns it, amounts in milliunits.
+type SavedTransaction struct {
+	ID           string `json:"id"`
+	AccountID    string `json:"account_id"`
+	Date         Date   `json:"date"`
+	Amount       int    `json:"amount"`
+	PayeeID      string `json:"payee_id"`
+	PayeeName    string `json:"payee_name"`
+	CategoryID   string `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Memo         string `json:"memo"`
+	Cleared      string `json:"cleared"`
+	Approved     bool   `json:"approved"`
+	FlagColor    string `json:"flag_color"`
+	ImportID     string `json:"import_id"`
+	Deleted      bool   `json:"deleted"`
+}
+
+// TransactionsPage is what ListTransactions returns.
+type TransactionsPage struct {
+	Transactions []SavedTransaction `json:"transactions"`
+	// ServerKnowledge is passed back as lastKnowledge to only get what changed since.
+	ServerKnowledge int64 `json:"server_knowledge"`
+}
+
+// CreatedTransactions is what YNAB answers when creating transactions.
+type CreatedTransactions struct {
+	TransactionIDs []string `json:"transaction_ids"`
+	// DuplicateImportIDs are the import IDs YNAB already knew, whose transactions it skipped.
+	DuplicateImportIDs []string `json:"duplicate_import_ids"`
+	// Transactions are the created transactions as saved, after YNAB's own rules ran.
+	Transactions    []SavedTransaction `json:"transactions"`
+	ServerKnowledge int64              `json:"server_knowledge"`
+}
+
+// Client calls the YNAB API with a personal access token.
+// Calls are bounded by their context only, callers set the timeouts.
+type Client struct {
+	httpClient *http.Client
+	token      string
+	// BaseURL defaults to DefaultBaseURL.
+	BaseURL string
+}
+
+// NewClient returns a client authenticating with token. A nil httpClient
+// means http.DefaultClient, see WrapClient to trace or count its calls.
+func NewClient(token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{httpClient: httpClient, token: token, BaseURL: DefaultBaseURL}
+}
+
+// CreateTransactions creates transactions in the budget and returns the import IDs
+// YNAB already knew, whose transactions it skipped.
+func (c *Client) CreateTransactions(
+	ctx context.Context,
+	budgetID string,
+	transactions []Transaction,
+) (duplicateImportIDs []string, err error) {
+	created, err := c.CreateTransactionsDetail(ctx, budgetID, transactions)
+
+	return created.DuplicateImportIDs, err
+}
+
+// CreateTransactionsDetail is CreateTransactions also returning what YNAB created.
+func (c *Client) CreateTransactionsDetail(
+	ctx context.Context,
+	budgetID string,
+	transactions []Transaction,
+) (CreatedTransactions, error) {
+	if len(transactions) == 0 {
+		return CreatedTransactions{}, nil
+	}
+
+	type payload struct {
+		Transactions []Transaction `json:"transactions"`
+	}
+
+	var resp struct {
+		Data CreatedTransactions `json:"data"`
+	}
+
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	err := c.request().
+		Pathf("budgets/%s/transactions", budgetID).
+		Method(http.MethodPost).
+		BodyJSON(payload{Transactions: transactions}).
+		ToJSON(&resp).
+		Fetch(ctx)
+	if err != nil {
+		return CreatedTransactions{}, wrapError("pushing transactions", err)
+	}
+
+	return resp.Data, nil
+}
+
+// GetUser returns the user the token belongs to, a cheap way to check the token.
+func (c *Client) GetUser(ctx context.Context) (User, error) {
+	var resp struct {
+		Data struct {
+			User User `json:"user"`
+		} `json:"data"`
+	}
+
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	err := c.request().
+		Path("user").
+		ToJSON(&resp).
+		Fetch(ctx)
+	if err != nil {
+		return User{}, wrapError("getting the user", err)
+	}
+
+	return resp.Data.User, nil
+}
+
+// ListBudgets returns every budget the token can read, with its accounts.
+func (c *Client) ListBudgets(ctx context.Context) ([]Budget, error) {
+	var resp struct {
+		Data struct {
+			Budgets []Budget `json:"budgets"`
+		} `json:"data"`
+	}
+
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	err := c.request().
+		Path("budgets").
+		Param("include_accounts", "true").
+		ToJSON(&resp).
+		Fetch(ctx)
+	if err != nil {
+		return nil, wrapError("listing budgets", err)
+	}
+
+	return resp.Data.Budgets, nil
+}
+
+// ListAccounts returns the accounts of the budget.
+func (c *Client) ListAccounts(ctx context.Context, budgetID string) ([]Account, error) {
+	var resp struct {
+		Data struct {
+			Accounts []Account `json:"accounts"`
+		} `json:"data"`
+	}
+
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	err := c.request().
+		Pathf("budgets/%s/accounts", budgetID).
+		ToJSON(&resp).
+		Fetch(ctx)
+	if err != nil {
+		return nil, wrapError("listing accounts", err)
+	}
+
+	return resp.Data.Accounts, nil
+}
+
+// GetAccount returns one account of the budget.
+func (c *Client) GetAccount(ctx context.Context, budgetID, accountID string) (Account, error) {
+	var resp struct {
+		Data struct {
+			Account Account `json:"account"`
+		} `json:"data"`
+	}
+
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	err := c.request().
+		Pathf("budgets/%s/accounts/%s", budgetID, accountID).
+		ToJSON(&resp).
+		Fetch(ctx)
+	if err != nil {
+		return Account{}, wrapError("getting the account", err)
+	}
+
+	return resp.Data.Account, nil
+}
+
+// GetBudgetSettings returns the display settings of the budget.
+func (c *Client) GetBudgetSettings(ctx context.Context, budgetID string) (BudgetSettings, error) {
+	var resp struct {
+		Data struct {
+			Settings BudgetSettings `json:"settings"`
+		} `json:"data"`
+	}
+
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	err := c.request().
+		Pathf("budgets/%s/settings", budgetID).
+		ToJSON(&resp).
+		Fetch(ctx)
+	if err != nil {
+		return BudgetSettings{}, wrapError("getting the budget settings", err)
+	}
+
+	return resp.Data.Settings, nil
+}
+
+// ListTransactions returns the transactions of the account dated since then, or all of
+// them for the zero Date. A lastKnowledge above 0 only returns the transactions changed
+// since the server knowledge it comes from, deleted ones included.
+func (c *Client) ListTransactions(
+	ctx context.Context,
+	budgetID, accountID string,
+	since Date,
+	lastKnowledge int64,
+) (TransactionsPage, error) {
+	var resp struct {
+		Data TransactionsPage `json:"data"`
+	}
+
+	builder := c.request().Pathf("budgets/%s/accounts/%s/transactions", budgetID, accountID)
+
+	if !since.IsZero() {
+		builder.Param("since_date", since.String())
+	}
+
+	if lastKnowledge > 0 {
+		builder.Param("last_knowledge_of_server", strconv.FormatInt(lastKnowledge, 10))
+	}
+
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	if err := builder.ToJSON(&resp).Fetch(ctx); err != nil {
+		return TransactionsPage{}, wrapError("listing transactions", err)
+	}
+
+	return resp.Data, nil
+}
+
+// FetchTransactions is ListTransactions making a single request per run: the caller keeps
+// the server knowledge it returns, to give it as lastKnowledge to the delta request of
+// the next run. Deleted transactions are only kept when lastKnowledge is above 0, for
+// the caller to forget them.
+func (c *Client) FetchTransactions(
+	ctx context.Context,
+	budgetID, accountID string,
+	since Date,
+	lastKnowledge int64,
+) (TransactionsPage, error) {
+	page, err := c.ListTransactions(ctx, budgetID, accountID, since, lastKnowledge)
+	if err != nil {
+		return TransactionsPage{}, err
+	}
+
+	if lastKnowledge == 0 {
+		page.Transactions = slices.DeleteFunc(page.Transactions, func(t SavedTransaction) bool { return t.Deleted })
+	}
+
+	return page, nil
+}
+
+// GetTransaction returns one transaction of the budget, deleted ones included.
+func (c *Client) GetTransaction(ctx context.Context, budgetID, transactionID string) (SavedTransaction, error) {
+	var resp struct {
+		Data struct {
+			Transaction SavedTransaction `json:"transaction"`
+		} `json:"data"`
+	}
+
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	err := c.request().
+		Pathf("budgets/%s/transactions/%s", budgetID, transactionID).
+		ToJSON(&resp).
+		Fetch(ctx)
+	if err != nil {
+		return SavedTransaction{}, wrapError("getting the transaction", err)
+	}
+
+	return resp.Data.Transaction, nil
+}
+
+// DeleteTransaction deletes one transaction of the budget.
+func (c *Client) DeleteTransaction(ctx context.Context, budgetID, transactionID string) error {
+	//nolint:bodyclose // reported https://github.com/earthboundkid/requests/discussions/121
+	err := c.request().
+		Pathf("budgets/%s/transactions/%s", budgetID, transactionID).
+		Method(http.MethodDelete).
+		Fetch(ctx)
+	if err != nil {
+		return wrapError("deleting the transaction", err)
+	}
+
+	return nil
+}
+
+// request returns a builder for the API, turning error responses into an *APIError.
+func (c *Client) request() *requests.Builder {
+	return requests.URL(c.BaseURL + "/").
+		Client(c.httpClient).
+		Bearer(c.token).
+		AddValidator(checkStatus)
+}
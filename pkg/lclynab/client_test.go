@@ -0,0 +1,405 @@
+package lclynab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestClient_CreateTransactions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "created",
+			status: http.StatusCreated,
+			body:   `{"data": {"duplicate_import_ids": ["YNAB:80000:2024-10-29:1"]}}`,
+			want:   []string{"YNAB:80000:2024-10-29:1"},
+		},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{}`, wantErr: ErrUnauthorized},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: `{}`, wantErr: ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var authorization string
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodPost, "https://api.youneedabudget.com/v1/budgets/bud-id/transactions",
+				func(req *http.Request) (*http.Response, error) {
+					authorization = req.Header.Get("Authorization")
+
+					resp := httpmock.NewStringResponse(tt.status, tt.body)
+					resp.Request = req
+
+					return resp, nil
+				})
+
+			client := NewClient("tok", &http.Client{Transport: transport})
+
+			got, err := client.CreateTransactions(context.Background(), "bud-id", []Transaction{{Amount: 80000}})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateTransactions() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CreateTransactions() = %v, want %v", got, tt.want)
+			}
+
+			if authorization != "Bearer tok" {
+				t.Errorf("Authorization = %q, want the token", authorization)
+			}
+		})
+	}
+}
+
+// TestClient_CreateTransactions_payload guards the JSON sent to YNAB: transactions
+// built before optional fields became pointers must serialize the same.
+func TestClient_CreateTransactions_payload(t *testing.T) {
+	t.Parallel()
+
+	var body []byte
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/bud-id/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			var err error
+
+			body, err = io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			return httpmock.NewStringResponse(http.StatusCreated, `{"data": {}}`), nil
+		})
+
+	transfer, err := NewTransaction("acc-id", mustDate("2024-10-29"), 80000,
+		WithPayee("VIREMENT M JEAN MARTIN OU"),
+		WithMemo("VIREMENT M JEAN MARTIN OU"),
+		WithCleared(ClearedCleared),
+		WithImportID("YNAB:80000:2024-10-29:1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card, err := NewTransaction("acc-id", mustDate("2024-10-28"), -21320,
+		WithPayee("CB  MERCH"),
+		WithMemo("CB  MERCH          28/10/24"),
+		WithCleared(ClearedCleared),
+		WithImportID("YNAB:-21320:2024-10-28:1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	categorized, err := card.With(WithPayee("Merch"), WithCategory("cat-groceries"), WithFlag("green"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient("tok", &http.Client{Transport: transport})
+
+	_, err = client.CreateTransactions(context.Background(), "bud-id", []Transaction{transfer, card, categorized})
+	if err != nil {
+		t.Fatalf("CreateTransactions() error = %v", err)
+	}
+
+	var got bytes.Buffer
+	if err := json.Indent(&got, body, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+
+	got.WriteByte('\n')
+
+	want, err := os.ReadFile("./testdata/payload.golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.String() != string(want) {
+		t.Errorf("payload = \n%s\nwant\n%s", got.String(), want)
+	}
+}
+
+func TestTransaction_MarshalJSON_optional(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []TransactionOption
+		want string
+	}{
+		{
+			name: "omitted",
+			opts: nil,
+			want: `{"account_id":"acc-id","date":"2024-10-28","amount":-21320}`,
+		},
+		{
+			name: "explicitly empty",
+			opts: []TransactionOption{WithPayeeID(""), WithCategory(""), WithApproved(false), WithFlag("")},
+			want: `{"account_id":"acc-id","date":"2024-10-28","amount":-21320,` +
+				`"payee_id":null,"category_id":null,"approved":false,"flag_color":null}`,
+		},
+		{
+			name: "set",
+			opts: []TransactionOption{WithPayeeID("payee-id"), WithCategory("cat-id"), WithApproved(true), WithFlag("red")},
+			want: `{"account_id":"acc-id","date":"2024-10-28","amount":-21320,` +
+				`"payee_id":"payee-id","category_id":"cat-id","approved":true,"flag_color":"red"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transaction, err := NewTransaction("acc-id", mustDate("2024-10-28"), -21320, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.Marshal(transaction)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("Marshal() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_CreateTransactions_none(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	client := NewClient("tok", &http.Client{Transport: transport})
+
+	if _, err := client.CreateTransactions(context.Background(), "bud-id", nil); err != nil {
+		t.Fatalf("CreateTransactions() error = %v", err)
+	}
+
+	if calls := transport.GetTotalCallCount(); calls != 0 {
+		t.Errorf("calls = %v, want none", calls)
+	}
+}
+
+func TestClient_accounts(t *testing.T) {
+	t.Parallel()
+
+	checking := Account{ID: "acc-id", Name: "Checking", Balance: 100060, ClearedBalance: 90060}
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"budgets": [{"id": "bud-id", "name": "Personal",
+			"accounts": [{"id": "acc-id", "name": "Checking", "balance": 100060, "cleared_balance": 90060}]}]}}`))
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/bud-id/accounts",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"accounts": [
+			{"id": "acc-id", "name": "Checking", "balance": 100060, "cleared_balance": 90060}]}}`))
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/bud-id/accounts/acc-id",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"account":
+			{"id": "acc-id", "name": "Checking", "balance": 100060, "cleared_balance": 90060}}}`))
+
+	client := NewClient("tok", &http.Client{Transport: transport})
+	ctx := context.Background()
+
+	budgets, err := client.ListBudgets(ctx)
+	if want := []Budget{{ID: "bud-id", Name: "Personal", Accounts: []Account{checking}}}; err != nil ||
+		!reflect.DeepEqual(budgets, want) {
+		t.Errorf("ListBudgets() = %+v, %v, want %+v", budgets, err, want)
+	}
+
+	accounts, err := client.ListAccounts(ctx, "bud-id")
+	if want := []Account{checking}; err != nil || !reflect.DeepEqual(accounts, want) {
+		t.Errorf("ListAccounts() = %+v, %v, want %+v", accounts, err, want)
+	}
+
+	account, err := client.GetAccount(ctx, "bud-id", "acc-id")
+	if err != nil || account != checking {
+		t.Errorf("GetAccount() = %+v, %v, want %+v", account, err, checking)
+	}
+}
+
+func TestClient_ListTransactions(t *testing.T) {
+	t.Parallel()
+
+	var query string
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/bud-id/accounts/acc-id/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			query = req.URL.RawQuery
+
+			return httpmock.NewStringResponse(http.StatusOK, `{"data": {"server_knowledge": 42, "transactions": [
+				{"id": "t-1", "account_id": "acc-id", "date": "2024-10-28", "amount": -21320,
+				"payee_name": "Merch", "category_name": "Groceries", "flag_color": null,
+				"import_id": "YNAB:-21320:2024-10-28:1", "approved": true}]}}`), nil
+		})
+
+	client := NewClient("tok", &http.Client{Transport: transport})
+
+	got, err := client.ListTransactions(context.Background(), "bud-id", "acc-id", mustDate("2024-10-01"), 41)
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+
+	want := TransactionsPage{
+		ServerKnowledge: 42,
+		Transactions: []SavedTransaction{{
+			ID: "t-1", AccountID: "acc-id", Date: mustDate("2024-10-28"), Amount: -21320, PayeeName: "Merch",
+			CategoryName: "Groceries", ImportID: "YNAB:-21320:2024-10-28:1", Approved: true,
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListTransactions() = %+v, want %+v", got, want)
+	}
+
+	if want := "last_knowledge_of_server=41&since_date=2024-10-01"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+}
+
+func TestClient_GetTransaction(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/bud-id/transactions/t-1",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"transaction": {"id": "t-1", "date": "2024-10-28",
+			"amount": -21320, "payee_name": "Merch", "deleted": true}}}`))
+
+	client := NewClient("tok", &http.Client{Transport: transport})
+
+	got, err := client.GetTransaction(context.Background(), "bud-id", "t-1")
+	if err != nil {
+		t.Fatalf("GetTransaction() error = %v", err)
+	}
+
+	want := SavedTransaction{ID: "t-1", Date: mustDate("2024-10-28"), Amount: -21320, PayeeName: "Merch", Deleted: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTransaction() = %+v, want %+v", got, want)
+	}
+}
+
+func TestClient_DeleteTransaction(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodDelete, "/v1/budgets/bud-id/transactions/t-1",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"transaction": {"id": "t-1", "deleted": true}}}`))
+	transport.RegisterResponder(http.MethodDelete, "/v1/budgets/bud-id/transactions/t-2",
+		httpmock.NewStringResponder(http.StatusNotFound, `{"error": {"id": "404.2", "name": "resource_not_found",
+			"detail": "Resource not found"}}`))
+
+	client := NewClient("tok", &http.Client{Transport: transport})
+
+	if err := client.DeleteTransaction(context.Background(), "bud-id", "t-1"); err != nil {
+		t.Errorf("DeleteTransaction() error = %v", err)
+	}
+
+	var apiErr *APIError
+	if err := client.DeleteTransaction(context.Background(), "bud-id", "t-2"); !errors.As(err, &apiErr) {
+		t.Errorf("DeleteTransaction() error = %v, want an *APIError", err)
+	}
+}
+
+func TestClient_FetchTransactions(t *testing.T) {
+	t.Parallel()
+
+	var queries []string
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/bud-id/accounts/acc-id/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			queries = append(queries, req.URL.RawQuery)
+
+			return httpmock.NewStringResponse(http.StatusOK, `{"data": {"server_knowledge": 12, "transactions": [
+				{"id": "t-1", "date": "2024-10-28", "amount": -21320, "payee_name": "Merch", "approved": true},
+				{"id": "t-2", "deleted": true},
+				{"id": "t-3", "date": "2024-10-30", "amount": -1000, "payee_name": "Bakery"}]}}`), nil
+		})
+
+	client := NewClient("tok", &http.Client{Transport: transport})
+
+	got, err := client.FetchTransactions(context.Background(), "bud-id", "acc-id", mustDate("2024-10-01"), 0)
+	if err != nil {
+		t.Fatalf("FetchTransactions() error = %v", err)
+	}
+
+	want := TransactionsPage{
+		ServerKnowledge: 12,
+		Transactions: []SavedTransaction{
+			{ID: "t-1", Date: mustDate("2024-10-28"), Amount: -21320, PayeeName: "Merch", Approved: true},
+			{ID: "t-3", Date: mustDate("2024-10-30"), Amount: -1000, PayeeName: "Bakery"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FetchTransactions() = %+v, want %+v", got, want)
+	}
+
+	if wantQueries := []string{"since_date=2024-10-01"}; !reflect.DeepEqual(queries, wantQueries) {
+		t.Errorf("queries = %q, want %q", queries, wantQueries)
+	}
+}
+
+func TestClient_FetchTransactions_delta(t *testing.T) {
+	t.Parallel()
+
+	var queries []string
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/bud-id/accounts/acc-id/transactions",
+		func(req *http.Request) (*http.Response, error) {
+			queries = append(queries, req.URL.RawQuery)
+
+			return httpmock.NewStringResponse(http.StatusOK, `{"data": {"server_knowledge": 12, "transactions": [
+				{"id": "t-2", "deleted": true}]}}`), nil
+		})
+
+	client := NewClient("tok", &http.Client{Transport: transport})
+
+	got, err := client.FetchTransactions(context.Background(), "bud-id", "acc-id", Date{}, 10)
+	if err != nil {
+		t.Fatalf("FetchTransactions() error = %v", err)
+	}
+
+	want := TransactionsPage{ServerKnowledge: 12, Transactions: []SavedTransaction{{ID: "t-2", Deleted: true}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FetchTransactions() = %+v, want %+v", got, want)
+	}
+
+	if wantQueries := []string{"last_knowledge_of_server=10"}; !reflect.DeepEqual(queries, wantQueries) {
+		t.Errorf("queries = %q, want a single delta request, %q", queries, wantQueries)
+	}
+}
+
+func TestClient_GetUser(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/user",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"user": {"id": "user-id"}}}`))
+
+	client := NewClient("tok", &http.Client{Transport: transport})
+
+	got, err := client.GetUser(context.Background())
+	if err != nil {
+		t.Fatalf("GetUser() error = %v", err)
+	}
+
+	if want := (User{ID: "user-id"}); got != want {
+		t.Errorf("GetUser() = %+v, want %+v", got, want)
+	}
+}
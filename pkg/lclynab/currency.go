@@ -0,0 +1,76 @@
+package lclynab
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	milliDigits = 3
+	groupDigits = 3
+)
+
+// CurrencyFormat is how a budget displays amounts, see its BudgetSettings.
+type CurrencyFormat struct {
+	ISOCode          string `json:"iso_code"`
+	ExampleFormat    string `json:"example_format"`
+	DecimalDigits    int    `json:"decimal_digits"`
+	DecimalSeparator string `json:"decimal_separator"`
+	SymbolFirst      bool   `json:"symbol_first"`
+	GroupSeparator   string `json:"group_separator"`
+	CurrencySymbol   string `json:"currency_symbol"`
+	DisplaySymbol    bool   `json:"display_symbol"`
+}
+
+// BudgetSettings are the display settings of a budget.
+type BudgetSettings struct {
+	CurrencyFormat CurrencyFormat `json:"currency_format"`
+}
+
+// Format returns amount, in milliunits, as the budget displays it, e.g. "-£1,234.56".
+// Digits beyond DecimalDigits are rounded half away from zero.
+func (f CurrencyFormat) Format(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	digits := min(max(f.DecimalDigits, 0), milliDigits)
+	scale := pow10(milliDigits - digits)
+	rounded := (amount + scale/2) / scale
+
+	units := strconv.Itoa(rounded / pow10(digits))
+
+	var number strings.Builder
+
+	for i, digit := range units {
+		if i > 0 && (len(units)-i)%groupDigits == 0 {
+			number.WriteString(f.GroupSeparator)
+		}
+
+		number.WriteRune(digit)
+	}
+
+	if digits > 0 {
+		fraction := strconv.Itoa(rounded % pow10(digits))
+		number.WriteString(f.DecimalSeparator + strings.Repeat("0", digits-len(fraction)) + fraction)
+	}
+
+	switch {
+	case !f.DisplaySymbol:
+		return sign + number.String()
+	case f.SymbolFirst:
+		return sign + f.CurrencySymbol + number.String()
+	default:
+		return sign + number.String() + f.CurrencySymbol
+	}
+}
+
+func pow10(n int) int {
+	p := 1
+	for range n {
+		p *= 10
+	}
+
+	return p
+}
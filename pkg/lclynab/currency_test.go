@@ -0,0 +1,84 @@
+package lclynab
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+//nolint:gochecknoglobals // test fixtures
+var (
+	gbpFormat = CurrencyFormat{
+		ISOCode: "GBP", DecimalDigits: 2, DecimalSeparator: ".", SymbolFirst: true,
+		GroupSeparator: ",", CurrencySymbol: "£", DisplaySymbol: true,
+	}
+	jpyFormat = CurrencyFormat{
+		ISOCode: "JPY", DecimalDigits: 0, DecimalSeparator: ".", SymbolFirst: true,
+		GroupSeparator: ",", CurrencySymbol: "¥", DisplaySymbol: true,
+	}
+	eurFormat = CurrencyFormat{
+		ISOCode: "EUR", DecimalDigits: 2, DecimalSeparator: ",", SymbolFirst: false,
+		GroupSeparator: " ", CurrencySymbol: "€", DisplaySymbol: true,
+	}
+)
+
+func TestCurrencyFormat_Format(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		format CurrencyFormat
+		amount int
+		want   string
+	}{
+		{name: "GBP", format: gbpFormat, amount: 1234560, want: "£1,234.56"},
+		{name: "GBP negative", format: gbpFormat, amount: -21320, want: "-£21.32"},
+		{name: "GBP zero", format: gbpFormat, amount: 0, want: "£0.00"},
+		{name: "GBP small", format: gbpFormat, amount: 50, want: "£0.05"},
+		{name: "JPY", format: jpyFormat, amount: 1234000, want: "¥1,234"},
+		{name: "JPY rounded", format: jpyFormat, amount: -1500, want: "-¥2"},
+		{name: "EUR", format: eurFormat, amount: 1234567060, want: "1 234 567,06€"},
+		{
+			name:   "no symbol",
+			format: CurrencyFormat{DecimalDigits: 3, DecimalSeparator: "."},
+			amount: 100060,
+			want:   "100.060",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.format.Format(tt.amount); got != tt.want {
+				t.Errorf("Format(%v) = %q, want %q", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_GetBudgetSettings(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets/bud-id/settings",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"settings": {"currency_format": {
+			"iso_code": "JPY", "example_format": "123,456", "decimal_digits": 0, "decimal_separator": ".",
+			"symbol_first": true, "group_separator": ",", "currency_symbol": "¥", "display_symbol": true}}}}`))
+
+	client := NewClient("tok", &http.Client{Transport: transport})
+
+	got, err := client.GetBudgetSettings(context.Background(), "bud-id")
+	if err != nil {
+		t.Fatalf("GetBudgetSettings() error = %v", err)
+	}
+
+	want := jpyFormat
+	want.ExampleFormat = "123,456"
+
+	if got.CurrencyFormat != want {
+		t.Errorf("GetBudgetSettings() = %+v, want %+v", got.CurrencyFormat, want)
+	}
+}
@@ -0,0 +1,90 @@
+package lclynab
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Date is a day, without time nor time zone, marshaled to JSON as YYYY-MM-DD like the
+// YNAB API expects. The zero Date marshals as null, and fields using `omitzero` omit it.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the day of t, in t's location.
+func NewDate(t time.Time) Date {
+	if t.IsZero() {
+		return Date{}
+	}
+
+	year, month, day := t.Date()
+
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses a YYYY-MM-DD date.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return Date{}, fmt.Errorf("parsing date: %w", err)
+	}
+
+	return Date{t}, nil
+}
+
+// String returns the date as YYYY-MM-DD, or an empty string for the zero Date.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.Format(time.DateOnly)
+}
+
+// Before reports whether d is the day before other or earlier.
+func (d Date) Before(other Date) bool {
+	return d.Time.Before(other.Time)
+}
+
+// After reports whether d is the day after other or later.
+func (d Date) After(other Date) bool {
+	return d.Time.After(other.Time)
+}
+
+// Equal reports whether d and other are the same day.
+func (d Date) Equal(other Date) bool {
+	return d.Time.Equal(other.Time)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, null and "" giving the zero Date.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("parsing date: %w", err)
+	}
+
+	if s == nil || *s == "" {
+		*d = Date{}
+
+		return nil
+	}
+
+	parsed, err := ParseDate(*s)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
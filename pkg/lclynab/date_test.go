@@ -0,0 +1,122 @@
+package lclynab
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// mustDate parses a YYYY-MM-DD date of a test case.
+func mustDate(s string) Date {
+	date, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return date
+}
+
+// ptr returns a pointer to v, for the optional fields of a test case.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestDate_JSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		date Date
+		json string
+	}{
+		{name: "day", date: mustDate("2024-10-29"), json: `"2024-10-29"`},
+		{name: "zero", date: Date{}, json: `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := json.Marshal(tt.date)
+			if err != nil || string(got) != tt.json {
+				t.Fatalf("Marshal() = %s, %v, want %s", got, err, tt.json)
+			}
+
+			var back Date
+			if err := json.Unmarshal(got, &back); err != nil || !back.Equal(tt.date) {
+				t.Errorf("Unmarshal() = %v, %v, want %v", back, err, tt.date)
+			}
+		})
+	}
+}
+
+func TestDate_UnmarshalJSONErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, data := range []string{`"29/10/2024"`, `20241029`, `"2024-10-29T10:00:00Z"`} {
+		var date Date
+		if err := json.Unmarshal([]byte(data), &date); err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want an error", data, date)
+		}
+	}
+}
+
+func TestNewDate(t *testing.T) {
+	t.Parallel()
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	got := NewDate(time.Date(2024, 10, 29, 23, 30, 0, 0, paris))
+	if want := mustDate("2024-10-29"); !got.Equal(want) {
+		t.Errorf("NewDate() = %v, want %v", got, want)
+	}
+
+	if got := NewDate(time.Time{}); !got.IsZero() {
+		t.Errorf("NewDate(zero) = %v, want the zero Date", got)
+	}
+}
+
+func TestDate_compare(t *testing.T) {
+	t.Parallel()
+
+	earlier, later := mustDate("2024-10-28"), mustDate("2024-10-29")
+
+	if !earlier.Before(later) || later.Before(earlier) || !later.After(earlier) || earlier.Equal(later) {
+		t.Errorf("comparing %v and %v gave the wrong order", earlier, later)
+	}
+}
+
+func TestTransaction_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		transaction Transaction
+		want        string
+	}{
+		{
+			name: "dated",
+			transaction: Transaction{
+				AccountID: "acc-id", Date: mustDate("2024-10-29"), Amount: 80000,
+				Cleared: ClearedCleared, ImportID: "YNAB:80000:2024-10-29:1",
+			},
+			want: `{"account_id":"acc-id","date":"2024-10-29","amount":80000,"cleared":"cleared",` +
+				`"import_id":"YNAB:80000:2024-10-29:1"}`,
+		},
+		{name: "zero date omitted", transaction: Transaction{Amount: 80000}, want: `{"amount":80000}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := json.Marshal(tt.transaction)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("Marshal() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package lclynab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxErrorBody bounds how much of an error response is read.
+	maxErrorBody = 64 << 10
+	// maxErrorDetail bounds the Detail kept from a body that isn't a YNAB error.
+	maxErrorDetail = 200
+)
+
+var (
+	// ErrUnauthorized matches the *APIError of a rejected token, see IsAuth.
+	ErrUnauthorized = errors.New("YNAB authentication failed")
+	// ErrRateLimited matches the *APIError of a token that made too many requests, see IsRateLimited.
+	ErrRateLimited = errors.New("YNAB rate limit reached")
+)
+
+// APIError is an error response of the YNAB API, see https://api.ynab.com/#errors.
+// Client methods return it wrapped, use errors.As to get it. Failures to reach the
+// API, like timeouts, aren't an *APIError.
+type APIError struct {
+	// Status is the HTTP status code.
+	Status int
+	// ID, Name and Detail come from the error body, e.g. "404.2", "resource_not_found"
+	// and "Resource not found". When the body isn't a YNAB error, ID and Name are empty
+	// and Detail holds the start of the body.
+	ID     string
+	Name   string
+	Detail string
+	// RetryAfter is how long the Retry-After header asks to wait before retrying,
+	// 0 when the response has none.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	var msg strings.Builder
+
+	switch {
+	case e.auth():
+		msg.WriteString(ErrUnauthorized.Error())
+	case e.rateLimited():
+		msg.WriteString(ErrRateLimited.Error())
+	default:
+		msg.WriteString("YNAB API error")
+	}
+
+	fmt.Fprintf(&msg, ": %d", e.Status)
+
+	if e.Name != "" {
+		fmt.Fprintf(&msg, " %v", e.Name)
+	}
+
+	if e.Detail != "" {
+		fmt.Fprintf(&msg, " - %v", e.Detail)
+	}
+
+	return msg.String()
+}
+
+// Is makes errors.Is match ErrUnauthorized and ErrRateLimited by status.
+func (e *APIError) Is(target error) bool {
+	switch target { //nolint:errorlint // comparing sentinels
+	case ErrUnauthorized:
+		return e.auth()
+	case ErrRateLimited:
+		return e.rateLimited()
+	default:
+		return false
+	}
+}
+
+func (e *APIError) auth() bool {
+	return e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden
+}
+
+func (e *APIError) rateLimited() bool {
+	return e.Status == http.StatusTooManyRequests
+}
+
+// IsAuth reports whether err comes from YNAB rejecting the token.
+func IsAuth(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsRateLimited reports whether err comes from YNAB limiting the requests of the token.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// wrapError returns the *APIError in err as is, it tells what failed on its own,
+// and prefixes other errors, like network failures, with action.
+func wrapError(action string, err error) error {
+	if apiErr := new(APIError); errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	return fmt.Errorf("%v: %w", action, err)
+}
+
+// checkStatus is the response validator of the client.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+
+	apiErr := &APIError{Status: resp.StatusCode, RetryAfter: retryAfter(resp.Header)}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err != nil {
+		return fmt.Errorf("%w (reading the body: %w)", apiErr, err)
+	}
+
+	var errResp struct {
+		Error struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Detail string `json:"detail"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error.Name == "" {
+		apiErr.Detail = strings.TrimSpace(string(body[:min(len(body), maxErrorDetail)]))
+
+		return apiErr
+	}
+
+	apiErr.ID, apiErr.Name, apiErr.Detail = errResp.Error.ID, errResp.Error.Name, errResp.Error.Detail
+
+	return apiErr
+}
+
+// retryAfter reads a Retry-After header given in seconds, 0 when it's missing or a date.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
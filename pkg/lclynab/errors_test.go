@@ -0,0 +1,152 @@
+package lclynab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+//nolint:funlen // mostly test cases in list
+func TestClient_APIError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		retryAfter  string
+		want        APIError
+		wantMsg     string
+		auth        bool
+		rateLimited bool
+	}{
+		{
+			name:    "bad request",
+			status:  http.StatusBadRequest,
+			body:    `{"error": {"id": "400", "name": "bad_request", "detail": "date is invalid"}}`,
+			want:    APIError{Status: 400, ID: "400", Name: "bad_request", Detail: "date is invalid"},
+			wantMsg: "YNAB API error: 400 bad_request - date is invalid",
+		},
+		{
+			name:    "unauthorized",
+			status:  http.StatusUnauthorized,
+			body:    `{"error": {"id": "401", "name": "unauthorized", "detail": "Unauthorized"}}`,
+			want:    APIError{Status: 401, ID: "401", Name: "unauthorized", Detail: "Unauthorized"},
+			wantMsg: "YNAB authentication failed: 401 unauthorized - Unauthorized",
+			auth:    true,
+		},
+		{
+			name:    "not found",
+			status:  http.StatusNotFound,
+			body:    `{"error": {"id": "404.2", "name": "resource_not_found", "detail": "Resource not found"}}`,
+			want:    APIError{Status: 404, ID: "404.2", Name: "resource_not_found", Detail: "Resource not found"},
+			wantMsg: "YNAB API error: 404 resource_not_found - Resource not found",
+		},
+		{
+			name:    "conflict",
+			status:  http.StatusConflict,
+			body:    `{"error": {"id": "409", "name": "conflict", "detail": "import_id already exists"}}`,
+			want:    APIError{Status: 409, ID: "409", Name: "conflict", Detail: "import_id already exists"},
+			wantMsg: "YNAB API error: 409 conflict - import_id already exists",
+		},
+		{
+			name:        "rate limited",
+			status:      http.StatusTooManyRequests,
+			body:        `{"error": {"id": "429", "name": "too_many_requests", "detail": "Too many requests"}}`,
+			want:        APIError{Status: 429, ID: "429", Name: "too_many_requests", Detail: "Too many requests"},
+			wantMsg:     "YNAB rate limit reached: 429 too_many_requests - Too many requests",
+			rateLimited: true,
+		},
+		{
+			name:       "rate limited with Retry-After",
+			status:     http.StatusTooManyRequests,
+			body:       `{"error": {"id": "429", "name": "too_many_requests", "detail": "Too many requests"}}`,
+			retryAfter: "30",
+			want: APIError{
+				Status: 429, ID: "429", Name: "too_many_requests", Detail: "Too many requests",
+				RetryAfter: 30 * time.Second,
+			},
+			wantMsg:     "YNAB rate limit reached: 429 too_many_requests - Too many requests",
+			rateLimited: true,
+		},
+		{
+			name:    "internal error",
+			status:  http.StatusInternalServerError,
+			body:    `{"error": {"id": "500", "name": "internal_server_error", "detail": "Internal server error"}}`,
+			want:    APIError{Status: 500, ID: "500", Name: "internal_server_error", Detail: "Internal server error"},
+			wantMsg: "YNAB API error: 500 internal_server_error - Internal server error",
+		},
+		{
+			name:    "malformed body",
+			status:  http.StatusBadGateway,
+			body:    "<html>Bad Gateway</html>\n",
+			want:    APIError{Status: 502, Detail: "<html>Bad Gateway</html>"},
+			wantMsg: "YNAB API error: 502 - <html>Bad Gateway</html>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodGet, "/v1/budgets",
+				func(req *http.Request) (*http.Response, error) {
+					resp := httpmock.NewStringResponse(tt.status, tt.body)
+					resp.Request = req
+
+					if tt.retryAfter != "" {
+						resp.Header.Set("Retry-After", tt.retryAfter)
+					}
+
+					return resp, nil
+				})
+
+			_, err := NewClient("tok", &http.Client{Transport: transport}).ListBudgets(context.Background())
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("ListBudgets() error = %v, want an *APIError", err)
+			}
+
+			if !reflect.DeepEqual(*apiErr, tt.want) {
+				t.Errorf("APIError = %+v, want %+v", *apiErr, tt.want)
+			}
+
+			if err.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.wantMsg)
+			}
+
+			if IsAuth(err) != tt.auth || IsRateLimited(err) != tt.rateLimited {
+				t.Errorf("IsAuth() = %v, IsRateLimited() = %v, want %v and %v",
+					IsAuth(err), IsRateLimited(err), tt.auth, tt.rateLimited)
+			}
+		})
+	}
+}
+
+func TestClient_transportError(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets",
+		httpmock.NewErrorResponder(errors.New("connection refused")))
+
+	_, err := NewClient("tok", &http.Client{Transport: transport}).ListBudgets(context.Background())
+	if err == nil {
+		t.Fatal("ListBudgets() error = nil, want an error")
+	}
+
+	if apiErr := new(APIError); errors.As(err, &apiErr) {
+		t.Errorf("ListBudgets() error = %v, want a transport error, not an *APIError", err)
+	}
+
+	if IsAuth(err) || IsRateLimited(err) {
+		t.Errorf("ListBudgets() error = %v, want neither auth nor rate limited", err)
+	}
+}
@@ -0,0 +1,61 @@
+package lclynab_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/Crocmagnon/lcl-ynab-go/pkg/lclynab"
+)
+
+func ExampleTransactions() {
+	export := strings.NewReader("29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers\n" +
+		"29/11/2024;100,06;;01234 123456A")
+
+	statement, err := lclynab.Parse(export, lclynab.ParseOptions{AccountID: "acc-id"})
+	if err != nil {
+		panic(err)
+	}
+
+	transactions, err := lclynab.Transactions(statement)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, t := range transactions {
+		fmt.Println(t.Date, t.Amount, t.PayeeName, t.ImportID)
+	}
+
+	fmt.Println("balance:", statement.Balance)
+	// Output:
+	// 2024-10-28 -21320 CB  MERCH YNAB:-21320:2024-10-28:1
+	// balance: 100060
+}
+
+func ExampleSync() {
+	// A stand-in for the YNAB API, reporting no duplicates.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, `{"data": {"transaction_ids": ["t-1"], "duplicate_import_ids": []}}`)
+	}))
+	defer server.Close()
+
+	client := lclynab.NewClient("personal-access-token", nil)
+	client.BaseURL = server.URL + "/v1"
+
+	res, err := lclynab.Sync(context.Background(), lclynab.SyncOptions{
+		Client:    client,
+		BudgetID:  "budget-id",
+		AccountID: "account-id",
+		Export: strings.NewReader("29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers\n" +
+			"29/11/2024;100,06;;01234 123456A"),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("pushed %d, duplicates %d, reconciled %d\n", res.Counts.Pushed, res.Counts.Duplicates, res.Reconciled)
+	// Output:
+	// pushed 1, duplicates 0, reconciled 100060
+}
@@ -0,0 +1,108 @@
+package lclynab
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestImportID(t *testing.T) {
+	t.Parallel()
+
+	importIDs := make(map[string]int)
+
+	tests := []struct {
+		amount int
+		date   Date
+		want   string
+	}{
+		{amount: -21320, date: mustDate("2024-10-28"), want: "YNAB:-21320:2024-10-28:1"},
+		{amount: 80000, date: mustDate("2024-10-29"), want: "YNAB:80000:2024-10-29:1"},
+		{amount: -21320, date: mustDate("2024-10-28"), want: "YNAB:-21320:2024-10-28:2"},
+		{amount: -21320, date: mustDate("2024-10-29"), want: "YNAB:-21320:2024-10-29:1"},
+		{amount: 0, date: Date{}, want: "YNAB:0::1"},
+	}
+
+	// Sequential: each call depends on the import IDs counted by the previous ones.
+	for _, tt := range tests {
+		if got := ImportID(tt.amount, tt.date, importIDs); got != tt.want {
+			t.Errorf("ImportID(%v, %v) = %q, want %q", tt.amount, tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestHashImportID(t *testing.T) {
+	t.Parallel()
+
+	lines := make(map[string]int)
+	coffee := "28/10/2024;-2,5;Carte;;CB CAFE 28/10/24;;0;Divers"
+
+	first := HashImportID(coffee, lines)
+	if want := "YNAB:SHA256:"; !strings.HasPrefix(first, want) || len(first) != len(want)+2*hashImportIDLen {
+		t.Errorf("HashImportID() = %q, want %v and %d hex digits", first, want, 2*hashImportIDLen)
+	}
+
+	if got := HashImportID(coffee, make(map[string]int)); got != first {
+		t.Errorf("HashImportID() in another export = %q, want %q", got, first)
+	}
+
+	// The same coffee bought twice that day.
+	if second := HashImportID(coffee, lines); second == first {
+		t.Errorf("HashImportID() of an identical line = %q, want it told apart", second)
+	}
+
+	// 36 characters at most, 8 more for the hash of -import-id-salt replacing "YNAB".
+	if len(first)+4 > 36 {
+		t.Errorf("HashImportID() = %d characters, too long for a salt", len(first))
+	}
+}
+
+func BenchmarkImportID(b *testing.B) {
+	date := mustDate("2024-10-28")
+	importIDs := make(map[string]int)
+
+	b.ReportAllocs()
+
+	for i := range b.N {
+		_ = ImportID(-i%100000, date, importIDs)
+	}
+}
+
+func TestTransactions_truncates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		payee string
+		label string
+	}{
+		{name: "at the limits", payee: strings.Repeat("p", 200), label: strings.Repeat("m", 500)},
+		{name: "one over", payee: strings.Repeat("p", 201), label: strings.Repeat("m", 501)},
+		{name: "far over", payee: strings.Repeat("\u00e9", 400), label: strings.Repeat("\u00e9", 1000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Transactions(Statement{Transactions: []StatementTransaction{{
+				AccountID: "acc-id", Date: time.Date(2024, 10, 28, 0, 0, 0, 0, time.UTC), Amount: -21320,
+				Payee: tt.payee, Label: tt.label,
+			}}})
+			if err != nil {
+				t.Fatalf("Transactions() error = %v", err)
+			}
+
+			payee, memo := utf8.RuneCountInString(got[0].PayeeName), utf8.RuneCountInString(got[0].Memo)
+			if payee != MaxPayeeNameLen || memo != MaxMemoLen {
+				t.Errorf("Transactions() payee, memo = %d, %d characters, want %d, %d",
+					payee, memo, MaxPayeeNameLen, MaxMemoLen)
+			}
+
+			if !strings.HasPrefix(tt.label, got[0].Memo) {
+				t.Errorf("Transactions() memo = %q, want the start of the label", got[0].Memo)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package lclynab
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Counts are the transaction counts of a run, as the push reports show them.
+type Counts struct {
+	Converted int `json:"converted"`
+	// Filtered is the number of lines left out by a filter, e.g. pending card payments.
+	Filtered int `json:"filtered"`
+	// Pushed is the number of transactions YNAB created, leaving out the duplicates.
+	Pushed     int `json:"pushed"`
+	Duplicates int `json:"duplicates"`
+	Matched    int `json:"matched"`
+}
+
+// SyncOptions describe what Sync pushes where.
+type SyncOptions struct {
+	Client    *Client
+	BudgetID  string
+	AccountID string
+	// Export is the LCL export to push, nil to push Transactions instead.
+	Export io.Reader
+	// Parse tunes how Export is read, its account ID is replaced with AccountID.
+	Parse ParseOptions
+	// Transactions are pushed as they are when Export is nil, for exports the caller
+	// converted itself, from another bank or with its own tweaks.
+	Transactions []Transaction
+}
+
+// Result is the outcome of a sync.
+type Result struct {
+	Counts Counts `json:"counts"`
+	// Reconciled is the balance of the account the export ends with.
+	Reconciled Milliunits `json:"reconciled_milliunits"`
+	// ReconciledDate is the day Reconciled applies to, zero when the export doesn't say.
+	ReconciledDate Date   `json:"reconciled_date,omitzero"`
+	AccountRef     string `json:"account_ref"`
+	// Transactions are the transactions sent to YNAB, Duplicates those it already had.
+	Transactions []Transaction `json:"transactions"`
+	Duplicates   []Transaction `json:"duplicates"`
+	// Created are the transactions YNAB created, as it saved them.
+	Created []SavedTransaction `json:"created"`
+}
+
+// Sync parses the export, converts it and creates the transactions in YNAB.
+// When the push fails, the result still holds what was converted.
+func Sync(ctx context.Context, opts SyncOptions) (*Result, error) {
+	res := &Result{Transactions: opts.Transactions}
+
+	if opts.Export != nil {
+		parseOptions := opts.Parse
+		parseOptions.AccountID = opts.AccountID
+
+		statement, err := ParseContext(ctx, opts.Export, parseOptions)
+		if err != nil {
+			return nil, fmt.Errorf("converting to YNAB transactions: %w", err)
+		}
+
+		res.Transactions, err = Transactions(statement)
+		if err != nil {
+			return nil, fmt.Errorf("converting to YNAB transactions: %w", err)
+		}
+
+		res.Reconciled = statement.Balance
+		res.ReconciledDate = NewDate(statement.BalanceDate)
+		res.AccountRef = statement.AccountRef
+	}
+
+	res.Counts.Converted = len(res.Transactions)
+
+	created, err := opts.Client.CreateTransactionsDetail(ctx, opts.BudgetID, res.Transactions)
+	if err != nil {
+		return res, fmt.Errorf("pushing to YNAB: %w", err)
+	}
+
+	// YNAB counts the duplicates among the transaction IDs it returns, they weren't pushed.
+	res.Counts.Pushed = max(len(created.TransactionIDs)-len(created.DuplicateImportIDs), 0)
+	res.Counts.Duplicates = len(created.DuplicateImportIDs)
+	res.Duplicates = filterByImportID(res.Transactions, created.DuplicateImportIDs)
+	res.Created = created.Transactions
+
+	return res, nil
+}
+
+// filterByImportID returns the transactions whose import ID is in importIDs, in order.
+// The IDs are looked up in a set: a backfill can have thousands of duplicates.
+//
+// The sync isn't split into monthly windows. The transactions and their import IDs come
+// from the one file being pushed, which is already in memory, and YNAB reports the
+// duplicates of the single request it gets. Windows would send a request per month,
+// changing what YNAB sees, without lowering the peak, which is the file itself. The
+// import IDs push remembers between runs are capped in its state file.
+func filterByImportID(transactions []Transaction, importIDs []string) []Transaction {
+	if len(importIDs) == 0 {
+		return nil
+	}
+
+	wanted := make(map[string]struct{}, len(importIDs))
+	for _, id := range importIDs {
+		wanted[id] = struct{}{}
+	}
+
+	var filtered []Transaction
+
+	for _, transaction := range transactions {
+		if _, ok := wanted[transaction.ImportID]; ok {
+			filtered = append(filtered, transaction)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,166 @@
+package lclynab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+const export = "29/10/2024;80;Virement;;;VIREMENT M JEAN MARTIN OU;;\n" +
+	"29/10/2024;-21,32;Carte;;CB  MERCH          28/10/24;;0;Divers\n" +
+	"29/11/2024;100,06;;01234 123456A"
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	transfer := Transaction{
+		AccountID: "acc-id",
+		Date:      mustDate("2024-10-29"),
+		Amount:    80000,
+		PayeeName: "VIREMENT M JEAN MARTIN OU",
+		Memo:      "VIREMENT M JEAN MARTIN OU",
+		Cleared:   ClearedCleared,
+		ImportID:  "YNAB:80000:2024-10-29:1",
+	}
+	payment := Transaction{
+		AccountID: "acc-id",
+		Date:      mustDate("2024-10-28"),
+		Amount:    -21320,
+		PayeeName: "CB  MERCH",
+		Memo:      "CB  MERCH          28/10/24",
+		Cleared:   ClearedCleared,
+		ImportID:  "YNAB:-21320:2024-10-28:1",
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		want    *Result
+		wantErr error
+	}{
+		{
+			name:   "pushed",
+			status: http.StatusCreated,
+			want: &Result{
+				Counts:         Counts{Converted: 2, Pushed: 1, Duplicates: 1},
+				Reconciled:     100060,
+				ReconciledDate: mustDate("2024-11-29"),
+				AccountRef:     "01234 123456A",
+				Transactions:   []Transaction{transfer, payment},
+				Duplicates:     []Transaction{payment},
+			},
+		},
+		{
+			name:   "push failed",
+			status: http.StatusUnauthorized,
+			want: &Result{
+				Counts:         Counts{Converted: 2},
+				Reconciled:     100060,
+				ReconciledDate: mustDate("2024-11-29"),
+				AccountRef:     "01234 123456A",
+				Transactions:   []Transaction{transfer, payment},
+			},
+			wantErr: ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			transport := httpmock.NewMockTransport()
+			transport.RegisterResponder(http.MethodPost, "/v1/budgets/bud-id/transactions",
+				func(req *http.Request) (*http.Response, error) {
+					resp := httpmock.NewStringResponse(tt.status,
+						`{"data": {"transaction_ids": ["t-1", "t-2"], "duplicate_import_ids": ["YNAB:-21320:2024-10-28:1"]}}`)
+					resp.Request = req
+
+					return resp, nil
+				})
+
+			got, err := Sync(context.Background(), SyncOptions{
+				Client:    NewClient("tok", &http.Client{Transport: transport}),
+				BudgetID:  "bud-id",
+				AccountID: "acc-id",
+				Export:    strings.NewReader(export),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Sync() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Sync() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSync_transactions(t *testing.T) {
+	t.Parallel()
+
+	payment := Transaction{AccountID: "acc-id", Date: mustDate("2024-10-28"), Amount: -21320, ImportID: "id-1"}
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodPost, "/v1/budgets/bud-id/transactions",
+		httpmock.NewStringResponder(http.StatusCreated,
+			`{"data": {"transaction_ids": ["t-1"], "duplicate_import_ids": ["id-1"]}}`))
+
+	got, err := Sync(context.Background(), SyncOptions{
+		Client:       NewClient("tok", &http.Client{Transport: transport}),
+		BudgetID:     "bud-id",
+		AccountID:    "acc-id",
+		Transactions: []Transaction{payment},
+	})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	want := &Result{
+		Counts:       Counts{Converted: 1, Duplicates: 1},
+		Transactions: []Transaction{payment},
+		Duplicates:   []Transaction{payment},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sync() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSync_invalidExport(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+
+	_, err := Sync(context.Background(), SyncOptions{
+		Client:    NewClient("tok", &http.Client{Transport: transport}),
+		BudgetID:  "bud-id",
+		AccountID: "acc-id",
+		Export:    strings.NewReader("29/10/2024;eighty;Virement;;;VIREMENT M JEAN MARTIN OU;;"),
+	})
+	if err == nil {
+		t.Fatal("Sync() error = nil, want an error")
+	}
+
+	if calls := transport.GetTotalCallCount(); calls != 0 {
+		t.Errorf("calls = %v, want none", calls)
+	}
+}
+
+func Test_filterByImportID(t *testing.T) {
+	t.Parallel()
+
+	transactions := []Transaction{{ImportID: "id-1"}, {ImportID: "id-2"}, {ImportID: "id-3"}, {ImportID: "id-4"}}
+
+	got := filterByImportID(transactions, []string{"id-4", "id-2", "id-unknown"})
+	if want := []Transaction{{ImportID: "id-2"}, {ImportID: "id-4"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filterByImportID() = %+v, want %+v", got, want)
+	}
+
+	if got := filterByImportID(transactions, nil); got != nil {
+		t.Errorf("filterByImportID() without duplicates = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,212 @@
+package lclynab
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+)
+
+// Limits of the YNAB API on transaction fields, in characters.
+const (
+	MaxImportIDLen  = 36
+	MaxPayeeNameLen = 200
+	MaxMemoLen      = 500
+)
+
+// ClearedReconciled is the cleared status of a reconciled transaction.
+const ClearedReconciled = "reconciled"
+
+// ErrInvalidTransaction is returned by NewTransaction and the options when a field
+// breaks a rule of the YNAB API.
+var ErrInvalidTransaction = errors.New("invalid transaction")
+
+//nolint:gochecknoglobals // constant list
+var flagColors = []string{"red", "orange", "yellow", "green", "blue", "purple"}
+
+// Subtransaction is a part of a split transaction.
+type Subtransaction struct {
+	Amount     int    `json:"amount"`
+	PayeeName  string `json:"payee_name,omitempty"`
+	CategoryID string `json:"category_id,omitempty"`
+	Memo       string `json:"memo,omitempty"`
+}
+
+// TransactionOption sets a field of a transaction, failing with ErrInvalidTransaction
+// when the value isn't valid.
+type TransactionOption func(t *Transaction) error
+
+// NewTransaction returns a transaction of amount milliunits, negative for an outflow,
+// in the account on date, with opts applied in order.
+func NewTransaction(accountID string, date Date, amount int, opts ...TransactionOption) (Transaction, error) {
+	if accountID == "" {
+		return Transaction{}, fmt.Errorf("%w: missing account", ErrInvalidTransaction)
+	}
+
+	if date.IsZero() {
+		return Transaction{}, fmt.Errorf("%w: missing date", ErrInvalidTransaction)
+	}
+
+	return Transaction{AccountID: accountID, Date: date, Amount: amount}.With(opts...)
+}
+
+// With returns a copy of the transaction with opts applied in order,
+// or the transaction unchanged and the first error.
+func (t Transaction) With(opts ...TransactionOption) (Transaction, error) {
+	updated := t
+	updated.Subtransactions = slices.Clone(t.Subtransactions)
+
+	for _, opt := range opts {
+		if err := opt(&updated); err != nil {
+			return t, err
+		}
+	}
+
+	return updated, nil
+}
+
+// WithPayee sets the payee name, created in YNAB when it doesn't exist yet.
+func WithPayee(name string) TransactionOption {
+	return func(t *Transaction) error {
+		if err := checkLen("payee name", name, MaxPayeeNameLen); err != nil {
+			return err
+		}
+
+		t.PayeeName = name
+
+		return nil
+	}
+}
+
+// WithMemo sets the memo.
+func WithMemo(memo string) TransactionOption {
+	return func(t *Transaction) error {
+		if err := checkLen("memo", memo, MaxMemoLen); err != nil {
+			return err
+		}
+
+		t.Memo = memo
+
+		return nil
+	}
+}
+
+// WithCleared sets the cleared status: ClearedCleared, ClearedUncleared or ClearedReconciled.
+func WithCleared(status string) TransactionOption {
+	return func(t *Transaction) error {
+		if status != ClearedCleared && status != ClearedUncleared && status != ClearedReconciled {
+			return fmt.Errorf("%w: unknown cleared status %q", ErrInvalidTransaction, status)
+		}
+
+		t.Cleared = status
+
+		return nil
+	}
+}
+
+// WithPayeeID sets the payee by ID, taking precedence over the payee name in YNAB.
+// An empty ID leaves YNAB to match the payee name.
+func WithPayeeID(payeeID string) TransactionOption {
+	return func(t *Transaction) error {
+		t.PayeeID = &payeeID
+
+		return nil
+	}
+}
+
+// WithCategory sets the category, an empty ID leaving the transaction uncategorized
+// rather than letting YNAB pick one.
+func WithCategory(categoryID string) TransactionOption {
+	return func(t *Transaction) error {
+		t.CategoryID = &categoryID
+
+		return nil
+	}
+}
+
+// WithFlag sets the flag color: red, orange, yellow, green, blue or purple.
+// An empty color removes the flag.
+func WithFlag(color string) TransactionOption {
+	return func(t *Transaction) error {
+		if color != "" && !slices.Contains(flagColors, color) {
+			return fmt.Errorf("%w: unknown flag color %q", ErrInvalidTransaction, color)
+		}
+
+		t.FlagColor = &color
+
+		return nil
+	}
+}
+
+// WithApproved sets whether the transaction is approved, left to YNAB otherwise.
+func WithApproved(approved bool) TransactionOption {
+	return func(t *Transaction) error {
+		t.Approved = &approved
+
+		return nil
+	}
+}
+
+// WithImportID sets the import ID, see ImportID.
+func WithImportID(id string) TransactionOption {
+	return func(t *Transaction) error {
+		if len(id) > MaxImportIDLen {
+			return fmt.Errorf("%w: import ID %q longer than %d characters", ErrInvalidTransaction, id, MaxImportIDLen)
+		}
+
+		t.ImportID = id
+
+		return nil
+	}
+}
+
+// WithSubtransactions splits the transaction. The amounts of the parts must add up
+// to the amount of the transaction.
+func WithSubtransactions(subtransactions ...Subtransaction) TransactionOption {
+	return func(t *Transaction) error {
+		sum := 0
+
+		for _, sub := range subtransactions {
+			if err := checkLen("subtransaction payee name", sub.PayeeName, MaxPayeeNameLen); err != nil {
+				return err
+			}
+
+			if err := checkLen("subtransaction memo", sub.Memo, MaxMemoLen); err != nil {
+				return err
+			}
+
+			sum += sub.Amount
+		}
+
+		if len(subtransactions) > 0 && sum != t.Amount {
+			return fmt.Errorf("%w: subtransactions add up to %d, want %d", ErrInvalidTransaction, sum, t.Amount)
+		}
+
+		t.Subtransactions = slices.Clone(subtransactions)
+
+		return nil
+	}
+}
+
+// Truncate returns value cut to its first limit characters, and whether it was longer.
+func Truncate(value string, limit int) (string, bool) {
+	count := 0
+
+	for i := range value {
+		if count == limit {
+			return value[:i], true
+		}
+
+		count++
+	}
+
+	return value, false
+}
+
+func checkLen(field, value string, limit int) error {
+	if utf8.RuneCountInString(value) > limit {
+		return fmt.Errorf("%w: %v longer than %d characters", ErrInvalidTransaction, field, limit)
+	}
+
+	return nil
+}
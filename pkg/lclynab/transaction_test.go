@@ -0,0 +1,172 @@
+package lclynab
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewTransaction(t *testing.T) {
+	t.Parallel()
+
+	got, err := NewTransaction("acc-id", mustDate("2024-10-28"), -21320,
+		WithPayeeID("payee-merch"),
+		WithPayee("CB  MERCH"),
+		WithMemo("CB  MERCH          28/10/24"),
+		WithCleared(ClearedCleared),
+		WithApproved(true),
+		WithCategory("cat-groceries"),
+		WithFlag("green"),
+		WithImportID("YNAB:-21320:2024-10-28:1"),
+		WithSubtransactions(Subtransaction{Amount: -20000}, Subtransaction{Amount: -1320, Memo: "bag"}),
+	)
+	if err != nil {
+		t.Fatalf("NewTransaction() error = %v", err)
+	}
+
+	want := Transaction{
+		AccountID:       "acc-id",
+		Date:            mustDate("2024-10-28"),
+		Amount:          -21320,
+		PayeeID:         ptr("payee-merch"),
+		PayeeName:       "CB  MERCH",
+		CategoryID:      ptr("cat-groceries"),
+		Memo:            "CB  MERCH          28/10/24",
+		Cleared:         ClearedCleared,
+		Approved:        ptr(true),
+		FlagColor:       ptr("green"),
+		ImportID:        "YNAB:-21320:2024-10-28:1",
+		Subtransactions: []Subtransaction{{Amount: -20000}, {Amount: -1320, Memo: "bag"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewTransaction() = %+v, want %+v", got, want)
+	}
+}
+
+//nolint:funlen // mostly test cases in list
+func TestNewTransaction_invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		accountID string
+		date      Date
+		opts      []TransactionOption
+	}{
+		{name: "missing account", date: mustDate("2024-10-28")},
+		{name: "missing date", accountID: "acc-id"},
+		{
+			name:      "payee too long",
+			accountID: "acc-id",
+			date:      mustDate("2024-10-28"),
+			opts:      []TransactionOption{WithPayee(strings.Repeat("\u00e9", MaxPayeeNameLen+1))},
+		},
+		{
+			name:      "memo too long",
+			accountID: "acc-id",
+			date:      mustDate("2024-10-28"),
+			opts:      []TransactionOption{WithMemo(strings.Repeat("a", MaxMemoLen+1))},
+		},
+		{
+			name:      "unknown cleared status",
+			accountID: "acc-id",
+			date:      mustDate("2024-10-28"),
+			opts:      []TransactionOption{WithCleared("pending")},
+		},
+		{
+			name:      "unknown flag color",
+			accountID: "acc-id",
+			date:      mustDate("2024-10-28"),
+			opts:      []TransactionOption{WithFlag("pink")},
+		},
+		{
+			name:      "import ID too long",
+			accountID: "acc-id",
+			date:      mustDate("2024-10-28"),
+			opts:      []TransactionOption{WithImportID("YNAB:-2132000000:2024-10-28:100000000")},
+		},
+		{
+			name:      "splits not adding up",
+			accountID: "acc-id",
+			date:      mustDate("2024-10-28"),
+			opts:      []TransactionOption{WithSubtransactions(Subtransaction{Amount: -20000}, Subtransaction{Amount: -1000})},
+		},
+		{
+			name:      "split memo too long",
+			accountID: "acc-id",
+			date:      mustDate("2024-10-28"),
+			opts: []TransactionOption{WithSubtransactions(
+				Subtransaction{Amount: -21320, Memo: strings.Repeat("a", MaxMemoLen+1)},
+			)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewTransaction(tt.accountID, tt.date, -21320, tt.opts...)
+			if !errors.Is(err, ErrInvalidTransaction) {
+				t.Errorf("NewTransaction() = %+v, %v, want ErrInvalidTransaction", got, err)
+			}
+		})
+	}
+}
+
+func TestNewTransaction_limits(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewTransaction("acc-id", mustDate("2024-10-28"), -21320,
+		WithPayee(strings.Repeat("\u00e9", MaxPayeeNameLen)),
+		WithMemo(strings.Repeat("a", MaxMemoLen)),
+		WithImportID(strings.Repeat("1", MaxImportIDLen)),
+		WithFlag(""),
+		WithCleared(ClearedReconciled),
+	)
+	if err != nil {
+		t.Errorf("NewTransaction() error = %v, want values at the limits accepted", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		limit   int
+		want    string
+		wantCut bool
+	}{
+		{value: "CB MERCH", limit: 8, want: "CB MERCH"},
+		{value: "CB MERCH", limit: 5, want: "CB ME", wantCut: true},
+		{value: "caf\u00e9 cr\u00e8me", limit: 4, want: "caf\u00e9", wantCut: true},
+		{value: "", limit: 0, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got, cut := Truncate(tt.value, tt.limit); got != tt.want || cut != tt.wantCut {
+			t.Errorf("Truncate(%q, %d) = %q, %v, want %q, %v", tt.value, tt.limit, got, cut, tt.want, tt.wantCut)
+		}
+	}
+}
+
+func TestTransaction_With(t *testing.T) {
+	t.Parallel()
+
+	original := Transaction{AccountID: "acc-id", Amount: -21320, PayeeName: "CB  MERCH"}
+
+	got, err := original.With(WithPayee("Merch"), WithFlag("pink"))
+	if err == nil {
+		t.Fatalf("With() = %+v, want an error", got)
+	}
+
+	if !reflect.DeepEqual(got, original) {
+		t.Errorf("With() = %+v, want the transaction unchanged", got)
+	}
+
+	got, err = original.With(WithPayee("Merch"))
+	if err != nil || got.PayeeName != "Merch" || original.PayeeName != "CB  MERCH" {
+		t.Errorf("With() = %+v, %v, want a renamed copy", got, err)
+	}
+}
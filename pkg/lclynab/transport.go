@@ -0,0 +1,43 @@
+package lclynab
+
+import (
+	"net/http"
+)
+
+// Middleware wraps the transport of HTTP calls, e.g. to log, count or trace them.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper, to write middlewares.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WrapClient returns a copy of client whose transport goes through middlewares,
+// the first one seeing requests first. A nil client means http.DefaultClient, and
+// the client is returned as is without middlewares.
+func WrapClient(client *http.Client, middlewares ...Middleware) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if len(middlewares) == 0 {
+		return client
+	}
+
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+
+	wrapped := *client
+	wrapped.Transport = transport
+
+	return &wrapped
+}
@@ -0,0 +1,49 @@
+package lclynab
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestWrapClient(t *testing.T) {
+	t.Parallel()
+
+	transport := httpmock.NewMockTransport()
+	transport.RegisterResponder(http.MethodGet, "/v1/budgets",
+		httpmock.NewStringResponder(http.StatusOK, `{"data": {"budgets": []}}`))
+
+	var order []string
+
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+
+				return next.RoundTrip(req) //nolint:wrapcheck // transparent middleware
+			})
+		}
+	}
+
+	base := &http.Client{Transport: transport}
+	client := WrapClient(base, tag("outer"), tag("inner"))
+
+	if _, err := NewClient("tok", client).ListBudgets(context.Background()); err != nil {
+		t.Fatalf("ListBudgets() error = %v", err)
+	}
+
+	if want := []string{"outer", "inner"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("middlewares ran in order %v, want %v", order, want)
+	}
+
+	if base.Transport != transport {
+		t.Error("WrapClient() changed the client it was given")
+	}
+
+	if WrapClient(base) != base {
+		t.Error("WrapClient() without middlewares = a copy, want the client as is")
+	}
+}